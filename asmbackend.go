@@ -0,0 +1,532 @@
+package baisl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Registers used for the first integer arguments in the System V AMD64 ABI
+var asmArgRegisters = []string{"%rdi", "%rsi", "%rdx", "%rcx", "%r8", "%r9"}
+
+// Generates x86-64 Linux assembly in GNU as (AT&T) syntax from resolved declarations.
+// Expressions are evaluated into %rax, with %rsp used as a stack for temporaries.
+// The output is a standalone program whose _start calls main and passes its result to exit.
+type AsmBackend struct {
+	Declarations []ResolvedDeclaration
+
+	out strings.Builder
+	// Frame offsets from %rbp of the variables in the current function
+	offsets map[ResolvedDeclaration]int
+	// Number of 8 byte temporaries currently pushed in the current function
+	depth int
+	// Label of the epilogue of the current function
+	returnLabel string
+	// Symbol and counter for the branch labels of the current function
+	currentFunction string
+	labels          int
+	// Labels continue and break jump to, for each loop enclosing the current statement
+	loops []asmLoop
+	// Messages of the divisions checked for a zero divisor, which are written out after the code
+	divisionErrors []string
+}
+
+type asmLoop struct {
+	continueLabel string
+	breakLabel    string
+}
+
+func asmFunctionName(id string) string {
+	return "baisl_" + symbolName(id)
+}
+
+func (ab *AsmBackend) emit(format string, args ...any) {
+	ab.out.WriteString("\t" + fmt.Sprintf(format, args...) + "\n")
+}
+
+func (ab *AsmBackend) push(register string) {
+	ab.emit("pushq %s", register)
+	ab.depth++
+}
+
+func (ab *AsmBackend) pop(register string) {
+	ab.emit("popq %s", register)
+	ab.depth--
+}
+
+var asmArithmeticInstructions = map[TokenType]string{
+	TokenType_PLUS:  "addq",
+	TokenType_MINUS: "subq",
+	TokenType_STAR:  "imulq",
+}
+
+// Condition code suffixes for the set instructions, for comparing %rax with %rcx
+var asmConditionCodes = map[TokenType]string{
+	TokenType_EQ:  "e",
+	TokenType_NEQ: "ne",
+	TokenType_LT:  "l",
+	TokenType_LTE: "le",
+	TokenType_GT:  "g",
+	TokenType_GTE: "ge",
+}
+
+// Computes %rax = %rax <operator> %rcx
+func (ab *AsmBackend) GenerateBinaryOperator(binaryExpr *ResolvedBinaryExpr) error {
+	operator := binaryExpr.Operator
+	if checksDivisor(binaryExpr) {
+		ab.generateCheckedDivision(binaryExpr)
+		return nil
+	}
+	instruction, ok := asmArithmeticInstructions[operator]
+	if ok {
+		ab.emit("%s %%rcx, %%rax", instruction)
+		return nil
+	}
+
+	conditionCode, ok := asmConditionCodes[operator]
+	if ok {
+		ab.emit("cmpq %%rcx, %%rax")
+		ab.emit("set%s %%al", conditionCode)
+		ab.emit("movzbq %%al, %%rax")
+		return nil
+	}
+
+	switch operator {
+	case TokenType_SLASH:
+		ab.emit("cqto")
+		ab.emit("idivq %%rcx")
+		return nil
+	case TokenType_PERCENT:
+		ab.emit("cqto")
+		ab.emit("idivq %%rcx")
+		ab.emit("movq %%rdx, %%rax")
+		return nil
+	}
+	return fmt.Errorf("Unknown binary operator %s", operator)
+}
+
+// Divides %rax by %rcx, jumping to baisl_division_fail with the division's message and its length in
+// %rsi and %rdx if %rcx is zero.
+// idivq faults dividing the smallest int by -1 too, so -1 negates instead, wrapping around like in Go,
+// and gives a remainder of 0.
+func (ab *AsmBackend) generateCheckedDivision(binaryExpr *ResolvedBinaryExpr) {
+	message := fmt.Sprintf(".Lbaisl_division_error_%d", len(ab.divisionErrors))
+	location := binaryExpr.Location
+	errorMessage := fmt.Sprintf("Division by zero at %d:%d\n", location.Line, location.Column)
+	ab.divisionErrors = append(ab.divisionErrors, errorMessage)
+	nonZeroLabel := ab.newLabel("divisor")
+	divideLabel := ab.newLabel("divide")
+	endLabel := ab.newLabel("divided")
+
+	ab.emit("testq %%rcx, %%rcx")
+	ab.emit("jne %s", nonZeroLabel)
+	ab.emit("leaq %s(%%rip), %%rsi", message)
+	ab.emit("movq $%d, %%rdx", len(errorMessage))
+	ab.emit("jmp baisl_division_fail")
+	ab.out.WriteString(nonZeroLabel + ":\n")
+	ab.emit("cmpq $-1, %%rcx")
+	ab.emit("jne %s", divideLabel)
+	if binaryExpr.Operator == TokenType_SLASH {
+		ab.emit("negq %%rax")
+	} else {
+		ab.emit("xorl %%eax, %%eax")
+	}
+	ab.emit("jmp %s", endLabel)
+	ab.out.WriteString(divideLabel + ":\n")
+	ab.emit("cqto")
+	ab.emit("idivq %%rcx")
+	if binaryExpr.Operator == TokenType_PERCENT {
+		ab.emit("movq %%rdx, %%rax")
+	}
+	ab.out.WriteString(endLabel + ":\n")
+}
+
+// Writes the %rdx bytes %rsi points to to standard error and exits with code 1
+const asmDivisionFail = `
+baisl_division_fail:
+	movq $1, %rax
+	movq $2, %rdi
+	syscall
+	movq $60, %rax
+	movq $1, %rdi
+	syscall
+`
+
+func (ab *AsmBackend) GenerateCall(fn *ResolvedFunctionDeclaration, args []ResolvedExpr) error {
+	stackArgs := max(len(args)-len(asmArgRegisters), 0)
+
+	// %rsp has to be 16 byte aligned at the call, counting every temporary pushed below
+	padding := (ab.depth + len(args) + stackArgs) % 2
+	if padding != 0 {
+		ab.emit("subq $8, %%rsp")
+		ab.depth++
+	}
+
+	// Arguments are evaluated left to right, then the ones passed on the stack are copied
+	// so that the first of them ends up on top, as the ABI expects
+	for _, arg := range args {
+		err := ab.GenerateExpr(arg)
+		if err != nil {
+			return err
+		}
+		ab.push("%rax")
+	}
+	for i, copied := len(args)-1, 0; i >= len(asmArgRegisters); i, copied = i-1, copied+1 {
+		ab.emit("pushq %d(%%rsp)", 8*(len(args)-1-i)+8*copied)
+		ab.depth++
+	}
+	for i := 0; i < len(args) && i < len(asmArgRegisters); i++ {
+		ab.emit("movq %d(%%rsp), %s", 8*(len(args)-1-i)+8*stackArgs, asmArgRegisters[i])
+	}
+
+	ab.emit("call %s", asmFunctionName(fn.GetId()))
+
+	pushed := len(args) + stackArgs + padding
+	if pushed > 0 {
+		ab.emit("addq $%d, %%rsp", 8*pushed)
+		ab.depth -= pushed
+	}
+	return nil
+}
+
+func (ab *AsmBackend) GenerateExpr(expr ResolvedExpr) error {
+	switch expr.(type) {
+	case *ResolvedValueExpr:
+		value := expr.(*ResolvedValueExpr).Value
+		if value == int(int32(value)) {
+			ab.emit("movq $%d, %%rax", value)
+		} else {
+			ab.emit("movabsq $%d, %%rax", value)
+		}
+		return nil
+	case *ResolvedBoolExpr:
+		// Bools are 0 or 1, like the results of comparisons
+		if expr.(*ResolvedBoolExpr).Value {
+			ab.emit("movq $1, %%rax")
+		} else {
+			ab.emit("movq $0, %%rax")
+		}
+		return nil
+	case *ResolvedStringExpr:
+		return fmt.Errorf("Strings are not supported by the assembly backend")
+	case *ResolvedStructExpr, *ResolvedFieldExpr:
+		return fmt.Errorf("Structs are not supported by the assembly backend")
+	case *ResolvedArrayExpr, *ResolvedIndexExpr:
+		return fmt.Errorf("Arrays are not supported by the assembly backend")
+	case *ResolvedVariantExpr, *ResolvedMatchExpr:
+		return fmt.Errorf("Enums are not supported by the assembly backend")
+	case *ResolvedBuiltinCallExpr:
+		return fmt.Errorf("Builtin %s is not supported by the assembly backend", expr.(*ResolvedBuiltinCallExpr).Builtin)
+	case *ResolvedRefExpr:
+		refExpr := expr.(*ResolvedRefExpr)
+		decl := *refExpr.Value
+		if refExpr.IsCall {
+			return ab.GenerateCall(decl.(*ResolvedFunctionDeclaration), refExpr.Args)
+		}
+
+		offset, ok := ab.offsets[decl]
+		if !ok {
+			return fmt.Errorf("Unknown variable %s", decl.GetId())
+		}
+		ab.emit("movq %d(%%rbp), %%rax", offset)
+		return nil
+	case *ResolvedBinaryExpr:
+		binaryExpr := expr.(*ResolvedBinaryExpr)
+		err := ab.GenerateExpr(binaryExpr.Lhs)
+		if err != nil {
+			return err
+		}
+
+		// The left operand of && being false or of || being true is already the result
+		switch binaryExpr.Operator {
+		case TokenType_AND, TokenType_OR:
+			endLabel := ab.newLabel("end")
+			ab.emit("testq %%rax, %%rax")
+			if binaryExpr.Operator == TokenType_AND {
+				ab.emit("je %s", endLabel)
+			} else {
+				ab.emit("jne %s", endLabel)
+			}
+			err = ab.GenerateExpr(binaryExpr.Rhs)
+			if err != nil {
+				return err
+			}
+			ab.out.WriteString(endLabel + ":\n")
+			return nil
+		}
+
+		ab.push("%rax")
+		err = ab.GenerateExpr(binaryExpr.Rhs)
+		if err != nil {
+			return err
+		}
+		ab.emit("movq %%rax, %%rcx")
+		ab.pop("%rax")
+		return ab.GenerateBinaryOperator(binaryExpr)
+	case *ResolvedUnaryExpr:
+		unaryExpr := expr.(*ResolvedUnaryExpr)
+		err := ab.GenerateExpr(unaryExpr.Operand)
+		if err != nil {
+			return err
+		}
+
+		switch unaryExpr.Operator {
+		case TokenType_MINUS:
+			ab.emit("negq %%rax")
+		case TokenType_BANG:
+			ab.emit("testq %%rax, %%rax")
+			ab.emit("sete %%al")
+			ab.emit("movzbq %%al, %%rax")
+		default:
+			return fmt.Errorf("Unknown unary operator %s", unaryExpr.Operator)
+		}
+		return nil
+	}
+	return fmt.Errorf("Unknown expression type %d", expr.GetExprType())
+}
+
+// Returns a label local to the current function
+func (ab *AsmBackend) newLabel(prefix string) string {
+	label := fmt.Sprintf(".L%s_%s_%d", ab.currentFunction, prefix, ab.labels)
+	ab.labels++
+	return label
+}
+
+func (ab *AsmBackend) generateLoopBody(body *ResolvedBlock, loop asmLoop) error {
+	ab.loops = append(ab.loops, loop)
+	err := ab.GenerateBlock(body)
+	ab.loops = ab.loops[:len(ab.loops)-1]
+	return err
+}
+
+func (ab *AsmBackend) GenerateBlock(block *ResolvedBlock) error {
+	for _, stmt := range block.Stmts {
+		switch stmt.(type) {
+		case *ResolvedReturnStatement:
+			returnStmt := stmt.(*ResolvedReturnStatement)
+			if returnStmt.Expr != nil {
+				err := ab.GenerateExpr(returnStmt.Expr)
+				if err != nil {
+					return err
+				}
+			}
+			ab.emit("jmp %s", ab.returnLabel)
+		case *ResolvedExprStatement:
+			err := ab.GenerateExpr(stmt.(*ResolvedExprStatement).Expr)
+			if err != nil {
+				return err
+			}
+		case *ResolvedLetStatement:
+			variable := stmt.(*ResolvedLetStatement).Variable
+			err := ab.GenerateExpr(variable.Value)
+			if err != nil {
+				return err
+			}
+			ab.emit("movq %%rax, %d(%%rbp)", ab.offsets[variable])
+		case *ResolvedAssignStatement:
+			assignStmt := stmt.(*ResolvedAssignStatement)
+			err := ab.GenerateExpr(assignStmt.Expr)
+			if err != nil {
+				return err
+			}
+			ab.emit("movq %%rax, %d(%%rbp)", ab.offsets[assignStmt.Variable])
+		case *ResolvedIfStatement:
+			ifStmt := stmt.(*ResolvedIfStatement)
+			err := ab.GenerateExpr(ifStmt.Cond)
+			if err != nil {
+				return err
+			}
+			elseLabel := ab.newLabel("else")
+			endLabel := ab.newLabel("end")
+			ab.emit("testq %%rax, %%rax")
+			ab.emit("je %s", elseLabel)
+			err = ab.GenerateBlock(ifStmt.Then)
+			if err != nil {
+				return err
+			}
+			ab.emit("jmp %s", endLabel)
+			ab.out.WriteString(elseLabel + ":\n")
+			if ifStmt.Else != nil {
+				err = ab.GenerateBlock(ifStmt.Else)
+				if err != nil {
+					return err
+				}
+			}
+			ab.out.WriteString(endLabel + ":\n")
+		case *ResolvedWhileStatement:
+			whileStmt := stmt.(*ResolvedWhileStatement)
+			condLabel := ab.newLabel("cond")
+			endLabel := ab.newLabel("end")
+			ab.out.WriteString(condLabel + ":\n")
+			err := ab.GenerateExpr(whileStmt.Cond)
+			if err != nil {
+				return err
+			}
+			ab.emit("testq %%rax, %%rax")
+			ab.emit("je %s", endLabel)
+			err = ab.generateLoopBody(whileStmt.Body, asmLoop{condLabel, endLabel})
+			if err != nil {
+				return err
+			}
+			ab.emit("jmp %s", condLabel)
+			ab.out.WriteString(endLabel + ":\n")
+		case *ResolvedForStatement:
+			forStmt := stmt.(*ResolvedForStatement)
+			variable := ab.offsets[forStmt.Variable]
+			limit := ab.offsets[forStmt.Limit]
+			err := ab.GenerateExpr(forStmt.Variable.Value)
+			if err != nil {
+				return err
+			}
+			ab.emit("movq %%rax, %d(%%rbp)", variable)
+			err = ab.GenerateExpr(forStmt.Limit.Value)
+			if err != nil {
+				return err
+			}
+			ab.emit("movq %%rax, %d(%%rbp)", limit)
+
+			condLabel := ab.newLabel("cond")
+			stepLabel := ab.newLabel("step")
+			endLabel := ab.newLabel("end")
+			ab.out.WriteString(condLabel + ":\n")
+			ab.emit("movq %d(%%rbp), %%rax", variable)
+			ab.emit("cmpq %d(%%rbp), %%rax", limit)
+			ab.emit("jge %s", endLabel)
+			err = ab.generateLoopBody(forStmt.Body, asmLoop{stepLabel, endLabel})
+			if err != nil {
+				return err
+			}
+			ab.out.WriteString(stepLabel + ":\n")
+			ab.emit("incq %d(%%rbp)", variable)
+			ab.emit("jmp %s", condLabel)
+			ab.out.WriteString(endLabel + ":\n")
+		case *ResolvedBreakStatement:
+			ab.emit("jmp %s", ab.loops[len(ab.loops)-1].breakLabel)
+		case *ResolvedContinueStatement:
+			ab.emit("jmp %s", ab.loops[len(ab.loops)-1].continueLabel)
+		default:
+			return fmt.Errorf("Unknown statement type %s", stmt.GetStmtType())
+		}
+	}
+	return nil
+}
+
+// Every supported value fits in a register
+func asmCheckType(t Type) error {
+	switch t.Kind {
+	case TypeType_INT, TypeType_BOOL, TypeType_VOID:
+		return nil
+	}
+	return fmt.Errorf("Type %s is not supported by the assembly backend", t)
+}
+
+func (ab *AsmBackend) GenerateFunction(fn *ResolvedFunctionDeclaration) error {
+	err := asmCheckType(fn.ReturnType)
+	for _, param := range fn.Params {
+		if err == nil {
+			err = asmCheckType(param.(*ResolvedVariableDeclaration).Type)
+		}
+	}
+	for _, local := range fn.Body.Locals() {
+		if err == nil {
+			err = asmCheckType(local.Type)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("Error generating function %s: %s", fn.GetId(), err)
+	}
+
+	ab.offsets = make(map[ResolvedDeclaration]int)
+	ab.depth = 0
+	ab.labels = 0
+	ab.currentFunction = asmFunctionName(fn.GetId())
+	ab.returnLabel = ".L" + ab.currentFunction + "_return"
+
+	// Register parameters are spilled below %rbp, followed by the locals.
+	// Stack parameters stay where the caller put them.
+	registerParams := min(len(fn.Params), len(asmArgRegisters))
+	for i, param := range fn.Params {
+		if i < len(asmArgRegisters) {
+			ab.offsets[param] = -8 * (i + 1)
+		} else {
+			ab.offsets[param] = 16 + 8*(i-len(asmArgRegisters))
+		}
+	}
+	locals := fn.Body.Locals()
+	for i, local := range locals {
+		ab.offsets[local] = -8 * (registerParams + i + 1)
+	}
+	frameSize := 8 * (registerParams + len(locals))
+	frameSize += frameSize % 16
+
+	name := asmFunctionName(fn.GetId())
+	ab.out.WriteString("\n\t.globl " + name + "\n\t.type " + name + ", @function\n" + name + ":\n")
+	ab.emit("pushq %%rbp")
+	ab.emit("movq %%rsp, %%rbp")
+	if frameSize > 0 {
+		ab.emit("subq $%d, %%rsp", frameSize)
+	}
+	for i := 0; i < registerParams; i++ {
+		ab.emit("movq %s, %d(%%rbp)", asmArgRegisters[i], ab.offsets[fn.Params[i]])
+	}
+
+	err = ab.GenerateBlock(fn.Body)
+	if err != nil {
+		return fmt.Errorf("Error generating function %s: %s", fn.GetId(), err)
+	}
+
+	// Void functions may fall off the end of their body into the epilogue
+	ab.out.WriteString(ab.returnLabel + ":\n")
+	ab.emit("leave")
+	ab.emit("ret")
+	return nil
+}
+
+func (ab *AsmBackend) Generate() (string, error) {
+	ab.out.Reset()
+	ab.divisionErrors = nil
+
+	var main *ResolvedFunctionDeclaration
+	for _, decl := range ab.Declarations {
+		fn, ok := decl.(*ResolvedFunctionDeclaration)
+		if ok && fn.GetId() == "main" {
+			main = fn
+		}
+	}
+	if main == nil {
+		return "", fmt.Errorf("No main function found")
+	}
+
+	ab.out.WriteString("\t.text\n\n\t.globl _start\n_start:\n")
+	ab.emit("call %s", asmFunctionName(main.GetId()))
+	if main.ReturnType.Kind == TypeType_VOID {
+		ab.emit("xorl %%edi, %%edi")
+	} else {
+		ab.emit("movq %%rax, %%rdi")
+	}
+	ab.emit("movq $60, %%rax")
+	ab.emit("syscall")
+
+	for _, decl := range ab.Declarations {
+		fn, ok := decl.(*ResolvedFunctionDeclaration)
+		if !ok {
+			continue
+		}
+
+		err := ab.GenerateFunction(fn)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if len(ab.divisionErrors) > 0 {
+		ab.out.WriteString(asmDivisionFail)
+		ab.out.WriteString("\n\t.section .rodata\n")
+		for i, message := range ab.divisionErrors {
+			ab.out.WriteString(fmt.Sprintf(".Lbaisl_division_error_%d:\n\t.ascii %s\n", i, strconv.Quote(message)))
+		}
+	}
+
+	ab.out.WriteString("\n\t.section .note.GNU-stack,\"\",@progbits\n")
+	return ab.out.String(), nil
+}
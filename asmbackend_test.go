@@ -0,0 +1,219 @@
+package baisl_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/frodi-karlsson/baisl"
+)
+
+type asmBackendTest struct {
+	path     string
+	expected string
+}
+
+var asmBackendTests = []asmBackendTest{
+	{"raw/fnCall.baisl", `	.text
+
+	.globl _start
+_start:
+	call baisl_main
+	movq %rax, %rdi
+	movq $60, %rax
+	syscall
+
+	.globl baisl_returnParam
+	.type baisl_returnParam, @function
+baisl_returnParam:
+	pushq %rbp
+	movq %rsp, %rbp
+	subq $16, %rsp
+	movq %rdi, -8(%rbp)
+	movq -8(%rbp), %rax
+	jmp .Lbaisl_returnParam_return
+.Lbaisl_returnParam_return:
+	leave
+	ret
+
+	.globl baisl_main
+	.type baisl_main, @function
+baisl_main:
+	pushq %rbp
+	movq %rsp, %rbp
+	subq $8, %rsp
+	movq $5, %rax
+	pushq %rax
+	movq 0(%rsp), %rdi
+	call baisl_returnParam
+	addq $16, %rsp
+	jmp .Lbaisl_main_return
+.Lbaisl_main_return:
+	leave
+	ret
+
+	.section .note.GNU-stack,"",@progbits
+`},
+	{"raw/divByZero.baisl", `	.text
+
+	.globl _start
+_start:
+	call baisl_main
+	movq %rax, %rdi
+	movq $60, %rax
+	syscall
+
+	.globl baisl_main
+	.type baisl_main, @function
+baisl_main:
+	pushq %rbp
+	movq %rsp, %rbp
+	movq $1, %rax
+	pushq %rax
+	movq $2, %rax
+	pushq %rax
+	movq $2, %rax
+	movq %rax, %rcx
+	popq %rax
+	subq %rcx, %rax
+	movq %rax, %rcx
+	popq %rax
+	testq %rcx, %rcx
+	jne .Lbaisl_main_divisor_0
+	leaq .Lbaisl_division_error_0(%rip), %rsi
+	movq $25, %rdx
+	jmp baisl_division_fail
+.Lbaisl_main_divisor_0:
+	cmpq $-1, %rcx
+	jne .Lbaisl_main_divide_1
+	negq %rax
+	jmp .Lbaisl_main_divided_2
+.Lbaisl_main_divide_1:
+	cqto
+	idivq %rcx
+.Lbaisl_main_divided_2:
+	jmp .Lbaisl_main_return
+.Lbaisl_main_return:
+	leave
+	ret
+
+baisl_division_fail:
+	movq $1, %rax
+	movq $2, %rdi
+	syscall
+	movq $60, %rax
+	movq $1, %rdi
+	syscall
+
+	.section .rodata
+.Lbaisl_division_error_0:
+	.ascii "Division by zero at 2:12\n"
+
+	.section .note.GNU-stack,"",@progbits
+`},
+}
+
+func TestAsmBackend(t *testing.T) {
+	for _, test := range asmBackendTests {
+		backend := baisl.AsmBackend{
+			Declarations: getResolvedDeclarations(t, test.path),
+		}
+
+		result, err := backend.Generate()
+		if err != nil {
+			t.Errorf("Error generating assembly for %s: %s", test.path, err)
+			continue
+		}
+
+		if result != test.expected {
+			t.Errorf("\nExpected <%s>, got <%s>", test.expected, result)
+		}
+	}
+}
+
+// Assembles and links a program, returning the path of the binary
+func assembleProgram(t *testing.T, as string, ld string, dir string, path string) (string, bool) {
+	backend := baisl.AsmBackend{
+		Declarations: getResolvedDeclarations(t, path),
+	}
+
+	result, err := backend.Generate()
+	if err != nil {
+		t.Errorf("Error generating assembly for %s: %s", path, err)
+		return "", false
+	}
+
+	name := filepath.Base(path)
+	source := filepath.Join(dir, name+".s")
+	object := filepath.Join(dir, name+".o")
+	binary := filepath.Join(dir, name)
+	err = os.WriteFile(source, []byte(result), 0644)
+	if err != nil {
+		t.Fatalf("Error writing %s: %s", source, err)
+	}
+
+	output, err := exec.Command(as, "-o", object, source).CombinedOutput()
+	if err != nil {
+		t.Errorf("Error assembling %s: %s\n%s", path, err, output)
+		return "", false
+	}
+
+	output, err = exec.Command(ld, "-o", binary, object).CombinedOutput()
+	if err != nil {
+		t.Errorf("Error linking %s: %s\n%s", path, err, output)
+		return "", false
+	}
+	return binary, true
+}
+
+// Returns the assembler and linker, skipping the test where the output can't run
+func assemblyTools(t *testing.T) (string, string) {
+	if runtime.GOOS != "linux" || runtime.GOARCH != "amd64" {
+		t.Skip("Assembly output only runs on x86-64 Linux")
+	}
+	as, err := exec.LookPath("as")
+	if err != nil {
+		t.Skip("No assembler found")
+	}
+	ld, err := exec.LookPath("ld")
+	if err != nil {
+		t.Skip("No linker found")
+	}
+	return as, ld
+}
+
+func TestAsmBackendAssembles(t *testing.T) {
+	as, ld := assemblyTools(t)
+
+	dir := t.TempDir()
+	for _, test := range compiledProgramTests {
+		binary, ok := assembleProgram(t, as, ld, dir, test.path)
+		if !ok {
+			continue
+		}
+
+		exitCode := runProgram(t, binary)
+		if exitCode != test.exitCode {
+			t.Errorf("Running %s, expected exit code %d, got %d", test.path, test.exitCode, exitCode)
+		}
+	}
+}
+
+func TestAsmBackendRuntimeErrors(t *testing.T) {
+	as, ld := assemblyTools(t)
+
+	dir := t.TempDir()
+	for _, test := range divisionErrorTests {
+		binary, ok := assembleProgram(t, as, ld, dir, test.path)
+		if !ok {
+			continue
+		}
+
+		got := programError(t, binary)
+		if got != test.expected {
+			t.Errorf("Running %s, expected error <%s>, got <%s>", test.path, test.expected, got)
+		}
+	}
+}
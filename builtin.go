@@ -0,0 +1,33 @@
+package baisl
+
+// A function provided by the language instead of declared by the program
+type Builtin int
+
+const (
+	// Writes its argument to the program's output
+	Builtin_PRINT Builtin = iota
+	// Like print, followed by a newline
+	Builtin_PRINTLN
+	// Returns the number of elements of an array or slice
+	Builtin_LEN
+)
+
+// Builtins are only found when no declaration in scope has their name, so a program may shadow them
+var builtinNames = map[string]Builtin{
+	"print":   Builtin_PRINT,
+	"println": Builtin_PRINTLN,
+	"len":     Builtin_LEN,
+}
+
+func (b Builtin) String() string {
+	switch b {
+	case Builtin_PRINT:
+		return "print"
+	case Builtin_PRINTLN:
+		return "println"
+	case Builtin_LEN:
+		return "len"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,1015 @@
+package baisl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+type Opcode byte
+
+const (
+	// Pushes its operand
+	Opcode_PUSH_INT Opcode = iota
+	// Pushes the local with the operand's index, where the parameters are the first locals
+	Opcode_LOAD_LOCAL
+	// Calls the function with the operand's index, popping its arguments and pushing its result
+	Opcode_CALL
+	// Returns the value on top of the stack
+	Opcode_RET
+	// Returns from a void function
+	Opcode_RET_VOID
+	// Binary operators pop their right then left operand and push the result. The operands of DIV and MOD
+	// are the line and column of the division, reported if the divisor is zero.
+	Opcode_ADD
+	Opcode_SUB
+	Opcode_MUL
+	Opcode_DIV
+	Opcode_MOD
+	Opcode_EQ
+	Opcode_NE
+	Opcode_LT
+	Opcode_LE
+	Opcode_GT
+	Opcode_GE
+	// Unary operators replace the value on top of the stack
+	Opcode_NEG
+	Opcode_NOT
+	// Pops a value into the local with the operand's index
+	Opcode_STORE_LOCAL
+	// Jumps by the operand, relative to the start of the next instruction.
+	// The operand is a fixed width int32, so it can be patched once the target is known.
+	Opcode_JUMP
+	// Pops a bool and jumps like JUMP if it is false
+	Opcode_JUMP_IF_FALSE
+	// Pushes true if its operand is 1 and false if it is 0
+	Opcode_PUSH_BOOL
+	// Pushes the program's string constant with the operand's index
+	Opcode_PUSH_STRING
+	// Pops a value and writes it to the output, followed by a newline if the operand is 1
+	Opcode_PRINT
+	// Pops a value and discards it
+	Opcode_POP
+	// Pops the fields of the program's struct with the operand's index, last field on top,
+	// and pushes the struct built from them
+	Opcode_MAKE_STRUCT
+	// Replaces the struct on top of the stack with its field with the operand's index
+	Opcode_GET_FIELD
+	// Pops the operand's number of elements, last element on top, and pushes the array built from them
+	Opcode_MAKE_ARRAY
+	// Pops an index then an array or slice, and pushes its element at the index. The operands are
+	// the line and column of the indexing, reported if the index is out of range.
+	Opcode_INDEX
+	// Replaces the array or slice on top of the stack with its number of elements
+	Opcode_LEN
+	// Pops the payload of the variant with the second operand's index in the program's enum with the first
+	// operand's index, last value on top, and pushes the enum value built from them
+	Opcode_MAKE_VARIANT
+	// Replaces the enum value on top of the stack with the index of its variant
+	Opcode_TAG
+	// Replaces the enum value on top of the stack with its payload value with the operand's index
+	Opcode_GET_PAYLOAD
+)
+
+type opcodeInfo struct {
+	name string
+	// Number of signed varint operands following the opcode
+	operands int
+	// Whether the single operand is a little-endian int32 jump offset instead
+	jump bool
+}
+
+var opcodeInfos = map[Opcode]opcodeInfo{
+	Opcode_PUSH_INT:      {"PUSH_INT", 1, false},
+	Opcode_LOAD_LOCAL:    {"LOAD_LOCAL", 1, false},
+	Opcode_CALL:          {"CALL", 1, false},
+	Opcode_RET:           {"RET", 0, false},
+	Opcode_RET_VOID:      {"RET_VOID", 0, false},
+	Opcode_ADD:           {"ADD", 0, false},
+	Opcode_SUB:           {"SUB", 0, false},
+	Opcode_MUL:           {"MUL", 0, false},
+	Opcode_DIV:           {"DIV", 2, false},
+	Opcode_MOD:           {"MOD", 2, false},
+	Opcode_EQ:            {"EQ", 0, false},
+	Opcode_NE:            {"NE", 0, false},
+	Opcode_LT:            {"LT", 0, false},
+	Opcode_LE:            {"LE", 0, false},
+	Opcode_GT:            {"GT", 0, false},
+	Opcode_GE:            {"GE", 0, false},
+	Opcode_NEG:           {"NEG", 0, false},
+	Opcode_NOT:           {"NOT", 0, false},
+	Opcode_STORE_LOCAL:   {"STORE_LOCAL", 1, false},
+	Opcode_JUMP:          {"JUMP", 1, true},
+	Opcode_JUMP_IF_FALSE: {"JUMP_IF_FALSE", 1, true},
+	Opcode_PUSH_BOOL:     {"PUSH_BOOL", 1, false},
+	Opcode_PUSH_STRING:   {"PUSH_STRING", 1, false},
+	Opcode_PRINT:         {"PRINT", 1, false},
+	Opcode_POP:           {"POP", 0, false},
+	Opcode_MAKE_STRUCT:   {"MAKE_STRUCT", 1, false},
+	Opcode_GET_FIELD:     {"GET_FIELD", 1, false},
+	Opcode_MAKE_ARRAY:    {"MAKE_ARRAY", 1, false},
+	Opcode_INDEX:         {"INDEX", 2, false},
+	Opcode_LEN:           {"LEN", 0, false},
+	Opcode_MAKE_VARIANT:  {"MAKE_VARIANT", 2, false},
+	Opcode_TAG:           {"TAG", 0, false},
+	Opcode_GET_PAYLOAD:   {"GET_PAYLOAD", 1, false},
+}
+
+var binaryOpcodes = map[TokenType]Opcode{
+	TokenType_PLUS:    Opcode_ADD,
+	TokenType_MINUS:   Opcode_SUB,
+	TokenType_STAR:    Opcode_MUL,
+	TokenType_SLASH:   Opcode_DIV,
+	TokenType_PERCENT: Opcode_MOD,
+	TokenType_EQ:      Opcode_EQ,
+	TokenType_NEQ:     Opcode_NE,
+	TokenType_LT:      Opcode_LT,
+	TokenType_LTE:     Opcode_LE,
+	TokenType_GT:      Opcode_GT,
+	TokenType_GTE:     Opcode_GE,
+}
+
+var unaryOpcodes = map[TokenType]Opcode{
+	TokenType_MINUS: Opcode_NEG,
+	TokenType_BANG:  Opcode_NOT,
+}
+
+func (op Opcode) String() string {
+	info, ok := opcodeInfos[op]
+	if !ok {
+		return "UNKNOWN"
+	}
+	return info.name
+}
+
+type BytecodeFunction struct {
+	Name      string
+	NumParams int
+	// Number of locals besides the parameters
+	NumLocals int
+	Code      []byte
+}
+
+// The layout of a struct, which MAKE_STRUCT builds values of
+type BytecodeStruct struct {
+	Name   string
+	Fields []string
+}
+
+// The variants of an enum, which MAKE_VARIANT builds values of
+type BytecodeEnum struct {
+	Name     string
+	Variants []*BytecodeVariant
+}
+
+type BytecodeVariant struct {
+	Name string
+	// Number of values in the variant's payload
+	NumPayload int
+}
+
+// A compiled program, as produced by the BytecodeCompiler and run by the VM
+type BytecodeProgram struct {
+	Functions []*BytecodeFunction
+	// Index of main in Functions
+	Main int
+	// Constants of the string literals, which PUSH_STRING refers to by index
+	Strings []string
+	// Structs of the program, which MAKE_STRUCT refers to by index
+	Structs []*BytecodeStruct
+	// Enums of the program, which MAKE_VARIANT refers to by index
+	Enums []*BytecodeEnum
+}
+
+// Reads the instruction at pc, returning its opcode, operands and the pc of the next instruction
+func DecodeInstruction(code []byte, pc int) (Opcode, []int, int, error) {
+	if pc >= len(code) {
+		return 0, nil, 0, fmt.Errorf("Instruction pointer %d out of range", pc)
+	}
+
+	op := Opcode(code[pc])
+	info, ok := opcodeInfos[op]
+	if !ok {
+		return 0, nil, 0, fmt.Errorf("Unknown opcode %d at %d", op, pc)
+	}
+
+	pc++
+	if info.jump {
+		if pc+4 > len(code) {
+			return 0, nil, 0, fmt.Errorf("Malformed operand of %s at %d", info.name, pc)
+		}
+		return op, []int{int(int32(binary.LittleEndian.Uint32(code[pc:])))}, pc + 4, nil
+	}
+
+	operands := make([]int, info.operands)
+	for i := range operands {
+		value, n := binary.Varint(code[pc:])
+		if n <= 0 {
+			return 0, nil, 0, fmt.Errorf("Malformed operand of %s at %d", info.name, pc)
+		}
+		operands[i] = int(value)
+		pc += n
+	}
+	return op, operands, pc, nil
+}
+
+func (p *BytecodeProgram) Disassemble() string {
+	out := ""
+	for _, fn := range p.Functions {
+		out += fmt.Sprintf("function %s (params %d, locals %d):\n", fn.Name, fn.NumParams, fn.NumLocals)
+		for pc := 0; pc < len(fn.Code); {
+			op, operands, next, err := DecodeInstruction(fn.Code, pc)
+			if err != nil {
+				out += fmt.Sprintf("  %04d <%s>\n", pc, err)
+				break
+			}
+
+			line := fmt.Sprintf("  %04d %s", pc, op)
+			for _, operand := range operands {
+				line += fmt.Sprintf(" %d", operand)
+			}
+			if op == Opcode_CALL && operands[0] >= 0 && operands[0] < len(p.Functions) {
+				line += " ; " + p.Functions[operands[0]].Name
+			}
+			if op == Opcode_JUMP || op == Opcode_JUMP_IF_FALSE {
+				line += fmt.Sprintf(" ; %04d", next+operands[0])
+			}
+			if op == Opcode_PUSH_STRING && operands[0] >= 0 && operands[0] < len(p.Strings) {
+				line += " ; " + strconv.Quote(p.Strings[operands[0]])
+			}
+			if op == Opcode_MAKE_STRUCT && operands[0] >= 0 && operands[0] < len(p.Structs) {
+				line += " ; " + p.Structs[operands[0]].Name
+			}
+			if op == Opcode_MAKE_VARIANT && p.hasVariant(operands[0], operands[1]) {
+				line += " ; " + p.Enums[operands[0]].Name + "." + p.Enums[operands[0]].Variants[operands[1]].Name
+			}
+			out += line + "\n"
+			pc = next
+		}
+	}
+	return out
+}
+
+// Whether the program has an enum with the given index with a variant with the given index
+func (p *BytecodeProgram) hasVariant(enum int, variant int) bool {
+	return enum >= 0 && enum < len(p.Enums) && variant >= 0 && variant < len(p.Enums[enum].Variants)
+}
+
+// Identifies .baislc files, followed by the format version
+const bytecodeMagic = "BAISLC"
+
+// Bumped whenever the encoding changes, so stale cached files are rejected instead of misread
+const BytecodeVersion = 9
+
+func appendBytecodeString(out []byte, value string) []byte {
+	out = binary.AppendUvarint(out, uint64(len(value)))
+	return append(out, value...)
+}
+
+// Serializes the program into the .baislc format
+func (p *BytecodeProgram) Encode() []byte {
+	out := []byte(bytecodeMagic)
+	out = append(out, BytecodeVersion)
+	out = binary.AppendUvarint(out, uint64(p.Main))
+	out = binary.AppendUvarint(out, uint64(len(p.Strings)))
+	for _, value := range p.Strings {
+		out = appendBytecodeString(out, value)
+	}
+	out = binary.AppendUvarint(out, uint64(len(p.Structs)))
+	for _, structDef := range p.Structs {
+		out = appendBytecodeString(out, structDef.Name)
+		out = binary.AppendUvarint(out, uint64(len(structDef.Fields)))
+		for _, field := range structDef.Fields {
+			out = appendBytecodeString(out, field)
+		}
+	}
+	out = binary.AppendUvarint(out, uint64(len(p.Enums)))
+	for _, enumDef := range p.Enums {
+		out = appendBytecodeString(out, enumDef.Name)
+		out = binary.AppendUvarint(out, uint64(len(enumDef.Variants)))
+		for _, variant := range enumDef.Variants {
+			out = appendBytecodeString(out, variant.Name)
+			out = binary.AppendUvarint(out, uint64(variant.NumPayload))
+		}
+	}
+	out = binary.AppendUvarint(out, uint64(len(p.Functions)))
+	for _, fn := range p.Functions {
+		out = appendBytecodeString(out, fn.Name)
+		out = binary.AppendUvarint(out, uint64(fn.NumParams))
+		out = binary.AppendUvarint(out, uint64(fn.NumLocals))
+		out = appendBytecodeString(out, string(fn.Code))
+	}
+	return out
+}
+
+type bytecodeReader struct {
+	reader *bytes.Reader
+}
+
+func (br *bytecodeReader) readUvarint() (int, error) {
+	value, err := binary.ReadUvarint(br.reader)
+	if err != nil {
+		return 0, fmt.Errorf("Truncated bytecode")
+	}
+	if value > math.MaxInt32 {
+		return 0, fmt.Errorf("Bytecode value %d out of range", value)
+	}
+	return int(value), nil
+}
+
+func (br *bytecodeReader) readString() (string, error) {
+	length, err := br.readUvarint()
+	if err != nil {
+		return "", err
+	}
+	if length > br.reader.Len() {
+		return "", fmt.Errorf("Truncated bytecode")
+	}
+
+	value := make([]byte, length)
+	_, _ = br.reader.Read(value)
+	return string(value), nil
+}
+
+// Checks that every instruction of a function decodes, refers to existing functions and locals,
+// and jumps to the start of an instruction or the end of the code
+func (p *BytecodeProgram) validateFunction(fn *BytecodeFunction) error {
+	starts := map[int]bool{len(fn.Code): true}
+	jumpTargets := make(map[int]int)
+	for pc := 0; pc < len(fn.Code); {
+		starts[pc] = true
+		op, operands, next, err := DecodeInstruction(fn.Code, pc)
+		if err != nil {
+			return err
+		}
+
+		switch op {
+		case Opcode_LOAD_LOCAL, Opcode_STORE_LOCAL:
+			if operands[0] < 0 || operands[0] >= fn.NumParams+fn.NumLocals {
+				return fmt.Errorf("Local %d out of range at %d", operands[0], pc)
+			}
+		case Opcode_CALL:
+			if operands[0] < 0 || operands[0] >= len(p.Functions) {
+				return fmt.Errorf("Function %d out of range at %d", operands[0], pc)
+			}
+		case Opcode_PUSH_STRING:
+			if operands[0] < 0 || operands[0] >= len(p.Strings) {
+				return fmt.Errorf("String %d out of range at %d", operands[0], pc)
+			}
+		case Opcode_MAKE_STRUCT:
+			if operands[0] < 0 || operands[0] >= len(p.Structs) {
+				return fmt.Errorf("Struct %d out of range at %d", operands[0], pc)
+			}
+		case Opcode_MAKE_VARIANT:
+			if !p.hasVariant(operands[0], operands[1]) {
+				return fmt.Errorf("Variant %d of enum %d out of range at %d", operands[1], operands[0], pc)
+			}
+		case Opcode_MAKE_ARRAY:
+			if operands[0] < 1 {
+				return fmt.Errorf("Array length %d out of range at %d", operands[0], pc)
+			}
+		case Opcode_JUMP, Opcode_JUMP_IF_FALSE:
+			jumpTargets[pc] = next + operands[0]
+		}
+		pc = next
+	}
+
+	for pc, target := range jumpTargets {
+		if !starts[target] {
+			return fmt.Errorf("Jump target %d out of range at %d", target, pc)
+		}
+	}
+	return nil
+}
+
+// Reads a program from the .baislc format
+func DecodeBytecode(data []byte) (*BytecodeProgram, error) {
+	if !bytes.HasPrefix(data, []byte(bytecodeMagic)) {
+		return nil, fmt.Errorf("Not a baisl bytecode file")
+	}
+	data = data[len(bytecodeMagic):]
+	if len(data) == 0 || data[0] != BytecodeVersion {
+		return nil, fmt.Errorf("Unsupported bytecode version, expected %d", BytecodeVersion)
+	}
+
+	br := bytecodeReader{reader: bytes.NewReader(data[1:])}
+	main, err := br.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+
+	program := &BytecodeProgram{
+		Main: main,
+	}
+	count, err := br.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < count; i++ {
+		value, err := br.readString()
+		if err != nil {
+			return nil, err
+		}
+		program.Strings = append(program.Strings, value)
+	}
+
+	count, err = br.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < count; i++ {
+		name, err := br.readString()
+		if err != nil {
+			return nil, err
+		}
+		numFields, err := br.readUvarint()
+		if err != nil {
+			return nil, err
+		}
+		structDef := &BytecodeStruct{Name: name}
+		for j := 0; j < numFields; j++ {
+			field, err := br.readString()
+			if err != nil {
+				return nil, err
+			}
+			structDef.Fields = append(structDef.Fields, field)
+		}
+		program.Structs = append(program.Structs, structDef)
+	}
+
+	count, err = br.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < count; i++ {
+		name, err := br.readString()
+		if err != nil {
+			return nil, err
+		}
+		numVariants, err := br.readUvarint()
+		if err != nil {
+			return nil, err
+		}
+		enumDef := &BytecodeEnum{Name: name}
+		for j := 0; j < numVariants; j++ {
+			variantName, err := br.readString()
+			if err != nil {
+				return nil, err
+			}
+			numPayload, err := br.readUvarint()
+			if err != nil {
+				return nil, err
+			}
+			enumDef.Variants = append(enumDef.Variants, &BytecodeVariant{Name: variantName, NumPayload: numPayload})
+		}
+		program.Enums = append(program.Enums, enumDef)
+	}
+
+	count, err = br.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	program.Functions = make([]*BytecodeFunction, 0, min(count, 1024))
+	for i := 0; i < count; i++ {
+		name, err := br.readString()
+		if err != nil {
+			return nil, err
+		}
+		numParams, err := br.readUvarint()
+		if err != nil {
+			return nil, err
+		}
+		numLocals, err := br.readUvarint()
+		if err != nil {
+			return nil, err
+		}
+		code, err := br.readString()
+		if err != nil {
+			return nil, err
+		}
+
+		program.Functions = append(program.Functions, &BytecodeFunction{
+			Name:      name,
+			NumParams: numParams,
+			NumLocals: numLocals,
+			Code:      []byte(code),
+		})
+	}
+	if br.reader.Len() != 0 {
+		return nil, fmt.Errorf("Trailing data after bytecode")
+	}
+	if main >= len(program.Functions) {
+		return nil, fmt.Errorf("Main function %d out of range", main)
+	}
+
+	for _, fn := range program.Functions {
+		err := program.validateFunction(fn)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid bytecode in %s: %s", fn.Name, err)
+		}
+	}
+	return program, nil
+}
+
+// Compiles resolved declarations into a BytecodeProgram
+type BytecodeCompiler struct {
+	Declarations []ResolvedDeclaration
+
+	functionIndices map[string]int
+	structIndices   map[string]int
+	enumIndices     map[string]int
+	// Indices of the parameters and locals of the current function
+	locals map[ResolvedDeclaration]int
+	// Loops enclosing the current statement, innermost last
+	loops []*bytecodeLoop
+	// String constants of the program, with the indices of their values so each is stored once
+	strings       []string
+	stringIndices map[string]int
+}
+
+// Collects the jumps of break and continue statements, to patch once the loop's code is complete
+type bytecodeLoop struct {
+	breaks    []int
+	continues []int
+}
+
+func (bc *BytecodeCompiler) emit(code []byte, op Opcode, operands ...int) []byte {
+	code = append(code, byte(op))
+	for _, operand := range operands {
+		code = binary.AppendVarint(code, int64(operand))
+	}
+	return code
+}
+
+// Emits a jump with a placeholder offset, returning the position of the operand to patch
+func (bc *BytecodeCompiler) emitJump(code []byte, op Opcode) ([]byte, int) {
+	code = append(code, byte(op))
+	return append(code, 0, 0, 0, 0), len(code)
+}
+
+// Points the jump whose operand is at the given position to target
+func (bc *BytecodeCompiler) patchJump(code []byte, operand int, target int) {
+	binary.LittleEndian.PutUint32(code[operand:], uint32(int32(target-(operand+4))))
+}
+
+// Emits a jump back to an already compiled target
+func (bc *BytecodeCompiler) emitJumpTo(code []byte, target int) []byte {
+	code, operand := bc.emitJump(code, Opcode_JUMP)
+	bc.patchJump(code, operand, target)
+	return code
+}
+
+func (bc *BytecodeCompiler) compileLoopBody(code []byte, body *ResolvedBlock, loop *bytecodeLoop) ([]byte, error) {
+	bc.loops = append(bc.loops, loop)
+	code, err := bc.CompileBlock(code, body)
+	bc.loops = bc.loops[:len(bc.loops)-1]
+	return code, err
+}
+
+func (bc *BytecodeCompiler) patchLoop(code []byte, loop *bytecodeLoop, continueTarget int, breakTarget int) {
+	for _, jump := range loop.continues {
+		bc.patchJump(code, jump, continueTarget)
+	}
+	for _, jump := range loop.breaks {
+		bc.patchJump(code, jump, breakTarget)
+	}
+}
+
+// Compiles && and || with jumps, so the right operand is only evaluated if the left one doesn't decide the result
+func (bc *BytecodeCompiler) compileLogicalExpr(code []byte, binaryExpr *ResolvedBinaryExpr) ([]byte, error) {
+	code, err := bc.CompileExpr(code, binaryExpr.Lhs)
+	if err != nil {
+		return nil, err
+	}
+
+	var shortCircuitJump, endJump int
+	code, shortCircuitJump = bc.emitJump(code, Opcode_JUMP_IF_FALSE)
+	if binaryExpr.Operator == TokenType_AND {
+		code, err = bc.CompileExpr(code, binaryExpr.Rhs)
+		if err != nil {
+			return nil, err
+		}
+		code, endJump = bc.emitJump(code, Opcode_JUMP)
+		bc.patchJump(code, shortCircuitJump, len(code))
+		code = bc.emit(code, Opcode_PUSH_BOOL, 0)
+	} else {
+		code = bc.emit(code, Opcode_PUSH_BOOL, 1)
+		code, endJump = bc.emitJump(code, Opcode_JUMP)
+		bc.patchJump(code, shortCircuitJump, len(code))
+		code, err = bc.CompileExpr(code, binaryExpr.Rhs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	bc.patchJump(code, endJump, len(code))
+	return code, nil
+}
+
+// Compiles a match into a test of the matched value's tag for each arm but the last, which the
+// analyser guarantees is taken for every variant left
+func (bc *BytecodeCompiler) compileMatch(code []byte, matchExpr *ResolvedMatchExpr) ([]byte, error) {
+	code, err := bc.CompileExpr(code, matchExpr.Value.Value)
+	if err != nil {
+		return nil, err
+	}
+	value := bc.locals[matchExpr.Value]
+	code = bc.emit(code, Opcode_STORE_LOCAL, value)
+
+	endJumps := make([]int, 0)
+	for i, arm := range matchExpr.Arms {
+		last := i == len(matchExpr.Arms)-1
+		var nextJump int
+		if !last {
+			code = bc.emit(code, Opcode_LOAD_LOCAL, value)
+			code = bc.emit(code, Opcode_TAG)
+			code = bc.emit(code, Opcode_PUSH_INT, arm.Variant.Index)
+			code = bc.emit(code, Opcode_EQ)
+			code, nextJump = bc.emitJump(code, Opcode_JUMP_IF_FALSE)
+		}
+
+		for j, binding := range arm.Bindings {
+			if binding == nil {
+				continue
+			}
+			code = bc.emit(code, Opcode_LOAD_LOCAL, value)
+			code = bc.emit(code, Opcode_GET_PAYLOAD, j)
+			code = bc.emit(code, Opcode_STORE_LOCAL, bc.locals[binding])
+		}
+		code, err = bc.CompileExpr(code, arm.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		if !last {
+			var endJump int
+			code, endJump = bc.emitJump(code, Opcode_JUMP)
+			endJumps = append(endJumps, endJump)
+			bc.patchJump(code, nextJump, len(code))
+		}
+	}
+	for _, jump := range endJumps {
+		bc.patchJump(code, jump, len(code))
+	}
+	return code, nil
+}
+
+func (bc *BytecodeCompiler) CompileExpr(code []byte, expr ResolvedExpr) ([]byte, error) {
+	switch expr.(type) {
+	case *ResolvedValueExpr:
+		return bc.emit(code, Opcode_PUSH_INT, expr.(*ResolvedValueExpr).Value), nil
+	case *ResolvedRefExpr:
+		refExpr := expr.(*ResolvedRefExpr)
+		decl := *refExpr.Value
+		if !refExpr.IsCall {
+			index, ok := bc.locals[decl]
+			if !ok {
+				return nil, fmt.Errorf("Unknown variable %s", decl.GetId())
+			}
+			return bc.emit(code, Opcode_LOAD_LOCAL, index), nil
+		}
+
+		for _, arg := range refExpr.Args {
+			var err error
+			code, err = bc.CompileExpr(code, arg)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return bc.emit(code, Opcode_CALL, bc.functionIndices[decl.GetId()]), nil
+	case *ResolvedBoolExpr:
+		value := 0
+		if expr.(*ResolvedBoolExpr).Value {
+			value = 1
+		}
+		return bc.emit(code, Opcode_PUSH_BOOL, value), nil
+	case *ResolvedStringExpr:
+		value := expr.(*ResolvedStringExpr).Value
+		index, ok := bc.stringIndices[value]
+		if !ok {
+			index = len(bc.strings)
+			bc.stringIndices[value] = index
+			bc.strings = append(bc.strings, value)
+		}
+		return bc.emit(code, Opcode_PUSH_STRING, index), nil
+	case *ResolvedBuiltinCallExpr:
+		builtinCall := expr.(*ResolvedBuiltinCallExpr)
+		code, err := bc.CompileExpr(code, builtinCall.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		if builtinCall.Builtin == Builtin_LEN {
+			return bc.emit(code, Opcode_LEN), nil
+		}
+		newline := 0
+		if builtinCall.Builtin == Builtin_PRINTLN {
+			newline = 1
+		}
+		return bc.emit(code, Opcode_PRINT, newline), nil
+	case *ResolvedStructExpr:
+		structExpr := expr.(*ResolvedStructExpr)
+		for _, field := range structExpr.Fields {
+			var err error
+			code, err = bc.CompileExpr(code, field)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return bc.emit(code, Opcode_MAKE_STRUCT, bc.structIndices[structExpr.Struct.Id]), nil
+	case *ResolvedVariantExpr:
+		variantExpr := expr.(*ResolvedVariantExpr)
+		for _, payload := range variantExpr.Payload {
+			var err error
+			code, err = bc.CompileExpr(code, payload)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return bc.emit(code, Opcode_MAKE_VARIANT, bc.enumIndices[variantExpr.Variant.Enum], variantExpr.Variant.Index), nil
+	case *ResolvedMatchExpr:
+		return bc.compileMatch(code, expr.(*ResolvedMatchExpr))
+	case *ResolvedFieldExpr:
+		fieldExpr := expr.(*ResolvedFieldExpr)
+		code, err := bc.CompileExpr(code, fieldExpr.Struct)
+		if err != nil {
+			return nil, err
+		}
+		return bc.emit(code, Opcode_GET_FIELD, fieldExpr.Index), nil
+	case *ResolvedArrayExpr:
+		arrayExpr := expr.(*ResolvedArrayExpr)
+		for _, elem := range arrayExpr.Elems {
+			var err error
+			code, err = bc.CompileExpr(code, elem)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return bc.emit(code, Opcode_MAKE_ARRAY, len(arrayExpr.Elems)), nil
+	case *ResolvedIndexExpr:
+		indexExpr := expr.(*ResolvedIndexExpr)
+		code, err := bc.CompileExpr(code, indexExpr.Array)
+		if err != nil {
+			return nil, err
+		}
+		code, err = bc.CompileExpr(code, indexExpr.Index)
+		if err != nil {
+			return nil, err
+		}
+		return bc.emit(code, Opcode_INDEX, indexExpr.Location.Line, indexExpr.Location.Column), nil
+	case *ResolvedBinaryExpr:
+		binaryExpr := expr.(*ResolvedBinaryExpr)
+		if binaryExpr.Operator == TokenType_AND || binaryExpr.Operator == TokenType_OR {
+			return bc.compileLogicalExpr(code, binaryExpr)
+		}
+
+		op, ok := binaryOpcodes[binaryExpr.Operator]
+		if !ok {
+			return nil, fmt.Errorf("Unknown binary operator %s", binaryExpr.Operator)
+		}
+
+		code, err := bc.CompileExpr(code, binaryExpr.Lhs)
+		if err != nil {
+			return nil, err
+		}
+		code, err = bc.CompileExpr(code, binaryExpr.Rhs)
+		if err != nil {
+			return nil, err
+		}
+		if op == Opcode_DIV || op == Opcode_MOD {
+			return bc.emit(code, op, binaryExpr.Location.Line, binaryExpr.Location.Column), nil
+		}
+		return bc.emit(code, op), nil
+	case *ResolvedUnaryExpr:
+		unaryExpr := expr.(*ResolvedUnaryExpr)
+		op, ok := unaryOpcodes[unaryExpr.Operator]
+		if !ok {
+			return nil, fmt.Errorf("Unknown unary operator %s", unaryExpr.Operator)
+		}
+
+		code, err := bc.CompileExpr(code, unaryExpr.Operand)
+		if err != nil {
+			return nil, err
+		}
+		return bc.emit(code, op), nil
+	}
+	return nil, fmt.Errorf("Unknown expression type %d", expr.GetExprType())
+}
+
+func (bc *BytecodeCompiler) CompileBlock(code []byte, block *ResolvedBlock) ([]byte, error) {
+	for _, stmt := range block.Stmts {
+		var err error
+		switch stmt.(type) {
+		case *ResolvedReturnStatement:
+			returnStmt := stmt.(*ResolvedReturnStatement)
+			if returnStmt.Expr == nil {
+				code = bc.emit(code, Opcode_RET_VOID)
+				continue
+			}
+
+			code, err = bc.CompileExpr(code, returnStmt.Expr)
+			if err != nil {
+				return nil, err
+			}
+			code = bc.emit(code, Opcode_RET)
+		case *ResolvedLetStatement:
+			variable := stmt.(*ResolvedLetStatement).Variable
+			code, err = bc.CompileExpr(code, variable.Value)
+			if err != nil {
+				return nil, err
+			}
+			code = bc.emit(code, Opcode_STORE_LOCAL, bc.locals[variable])
+		case *ResolvedAssignStatement:
+			assignStmt := stmt.(*ResolvedAssignStatement)
+			code, err = bc.CompileExpr(code, assignStmt.Expr)
+			if err != nil {
+				return nil, err
+			}
+			code = bc.emit(code, Opcode_STORE_LOCAL, bc.locals[assignStmt.Variable])
+		case *ResolvedExprStatement:
+			expr := stmt.(*ResolvedExprStatement).Expr
+			code, err = bc.CompileExpr(code, expr)
+			if err != nil {
+				return nil, err
+			}
+			if expr.GetType() != Type_VOID {
+				code = bc.emit(code, Opcode_POP)
+			}
+		case *ResolvedIfStatement:
+			ifStmt := stmt.(*ResolvedIfStatement)
+			code, err = bc.CompileExpr(code, ifStmt.Cond)
+			if err != nil {
+				return nil, err
+			}
+
+			var elseJump, endJump int
+			code, elseJump = bc.emitJump(code, Opcode_JUMP_IF_FALSE)
+			code, err = bc.CompileBlock(code, ifStmt.Then)
+			if err != nil {
+				return nil, err
+			}
+			if ifStmt.Else == nil {
+				bc.patchJump(code, elseJump, len(code))
+				continue
+			}
+
+			code, endJump = bc.emitJump(code, Opcode_JUMP)
+			bc.patchJump(code, elseJump, len(code))
+			code, err = bc.CompileBlock(code, ifStmt.Else)
+			if err != nil {
+				return nil, err
+			}
+			bc.patchJump(code, endJump, len(code))
+		case *ResolvedWhileStatement:
+			whileStmt := stmt.(*ResolvedWhileStatement)
+			condStart := len(code)
+			code, err = bc.CompileExpr(code, whileStmt.Cond)
+			if err != nil {
+				return nil, err
+			}
+			var endJump int
+			code, endJump = bc.emitJump(code, Opcode_JUMP_IF_FALSE)
+
+			loop := &bytecodeLoop{}
+			code, err = bc.compileLoopBody(code, whileStmt.Body, loop)
+			if err != nil {
+				return nil, err
+			}
+			code = bc.emitJumpTo(code, condStart)
+			bc.patchJump(code, endJump, len(code))
+			bc.patchLoop(code, loop, condStart, len(code))
+		case *ResolvedForStatement:
+			forStmt := stmt.(*ResolvedForStatement)
+			variable := bc.locals[forStmt.Variable]
+			limit := bc.locals[forStmt.Limit]
+			code, err = bc.CompileExpr(code, forStmt.Variable.Value)
+			if err != nil {
+				return nil, err
+			}
+			code = bc.emit(code, Opcode_STORE_LOCAL, variable)
+			code, err = bc.CompileExpr(code, forStmt.Limit.Value)
+			if err != nil {
+				return nil, err
+			}
+			code = bc.emit(code, Opcode_STORE_LOCAL, limit)
+
+			condStart := len(code)
+			code = bc.emit(code, Opcode_LOAD_LOCAL, variable)
+			code = bc.emit(code, Opcode_LOAD_LOCAL, limit)
+			code = bc.emit(code, Opcode_LT)
+			var endJump int
+			code, endJump = bc.emitJump(code, Opcode_JUMP_IF_FALSE)
+
+			loop := &bytecodeLoop{}
+			code, err = bc.compileLoopBody(code, forStmt.Body, loop)
+			if err != nil {
+				return nil, err
+			}
+			stepStart := len(code)
+			code = bc.emit(code, Opcode_LOAD_LOCAL, variable)
+			code = bc.emit(code, Opcode_PUSH_INT, 1)
+			code = bc.emit(code, Opcode_ADD)
+			code = bc.emit(code, Opcode_STORE_LOCAL, variable)
+			code = bc.emitJumpTo(code, condStart)
+			bc.patchJump(code, endJump, len(code))
+			bc.patchLoop(code, loop, stepStart, len(code))
+		case *ResolvedBreakStatement:
+			loop := bc.loops[len(bc.loops)-1]
+			var jump int
+			code, jump = bc.emitJump(code, Opcode_JUMP)
+			loop.breaks = append(loop.breaks, jump)
+		case *ResolvedContinueStatement:
+			loop := bc.loops[len(bc.loops)-1]
+			var jump int
+			code, jump = bc.emitJump(code, Opcode_JUMP)
+			loop.continues = append(loop.continues, jump)
+		default:
+			return nil, fmt.Errorf("Unknown statement type %s", stmt.GetStmtType())
+		}
+	}
+	return code, nil
+}
+
+func (bc *BytecodeCompiler) CompileFunction(fn *ResolvedFunctionDeclaration) (*BytecodeFunction, error) {
+	bc.locals = make(map[ResolvedDeclaration]int)
+	for i, param := range fn.Params {
+		bc.locals[param] = i
+	}
+	locals := fn.Body.Locals()
+	for i, local := range locals {
+		bc.locals[local] = len(fn.Params) + i
+	}
+
+	code, err := bc.CompileBlock([]byte{}, fn.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Error compiling function %s: %s", fn.GetId(), err)
+	}
+
+	// Void functions may fall off the end of their body
+	if fn.ReturnType.Kind == TypeType_VOID {
+		code = bc.emit(code, Opcode_RET_VOID)
+	}
+
+	return &BytecodeFunction{
+		Name:      fn.GetId(),
+		NumParams: len(fn.Params),
+		NumLocals: len(locals),
+		Code:      code,
+	}, nil
+}
+
+func (bc *BytecodeCompiler) Compile() (*BytecodeProgram, error) {
+	functions := make([]*ResolvedFunctionDeclaration, 0)
+	structs := make([]*BytecodeStruct, 0)
+	enums := make([]*BytecodeEnum, 0)
+	bc.functionIndices = make(map[string]int)
+	bc.structIndices = make(map[string]int)
+	bc.enumIndices = make(map[string]int)
+	for _, decl := range bc.Declarations {
+		switch decl.(type) {
+		case *ResolvedFunctionDeclaration:
+			bc.functionIndices[decl.GetId()] = len(functions)
+			functions = append(functions, decl.(*ResolvedFunctionDeclaration))
+		case *ResolvedStructDeclaration:
+			structDef := &BytecodeStruct{Name: decl.GetId()}
+			for _, field := range decl.(*ResolvedStructDeclaration).Fields {
+				structDef.Fields = append(structDef.Fields, field.GetId())
+			}
+			bc.structIndices[decl.GetId()] = len(structs)
+			structs = append(structs, structDef)
+		case *ResolvedEnumDeclaration:
+			enumDef := &BytecodeEnum{Name: decl.GetId()}
+			for _, variant := range decl.(*ResolvedEnumDeclaration).Variants {
+				enumDef.Variants = append(enumDef.Variants, &BytecodeVariant{Name: variant.Id, NumPayload: len(variant.Payload)})
+			}
+			bc.enumIndices[decl.GetId()] = len(enums)
+			enums = append(enums, enumDef)
+		}
+	}
+
+	main, ok := bc.functionIndices["main"]
+	if !ok {
+		return nil, fmt.Errorf("No main function found")
+	}
+
+	bc.strings = nil
+	bc.stringIndices = make(map[string]int)
+	program := &BytecodeProgram{
+		Main: main,
+	}
+	if len(structs) > 0 {
+		program.Structs = structs
+	}
+	if len(enums) > 0 {
+		program.Enums = enums
+	}
+	for _, fn := range functions {
+		compiled, err := bc.CompileFunction(fn)
+		if err != nil {
+			return nil, err
+		}
+		program.Functions = append(program.Functions, compiled)
+	}
+	program.Strings = bc.strings
+	return program, nil
+}
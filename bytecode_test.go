@@ -0,0 +1,248 @@
+package baisl_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/frodi-karlsson/baisl"
+)
+
+type bytecodeTest struct {
+	path     string
+	expected string
+}
+
+type failBytecodeDecodeTest struct {
+	data          []byte
+	errorContains string
+	name          string
+}
+
+var bytecodeTests = []bytecodeTest{
+	{"raw/fnCall.baisl", `function returnParam (params 1, locals 0):
+  0000 LOAD_LOCAL 0
+  0002 RET
+function main (params 0, locals 0):
+  0000 PUSH_INT 5
+  0002 CALL 0 ; returnParam
+  0004 RET
+`},
+	{"raw/ret2.baisl", `function main (params 0, locals 0):
+  0000 RET_VOID
+  0001 RET_VOID
+function return2 (params 0, locals 0):
+  0000 PUSH_INT 2
+  0002 RET
+`},
+	{"raw/loopControl.baisl", `function main (params 0, locals 3):
+  0000 PUSH_INT 0
+  0002 STORE_LOCAL 0
+  0004 LOAD_LOCAL 0
+  0006 PUSH_INT 10
+  0008 LT
+  0009 JUMP_IF_FALSE 27 ; 0041
+  0014 LOAD_LOCAL 0
+  0016 PUSH_INT 3
+  0018 ADD
+  0019 STORE_LOCAL 0
+  0021 LOAD_LOCAL 0
+  0023 PUSH_INT 6
+  0025 EQ
+  0026 JUMP_IF_FALSE 5 ; 0036
+  0031 JUMP -32 ; 0004
+  0036 JUMP -37 ; 0004
+  0041 PUSH_INT 0
+  0043 STORE_LOCAL 1
+  0045 LOAD_LOCAL 0
+  0047 STORE_LOCAL 2
+  0049 LOAD_LOCAL 1
+  0051 LOAD_LOCAL 2
+  0053 LT
+  0054 JUMP_IF_FALSE 34 ; 0093
+  0059 LOAD_LOCAL 1
+  0061 PUSH_INT 2
+  0063 EQ
+  0064 JUMP_IF_FALSE 5 ; 0074
+  0069 JUMP 19 ; 0093
+  0074 LOAD_LOCAL 0
+  0076 LOAD_LOCAL 1
+  0078 ADD
+  0079 STORE_LOCAL 0
+  0081 LOAD_LOCAL 1
+  0083 PUSH_INT 1
+  0085 ADD
+  0086 STORE_LOCAL 1
+  0088 JUMP -44 ; 0049
+  0093 LOAD_LOCAL 0
+  0095 RET
+`},
+	{"raw/structs.baisl", `function length (params 1, locals 0):
+  0000 LOAD_LOCAL 0
+  0002 GET_FIELD 1
+  0004 GET_FIELD 0
+  0006 LOAD_LOCAL 0
+  0008 GET_FIELD 0
+  0010 GET_FIELD 0
+  0012 SUB
+  0013 LOAD_LOCAL 0
+  0015 GET_FIELD 1
+  0017 GET_FIELD 1
+  0019 ADD
+  0020 LOAD_LOCAL 0
+  0022 GET_FIELD 0
+  0024 GET_FIELD 1
+  0026 SUB
+  0027 RET
+function origin (params 0, locals 0):
+  0000 PUSH_INT 0
+  0002 PUSH_INT 0
+  0004 MAKE_STRUCT 0 ; Point
+  0006 RET
+function main (params 0, locals 2):
+  0000 CALL 1 ; origin
+  0002 PUSH_INT 3
+  0004 PUSH_INT 4
+  0006 MAKE_STRUCT 0 ; Point
+  0008 PUSH_STRING 0 ; "diag"
+  0010 MAKE_STRUCT 1 ; Line
+  0012 STORE_LOCAL 0
+  0014 LOAD_LOCAL 0
+  0016 GET_FIELD 1
+  0018 STORE_LOCAL 1
+  0020 LOAD_LOCAL 1
+  0022 GET_FIELD 0
+  0024 LOAD_LOCAL 1
+  0026 GET_FIELD 1
+  0028 LT
+  0029 JUMP_IF_FALSE 6 ; 0040
+  0034 LOAD_LOCAL 0
+  0036 GET_FIELD 2
+  0038 PRINT 1
+  0040 LOAD_LOCAL 1
+  0042 GET_FIELD 0
+  0044 PUSH_INT 100
+  0047 GT
+  0048 JUMP_IF_FALSE 5 ; 0058
+  0053 JUMP -18 ; 0040
+  0058 LOAD_LOCAL 0
+  0060 CALL 0 ; length
+  0062 CALL 1 ; origin
+  0064 GET_FIELD 0
+  0066 ADD
+  0067 PRINT 1
+  0069 PUSH_INT 5
+  0071 PUSH_INT 6
+  0073 MAKE_STRUCT 0 ; Point
+  0075 GET_FIELD 1
+  0077 PRINT 1
+  0079 PUSH_INT 0
+  0081 RET
+`},
+	{"raw/indexOutOfRange.baisl", `function main (params 0, locals 2):
+  0000 PUSH_INT 1
+  0002 PUSH_INT 2
+  0004 PUSH_INT 3
+  0006 MAKE_ARRAY 3
+  0008 STORE_LOCAL 0
+  0010 LOAD_LOCAL 0
+  0012 LEN
+  0013 STORE_LOCAL 1
+  0015 LOAD_LOCAL 0
+  0017 LOAD_LOCAL 1
+  0019 INDEX 4 12
+  0022 RET
+`},
+	{"raw/matchShape.baisl", `function main (params 0, locals 2):
+  0000 PUSH_INT 2
+  0002 MAKE_VARIANT 0 0 ; Shape.Circle
+  0005 STORE_LOCAL 0
+  0007 LOAD_LOCAL 0
+  0009 TAG
+  0010 PUSH_INT 0
+  0012 EQ
+  0013 JUMP_IF_FALSE 13 ; 0031
+  0018 LOAD_LOCAL 0
+  0020 GET_PAYLOAD 0
+  0022 STORE_LOCAL 1
+  0024 LOAD_LOCAL 1
+  0026 JUMP 2 ; 0033
+  0031 PUSH_INT 0
+  0033 RET
+`},
+}
+
+var failBytecodeDecodeTests = []failBytecodeDecodeTest{
+	{[]byte("ELF"), "Not a baisl bytecode file", "Wrong magic"},
+	{[]byte("BAISLC\x63"), "Unsupported bytecode version", "Wrong version"},
+	{[]byte("BAISLC\x09\x00\x00\x00\x00\x01\x04main"), "Truncated bytecode", "Truncated"},
+	{[]byte("BAISLC\x09\x01\x00\x00\x00\x01\x04main\x00\x00\x01\x03"), "Main function 1 out of range", "Main out of range"},
+	{[]byte("BAISLC\x09\x00\x00\x00\x00\x01\x04main\x00\x00\x02\x02\x02"), "Function 1 out of range", "Call out of range"},
+	{[]byte("BAISLC\x09\x00\x00\x00\x00\x01\x04main\x00\x00\x02\x01\x00"), "Local 0 out of range", "Local out of range"},
+	{[]byte("BAISLC\x09\x00\x00\x00\x00\x01\x04main\x00\x00\x05\x13\xff\xff\xff\xff"), "Jump target 4 out of range", "Jump into an instruction"},
+	{[]byte("BAISLC\x09\x00\x00\x00\x00\x01\x04main\x00\x00\x01\x63"), "Unknown opcode", "Unknown opcode"},
+	{[]byte("BAISLC\x09\x00\x01\x02hi\x00\x00\x01\x04main\x00\x00\x03\x16\x02\x04"), "String 1 out of range", "String out of range"},
+	{[]byte("BAISLC\x09\x00\x00\x01\x05Point\x01\x01x\x00\x01\x04main\x00\x00\x03\x19\x02\x04"), "Struct 1 out of range", "Struct out of range"},
+	{[]byte("BAISLC\x09\x00\x00\x01\x05Point\x02\x01x"), "Truncated bytecode", "Truncated struct"},
+	{[]byte("BAISLC\x09\x00\x00\x00\x01\x05Shape\x01\x06Circle\x01\x01\x04main\x00\x00\x04\x1e\x00\x02\x04"), "Variant 1 of enum 0 out of range", "Variant out of range"},
+	{[]byte("BAISLC\x09\x00\x00\x00\x01\x05Shape\x02\x06Circle\x01"), "Truncated bytecode", "Truncated enum"},
+	{[]byte("BAISLC\x09\x00\x00\x00\x00\x01\x04main\x00\x00\x03\x1b\x00\x04"), "Array length 0 out of range", "Empty array"},
+	{[]byte("BAISLC\x09\x00\x00\x00\x00\x01\x04main\x00\x00\x01\x03\x00"), "Trailing data", "Trailing data"},
+}
+
+func compileBytecode(t *testing.T, path string) *baisl.BytecodeProgram {
+	compiler := baisl.BytecodeCompiler{
+		Declarations: getResolvedDeclarations(t, path),
+	}
+
+	program, err := compiler.Compile()
+	if err != nil {
+		t.Fatalf("Error compiling %s: %s", path, err)
+	}
+	return program
+}
+
+func TestBytecodeCompiler(t *testing.T) {
+	for _, test := range bytecodeTests {
+		result := compileBytecode(t, test.path).Disassemble()
+		if result != test.expected {
+			t.Errorf("\nExpected <%s>, got <%s>", test.expected, result)
+		}
+	}
+}
+
+func TestBytecodeEncoding(t *testing.T) {
+	paths := []string{}
+	for _, test := range interpreterTests {
+		paths = append(paths, test.path)
+	}
+	for _, test := range outputTests {
+		paths = append(paths, test.path)
+	}
+
+	for _, path := range paths {
+		program := compileBytecode(t, path)
+
+		decoded, err := baisl.DecodeBytecode(program.Encode())
+		if err != nil {
+			t.Errorf("Error decoding %s: %s", path, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(program, decoded) {
+			t.Errorf("Decoding %s, expected <%s>, got <%s>", path, program.Disassemble(), decoded.Disassemble())
+		}
+	}
+
+	for _, test := range failBytecodeDecodeTests {
+		_, err := baisl.DecodeBytecode(test.data)
+		if err == nil {
+			t.Errorf("Expected error in %s, got none", test.name)
+			continue
+		}
+
+		if !strings.Contains(err.Error(), test.errorContains) {
+			t.Errorf("Expected error containing <%s>, got <%s>", test.errorContains, err)
+		}
+	}
+}
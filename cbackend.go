@@ -0,0 +1,826 @@
+package baisl
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Generates a C translation unit from resolved declarations. The program's main becomes
+// the C entry point, with its return value used as the process exit code.
+type CBackend struct {
+	Declarations []ResolvedDeclaration
+
+	// Numbers telling apart the variables of the current function that share an id
+	variables map[ResolvedDeclaration]int
+	// Whether the program uses strings, printing, arrays or division, which need cRuntime
+	usesRuntime bool
+	// Struct declarations by name
+	structs map[string]*ResolvedStructDeclaration
+	// Enum declarations by name
+	enums map[string]*ResolvedEnumDeclaration
+	// Array and slice types used by the program, in order of first use, whose typedefs are generated last
+	sequences []Type
+	// Declarations of the temporaries the statement being generated assigns operands to
+	temporaries []string
+	// Number of temporaries declared by the current function, which numbers the next one
+	numTemporaries int
+}
+
+// Support code for strings, printing and arrays, only included in programs using them. Its names contain
+// a '_' after the baisl_ prefix, which baisl identifiers can't and instances of generic functions only
+// follow with a number, so they never clash with the program's.
+const cRuntime = `#include <inttypes.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+typedef struct {
+	int64_t len;
+	const char *data;
+} baisl_string;
+
+static baisl_string baisl_rt_concat(baisl_string a, baisl_string b) {
+	char *data = malloc(a.len + b.len);
+	memcpy(data, a.data, a.len);
+	memcpy(data + a.len, b.data, b.len);
+	return (baisl_string){a.len + b.len, data};
+}
+
+static void baisl_rt_print_string(baisl_string value, bool newline) {
+	fwrite(value.data, 1, value.len, stdout);
+	if (newline) {
+		putchar('\n');
+	}
+}
+
+static void baisl_rt_print_int(int64_t value, bool newline) {
+	printf("%" PRId64, value);
+	if (newline) {
+		putchar('\n');
+	}
+}
+
+static void baisl_rt_print_bool(bool value, bool newline) {
+	baisl_rt_print_string(value ? (baisl_string){4, "true"} : (baisl_string){5, "false"}, newline);
+}
+
+static void *baisl_rt_copy(const void *data, size_t size) {
+	void *copy = malloc(size);
+	memcpy(copy, data, size);
+	return copy;
+}
+
+static void baisl_rt_index_fail(int64_t index, int64_t len, int line, int column) {
+	fprintf(stderr, "Index %" PRId64 " out of range for length %" PRId64 " at %d:%d\n", index, len, line, column);
+	exit(1);
+}
+
+// Dividing the smallest int64_t by -1 overflows, so it wraps around instead, like in Go
+static int64_t baisl_rt_divide(int64_t lhs, int64_t rhs, int line, int column) {
+	if (rhs == 0) {
+		fprintf(stderr, "Division by zero at %d:%d\n", line, column);
+		exit(1);
+	}
+	return rhs == -1 ? (int64_t)(0 - (uint64_t)lhs) : lhs / rhs;
+}
+
+static int64_t baisl_rt_remainder(int64_t lhs, int64_t rhs, int line, int column) {
+	if (rhs == 0) {
+		fprintf(stderr, "Division by zero at %d:%d\n", line, column);
+		exit(1);
+	}
+	return rhs == -1 ? 0 : lhs % rhs;
+}
+`
+
+// Names of the runtime functions dividing ints, exiting with an error for a zero divisor
+var cDivisionFunctions = map[TokenType]string{
+	TokenType_SLASH:   "baisl_rt_divide",
+	TokenType_PERCENT: "baisl_rt_remainder",
+}
+
+// Operators overflowing int64_t, which is undefined in C, so they're computed on uint64_t instead and
+// wrap around like in Go
+var cWrappingOperators = map[TokenType]bool{
+	TokenType_PLUS:  true,
+	TokenType_MINUS: true,
+	TokenType_STAR:  true,
+}
+
+// Whether a binary expression divides by a divisor that may be zero, or -1 dividing the smallest int,
+// which backends check for. Shared by the C and LLVM backends.
+func checksDivisor(binaryExpr *ResolvedBinaryExpr) bool {
+	if binaryExpr.Operator != TokenType_SLASH && binaryExpr.Operator != TokenType_PERCENT {
+		return false
+	}
+	divisor, ok := binaryExpr.Rhs.(*ResolvedValueExpr)
+	return !ok || divisor.Value == 0 || divisor.Value == -1
+}
+
+// Names of the runtime functions printing each type
+var cPrintFunctions = map[TypeKind]string{
+	TypeType_INT:    "baisl_rt_print_int",
+	TypeType_BOOL:   "baisl_rt_print_bool",
+	TypeType_STRING: "baisl_rt_print_string",
+}
+
+// Prefixes keep baisl identifiers from clashing with C keywords and the C library
+func cFunctionName(id string) string {
+	return "baisl_" + symbolName(id)
+}
+
+// A C declaration is in scope in its own initializer, so variables shadowing others get a different name
+func cVariableName(id string, number int) string {
+	if number == 0 {
+		return "v_" + id
+	}
+	return "v" + strconv.Itoa(number) + "_" + id
+}
+
+// Struct fields get a prefix of their own, since they may be named like C keywords too
+func cFieldName(id string) string {
+	return "f_" + id
+}
+
+// The payload of an enum variant is a struct in a union of those of the enum's variants
+func cPayloadName(id string) string {
+	return "p_" + id
+}
+
+// Names the value with the given index in a variant's payload
+func cPayloadFieldName(variantId string, index int) string {
+	return cPayloadName(variantId) + ".f" + strconv.Itoa(index)
+}
+
+// Arrays and slices share a representation, named after the element type. An element type's symbol
+// only starts with "slice_" as part of "slice__", and never with '_', so those of different element
+// types never clash.
+func cSequenceTypeName(t Type) string {
+	elem := *t.Elem
+	if elem.IsSequence() {
+		return "baisl_slice_" + strings.TrimPrefix(cSequenceTypeName(elem), "baisl_")
+	}
+	return "baisl_slice_" + symbolName(elem.Name)
+}
+
+// Reads an element of an array or slice, exiting with an error if the index is out of range
+func cIndexFunctionName(t Type) string {
+	return cSequenceTypeName(t) + "_at"
+}
+
+func (cb *CBackend) variableName(decl ResolvedDeclaration) string {
+	return cVariableName(decl.GetId(), cb.variables[decl])
+}
+
+// Whether evaluating expr may call a function or fail at runtime, which C doesn't order among operands
+func cHasEffects(expr ResolvedExpr) bool {
+	var operands []ResolvedExpr
+	switch expr.(type) {
+	case *ResolvedRefExpr:
+		if expr.(*ResolvedRefExpr).IsCall {
+			return true
+		}
+	case *ResolvedBuiltinCallExpr:
+		builtinCall := expr.(*ResolvedBuiltinCallExpr)
+		if builtinCall.Builtin != Builtin_LEN {
+			return true
+		}
+		operands = builtinCall.Args
+	case *ResolvedIndexExpr:
+		return true
+	case *ResolvedStructExpr:
+		operands = expr.(*ResolvedStructExpr).Fields
+	case *ResolvedVariantExpr:
+		operands = expr.(*ResolvedVariantExpr).Payload
+	case *ResolvedArrayExpr:
+		operands = expr.(*ResolvedArrayExpr).Elems
+	case *ResolvedFieldExpr:
+		operands = []ResolvedExpr{expr.(*ResolvedFieldExpr).Struct}
+	case *ResolvedBinaryExpr:
+		binaryExpr := expr.(*ResolvedBinaryExpr)
+		if checksDivisor(binaryExpr) {
+			return true
+		}
+		operands = []ResolvedExpr{binaryExpr.Lhs, binaryExpr.Rhs}
+	case *ResolvedUnaryExpr:
+		operands = []ResolvedExpr{expr.(*ResolvedUnaryExpr).Operand}
+	case *ResolvedMatchExpr:
+		matchExpr := expr.(*ResolvedMatchExpr)
+		operands = []ResolvedExpr{matchExpr.Value.Value}
+		for _, arm := range matchExpr.Arms {
+			operands = append(operands, arm.Value)
+		}
+	}
+	return slices.ContainsFunc(operands, cHasEffects)
+}
+
+// Generates the operands of an expression, which C evaluates in no particular order. When more than one
+// of them has effects, those are assigned to temporaries declared before the statement, and the assignments
+// are returned to be sequenced before the expression, so the effects happen left to right.
+func (cb *CBackend) generateOperands(operands []ResolvedExpr) ([]string, string, error) {
+	effects := 0
+	for _, operand := range operands {
+		if cHasEffects(operand) {
+			effects++
+		}
+	}
+
+	out := make([]string, len(operands))
+	assignments := ""
+	for i, operand := range operands {
+		operandStr, err := cb.GenerateExpr(operand)
+		if err != nil {
+			return nil, "", err
+		}
+		if effects > 1 && cHasEffects(operand) {
+			operandType, err := cb.cType(operand.GetType())
+			if err != nil {
+				return nil, "", err
+			}
+			// Variables are prefixed with v, so temporaries can't clash with them
+			temporary := "t" + strconv.Itoa(cb.numTemporaries)
+			cb.numTemporaries++
+			cb.temporaries = append(cb.temporaries, operandType+" "+temporary)
+			assignments += temporary + " = " + operandStr + ", "
+			operandStr = temporary
+		}
+		out[i] = operandStr
+	}
+	return out, assignments, nil
+}
+
+// Sequences the assignments of generateOperands before the expression using their temporaries
+func cSequenced(assignments string, expr string) string {
+	if assignments == "" {
+		return expr
+	}
+	return "(" + assignments + expr + ")"
+}
+
+func (cb *CBackend) cType(t Type) (string, error) {
+	switch t.Kind {
+	case TypeType_INT:
+		return "int64_t", nil
+	case TypeType_VOID:
+		return "void", nil
+	case TypeType_BOOL:
+		return "bool", nil
+	case TypeType_STRING:
+		cb.usesRuntime = true
+		return "baisl_string", nil
+	case TypeType_CUSTOM:
+		return cFunctionName(t.Name), nil
+	case TypeType_ARRAY, TypeType_SLICE:
+		cb.usesRuntime = true
+		if !slices.ContainsFunc(cb.sequences, func(used Type) bool { return cSequenceTypeName(used) == cSequenceTypeName(t) }) {
+			cb.sequences = append(cb.sequences, t)
+		}
+		return cSequenceTypeName(t), nil
+	}
+	return "", fmt.Errorf("Type %s is not supported by the C backend", t)
+}
+
+func (cb *CBackend) GenerateExpr(expr ResolvedExpr) (string, error) {
+	switch expr.(type) {
+	case *ResolvedValueExpr:
+		return strconv.Itoa(expr.(*ResolvedValueExpr).Value), nil
+	case *ResolvedBoolExpr:
+		return strconv.FormatBool(expr.(*ResolvedBoolExpr).Value), nil
+	case *ResolvedStringExpr:
+		value := expr.(*ResolvedStringExpr).Value
+		cb.usesRuntime = true
+		return "(baisl_string){" + strconv.Itoa(len(value)) + ", " + cStringLiteral(value) + "}", nil
+	case *ResolvedBuiltinCallExpr:
+		builtinCall := expr.(*ResolvedBuiltinCallExpr)
+		arg, err := cb.GenerateExpr(builtinCall.Args[0])
+		if err != nil {
+			return "", err
+		}
+		if builtinCall.Builtin == Builtin_LEN {
+			return "(" + arg + ").len", nil
+		}
+		function, ok := cPrintFunctions[builtinCall.Args[0].GetType().Kind]
+		if !ok {
+			return "", fmt.Errorf("Type %s is not supported by the C backend", builtinCall.Args[0].GetType())
+		}
+		cb.usesRuntime = true
+		newline := strconv.FormatBool(builtinCall.Builtin == Builtin_PRINTLN)
+		return function + "(" + arg + ", " + newline + ")", nil
+	case *ResolvedStructExpr:
+		structExpr := expr.(*ResolvedStructExpr)
+		fields, assignments, err := cb.generateOperands(structExpr.Fields)
+		if err != nil {
+			return "", err
+		}
+		return cSequenced(assignments, "("+cFunctionName(structExpr.Struct.Id)+"){"+strings.Join(fields, ", ")+"}"), nil
+	case *ResolvedVariantExpr:
+		variantExpr := expr.(*ResolvedVariantExpr)
+		variant := variantExpr.Variant
+		out := "(" + cFunctionName(variant.Enum) + "){.tag = " + strconv.Itoa(variant.Index)
+		payload, assignments, err := cb.generateOperands(variantExpr.Payload)
+		if err != nil {
+			return "", err
+		}
+		if len(payload) > 0 {
+			out += ", ." + cPayloadName(variant.Id) + " = {" + strings.Join(payload, ", ") + "}"
+		}
+		return cSequenced(assignments, out+"}"), nil
+	case *ResolvedMatchExpr:
+		return cb.generateMatch(expr.(*ResolvedMatchExpr))
+	case *ResolvedFieldExpr:
+		fieldExpr := expr.(*ResolvedFieldExpr)
+		structStr, err := cb.GenerateExpr(fieldExpr.Struct)
+		if err != nil {
+			return "", err
+		}
+		structDecl := cb.structs[fieldExpr.Struct.GetType().Name]
+		return structStr + "." + cFieldName(structDecl.Fields[fieldExpr.Index].Id), nil
+	case *ResolvedArrayExpr:
+		arrayExpr := expr.(*ResolvedArrayExpr)
+		arrayType, err := cb.cType(arrayExpr.Type)
+		if err != nil {
+			return "", err
+		}
+		elemType, err := cb.cType(*arrayExpr.Type.Elem)
+		if err != nil {
+			return "", err
+		}
+		elems, assignments, err := cb.generateOperands(arrayExpr.Elems)
+		if err != nil {
+			return "", err
+		}
+		// The elements are copied to the heap, so slices of the array may outlive the function building it
+		length := strconv.Itoa(len(elems))
+		data := "baisl_rt_copy((" + elemType + "[]){" + strings.Join(elems, ", ") + "}, sizeof(" + elemType + "[" + length + "]))"
+		return cSequenced(assignments, "("+arrayType+"){"+length+", "+data+"}"), nil
+	case *ResolvedIndexExpr:
+		indexExpr := expr.(*ResolvedIndexExpr)
+		operands, assignments, err := cb.generateOperands([]ResolvedExpr{indexExpr.Array, indexExpr.Index})
+		if err != nil {
+			return "", err
+		}
+		location := indexExpr.Location
+		return cSequenced(assignments, fmt.Sprintf("%s(%s, %s, %d, %d)", cIndexFunctionName(indexExpr.Array.GetType()), operands[0], operands[1], location.Line, location.Column)), nil
+	case *ResolvedRefExpr:
+		refExpr := expr.(*ResolvedRefExpr)
+		decl := *refExpr.Value
+		if !refExpr.IsCall {
+			return cb.variableName(decl), nil
+		}
+
+		args, assignments, err := cb.generateOperands(refExpr.Args)
+		if err != nil {
+			return "", err
+		}
+		return cSequenced(assignments, cFunctionName(decl.GetId())+"("+strings.Join(args, ", ")+")"), nil
+	case *ResolvedBinaryExpr:
+		binaryExpr := expr.(*ResolvedBinaryExpr)
+		if binaryExpr.Operator == TokenType_AND || binaryExpr.Operator == TokenType_OR {
+			// C's && and || short-circuit like baisl's, evaluating their operands in order
+			lhs, err := cb.GenerateExpr(binaryExpr.Lhs)
+			if err != nil {
+				return "", err
+			}
+			rhs, err := cb.GenerateExpr(binaryExpr.Rhs)
+			if err != nil {
+				return "", err
+			}
+			return "(" + lhs + " " + TokenTypeToOperator[binaryExpr.Operator] + " " + rhs + ")", nil
+		}
+
+		operands, assignments, err := cb.generateOperands([]ResolvedExpr{binaryExpr.Lhs, binaryExpr.Rhs})
+		if err != nil {
+			return "", err
+		}
+		if binaryExpr.Type == Type_STRING {
+			return cSequenced(assignments, "baisl_rt_concat("+operands[0]+", "+operands[1]+")"), nil
+		}
+		if checksDivisor(binaryExpr) {
+			cb.usesRuntime = true
+			location := binaryExpr.Location
+			return cSequenced(assignments, fmt.Sprintf("%s(%s, %s, %d, %d)", cDivisionFunctions[binaryExpr.Operator], operands[0], operands[1], location.Line, location.Column)), nil
+		}
+		if binaryExpr.Type == Type_INT && cWrappingOperators[binaryExpr.Operator] {
+			return cSequenced(assignments, "(int64_t)((uint64_t)"+operands[0]+" "+TokenTypeToOperator[binaryExpr.Operator]+" (uint64_t)"+operands[1]+")"), nil
+		}
+		return cSequenced(assignments, "("+operands[0]+" "+TokenTypeToOperator[binaryExpr.Operator]+" "+operands[1]+")"), nil
+	case *ResolvedUnaryExpr:
+		unaryExpr := expr.(*ResolvedUnaryExpr)
+		operand, err := cb.GenerateExpr(unaryExpr.Operand)
+		if err != nil {
+			return "", err
+		}
+		// Negating the smallest int overflows, but a literal is never that
+		_, isLiteral := unaryExpr.Operand.(*ResolvedValueExpr)
+		if unaryExpr.Operator == TokenType_MINUS && !isLiteral {
+			return "(int64_t)(0 - (uint64_t)" + operand + ")", nil
+		}
+		return "(" + TokenTypeToOperator[unaryExpr.Operator] + operand + ")", nil
+	}
+	return "", fmt.Errorf("Unknown expression type %d", expr.GetExprType())
+}
+
+// Generates a match as a conditional expression testing the matched value's tag for each arm but
+// the last, which the analyser guarantees is taken for every variant left. The matched value and
+// bindings are declared before the statement containing the match.
+func (cb *CBackend) generateMatch(matchExpr *ResolvedMatchExpr) (string, error) {
+	valueStr, err := cb.GenerateExpr(matchExpr.Value.Value)
+	if err != nil {
+		return "", err
+	}
+	value := cb.variableName(matchExpr.Value)
+
+	arms := ""
+	for i, arm := range matchExpr.Arms {
+		parts := make([]string, 0)
+		for j, binding := range arm.Bindings {
+			if binding != nil {
+				parts = append(parts, cb.variableName(binding)+" = "+value+"."+cPayloadFieldName(arm.Variant.Id, j))
+			}
+		}
+		armStr, err := cb.GenerateExpr(arm.Value)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, armStr)
+
+		if i == len(matchExpr.Arms)-1 {
+			arms += "(" + strings.Join(parts, ", ") + ")"
+		} else {
+			arms += value + ".tag == " + strconv.Itoa(arm.Variant.Index) + " ? (" + strings.Join(parts, ", ") + ") : "
+		}
+	}
+	return "(" + value + " = " + valueStr + ", " + arms + ")", nil
+}
+
+// Quotes a string for C. Characters other than printable ASCII are written as octal escapes,
+// which unlike hex escapes can't swallow a following digit.
+func cStringLiteral(value string) string {
+	out := strings.Builder{}
+	out.WriteByte('"')
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == '"' || c == '\\':
+			out.WriteByte('\\')
+			out.WriteByte(c)
+		case c == '?':
+			// Keeps ?? from starting a trigraph
+			out.WriteString("\\?")
+		case c >= ' ' && c <= '~':
+			out.WriteByte(c)
+		default:
+			fmt.Fprintf(&out, "\\%03o", c)
+		}
+	}
+	out.WriteByte('"')
+	return out.String()
+}
+
+func (cb *CBackend) GenerateBlock(block *ResolvedBlock, level int) (string, error) {
+	// The statement containing the block may have temporaries of its own
+	outer := cb.temporaries
+	defer func() {
+		cb.temporaries = outer
+	}()
+
+	indent := strings.Repeat("\t", level)
+	out := ""
+	for _, stmt := range block.Stmts {
+		for _, local := range StatementMatchLocals(stmt) {
+			localType, err := cb.cType(local.Type)
+			if err != nil {
+				return "", err
+			}
+			out += indent + localType + " " + cb.variableName(local) + ";\n"
+		}
+		// The temporaries are only known once the statement is generated, and are declared before it
+		cb.temporaries = nil
+		start := len(out)
+
+		switch stmt.(type) {
+		case *ResolvedReturnStatement:
+			returnStmt := stmt.(*ResolvedReturnStatement)
+			if returnStmt.Expr == nil {
+				out += indent + "return;\n"
+				break
+			}
+
+			exprStr, err := cb.GenerateExpr(returnStmt.Expr)
+			if err != nil {
+				return "", err
+			}
+			out += indent + "return " + exprStr + ";\n"
+		case *ResolvedLetStatement:
+			variable := stmt.(*ResolvedLetStatement).Variable
+			varType, err := cb.cType(variable.Type)
+			if err != nil {
+				return "", err
+			}
+			exprStr, err := cb.GenerateExpr(variable.Value)
+			if err != nil {
+				return "", err
+			}
+			out += indent + varType + " " + cb.variableName(variable) + " = " + exprStr + ";\n"
+		case *ResolvedAssignStatement:
+			assignStmt := stmt.(*ResolvedAssignStatement)
+			exprStr, err := cb.GenerateExpr(assignStmt.Expr)
+			if err != nil {
+				return "", err
+			}
+			out += indent + cb.variableName(assignStmt.Variable) + " = " + exprStr + ";\n"
+		case *ResolvedExprStatement:
+			exprStr, err := cb.GenerateExpr(stmt.(*ResolvedExprStatement).Expr)
+			if err != nil {
+				return "", err
+			}
+			out += indent + exprStr + ";\n"
+		case *ResolvedIfStatement:
+			ifStmt := stmt.(*ResolvedIfStatement)
+			condStr, err := cb.GenerateExpr(ifStmt.Cond)
+			if err != nil {
+				return "", err
+			}
+			then, err := cb.GenerateBlock(ifStmt.Then, level+1)
+			if err != nil {
+				return "", err
+			}
+			out += indent + "if (" + condStr + ") {\n" + then + indent + "}"
+			if ifStmt.Else != nil {
+				elseStr, err := cb.GenerateBlock(ifStmt.Else, level+1)
+				if err != nil {
+					return "", err
+				}
+				out += " else {\n" + elseStr + indent + "}"
+			}
+			out += "\n"
+		case *ResolvedWhileStatement:
+			whileStmt := stmt.(*ResolvedWhileStatement)
+			condStr, err := cb.GenerateExpr(whileStmt.Cond)
+			if err != nil {
+				return "", err
+			}
+			body, err := cb.GenerateBlock(whileStmt.Body, level+1)
+			if err != nil {
+				return "", err
+			}
+			out += indent + "while (" + condStr + ") {\n" + body + indent + "}\n"
+		case *ResolvedForStatement:
+			forStmt := stmt.(*ResolvedForStatement)
+			start, err := cb.GenerateExpr(forStmt.Variable.Value)
+			if err != nil {
+				return "", err
+			}
+			end, err := cb.GenerateExpr(forStmt.Limit.Value)
+			if err != nil {
+				return "", err
+			}
+			body, err := cb.GenerateBlock(forStmt.Body, level+1)
+			if err != nil {
+				return "", err
+			}
+			variable := cb.variableName(forStmt.Variable)
+			limit := cb.variableName(forStmt.Limit)
+			out += indent + "for (int64_t " + variable + " = " + start + ", " + limit + " = " + end + "; " + variable + " < " + limit + "; " + variable + "++) {\n" + body + indent + "}\n"
+		case *ResolvedBreakStatement:
+			out += indent + "break;\n"
+		case *ResolvedContinueStatement:
+			out += indent + "continue;\n"
+		default:
+			return "", fmt.Errorf("Unknown statement type %s", stmt.GetStmtType())
+		}
+
+		temporaries := ""
+		for _, temporary := range cb.temporaries {
+			temporaries += indent + temporary + ";\n"
+		}
+		out = out[:start] + temporaries + out[start:]
+	}
+	return out, nil
+}
+
+func (cb *CBackend) GeneratePrototype(fn *ResolvedFunctionDeclaration) (string, error) {
+	returnType, err := cb.cType(fn.ReturnType)
+	if err != nil {
+		return "", fmt.Errorf("Error generating function %s: %s", fn.GetId(), err)
+	}
+
+	params := make([]string, len(fn.Params))
+	for i, param := range fn.Params {
+		paramType, err := cb.cType(param.(*ResolvedVariableDeclaration).Type)
+		if err != nil {
+			return "", fmt.Errorf("Error generating parameter %s of %s: %s", param.GetId(), fn.GetId(), err)
+		}
+		// Parameters are a function's first variables, so they never shadow another
+		params[i] = paramType + " " + cVariableName(param.GetId(), 0)
+	}
+	if len(params) == 0 {
+		params = append(params, "void")
+	}
+
+	return returnType + " " + cFunctionName(fn.GetId()) + "(" + strings.Join(params, ", ") + ")", nil
+}
+
+// Generates the typedef of a struct, after those of the types it contains, which C needs complete first
+func (cb *CBackend) generateStruct(structDecl *ResolvedStructDeclaration, generated map[string]bool) (string, error) {
+	if generated[structDecl.Id] {
+		return "", nil
+	}
+	generated[structDecl.Id] = true
+
+	out := ""
+	fields := ""
+	for _, field := range structDecl.Fields {
+		dependency, err := cb.generateType(field.Type, generated)
+		if err != nil {
+			return "", err
+		}
+		out += dependency
+		fieldType, err := cb.cType(field.Type)
+		if err != nil {
+			return "", fmt.Errorf("Error generating field %s of %s: %s", field.GetId(), structDecl.Id, err)
+		}
+		fields += "\t" + fieldType + " " + cFieldName(field.GetId()) + ";\n"
+	}
+	return out + "typedef struct {\n" + fields + "} " + cFunctionName(structDecl.Id) + ";\n", nil
+}
+
+// Generates the typedef of an enum as its variant's index and a union of the payloads of the
+// variants having one, after the typedefs of the payload types
+func (cb *CBackend) generateEnum(enumDecl *ResolvedEnumDeclaration, generated map[string]bool) (string, error) {
+	if generated[enumDecl.Id] {
+		return "", nil
+	}
+	generated[enumDecl.Id] = true
+
+	out := ""
+	payloads := ""
+	for _, variant := range enumDecl.Variants {
+		if len(variant.Payload) == 0 {
+			continue
+		}
+		fields := ""
+		for i, t := range variant.Payload {
+			dependency, err := cb.generateType(t, generated)
+			if err != nil {
+				return "", err
+			}
+			out += dependency
+			fieldType, err := cb.cType(t)
+			if err != nil {
+				return "", fmt.Errorf("Error generating variant %s of %s: %s", variant.Id, enumDecl.Id, err)
+			}
+			fields += "\t\t\t" + fieldType + " f" + strconv.Itoa(i) + ";\n"
+		}
+		payloads += "\t\tstruct {\n" + fields + "\t\t} " + cPayloadName(variant.Id) + ";\n"
+	}
+
+	out += "typedef struct {\n\tint64_t tag;\n"
+	if payloads != "" {
+		out += "\tunion {\n" + payloads + "\t};\n"
+	}
+	return out + "} " + cFunctionName(enumDecl.Id) + ";\n", nil
+}
+
+// Generates the definitions a type needs, after those of its element or field types
+func (cb *CBackend) generateType(t Type, generated map[string]bool) (string, error) {
+	if t.Kind == TypeType_CUSTOM {
+		enumDecl, ok := cb.enums[t.Name]
+		if ok {
+			return cb.generateEnum(enumDecl, generated)
+		}
+		return cb.generateStruct(cb.structs[t.Name], generated)
+	}
+	if !t.IsSequence() {
+		return "", nil
+	}
+
+	name := cSequenceTypeName(t)
+	if generated[name] {
+		return "", nil
+	}
+	generated[name] = true
+
+	out, err := cb.generateType(*t.Elem, generated)
+	if err != nil {
+		return "", err
+	}
+	elemType, err := cb.cType(*t.Elem)
+	if err != nil {
+		return "", err
+	}
+	out += "typedef struct {\n\tint64_t len;\n\tconst " + elemType + " *data;\n} " + name + ";\n\n"
+	out += "static " + elemType + " " + cIndexFunctionName(t) + "(" + name + " array, int64_t index, int line, int column) {\n"
+	out += "\tif (index < 0 || index >= array.len) {\n\t\tbaisl_rt_index_fail(index, array.len, line, column);\n\t}\n"
+	out += "\treturn array.data[index];\n}\n\n"
+	return out, nil
+}
+
+func (cb *CBackend) functions() []*ResolvedFunctionDeclaration {
+	functions := make([]*ResolvedFunctionDeclaration, 0)
+	for _, decl := range cb.Declarations {
+		fn, ok := decl.(*ResolvedFunctionDeclaration)
+		if ok {
+			functions = append(functions, fn)
+		}
+	}
+	return functions
+}
+
+func (cb *CBackend) Generate() (string, error) {
+	functions := cb.functions()
+
+	var main *ResolvedFunctionDeclaration
+	for _, fn := range functions {
+		if fn.GetId() == "main" {
+			main = fn
+		}
+	}
+	if main == nil {
+		return "", fmt.Errorf("No main function found")
+	}
+
+	cb.usesRuntime = false
+	cb.sequences = nil
+	out := ""
+
+	cb.structs = make(map[string]*ResolvedStructDeclaration)
+	cb.enums = make(map[string]*ResolvedEnumDeclaration)
+	for _, decl := range cb.Declarations {
+		switch decl.(type) {
+		case *ResolvedStructDeclaration:
+			cb.structs[decl.GetId()] = decl.(*ResolvedStructDeclaration)
+		case *ResolvedEnumDeclaration:
+			cb.enums[decl.GetId()] = decl.(*ResolvedEnumDeclaration)
+		}
+	}
+
+	// Prototypes let functions call each other regardless of declaration order
+	for _, fn := range functions {
+		prototype, err := cb.GeneratePrototype(fn)
+		if err != nil {
+			return "", err
+		}
+		out += prototype + ";\n"
+	}
+
+	for _, fn := range functions {
+		prototype, err := cb.GeneratePrototype(fn)
+		if err != nil {
+			return "", err
+		}
+
+		cb.variables = numberVariables(fn)
+		cb.numTemporaries = 0
+		body, err := cb.GenerateBlock(fn.Body, 1)
+		if err != nil {
+			return "", fmt.Errorf("Error generating function %s: %s", fn.GetId(), err)
+		}
+		out += "\n" + prototype + " {\n" + body + "}\n"
+	}
+
+	// Only a main returning an int or bool sets the exit code
+	out += "\nint main(void) {\n"
+	if main.ReturnType.Kind == TypeType_INT || main.ReturnType.Kind == TypeType_BOOL {
+		out += "\treturn (int)" + cFunctionName(main.GetId()) + "();\n"
+	} else {
+		out += "\t" + cFunctionName(main.GetId()) + "();\n\treturn 0;\n"
+	}
+	out += "}\n"
+
+	// The types are generated last, once the functions have used every array and slice type they need
+	types := ""
+	generated := make(map[string]bool)
+	for _, decl := range cb.Declarations {
+		var typedef string
+		var err error
+		switch decl.(type) {
+		case *ResolvedStructDeclaration:
+			typedef, err = cb.generateStruct(decl.(*ResolvedStructDeclaration), generated)
+		case *ResolvedEnumDeclaration:
+			typedef, err = cb.generateEnum(decl.(*ResolvedEnumDeclaration), generated)
+		}
+		if err != nil {
+			return "", err
+		}
+		types += typedef
+	}
+	for i := 0; i < len(cb.sequences); i++ {
+		typedef, err := cb.generateType(cb.sequences[i], generated)
+		if err != nil {
+			return "", err
+		}
+		types += typedef
+	}
+	if types != "" {
+		out = strings.TrimRight(types, "\n") + "\n\n" + out
+	}
+
+	includes := "#include <stdbool.h>\n#include <stdint.h>\n"
+	if cb.usesRuntime {
+		includes += cRuntime
+	}
+	return includes + "\n" + out, nil
+}
@@ -0,0 +1,300 @@
+package baisl_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/frodi-karlsson/baisl"
+)
+
+type cBackendTest struct {
+	path     string
+	expected string
+}
+
+type compiledProgramTest struct {
+	path     string
+	exitCode int
+}
+
+var cBackendTests = []cBackendTest{
+	{"raw/fnCall.baisl", `#include <stdbool.h>
+#include <stdint.h>
+
+int64_t baisl_returnParam(int64_t v_a);
+int64_t baisl_main(void);
+
+int64_t baisl_returnParam(int64_t v_a) {
+	return v_a;
+}
+
+int64_t baisl_main(void) {
+	return baisl_returnParam(5);
+}
+
+int main(void) {
+	return (int)baisl_main();
+}
+`},
+	{"raw/ret2.baisl", `#include <stdbool.h>
+#include <stdint.h>
+
+void baisl_main(void);
+int64_t baisl_return2(void);
+
+void baisl_main(void) {
+	return;
+}
+
+int64_t baisl_return2(void) {
+	return 2;
+}
+
+int main(void) {
+	baisl_main();
+	return 0;
+}
+`},
+	// main calls a function defined after it, which its prototype declares
+	{"raw/locals.baisl", `#include <stdbool.h>
+#include <stdint.h>
+
+int64_t baisl_addOne(int64_t v_a);
+int64_t baisl_square(int64_t v_a);
+int64_t baisl_main(void);
+
+int64_t baisl_addOne(int64_t v_a) {
+	v_a = (int64_t)((uint64_t)v_a + (uint64_t)1);
+	return v_a;
+}
+
+int64_t baisl_square(int64_t v_a) {
+	int64_t v_result = (int64_t)((uint64_t)v_a * (uint64_t)v_a);
+	return v_result;
+}
+
+int64_t baisl_main(void) {
+	int64_t v_x = 3;
+	int64_t v_y = baisl_square(v_x);
+	v_x = baisl_addOne((int64_t)((uint64_t)v_x + (uint64_t)v_y));
+	int64_t v_square = 2;
+	return (int64_t)((uint64_t)v_x * (uint64_t)v_square);
+}
+
+int main(void) {
+	return (int)baisl_main();
+}
+`},
+	{"raw/mainFirst.baisl", `#include <stdbool.h>
+#include <stdint.h>
+
+int64_t baisl_main(void);
+int64_t baisl_half(int64_t v_n);
+
+int64_t baisl_main(void) {
+	return baisl_half(14);
+}
+
+int64_t baisl_half(int64_t v_n) {
+	return (v_n / 2);
+}
+
+int main(void) {
+	return (int)baisl_main();
+}
+`},
+}
+
+var compiledProgramTests = []compiledProgramTest{
+	{"raw/mainVoid.baisl", 0},
+	{"raw/emptyVoid.baisl", 0},
+	{"raw/ret2.baisl", 0},
+	{"raw/fnCall.baisl", 5},
+	{"raw/params.baisl", 2},
+	{"raw/manyParams.baisl", 8},
+	{"raw/arithmetic.baisl", 13},
+	{"raw/locals.baisl", 26},
+	{"raw/ifElse.baisl", 39},
+	{"raw/loops.baisl", 107},
+	{"raw/bool.baisl", 21},
+	{"raw/recursion.baisl", 20},
+	{"raw/mainFirst.baisl", 7},
+	{"raw/divisionWraps.baisl", 5},
+}
+
+// Returns the exit code of a finished program, failing the test if it could not be run at all
+func runProgram(t *testing.T, path string, args ...string) int {
+	err := exec.Command(path, args...).Run()
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("Error running %s: %s", path, err)
+	}
+	return exitErr.ExitCode()
+}
+
+// Programs dividing by zero, paired with the message the compiled backends write for it
+var divisionErrorTests = []outputTest{
+	{"raw/divByZero.baisl", "Division by zero at 2:12\n"},
+	{"raw/remainderByZero.baisl", "Division by zero at 3:12\n"},
+}
+
+// Programs stopped by a runtime error, paired with the message the compiled backends write for it
+var runtimeErrorTests = append([]outputTest{
+	{"raw/indexOutOfRange.baisl", "Index 3 out of range for length 3 at 4:12\n"},
+}, divisionErrorTests...)
+
+// Returns the standard error of a program, failing the test unless it exits with code 1
+func programError(t *testing.T, path string, args ...string) string {
+	stderr := bytes.Buffer{}
+	cmd := exec.Command(path, args...)
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		t.Errorf("Running %s, expected exit code 1, got %v", path, err)
+	}
+	return stderr.String()
+}
+
+// Returns the standard output of a program, failing the test unless it exits successfully
+func programOutput(t *testing.T, path string, args ...string) string {
+	output, err := exec.Command(path, args...).Output()
+	if err != nil {
+		t.Errorf("Error running %s: %s", path, err)
+	}
+	return string(output)
+}
+
+func TestCBackend(t *testing.T) {
+	for _, test := range cBackendTests {
+		backend := baisl.CBackend{
+			Declarations: getResolvedDeclarations(t, test.path),
+		}
+
+		result, err := backend.Generate()
+		if err != nil {
+			t.Errorf("Error generating C for %s: %s", test.path, err)
+			continue
+		}
+
+		if result != test.expected {
+			t.Errorf("\nExpected <%s>, got <%s>", test.expected, result)
+		}
+	}
+}
+
+func TestCBackendCompiles(t *testing.T) {
+	cc, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("No C compiler found")
+	}
+
+	dir := t.TempDir()
+	for _, test := range compiledProgramTests {
+		backend := baisl.CBackend{
+			Declarations: getResolvedDeclarations(t, test.path),
+		}
+
+		result, err := backend.Generate()
+		if err != nil {
+			t.Errorf("Error generating C for %s: %s", test.path, err)
+			continue
+		}
+
+		name := filepath.Base(test.path)
+		source := filepath.Join(dir, name+".c")
+		binary := filepath.Join(dir, name)
+		err = os.WriteFile(source, []byte(result), 0644)
+		if err != nil {
+			t.Fatalf("Error writing %s: %s", source, err)
+		}
+
+		output, err := exec.Command(cc, "-o", binary, source).CombinedOutput()
+		if err != nil {
+			t.Errorf("Error compiling %s: %s\n%s", test.path, err, output)
+			continue
+		}
+
+		exitCode := runProgram(t, binary)
+		if exitCode != test.exitCode {
+			t.Errorf("Running %s, expected exit code %d, got %d", test.path, test.exitCode, exitCode)
+		}
+	}
+}
+
+// Generates C for a program and compiles it into dir, returning the binary's path
+func compileC(t *testing.T, cc string, dir string, path string) (string, bool) {
+	backend := baisl.CBackend{
+		Declarations: getResolvedDeclarations(t, path),
+	}
+
+	result, err := backend.Generate()
+	if err != nil {
+		t.Errorf("Error generating C for %s: %s", path, err)
+		return "", false
+	}
+
+	name := filepath.Base(path)
+	source := filepath.Join(dir, name+".c")
+	binary := filepath.Join(dir, name)
+	err = os.WriteFile(source, []byte(result), 0644)
+	if err != nil {
+		t.Fatalf("Error writing %s: %s", source, err)
+	}
+
+	output, err := exec.Command(cc, "-o", binary, source).CombinedOutput()
+	if err != nil {
+		t.Errorf("Error compiling %s: %s\n%s", path, err, output)
+		return "", false
+	}
+	return binary, true
+}
+
+func TestCBackendOutput(t *testing.T) {
+	cc, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("No C compiler found")
+	}
+
+	dir := t.TempDir()
+	for _, test := range outputTests {
+		binary, ok := compileC(t, cc, dir, test.path)
+		if !ok {
+			continue
+		}
+
+		got := programOutput(t, binary)
+		if got != test.expected {
+			t.Errorf("Running %s, expected output <%s>, got <%s>", test.path, test.expected, got)
+		}
+	}
+}
+
+func TestCBackendRuntimeErrors(t *testing.T) {
+	cc, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("No C compiler found")
+	}
+
+	dir := t.TempDir()
+	for _, test := range runtimeErrorTests {
+		binary, ok := compileC(t, cc, dir, test.path)
+		if !ok {
+			continue
+		}
+
+		got := programError(t, binary)
+		if got != test.expected {
+			t.Errorf("Running %s, expected error <%s>, got <%s>", test.path, test.expected, got)
+		}
+	}
+}
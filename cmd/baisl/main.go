@@ -0,0 +1,489 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/frodi-karlsson/baisl"
+)
+
+type command struct {
+	name        string
+	description string
+	run         func(args []string, stdout io.Writer, stderr io.Writer) int
+}
+
+var commands = []command{
+	{"tokens", "Print the token stream of a file", runTokens},
+	{"ast", "Print the syntax tree of a file", runAst},
+	{"check", "Analyse a module and report errors", runCheck},
+	{"run", "Run a module with the interpreter or the bytecode VM", runRun},
+	{"compile", "Compile a module to a .baislc bytecode file", runCompile},
+	{"disasm", "Print the bytecode of a module or .baislc file", runDisasm},
+	{"emit", "Print the generated source of a module", runEmit},
+	{"build", "Compile a module to a native executable", runBuild},
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: baisl <command> [arguments] <file>\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.description)
+	}
+	fmt.Fprintf(w, "\nA module is a .baisl file or a directory of them.\n")
+}
+
+// Contents of the files parsed so far by path, which diagnostics show the lines of
+var sources = map[string][]byte{}
+
+// Reports whether w is a terminal that should get colored output, which NO_COLOR turns off
+func isColorTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Prints an error, or each diagnostic with the source it points into if the error reports any
+func printError(w io.Writer, err error) {
+	diagnostics := baisl.DiagnosticsOf(err)
+	if diagnostics == nil {
+		fmt.Fprintf(w, "baisl: error: %s\n", err)
+		return
+	}
+	renderer := baisl.DiagnosticRenderer{
+		Sources: sources,
+		Color:   isColorTerminal(w),
+	}
+	fmt.Fprint(w, renderer.Render(diagnostics))
+}
+
+// Parses the flags of a command and returns the single source file argument
+func parseArgs(flags *flag.FlagSet, args []string, stderr io.Writer) (string, bool) {
+	flags.SetOutput(stderr)
+	err := flags.Parse(args)
+	if err != nil {
+		return "", false
+	}
+
+	if flags.NArg() != 1 {
+		fmt.Fprintf(stderr, "Usage: baisl %s [arguments] <file>\n", flags.Name())
+		flags.PrintDefaults()
+		return "", false
+	}
+
+	return flags.Arg(0), true
+}
+
+func parseFile(path string) ([]baisl.Declaration, error) {
+	sourceFile, err := baisl.GetSourceFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sources[sourceFile.Path()] = sourceFile.Content()
+
+	parser := baisl.Parser{
+		SourceFile: &sourceFile,
+	}
+	return parser.Parse()
+}
+
+// Analyses the module at path, a file or a directory, with the modules it imports
+func analyse(path string) ([]baisl.ResolvedDeclaration, error) {
+	loader := baisl.ModuleLoader{
+		Sources: sources,
+	}
+	modules, err := loader.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	analyser := baisl.SemanticAnalyser{}
+	return analyser.AnalyseModules(modules)
+}
+
+func runTokens(args []string, stdout io.Writer, stderr io.Writer) int {
+	path, ok := parseArgs(flag.NewFlagSet("tokens", flag.ContinueOnError), args, stderr)
+	if !ok {
+		return 2
+	}
+
+	sourceFile, err := baisl.GetSourceFile(path)
+	if err != nil {
+		printError(stderr, err)
+		return 1
+	}
+
+	for {
+		token := sourceFile.GetNextToken()
+		if token.HasValue {
+			fmt.Fprintf(stdout, "%d:%d %s %s\n", token.Location.Line, token.Location.Column, token.TType, token.Value)
+		} else {
+			fmt.Fprintf(stdout, "%d:%d %s\n", token.Location.Line, token.Location.Column, token.TType)
+		}
+
+		if token.TType == baisl.TokenType_EOF {
+			break
+		}
+	}
+
+	return 0
+}
+
+func runAst(args []string, stdout io.Writer, stderr io.Writer) int {
+	path, ok := parseArgs(flag.NewFlagSet("ast", flag.ContinueOnError), args, stderr)
+	if !ok {
+		return 2
+	}
+
+	// With syntax errors, what could be parsed is still printed, with the errors in it
+	declarations, err := parseFile(path)
+	for _, decl := range declarations {
+		fmt.Fprintln(stdout, decl.String(0))
+	}
+	if err != nil {
+		printError(stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+func runCheck(args []string, stdout io.Writer, stderr io.Writer) int {
+	path, ok := parseArgs(flag.NewFlagSet("check", flag.ContinueOnError), args, stderr)
+	if !ok {
+		return 2
+	}
+
+	_, err := analyse(path)
+	if err != nil {
+		printError(stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+// Loads a bytecode program, compiling it first unless the file is already a .baislc file
+func loadBytecode(path string) (*baisl.BytecodeProgram, error) {
+	if filepath.Ext(path) == ".baislc" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return baisl.DecodeBytecode(data)
+	}
+
+	resolved, err := analyse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	compiler := baisl.BytecodeCompiler{
+		Declarations: resolved,
+	}
+	return compiler.Compile()
+}
+
+// Runs main with the interpreter or the VM, exiting with its result if it returns an int
+func runRun(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	useVM := flags.Bool("vm", false, "run on the bytecode VM instead of the interpreter (implied for .baislc files)")
+	path, ok := parseArgs(flags, args, stderr)
+	if !ok {
+		return 2
+	}
+
+	var result baisl.Value
+	if *useVM || filepath.Ext(path) == ".baislc" {
+		program, err := loadBytecode(path)
+		if err != nil {
+			printError(stderr, err)
+			return 1
+		}
+
+		vm := baisl.VM{
+			Program: program,
+			Output:  stdout,
+		}
+		result, err = vm.Run()
+		if err != nil {
+			printError(stderr, err)
+			return 1
+		}
+	} else {
+		resolved, err := analyse(path)
+		if err != nil {
+			printError(stderr, err)
+			return 1
+		}
+
+		interpreter := baisl.Interpreter{
+			Declarations: resolved,
+			Output:       stdout,
+		}
+		result, err = interpreter.Run()
+		if err != nil {
+			printError(stderr, err)
+			return 1
+		}
+	}
+
+	if intResult, ok := result.(*baisl.IntValue); ok {
+		return intResult.Value
+	}
+	return 0
+}
+
+func runCompile(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("compile", flag.ContinueOnError)
+	output := flags.String("o", "", "output path (defaults to the file name with a .baislc extension)")
+	path, ok := parseArgs(flags, args, stderr)
+	if !ok {
+		return 2
+	}
+
+	if *output == "" {
+		*output = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) + ".baislc"
+	}
+
+	program, err := loadBytecode(path)
+	if err != nil {
+		printError(stderr, err)
+		return 1
+	}
+
+	err = os.WriteFile(*output, program.Encode(), 0644)
+	if err != nil {
+		printError(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func runDisasm(args []string, stdout io.Writer, stderr io.Writer) int {
+	path, ok := parseArgs(flag.NewFlagSet("disasm", flag.ContinueOnError), args, stderr)
+	if !ok {
+		return 2
+	}
+
+	program, err := loadBytecode(path)
+	if err != nil {
+		printError(stderr, err)
+		return 1
+	}
+
+	fmt.Fprint(stdout, program.Disassemble())
+	return 0
+}
+
+// Generates the source of a backend from a module
+func generate(backend string, path string) (string, error) {
+	resolved, err := analyse(path)
+	if err != nil {
+		return "", err
+	}
+
+	switch backend {
+	case "c":
+		cBackend := baisl.CBackend{
+			Declarations: resolved,
+		}
+		return cBackend.Generate()
+	case "asm":
+		asmBackend := baisl.AsmBackend{
+			Declarations: resolved,
+		}
+		return asmBackend.Generate()
+	case "wasm":
+		wasmBackend := baisl.WasmBackend{
+			Declarations: resolved,
+		}
+		module, err := wasmBackend.Generate()
+		return string(module), err
+	case "llvm":
+		llvmBackend := baisl.LlvmBackend{
+			Declarations: resolved,
+		}
+		return llvmBackend.Generate()
+	}
+	return "", fmt.Errorf("Unknown backend %q", backend)
+}
+
+func runEmit(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("emit", flag.ContinueOnError)
+	backend := flags.String("backend", "c", "backend to generate source for (c, asm, wasm, llvm)")
+	path, ok := parseArgs(flags, args, stderr)
+	if !ok {
+		return 2
+	}
+
+	source, err := generate(*backend, path)
+	if err != nil {
+		printError(stderr, err)
+		return 1
+	}
+
+	fmt.Fprint(stdout, source)
+	return 0
+}
+
+// Compiles C source with the compiler named by $CC, falling back to cc
+func compileC(source string, output string) error {
+	compiler := os.Getenv("CC")
+	if compiler == "" {
+		compiler = "cc"
+	}
+
+	cmd := exec.Command(compiler, "-x", "c", "-o", output, "-")
+	cmd.Stdin = strings.NewReader(source)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s failed: %s\n%s", compiler, err, out)
+	}
+	return nil
+}
+
+// Assembles GNU as source and links it into a standalone executable
+func assembleAndLink(source string, output string) error {
+	dir, err := os.MkdirTemp("", "baisl")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	object := filepath.Join(dir, "out.o")
+	cmd := exec.Command("as", "-o", object)
+	cmd.Stdin = strings.NewReader(source)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("as failed: %s\n%s", err, out)
+	}
+
+	out, err = exec.Command("ld", "-o", output, object).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ld failed: %s\n%s", err, out)
+	}
+	return nil
+}
+
+// Compiles LLVM IR with clang if it is installed, or with llc and the C compiler otherwise
+func compileLlvm(source string, output string) error {
+	clang, err := exec.LookPath("clang")
+	if err == nil {
+		cmd := exec.Command(clang, "-x", "ir", "-O2", "-o", output, "-")
+		cmd.Stdin = strings.NewReader(source)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("clang failed: %s\n%s", err, out)
+		}
+		return nil
+	}
+
+	dir, err := os.MkdirTemp("", "baisl")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	object := filepath.Join(dir, "out.o")
+	cmd := exec.Command("llc", "-O2", "-filetype=obj", "-relocation-model=pic", "-o", object)
+	cmd.Stdin = strings.NewReader(source)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("llc failed: %s\n%s", err, out)
+	}
+
+	compiler := os.Getenv("CC")
+	if compiler == "" {
+		compiler = "cc"
+	}
+	out, err = exec.Command(compiler, "-o", output, object).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s failed: %s\n%s", compiler, err, out)
+	}
+	return nil
+}
+
+// Names the output after the file built, without its extension. A directory module or a file without an
+// extension would have the output replace it, so .out is appended then instead.
+func defaultOutput(path string, backend string) string {
+	name := filepath.Base(path)
+	extension := filepath.Ext(name)
+	if backend == "wasm" {
+		return strings.TrimSuffix(name, extension) + ".wasm"
+	}
+	if extension == "" {
+		return name + ".out"
+	}
+	return strings.TrimSuffix(name, extension)
+}
+
+func runBuild(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("build", flag.ContinueOnError)
+	backend := flags.String("backend", "c", "backend to compile with (c, asm, wasm, llvm)")
+	output := flags.String("o", "", "output path (defaults to the file or directory name without its extension, or with .out if it has none)")
+	path, ok := parseArgs(flags, args, stderr)
+	if !ok {
+		return 2
+	}
+
+	if *output == "" {
+		*output = defaultOutput(path, *backend)
+	}
+
+	source, err := generate(*backend, path)
+	if err != nil {
+		printError(stderr, err)
+		return 1
+	}
+
+	switch *backend {
+	case "c":
+		err = compileC(source, *output)
+	case "asm":
+		err = assembleAndLink(source, *output)
+	case "wasm":
+		err = os.WriteFile(*output, []byte(source), 0644)
+	case "llvm":
+		err = compileLlvm(source, *output)
+	}
+	if err != nil {
+		printError(stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+func runCommand(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		printUsage(stderr)
+		return 2
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:], stdout, stderr)
+		}
+	}
+
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(stdout)
+		return 0
+	}
+
+	fmt.Fprintf(stderr, "baisl: unknown command %q\n\n", args[0])
+	printUsage(stderr)
+	return 2
+}
+
+func main() {
+	os.Exit(runCommand(os.Args[1:], os.Stdout, os.Stderr))
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type commandTest struct {
+	args           []string
+	exitCode       int
+	stdoutContains string
+	stderrContains string
+}
+
+var commandTests = []commandTest{
+	{[]string{"tokens", "../../raw/mainVoid.baisl"}, 0, "1:1 KEYW_FN\n1:4 IDENTIFIER main\n", ""},
+	{[]string{"ast", "../../raw/fnCall.baisl"}, 0, "Return Call returnParam(5)", ""},
+	{[]string{"check", "../../raw/fnCall.baisl"}, 0, "", ""},
+	{[]string{"check", "../../raw/invalidParamRef.baisl"}, 1, "", "error[E0101]: Undeclared variable b"},
+	{[]string{"check", "../../raw/unclosedParen.baisl"}, 1, "", "error[E0001]: Expected token type RPAREN"},
+	{[]string{"check", "../../raw/duplicateLocal.baisl"}, 1, "", "2 |   let x = 1\n  |       - x is first declared\n3 |   let x = 2\n  |       ^\n"},
+	{[]string{"check", "../../raw/multipleSemanticErrors.baisl"}, 1, "", "error[E0101]: Undeclared variable missing"},
+	{[]string{"check", "../../raw/missing.baisl"}, 1, "", "no such file"},
+	{[]string{"ast", "../../raw/multipleErrors.baisl"}, 1, "Function main(): int:\n  Block:\n    While true:", "error[E0001]: Expected token type FATARROW"},
+	{[]string{"run", "../../raw/fnCall.baisl"}, 5, "", ""},
+	{[]string{"run", "../../raw/modules/app"}, 17, "== total ==\n17\na\n9\n", ""},
+	{[]string{"check", "../../raw/modules/cycle.baisl"}, 1, "", "error[E0602]: Import cycle cycle/a -> cycle/b -> cycle/a"},
+	{[]string{"run", "../../raw/mainVoid.baisl"}, 0, "", ""},
+	{[]string{"run", "-vm", "../../raw/manyParams.baisl"}, 8, "", ""},
+	{[]string{"run", "../../raw/strings.baisl"}, 0, "Hello, baisl!\n", ""},
+	{[]string{"run", "-vm", "../../raw/strings.baisl"}, 0, "sum: 5\ntrue\n", ""},
+	{[]string{"run", "../../raw/divByZero.baisl"}, 1, "", "Division by zero at 2:12"},
+	{[]string{"run", "-vm", "../../raw/divByZero.baisl"}, 1, "", "Division by zero at 2:12"},
+	{[]string{"run", "../../raw/remainderByZero.baisl"}, 1, "", "Division by zero at 3:12"},
+	{[]string{"run", "-vm", "../../raw/remainderByZero.baisl"}, 1, "", "Division by zero at 3:12"},
+	{[]string{"disasm", "../../raw/fnCall.baisl"}, 0, "CALL 0 ; returnParam", ""},
+	{[]string{"disasm", "../../raw/fnCall.baisl.baislc"}, 1, "", "no such file"},
+	{[]string{"emit", "../../raw/fnCall.baisl"}, 0, "return baisl_returnParam(5);", ""},
+	{[]string{"emit", "-backend", "asm", "../../raw/fnCall.baisl"}, 0, "call baisl_returnParam", ""},
+	{[]string{"emit", "-backend", "wasm", "../../raw/fnCall.baisl"}, 0, "\x00asm", ""},
+	{[]string{"emit", "-backend", "llvm", "../../raw/fnCall.baisl"}, 0, "call i64 @baisl_returnParam(i64 5)", ""},
+	{[]string{"emit", "-backend", "cobol", "../../raw/fnCall.baisl"}, 1, "", "Unknown backend"},
+	{[]string{"run"}, 2, "", "Usage: baisl run"},
+	{[]string{"frobnicate"}, 2, "", "unknown command"},
+	{[]string{}, 2, "", "Commands:"},
+}
+
+func TestCommands(t *testing.T) {
+	for _, test := range commandTests {
+		stdout := bytes.Buffer{}
+		stderr := bytes.Buffer{}
+		exitCode := runCommand(test.args, &stdout, &stderr)
+
+		if exitCode != test.exitCode {
+			t.Errorf("%v: expected exit code %d, got %d (stderr <%s>)", test.args, test.exitCode, exitCode, stderr.String())
+		}
+
+		if !strings.Contains(stdout.String(), test.stdoutContains) {
+			t.Errorf("%v: expected stdout containing <%s>, got <%s>", test.args, test.stdoutContains, stdout.String())
+		}
+
+		if !strings.Contains(stderr.String(), test.stderrContains) {
+			t.Errorf("%v: expected stderr containing <%s>, got <%s>", test.args, test.stderrContains, stderr.String())
+		}
+	}
+}
+
+type defaultOutputTest struct {
+	path     string
+	backend  string
+	expected string
+}
+
+var defaultOutputTests = []defaultOutputTest{
+	{"../../raw/fnCall.baisl", "c", "fnCall"},
+	{"../../raw/fnCall.baisl", "wasm", "fnCall.wasm"},
+	{"../../raw/modules/app", "c", "app.out"},
+	{"../../raw/modules/app/", "asm", "app.out"},
+	{"../../raw/modules/app", "wasm", "app.wasm"},
+}
+
+func TestDefaultOutput(t *testing.T) {
+	for _, test := range defaultOutputTests {
+		got := defaultOutput(test.path, test.backend)
+		if got != test.expected {
+			t.Errorf("%s with backend %s: expected output %s, got %s", test.path, test.backend, test.expected, got)
+		}
+	}
+}
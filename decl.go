@@ -1,6 +1,7 @@
 package baisl
 
 import (
+	"strconv"
 	"strings"
 )
 
@@ -29,12 +30,75 @@ type TypeKind int
 const (
 	TypeType_INT TypeKind = iota
 	TypeType_VOID
+	TypeType_BOOL
+	TypeType_STRING
+	// A struct or enum, named by the Type's Name
 	TypeType_CUSTOM
+	// Stands in for the type of a let without an annotation until the analyser infers it
+	TypeType_INFERRED
+	// A fixed number of Elem values, written [Length]Elem
+	TypeType_ARRAY
+	// Any number of Elem values, written []Elem. Arrays convert to slices of their element type.
+	TypeType_SLICE
+	// The type of an expression or declaration the analyser reported an error for. It fits wherever any
+	// type is expected, so one error doesn't cause others.
+	TypeType_ERROR
 )
 
+// Name is the type as written in the source, so two types are the same if their kinds and names are
 type Type struct {
 	Kind TypeKind
 	Name string
+	// Only filled for TypeType_ARRAY and TypeType_SLICE
+	Elem   *Type `json:",omitempty"`
+	Length int   `json:",omitempty"`
+}
+
+func ArrayType(elem Type, length int) Type {
+	return Type{Kind: TypeType_ARRAY, Name: "[" + strconv.Itoa(length) + "]" + elem.Name, Elem: &elem, Length: length}
+}
+
+func SliceType(elem Type) Type {
+	return Type{Kind: TypeType_SLICE, Name: "[]" + elem.Name, Elem: &elem}
+}
+
+// Compares types by name, since array and slice types hold their element type by pointer. A type made of
+// the error type equals any other.
+func (t Type) Equals(other Type) bool {
+	if t.IsError() || other.IsError() {
+		return true
+	}
+	return t.Kind == other.Kind && t.Name == other.Name
+}
+
+// Whether a value of type t can be used where other is expected, which besides the same type
+// allows an array where a slice of its element type is expected
+func (t Type) AssignableTo(other Type) bool {
+	if t.Kind == TypeType_ARRAY && other.Kind == TypeType_SLICE {
+		return t.Elem.Equals(*other.Elem)
+	}
+	return t.Equals(other)
+}
+
+// Whether values of the type hold an element type, which is the same for arrays and slices
+func (t Type) IsSequence() bool {
+	return t.Kind == TypeType_ARRAY || t.Kind == TypeType_SLICE
+}
+
+// Whether the type is Type_INFERRED, or an array or slice of it
+func (t Type) mentionsInferred() bool {
+	if t.IsSequence() {
+		return t.Elem.mentionsInferred()
+	}
+	return t.Kind == TypeType_INFERRED
+}
+
+// Whether the type is the error type, or an array or slice of it
+func (t Type) IsError() bool {
+	if t.IsSequence() {
+		return t.Elem.IsError()
+	}
+	return t.Kind == TypeType_ERROR
 }
 
 type DeclType int
@@ -42,6 +106,11 @@ type DeclType int
 const (
 	DeclType_FUNCTION DeclType = iota
 	DeclType_VARIABLE
+	DeclType_STRUCT
+	DeclType_ENUM
+	DeclType_VARIANT
+	DeclType_IMPORT
+	DeclType_ERROR
 )
 
 func (d DeclType) String() string {
@@ -50,13 +119,27 @@ func (d DeclType) String() string {
 		return "Function"
 	case DeclType_VARIABLE:
 		return "Variable"
+	case DeclType_STRUCT:
+		return "Struct"
+	case DeclType_ENUM:
+		return "Enum"
+	case DeclType_VARIANT:
+		return "Variant"
+	case DeclType_IMPORT:
+		return "Import"
+	case DeclType_ERROR:
+		return "Error"
 	default:
 		return "Unknown"
 	}
 }
 
-var Type_INT = Type{TypeType_INT, "int"}
-var Type_VOID = Type{TypeType_VOID, "void"}
+var Type_INT = Type{Kind: TypeType_INT, Name: "int"}
+var Type_VOID = Type{Kind: TypeType_VOID, Name: "void"}
+var Type_BOOL = Type{Kind: TypeType_BOOL, Name: "bool"}
+var Type_STRING = Type{Kind: TypeType_STRING, Name: "string"}
+var Type_INFERRED = Type{Kind: TypeType_INFERRED, Name: "inferred"}
+var Type_ERROR = Type{Kind: TypeType_ERROR, Name: "error"}
 
 func (t Type) String() string {
 	return t.Name
@@ -66,12 +149,39 @@ type StmtType int
 
 const (
 	StmtType_RETURN StmtType = iota
+	StmtType_LET
+	StmtType_ASSIGN
+	StmtType_IF
+	StmtType_WHILE
+	StmtType_FOR
+	StmtType_BREAK
+	StmtType_CONTINUE
+	StmtType_EXPR
+	StmtType_ERROR
 )
 
 func (s StmtType) String() string {
 	switch s {
 	case StmtType_RETURN:
 		return "Return"
+	case StmtType_LET:
+		return "Let"
+	case StmtType_ASSIGN:
+		return "Assign"
+	case StmtType_IF:
+		return "If"
+	case StmtType_WHILE:
+		return "While"
+	case StmtType_FOR:
+		return "For"
+	case StmtType_BREAK:
+		return "Break"
+	case StmtType_CONTINUE:
+		return "Continue"
+	case StmtType_EXPR:
+		return "Expr"
+	case StmtType_ERROR:
+		return "Error"
 	default:
 		return "Unknown"
 	}
@@ -87,6 +197,22 @@ type ExprType int
 const (
 	ExprType_DECL_REF ExprType = iota
 	ExprType_INT
+	ExprType_BINARY
+	ExprType_UNARY
+	// A true or false literal
+	ExprType_BOOL
+	// A string literal, whose Value has its escapes decoded
+	ExprType_STRING
+	// A struct literal, whose Value names the struct
+	ExprType_STRUCT
+	// A field access, whose Value names the field of the struct Lhs
+	ExprType_FIELD
+	// An array literal, whose elements are Args
+	ExprType_ARRAY
+	// Indexes the array or slice Lhs with Rhs
+	ExprType_INDEX
+	// Matches the enum value Lhs against Arms
+	ExprType_MATCH
 )
 
 type Expr struct {
@@ -94,8 +220,45 @@ type Expr struct {
 	Type     ExprType
 	Value    string
 	IsCall   bool
-	// Only filled if IsCall is true
+	// Only filled if IsCall is true, or with the field values of a struct literal or the elements of an array literal
 	Args []*Expr
+	// Only filled for ExprType_STRUCT, naming the field each of Args initializes
+	Fields []string
+	// Only filled for ExprType_BINARY, ExprType_UNARY, ExprType_FIELD and ExprType_INDEX, where the operand
+	// of a unary operator is Rhs, the struct of a field access is Lhs and an index is Rhs
+	Operator TokenType
+	Lhs      *Expr
+	Rhs      *Expr
+	// Only filled for ExprType_MATCH
+	Arms []*MatchArm
+}
+
+// An arm of a match, taken if the value is Variant, with its payload bound to Bindings
+type MatchArm struct {
+	Location SourceLocation
+	// Empty for the `_` arm, which is taken for every variant without an arm of its own
+	Variant string
+	// One per payload value, nil where the value is skipped with `_`
+	Bindings []*VariableDecl
+	Value    *Expr
+}
+
+func (a *MatchArm) String(level int) string {
+	pattern := a.Variant
+	if pattern == "" {
+		pattern = "_"
+	}
+	if len(a.Bindings) > 0 {
+		bindingStrs := make([]string, len(a.Bindings))
+		for i, binding := range a.Bindings {
+			bindingStrs[i] = "_"
+			if binding != nil {
+				bindingStrs[i] = binding.Id
+			}
+		}
+		pattern += "(" + strings.Join(bindingStrs, ", ") + ")"
+	}
+	return pattern + " => " + a.Value.String(level)
 }
 
 type ReturnStmt struct {
@@ -117,11 +280,43 @@ func (s *ReturnStmt) GetKind() StmtType {
 	return s.Kind
 }
 
-func (e *Expr) String(_ int) string {
+// Operators are printed fully parenthesized, so the tree's shape is visible
+func (e *Expr) String(level int) string {
+	switch e.Type {
+	case ExprType_BINARY:
+		return "(" + e.Lhs.String(level) + " " + TokenTypeToOperator[e.Operator] + " " + e.Rhs.String(level) + ")"
+	case ExprType_UNARY:
+		return "(" + TokenTypeToOperator[e.Operator] + e.Rhs.String(level) + ")"
+	case ExprType_STRING:
+		return strconv.Quote(e.Value)
+	case ExprType_STRUCT:
+		fieldStrs := make([]string, len(e.Args))
+		for i, arg := range e.Args {
+			fieldStrs[i] = e.Fields[i] + ": " + arg.String(level)
+		}
+		return e.Value + "{" + strings.Join(fieldStrs, ", ") + "}"
+	case ExprType_FIELD:
+		return e.Lhs.String(level) + "." + e.Value
+	case ExprType_ARRAY:
+		elemStrs := make([]string, len(e.Args))
+		for i, arg := range e.Args {
+			elemStrs[i] = arg.String(level)
+		}
+		return "[" + strings.Join(elemStrs, ", ") + "]"
+	case ExprType_INDEX:
+		return e.Lhs.String(level) + "[" + e.Rhs.String(level) + "]"
+	case ExprType_MATCH:
+		armStrs := make([]string, len(e.Arms))
+		for i, arm := range e.Arms {
+			armStrs[i] = arm.String(level)
+		}
+		return "match " + e.Lhs.String(level) + " {" + strings.Join(armStrs, ", ") + "}"
+	}
+
 	if e.IsCall {
 		argsStrs := make([]string, len(e.Args))
 		for i, arg := range e.Args {
-			argsStrs[i] = arg.Value
+			argsStrs[i] = arg.String(level)
 		}
 		return "Call " + e.Value + "(" + strings.Join(argsStrs, ", ") + ")"
 	}
@@ -135,6 +330,178 @@ func (s *ReturnStmt) String(level int) string {
 	return strings.Repeat("  ", level) + "Return " + s.Expr.String(level)
 }
 
+// Declares a local variable, whose type is Type_INFERRED if it has no annotation
+type LetStmt struct {
+	Stmt
+	Decl *VariableDecl
+}
+
+func (s *LetStmt) GetLocation() *SourceLocation {
+	return &s.Stmt.Location
+}
+
+func (s *LetStmt) GetKind() StmtType {
+	return s.Kind
+}
+
+func (s *LetStmt) String(level int) string {
+	typeStr := ""
+	if s.Decl.Type != Type_INFERRED {
+		typeStr = ": " + s.Decl.Type.String()
+	}
+	return strings.Repeat("  ", level) + "Let " + s.Decl.Id + typeStr + " = " + s.Decl.Value.String(level)
+}
+
+type AssignStmt struct {
+	Stmt
+	Id   string
+	Expr *Expr
+}
+
+func (s *AssignStmt) GetLocation() *SourceLocation {
+	return &s.Stmt.Location
+}
+
+func (s *AssignStmt) GetKind() StmtType {
+	return s.Kind
+}
+
+func (s *AssignStmt) String(level int) string {
+	return strings.Repeat("  ", level) + "Assign " + s.Id + " = " + s.Expr.String(level)
+}
+
+// An `else if` is an Else block holding just the nested IfStmt
+type IfStmt struct {
+	Stmt
+	Cond *Expr
+	Then *Block
+	// Nil if there is no else branch
+	Else *Block
+}
+
+func (s *IfStmt) GetLocation() *SourceLocation {
+	return &s.Stmt.Location
+}
+
+func (s *IfStmt) GetKind() StmtType {
+	return s.Kind
+}
+
+func (s *IfStmt) String(level int) string {
+	indent := strings.Repeat("  ", level)
+	out := indent + "If " + s.Cond.String(level) + ":\n" + s.Then.String(level+1)
+	if s.Else != nil {
+		out += indent + "Else:\n" + s.Else.String(level+1)
+	}
+	return strings.TrimSuffix(out, "\n")
+}
+
+type WhileStmt struct {
+	Stmt
+	Cond *Expr
+	Body *Block
+}
+
+func (s *WhileStmt) GetLocation() *SourceLocation {
+	return &s.Stmt.Location
+}
+
+func (s *WhileStmt) GetKind() StmtType {
+	return s.Kind
+}
+
+func (s *WhileStmt) String(level int) string {
+	return strings.Repeat("  ", level) + "While " + s.Cond.String(level) + ":\n" + strings.TrimSuffix(s.Body.String(level+1), "\n")
+}
+
+// Loops over `for id in start..end`, excluding end. Var is an int variable initialized to start.
+type ForStmt struct {
+	Stmt
+	Var  *VariableDecl
+	End  *Expr
+	Body *Block
+}
+
+func (s *ForStmt) GetLocation() *SourceLocation {
+	return &s.Stmt.Location
+}
+
+func (s *ForStmt) GetKind() StmtType {
+	return s.Kind
+}
+
+func (s *ForStmt) String(level int) string {
+	header := "For " + s.Var.Id + " in " + s.Var.Value.String(level) + ".." + s.End.String(level) + ":\n"
+	return strings.Repeat("  ", level) + header + strings.TrimSuffix(s.Body.String(level+1), "\n")
+}
+
+// A call evaluated for its effects, whose result is discarded
+type ExprStmt struct {
+	Stmt
+	Expr *Expr
+}
+
+func (s *ExprStmt) GetLocation() *SourceLocation {
+	return &s.Stmt.Location
+}
+
+func (s *ExprStmt) GetKind() StmtType {
+	return s.Kind
+}
+
+func (s *ExprStmt) String(level int) string {
+	return strings.Repeat("  ", level) + "Expr " + s.Expr.String(level)
+}
+
+type BreakStmt struct {
+	Stmt
+}
+
+func (s *BreakStmt) GetLocation() *SourceLocation {
+	return &s.Stmt.Location
+}
+
+func (s *BreakStmt) GetKind() StmtType {
+	return s.Kind
+}
+
+func (s *BreakStmt) String(level int) string {
+	return strings.Repeat("  ", level) + "Break"
+}
+
+type ContinueStmt struct {
+	Stmt
+}
+
+func (s *ContinueStmt) GetLocation() *SourceLocation {
+	return &s.Stmt.Location
+}
+
+func (s *ContinueStmt) GetKind() StmtType {
+	return s.Kind
+}
+
+func (s *ContinueStmt) String(level int) string {
+	return strings.Repeat("  ", level) + "Continue"
+}
+
+// A statement the parser skipped after a syntax error in it, at the start of the statement
+type ErrorStmt struct {
+	Stmt
+}
+
+func (s *ErrorStmt) GetLocation() *SourceLocation {
+	return &s.Stmt.Location
+}
+
+func (s *ErrorStmt) GetKind() StmtType {
+	return s.Kind
+}
+
+func (s *ErrorStmt) String(level int) string {
+	return strings.Repeat("  ", level) + "Error"
+}
+
 type Block struct {
 	Location SourceLocation
 	Stmts    []Statement
@@ -155,9 +522,14 @@ func (b *Block) GetLocation() *SourceLocation {
 
 type FunctionDecl struct {
 	Decl
+	// Whether modules importing the function's own may call it
+	Pub bool
+	// Type_INFERRED if the function has no annotation
 	ReturnType Type
 	Body       *Block
 	Params     []*VariableDecl
+	// Type parameters of a generic function, whose arguments are inferred at each call
+	TypeParams []*Decl
 }
 
 func (f *FunctionDecl) GetId() string {
@@ -179,7 +551,19 @@ func (f *FunctionDecl) String(level int) string {
 	for i, param := range f.Params {
 		paramsStrs[i] = param.GetId() + ": " + param.Type.String()
 	}
-	return strings.Repeat("  ", level) + "Function " + f.Id + "(" + strings.Join(paramsStrs, ", ") + "): " + f.ReturnType.String() + ":\n" + bodyStr
+	typeParamsStr := ""
+	if len(f.TypeParams) > 0 {
+		typeParamsStrs := make([]string, len(f.TypeParams))
+		for i, typeParam := range f.TypeParams {
+			typeParamsStrs[i] = typeParam.Id
+		}
+		typeParamsStr = "<" + strings.Join(typeParamsStrs, ", ") + ">"
+	}
+	returnTypeStr := ""
+	if f.ReturnType != Type_INFERRED {
+		returnTypeStr = ": " + f.ReturnType.String()
+	}
+	return strings.Repeat("  ", level) + pubPrefix(f.Pub) + "Function " + f.Id + typeParamsStr + "(" + strings.Join(paramsStrs, ", ") + ")" + returnTypeStr + ":\n" + bodyStr
 }
 
 type VariableDecl struct {
@@ -202,7 +586,128 @@ func (v *VariableDecl) GetKind() DeclType {
 
 func (v *VariableDecl) String(level int) string {
 	if v.Value != nil {
-		return strings.Repeat("  ", level) + "Variable " + v.Id + " " + v.Type.String() + " = " + v.Value.Value
+		return strings.Repeat("  ", level) + "Variable " + v.Id + " " + v.Type.String() + " = " + v.Value.String(level)
 	}
 	return strings.Repeat("  ", level) + "Variable " + v.Id + " " + v.Type.String()
 }
+
+// A struct type, whose fields are VariableDecls without values
+type StructDecl struct {
+	Decl
+	// Whether modules importing the struct's own may use it
+	Pub    bool
+	Fields []*VariableDecl
+}
+
+func (s *StructDecl) GetId() string {
+	return s.Id
+}
+
+func (s *StructDecl) GetLocation() *SourceLocation {
+	return &s.Location
+}
+
+func (s *StructDecl) GetKind() DeclType {
+	return DeclType_STRUCT
+}
+
+func (s *StructDecl) String(level int) string {
+	fieldStrs := ""
+	for _, field := range s.Fields {
+		fieldStrs += strings.Repeat("  ", level+1) + field.GetId() + ": " + field.Type.String() + "\n"
+	}
+	return strings.Repeat("  ", level) + pubPrefix(s.Pub) + "Struct " + s.Id + ":\n" + fieldStrs
+}
+
+// An enum type, whose values are one of Variants
+type EnumDecl struct {
+	Decl
+	// Whether modules importing the enum's own may use it and its variants
+	Pub      bool
+	Variants []*VariantDecl
+}
+
+func (e *EnumDecl) GetId() string {
+	return e.Id
+}
+
+func (e *EnumDecl) GetLocation() *SourceLocation {
+	return &e.Location
+}
+
+func (e *EnumDecl) GetKind() DeclType {
+	return DeclType_ENUM
+}
+
+func (e *EnumDecl) String(level int) string {
+	variantStrs := ""
+	for _, variant := range e.Variants {
+		variantStrs += variant.String(level+1) + "\n"
+	}
+	return strings.Repeat("  ", level) + pubPrefix(e.Pub) + "Enum " + e.Id + ":\n" + variantStrs
+}
+
+// A variant of an enum, holding a value of each type in Payload. Variants are declared in the global
+// scope, so they are built by their name alone, like `Circle(2)` or `Empty`.
+type VariantDecl struct {
+	Decl
+	Payload []Type
+}
+
+func (v *VariantDecl) GetId() string {
+	return v.Id
+}
+
+func (v *VariantDecl) GetLocation() *SourceLocation {
+	return &v.Location
+}
+
+func (v *VariantDecl) GetKind() DeclType {
+	return DeclType_VARIANT
+}
+
+func (v *VariantDecl) String(level int) string {
+	if len(v.Payload) == 0 {
+		return strings.Repeat("  ", level) + v.Id
+	}
+	payloadStrs := make([]string, len(v.Payload))
+	for i, t := range v.Payload {
+		payloadStrs[i] = t.String()
+	}
+	return strings.Repeat("  ", level) + v.Id + "(" + strings.Join(payloadStrs, ", ") + ")"
+}
+
+func pubPrefix(pub bool) string {
+	if pub {
+		return "Pub "
+	}
+	return ""
+}
+
+// Imports the module in the directory Path, relative to the directory the main module is in, making the
+// declarations it marks pub visible in every file of the importing module
+type ImportDecl struct {
+	Decl
+	Path string
+}
+
+func (i *ImportDecl) GetKind() DeclType {
+	return DeclType_IMPORT
+}
+
+func (i *ImportDecl) String(level int) string {
+	return strings.Repeat("  ", level) + "Import " + strconv.Quote(i.Path)
+}
+
+// A declaration the parser skipped after a syntax error in it, at the start of the declaration
+type ErrorDecl struct {
+	Decl
+}
+
+func (e *ErrorDecl) GetKind() DeclType {
+	return DeclType_ERROR
+}
+
+func (e *ErrorDecl) String(level int) string {
+	return strings.Repeat("  ", level) + "Error"
+}
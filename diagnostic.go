@@ -0,0 +1,237 @@
+package baisl
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type Severity int
+
+const (
+	Severity_ERROR Severity = iota
+	Severity_WARNING
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Severity_ERROR:
+		return "error"
+	case Severity_WARNING:
+		return "warning"
+	}
+	return "unknown"
+}
+
+// Identifies what a diagnostic is about, so tools and tests don't depend on its wording. A code keeps
+// its meaning, and one that is no longer reported is retired rather than reused.
+type DiagnosticCode string
+
+const (
+	// Syntax
+	DiagnosticCode_UNEXPECTED_TOKEN     DiagnosticCode = "E0001"
+	DiagnosticCode_INVALID_ARRAY_LENGTH DiagnosticCode = "E0002"
+	DiagnosticCode_INVALID_INTEGER      DiagnosticCode = "E0003"
+	DiagnosticCode_INVALID_ESCAPE       DiagnosticCode = "E0004"
+	DiagnosticCode_UNTERMINATED_STRING  DiagnosticCode = "E0005"
+
+	// Names
+	DiagnosticCode_UNDECLARED            DiagnosticCode = "E0101"
+	DiagnosticCode_DUPLICATE_DECLARATION DiagnosticCode = "E0102"
+	DiagnosticCode_UNKNOWN_TYPE          DiagnosticCode = "E0103"
+	DiagnosticCode_NOT_A_VALUE           DiagnosticCode = "E0104"
+	DiagnosticCode_NOT_A_FUNCTION        DiagnosticCode = "E0105"
+	DiagnosticCode_NOT_ASSIGNABLE        DiagnosticCode = "E0106"
+	DiagnosticCode_MISSING_MAIN          DiagnosticCode = "E0107"
+
+	// Types
+	DiagnosticCode_TYPE_MISMATCH      DiagnosticCode = "E0201"
+	DiagnosticCode_INVALID_OPERAND    DiagnosticCode = "E0202"
+	DiagnosticCode_VOID_VALUE         DiagnosticCode = "E0203"
+	DiagnosticCode_NON_BOOL_CONDITION DiagnosticCode = "E0204"
+	DiagnosticCode_NON_INT_RANGE      DiagnosticCode = "E0205"
+	DiagnosticCode_NOT_INDEXABLE      DiagnosticCode = "E0206"
+	DiagnosticCode_NON_INT_INDEX      DiagnosticCode = "E0207"
+	DiagnosticCode_INDEX_OUT_OF_RANGE DiagnosticCode = "E0208"
+	DiagnosticCode_UNKNOWN_FIELD      DiagnosticCode = "E0209"
+	DiagnosticCode_MISSING_FIELD      DiagnosticCode = "E0210"
+	DiagnosticCode_DUPLICATE_FIELD    DiagnosticCode = "E0211"
+	DiagnosticCode_EMPTY_ARRAY        DiagnosticCode = "E0212"
+	DiagnosticCode_RECURSIVE_TYPE     DiagnosticCode = "E0213"
+	DiagnosticCode_EMPTY_ENUM         DiagnosticCode = "E0214"
+
+	// Functions and calls
+	DiagnosticCode_ARGUMENT_COUNT      DiagnosticCode = "E0301"
+	DiagnosticCode_ARGUMENT_TYPE       DiagnosticCode = "E0302"
+	DiagnosticCode_PAYLOAD_COUNT       DiagnosticCode = "E0303"
+	DiagnosticCode_MISSING_RETURN      DiagnosticCode = "E0304"
+	DiagnosticCode_UNKNOWN_RETURN_TYPE DiagnosticCode = "E0305"
+	DiagnosticCode_OUTSIDE_LOOP        DiagnosticCode = "E0306"
+
+	// Generics
+	DiagnosticCode_CONFLICTING_TYPE_ARGUMENT DiagnosticCode = "E0401"
+	DiagnosticCode_UNUSED_TYPE_PARAMETER     DiagnosticCode = "E0402"
+	DiagnosticCode_INSTANTIATION_TOO_DEEP    DiagnosticCode = "E0403"
+
+	// Matches
+	DiagnosticCode_NOT_AN_ENUM          DiagnosticCode = "E0501"
+	DiagnosticCode_UNKNOWN_VARIANT      DiagnosticCode = "E0502"
+	DiagnosticCode_DUPLICATE_ARM        DiagnosticCode = "E0503"
+	DiagnosticCode_UNREACHABLE_ARM      DiagnosticCode = "E0504"
+	DiagnosticCode_NON_EXHAUSTIVE_MATCH DiagnosticCode = "E0505"
+
+	// Modules
+	DiagnosticCode_UNKNOWN_MODULE DiagnosticCode = "E0601"
+	DiagnosticCode_IMPORT_CYCLE   DiagnosticCode = "E0602"
+	DiagnosticCode_PRIVATE        DiagnosticCode = "E0603"
+
+	// A node the analyser doesn't know, which the parser never builds
+	DiagnosticCode_INTERNAL DiagnosticCode = "E0901"
+)
+
+// Another place in the source a diagnostic refers to, like the declaration of a function whose call is wrong
+type Label struct {
+	Location SourceLocation
+	Message  string
+}
+
+// A problem found in a program, at Location, which is zero for a problem with the program as a whole
+type Diagnostic struct {
+	Severity Severity
+	Code     DiagnosticCode
+	Message  string
+	Location SourceLocation
+	Labels   []Label
+	// Explanations that belong to no place in the source
+	Notes []string
+}
+
+func newDiagnostic(code DiagnosticCode, location SourceLocation, format string, args ...any) *Diagnostic {
+	return &Diagnostic{
+		Severity: Severity_ERROR,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+		Location: location,
+	}
+}
+
+func (d *Diagnostic) withLabel(location SourceLocation, format string, args ...any) *Diagnostic {
+	d.Labels = append(d.Labels, Label{
+		Location: location,
+		Message:  fmt.Sprintf(format, args...),
+	})
+	return d
+}
+
+func (d *Diagnostic) withNote(format string, args ...any) *Diagnostic {
+	d.Notes = append(d.Notes, fmt.Sprintf(format, args...))
+	return d
+}
+
+// Formats a location to follow a message, or nothing for the zero location. The path is left out when
+// it's the one given, which a label shares with its diagnostic.
+func locationSuffix(location SourceLocation, knownPath string) string {
+	if location.Line == 0 {
+		return ""
+	}
+	suffix := fmt.Sprintf(" at %d:%d", location.Line, location.Column)
+	if location.Path != "" && location.Path != knownPath {
+		suffix += " in " + location.Path
+	}
+	return suffix
+}
+
+// Renders the diagnostic on one line, followed by its labels and notes
+func (d *Diagnostic) Error() string {
+	var sb strings.Builder
+	sb.WriteString(d.Message)
+	sb.WriteString(locationSuffix(d.Location, ""))
+	for _, label := range d.Labels {
+		sb.WriteString("; ")
+		sb.WriteString(label.Message)
+		sb.WriteString(locationSuffix(label.Location, d.Location.Path))
+	}
+	for _, note := range d.Notes {
+		sb.WriteString("; ")
+		sb.WriteString(note)
+	}
+	return sb.String()
+}
+
+// Diagnostics found in a program, in the order they were found. As an error, each is on a line of its own.
+type Diagnostics []*Diagnostic
+
+func (ds Diagnostics) Error() string {
+	lines := make([]string, len(ds))
+	for i, d := range ds {
+		lines[i] = d.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Returns the diagnostics for which keep returns true
+func (ds Diagnostics) Filter(keep func(*Diagnostic) bool) Diagnostics {
+	var kept Diagnostics
+	for _, d := range ds {
+		if keep(d) {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
+
+func (ds Diagnostics) Errors() Diagnostics {
+	return ds.Filter(func(d *Diagnostic) bool {
+		return d.Severity == Severity_ERROR
+	})
+}
+
+func (ds Diagnostics) WithCode(code DiagnosticCode) Diagnostics {
+	return ds.Filter(func(d *Diagnostic) bool {
+		return d.Code == code
+	})
+}
+
+func (ds Diagnostics) HasErrors() bool {
+	return len(ds.Errors()) > 0
+}
+
+// Sorts the diagnostics by location, keeping ones at the same location in the order they were found.
+// Diagnostics about the program as a whole, without a location, come last.
+func (ds Diagnostics) Sort() {
+	slices.SortStableFunc(ds, func(a, b *Diagnostic) int {
+		if (a.Location.Line == 0) != (b.Location.Line == 0) {
+			if a.Location.Line == 0 {
+				return 1
+			}
+			return -1
+		}
+		return a.Location.Compare(b.Location)
+	})
+}
+
+// Returns the code of each diagnostic, without duplicates
+func (ds Diagnostics) Codes() []DiagnosticCode {
+	codes := make([]DiagnosticCode, 0)
+	for _, d := range ds {
+		if !slices.Contains(codes, d.Code) {
+			codes = append(codes, d.Code)
+		}
+	}
+	return codes
+}
+
+// Returns the diagnostics an error from the parser or the analyser reports, or nil for any other error,
+// like one reading the source
+func DiagnosticsOf(err error) Diagnostics {
+	var diagnostics Diagnostics
+	if errors.As(err, &diagnostics) {
+		return diagnostics
+	}
+	var diagnostic *Diagnostic
+	if errors.As(err, &diagnostic) {
+		return Diagnostics{diagnostic}
+	}
+	return nil
+}
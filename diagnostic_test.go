@@ -0,0 +1,91 @@
+package baisl_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/frodi-karlsson/baisl"
+)
+
+type diagnosticTest struct {
+	diagnostic *baisl.Diagnostic
+	expected   string
+	name       string
+}
+
+var diagnosticTests = []diagnosticTest{
+	{
+		diagnostic: &baisl.Diagnostic{
+			Code:     baisl.DiagnosticCode_UNDECLARED,
+			Message:  "Undeclared variable x",
+			Location: baisl.SourceLocation{Path: "main.baisl", Line: 2, Column: 5},
+		},
+		expected: "Undeclared variable x at 2:5 in main.baisl",
+		name:     "Location",
+	},
+	{
+		diagnostic: &baisl.Diagnostic{
+			Code:    baisl.DiagnosticCode_MISSING_MAIN,
+			Message: "No main function found",
+		},
+		expected: "No main function found",
+		name:     "Without a location",
+	},
+	{
+		diagnostic: &baisl.Diagnostic{
+			Code:     baisl.DiagnosticCode_ARGUMENT_COUNT,
+			Message:  "Function f expects 1 arguments, got 0",
+			Location: baisl.SourceLocation{Path: "main.baisl", Line: 6, Column: 10},
+			Labels: []baisl.Label{
+				{Location: baisl.SourceLocation{Path: "main.baisl", Line: 1, Column: 4}, Message: "f is declared"},
+				{Location: baisl.SourceLocation{Path: "lib.baisl", Line: 3, Column: 1}, Message: "f is instantiated"},
+			},
+			Notes: []string{"Pass an argument"},
+		},
+		expected: "Function f expects 1 arguments, got 0 at 6:10 in main.baisl; f is declared at 1:4; f is instantiated at 3:1 in lib.baisl; Pass an argument",
+		name:     "Labels and notes",
+	},
+}
+
+func TestDiagnosticError(t *testing.T) {
+	for _, test := range diagnosticTests {
+		if test.diagnostic.Error() != test.expected {
+			t.Errorf("%s: expected <%s>, got <%s>", test.name, test.expected, test.diagnostic.Error())
+		}
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	undeclared := &baisl.Diagnostic{Severity: baisl.Severity_ERROR, Code: baisl.DiagnosticCode_UNDECLARED, Message: "Undeclared variable x"}
+	unused := &baisl.Diagnostic{Severity: baisl.Severity_WARNING, Code: baisl.DiagnosticCode_UNUSED_TYPE_PARAMETER, Message: "Type parameter T is unused"}
+	alsoUndeclared := &baisl.Diagnostic{Severity: baisl.Severity_ERROR, Code: baisl.DiagnosticCode_UNDECLARED, Message: "Undeclared variable y"}
+	diagnostics := baisl.Diagnostics{undeclared, unused, alsoUndeclared}
+
+	if !slices.Equal(diagnostics.Errors(), baisl.Diagnostics{undeclared, alsoUndeclared}) {
+		t.Errorf("Expected the two errors, got %v", diagnostics.Errors())
+	}
+	if !slices.Equal(diagnostics.WithCode(baisl.DiagnosticCode_UNUSED_TYPE_PARAMETER), baisl.Diagnostics{unused}) {
+		t.Errorf("Expected the warning, got %v", diagnostics.WithCode(baisl.DiagnosticCode_UNUSED_TYPE_PARAMETER))
+	}
+	if !slices.Equal(diagnostics.Codes(), []baisl.DiagnosticCode{baisl.DiagnosticCode_UNDECLARED, baisl.DiagnosticCode_UNUSED_TYPE_PARAMETER}) {
+		t.Errorf("Expected each code once, got %v", diagnostics.Codes())
+	}
+	if !diagnostics.HasErrors() || (baisl.Diagnostics{unused}).HasErrors() {
+		t.Errorf("Expected only diagnostics with an error to have errors")
+	}
+	if diagnostics.Error() != "Undeclared variable x\nType parameter T is unused\nUndeclared variable y" {
+		t.Errorf("Expected a line per diagnostic, got <%s>", diagnostics.Error())
+	}
+
+	wrapped := fmt.Errorf("Checking main.baisl: %w", undeclared)
+	if !slices.Equal(baisl.DiagnosticsOf(wrapped), baisl.Diagnostics{undeclared}) {
+		t.Errorf("Expected the wrapped diagnostic, got %v", baisl.DiagnosticsOf(wrapped))
+	}
+	if !slices.Equal(baisl.DiagnosticsOf(diagnostics), diagnostics) {
+		t.Errorf("Expected the diagnostics themselves, got %v", baisl.DiagnosticsOf(diagnostics))
+	}
+	if baisl.DiagnosticsOf(fmt.Errorf("open main.baisl: no such file")) != nil {
+		t.Errorf("Expected no diagnostics for an error reading the source")
+	}
+}
@@ -0,0 +1,203 @@
+package baisl
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+	ansiBlue   = "\x1b[34m"
+)
+
+// Renders diagnostics for a terminal: the lines of source a diagnostic points into, with its span
+// underlined by ^~~~ and the span of each of its labels by ---
+type DiagnosticRenderer struct {
+	// Contents of the source files by path. A location in a file that isn't here is shown without its line.
+	Sources map[string][]byte
+	// Whether to color the output with ANSI escapes
+	Color bool
+}
+
+// A span to underline, which is the diagnostic's own or one of its labels
+type annotation struct {
+	location SourceLocation
+	message  string
+	primary  bool
+}
+
+// A line of a source file, with the byte offset it starts at
+type sourceLine struct {
+	text   string
+	offset int
+}
+
+// Renders each diagnostic, separated by blank lines
+func (r *DiagnosticRenderer) Render(diagnostics Diagnostics) string {
+	var sb strings.Builder
+	for i, d := range diagnostics {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		r.renderDiagnostic(&sb, d)
+	}
+	return sb.String()
+}
+
+func (r *DiagnosticRenderer) paint(style string, text string) string {
+	if !r.Color || text == "" {
+		return text
+	}
+	return style + text + ansiReset
+}
+
+func (r *DiagnosticRenderer) renderDiagnostic(sb *strings.Builder, d *Diagnostic) {
+	severityStyle := ansiBold + ansiRed
+	if d.Severity == Severity_WARNING {
+		severityStyle = ansiBold + ansiYellow
+	}
+	fmt.Fprintf(sb, "%s%s\n", r.paint(severityStyle, fmt.Sprintf("%s[%s]", d.Severity, d.Code)), r.paint(ansiBold, ": "+d.Message))
+
+	// Annotations grouped by file, starting with the diagnostic's own. A label that can't be shown in its source,
+	// because it has no location or the source isn't known, becomes a note.
+	var paths []string
+	byPath := make(map[string][]annotation)
+	add := func(a annotation) {
+		if _, ok := byPath[a.location.Path]; !ok {
+			paths = append(paths, a.location.Path)
+		}
+		byPath[a.location.Path] = append(byPath[a.location.Path], a)
+	}
+	var notes []string
+	if d.Location.Line != 0 {
+		add(annotation{location: d.Location, primary: true})
+	}
+	for _, label := range d.Labels {
+		_, known := r.Sources[label.Location.Path]
+		if label.Location.Line == 0 || !known {
+			notes = append(notes, label.Message+locationSuffix(label.Location, d.Location.Path))
+			continue
+		}
+		add(annotation{location: label.Location, message: label.Message})
+	}
+	notes = append(notes, d.Notes...)
+
+	width := 1
+	for _, annotations := range byPath {
+		for _, a := range annotations {
+			width = max(width, len(fmt.Sprint(a.location.Line)))
+		}
+	}
+	gutter := func(line string) string {
+		return r.paint(ansiBold+ansiBlue, fmt.Sprintf("%*s |", width, line))
+	}
+
+	snippets := false
+	for i, path := range paths {
+		annotations := byPath[path]
+		arrow := "-->"
+		if i > 0 {
+			arrow = ":::"
+		}
+		first := annotations[0].location
+		fmt.Fprintf(sb, "%*s%s %s:%d:%d\n", width, "", r.paint(ansiBold+ansiBlue, arrow), path, first.Line, first.Column)
+
+		source, ok := r.Sources[path]
+		if !ok {
+			continue
+		}
+
+		lines := splitSourceLines(source)
+		slices.SortStableFunc(annotations, func(a, b annotation) int {
+			if a.location.Line != b.location.Line {
+				return a.location.Line - b.location.Line
+			}
+			return a.location.Column - b.location.Column
+		})
+		sb.WriteString(gutter(""))
+		sb.WriteString("\n")
+		snippets = true
+		shown := 0
+		for _, a := range annotations {
+			if a.location.Line > len(lines) {
+				continue
+			}
+			line := lines[a.location.Line-1]
+			if a.location.Line != shown {
+				if shown != 0 && a.location.Line > shown+1 {
+					sb.WriteString(r.paint(ansiBold+ansiBlue, "...") + "\n")
+				}
+				fmt.Fprintf(sb, "%s %s\n", gutter(fmt.Sprint(a.location.Line)), line.text)
+				shown = a.location.Line
+			}
+			sb.WriteString(gutter(""))
+			sb.WriteString(" ")
+			sb.WriteString(r.underline(line, a, severityStyle))
+			sb.WriteString("\n")
+		}
+	}
+
+	if len(notes) > 0 && snippets {
+		sb.WriteString(gutter(""))
+		sb.WriteString("\n")
+	}
+	for _, note := range notes {
+		fmt.Fprintf(sb, "%*s %s %s\n", width, "", r.paint(ansiBold+ansiBlue, "="), r.paint(ansiBold, "note:")+" "+note)
+	}
+}
+
+// Splits a source into lines at the line breaks the lexer counts: \n, \r\n or a lone \r
+func splitSourceLines(source []byte) []sourceLine {
+	var lines []sourceLine
+	start := 0
+	for i := 0; i < len(source); i++ {
+		c := source[i]
+		if !isNewLine(c) {
+			continue
+		}
+		lines = append(lines, sourceLine{text: string(source[start:i]), offset: start})
+		if c == '\r' && i+1 < len(source) && source[i+1] == '\n' {
+			i++
+		}
+		start = i + 1
+	}
+	return append(lines, sourceLine{text: string(source[start:]), offset: start})
+}
+
+// Underlines the span of an annotation in its line, followed by its message. The span is cut off at the end
+// of the line, and is one character wide if the location has no end, like one made for a test.
+func (r *DiagnosticRenderer) underline(line sourceLine, a annotation, primaryStyle string) string {
+	start := a.location.Offset - line.offset
+	if start < 0 || start > len(line.text) || a.location.End == 0 {
+		start = min(max(a.location.Column-1, 0), len(line.text))
+	}
+	end := start
+	if a.location.End > a.location.Offset {
+		end = min(start+a.location.End-a.location.Offset, len(line.text))
+	}
+	spanWidth := max(utf8.RuneCountInString(line.text[start:end]), 1)
+
+	// Tabs are kept so the underline lines up with the line above however wide the terminal shows them
+	var indent strings.Builder
+	for _, c := range line.text[:start] {
+		if c == '\t' {
+			indent.WriteRune('\t')
+		} else {
+			indent.WriteRune(' ')
+		}
+	}
+
+	if a.primary {
+		return indent.String() + r.paint(primaryStyle, "^"+strings.Repeat("~", spanWidth-1))
+	}
+	marks := strings.Repeat("-", spanWidth)
+	if a.message != "" {
+		marks += " " + a.message
+	}
+	return indent.String() + r.paint(ansiBold+ansiBlue, marks)
+}
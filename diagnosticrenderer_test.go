@@ -0,0 +1,194 @@
+package baisl_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/frodi-karlsson/baisl"
+)
+
+type renderTest struct {
+	diagnostic *baisl.Diagnostic
+	sources    map[string]string
+	color      bool
+	expected   string
+	name       string
+}
+
+const renderMain = "fn f(a: int): int {\n  return a\n}\n\nfn main: int {\n  return f()\n}\n"
+
+var renderTests = []renderTest{
+	{
+		diagnostic: &baisl.Diagnostic{
+			Code:     baisl.DiagnosticCode_ARGUMENT_COUNT,
+			Message:  "Function f expects 1 arguments, got 0",
+			Location: baisl.SourceLocation{Path: "main.baisl", Line: 6, Column: 10, Offset: 58, End: 59},
+			Labels: []baisl.Label{
+				{Location: baisl.SourceLocation{Path: "main.baisl", Line: 1, Column: 4, Offset: 3, End: 4}, Message: "f is declared"},
+			},
+		},
+		sources: map[string]string{"main.baisl": renderMain},
+		expected: "error[E0301]: Function f expects 1 arguments, got 0\n" +
+			" --> main.baisl:6:10\n" +
+			"  |\n" +
+			"1 | fn f(a: int): int {\n" +
+			"  |    - f is declared\n" +
+			"...\n" +
+			"6 |   return f()\n" +
+			"  |          ^\n",
+		name: "Label above the diagnostic",
+	},
+	{
+		diagnostic: &baisl.Diagnostic{
+			Code:     baisl.DiagnosticCode_INVALID_OPERAND,
+			Message:  "Cannot add string and int",
+			Location: baisl.SourceLocation{Path: "main.baisl", Line: 2, Column: 10, Offset: 24, End: 28},
+		},
+		sources: map[string]string{"main.baisl": "fn main: int {\n\tlet s = \"é\" + 1\n}\n"},
+		expected: "error[E0202]: Cannot add string and int\n" +
+			" --> main.baisl:2:10\n" +
+			"  |\n" +
+			"2 | \tlet s = \"é\" + 1\n" +
+			"  | \t        ^~~\n",
+		name: "Tabs and wide characters",
+	},
+	{
+		diagnostic: &baisl.Diagnostic{
+			Code:     baisl.DiagnosticCode_ARGUMENT_COUNT,
+			Message:  "Function f expects 1 arguments, got 0",
+			Location: baisl.SourceLocation{Path: "main.baisl", Line: 6, Column: 10, Offset: 58, End: 59},
+			Labels: []baisl.Label{
+				{Location: baisl.SourceLocation{Path: "lib.baisl", Line: 10, Column: 4, Offset: 12, End: 13}, Message: "f is declared"},
+				{Location: baisl.SourceLocation{Path: "gone.baisl", Line: 3, Column: 1}, Message: "f is instantiated"},
+			},
+			Notes: []string{"Pass an argument"},
+		},
+		sources: map[string]string{
+			"main.baisl": renderMain,
+			"lib.baisl":  "\n\n\n\n\n\n\n\n\nfn f(a: int): int { return a }\n",
+		},
+		expected: "error[E0301]: Function f expects 1 arguments, got 0\n" +
+			"  --> main.baisl:6:10\n" +
+			"   |\n" +
+			" 6 |   return f()\n" +
+			"   |          ^\n" +
+			"  ::: lib.baisl:10:4\n" +
+			"   |\n" +
+			"10 | fn f(a: int): int { return a }\n" +
+			"   |    - f is declared\n" +
+			"   |\n" +
+			"   = note: f is instantiated at 3:1 in gone.baisl\n" +
+			"   = note: Pass an argument\n",
+		name: "Labels in other files",
+	},
+	{
+		diagnostic: &baisl.Diagnostic{
+			Code:     baisl.DiagnosticCode_UNDECLARED,
+			Message:  "Undeclared variable x",
+			Location: baisl.SourceLocation{Path: "main.baisl", Line: 2, Column: 5},
+		},
+		expected: "error[E0101]: Undeclared variable x\n" +
+			" --> main.baisl:2:5\n",
+		name: "Unknown source",
+	},
+	{
+		diagnostic: &baisl.Diagnostic{
+			Code:    baisl.DiagnosticCode_MISSING_MAIN,
+			Message: "No main function found",
+			Notes:   []string{"Declare fn main: int"},
+		},
+		expected: "error[E0107]: No main function found\n" +
+			"  = note: Declare fn main: int\n",
+		name: "Without a location",
+	},
+	{
+		diagnostic: &baisl.Diagnostic{
+			Severity: baisl.Severity_WARNING,
+			Code:     baisl.DiagnosticCode_UNUSED_TYPE_PARAMETER,
+			Message:  "Type parameter T is unused",
+			Location: baisl.SourceLocation{Path: "main.baisl", Line: 1, Column: 7, Offset: 6, End: 7},
+		},
+		sources: map[string]string{"main.baisl": "fn id<T>(x: int): int { return x }\n"},
+		color:   true,
+		expected: "\x1b[1m\x1b[33mwarning[E0402]\x1b[0m\x1b[1m: Type parameter T is unused\x1b[0m\n" +
+			" \x1b[1m\x1b[34m-->\x1b[0m main.baisl:1:7\n" +
+			"\x1b[1m\x1b[34m  |\x1b[0m\n" +
+			"\x1b[1m\x1b[34m1 |\x1b[0m fn id<T>(x: int): int { return x }\n" +
+			"\x1b[1m\x1b[34m  |\x1b[0m       \x1b[1m\x1b[33m^\x1b[0m\n",
+		name: "Color",
+	},
+}
+
+func TestDiagnosticRenderer(t *testing.T) {
+	for _, test := range renderTests {
+		sources := make(map[string][]byte)
+		for path, source := range test.sources {
+			sources[path] = []byte(source)
+		}
+		renderer := baisl.DiagnosticRenderer{Sources: sources, Color: test.color}
+		rendered := renderer.Render(baisl.Diagnostics{test.diagnostic})
+		if rendered != test.expected {
+			t.Errorf("%s: expected\n%s\ngot\n%s", test.name, test.expected, rendered)
+		}
+	}
+}
+
+// The spans of tokens reach the renderer through the parser and the analyser
+func TestDiagnosticRendererSource(t *testing.T) {
+	path := "raw/duplicateArm.baisl"
+	sourceFile, err := baisl.GetSourceFile(path)
+	if err != nil {
+		t.Fatalf("Error opening file")
+	}
+	parser := baisl.Parser{SourceFile: &sourceFile}
+	declarations, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Error parsing file: %s", err)
+	}
+	analyser := baisl.SemanticAnalyser{}
+	_, err = analyser.Analyse(declarations)
+
+	renderer := baisl.DiagnosticRenderer{Sources: map[string][]byte{sourceFile.Path(): sourceFile.Content()}}
+	expected := "error[E0503]: Variant Red matched twice\n" +
+		" --> raw/duplicateArm.baisl:4:32\n" +
+		"  |\n" +
+		"4 |   return match Red { Red => 1, Red => 2, _ => 3 }\n" +
+		"  |                      --- Red is first matched\n" +
+		"  |                                ^~~\n"
+	rendered := renderer.Render(baisl.DiagnosticsOf(err))
+	if rendered != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, rendered)
+	}
+}
+
+// A \r\n line break is one line, both to the lexer and to the renderer showing the line
+func TestDiagnosticRendererCRLF(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crlf.baisl")
+	err := os.WriteFile(path, []byte("fn main: int {\r\n  return x\r\n}\r\n"), 0o644)
+	if err != nil {
+		t.Fatalf("Error writing file: %s", err)
+	}
+	sourceFile, err := baisl.GetSourceFile(path)
+	if err != nil {
+		t.Fatalf("Error opening file")
+	}
+	parser := baisl.Parser{SourceFile: &sourceFile}
+	declarations, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Error parsing file: %s", err)
+	}
+	analyser := baisl.SemanticAnalyser{}
+	_, err = analyser.Analyse(declarations)
+
+	renderer := baisl.DiagnosticRenderer{Sources: map[string][]byte{sourceFile.Path(): sourceFile.Content()}}
+	expected := "error[E0101]: Undeclared variable x\n" +
+		" --> " + path + ":2:10\n" +
+		"  |\n" +
+		"2 |   return x\n" +
+		"  |          ^\n"
+	rendered := renderer.Render(baisl.DiagnosticsOf(err))
+	if rendered != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, rendered)
+	}
+}
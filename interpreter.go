@@ -0,0 +1,699 @@
+package baisl
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// A runtime value produced while interpreting a program
+type Value interface {
+	GetType() Type
+	String() string
+}
+
+type IntValue struct {
+	Value int
+}
+
+func (iv *IntValue) GetType() Type {
+	return Type_INT
+}
+
+func (iv *IntValue) String() string {
+	return strconv.Itoa(iv.Value)
+}
+
+type BoolValue struct {
+	Value bool
+}
+
+func (bv *BoolValue) GetType() Type {
+	return Type_BOOL
+}
+
+func (bv *BoolValue) String() string {
+	return strconv.FormatBool(bv.Value)
+}
+
+type StringValue struct {
+	Value string
+}
+
+func (sv *StringValue) GetType() Type {
+	return Type_STRING
+}
+
+// Strings stand for themselves, so printing one writes its contents
+func (sv *StringValue) String() string {
+	return sv.Value
+}
+
+// Structs can't be modified once built, so values may share them
+type StructValue struct {
+	Name string
+	// Names of Fields, in declaration order
+	FieldNames []string
+	Fields     []Value
+}
+
+func (sv *StructValue) GetType() Type {
+	return Type{Kind: TypeType_CUSTOM, Name: sv.Name}
+}
+
+func (sv *StructValue) String() string {
+	fieldStrs := make([]string, len(sv.Fields))
+	for i, field := range sv.Fields {
+		fieldStrs[i] = sv.FieldNames[i] + ": " + nestedString(field)
+	}
+	return sv.Name + "{" + strings.Join(fieldStrs, ", ") + "}"
+}
+
+// A value of an enum, tagged with the index of its variant. Like structs, values may share them.
+type EnumValue struct {
+	Enum    string
+	Variant string
+	Index   int
+	Payload []Value
+}
+
+func (ev *EnumValue) GetType() Type {
+	return Type{Kind: TypeType_CUSTOM, Name: ev.Enum}
+}
+
+func (ev *EnumValue) String() string {
+	if len(ev.Payload) == 0 {
+		return ev.Variant
+	}
+	payloadStrs := make([]string, len(ev.Payload))
+	for i, value := range ev.Payload {
+		payloadStrs[i] = nestedString(value)
+	}
+	return ev.Variant + "(" + strings.Join(payloadStrs, ", ") + ")"
+}
+
+// Arrays and slices are both a list of elements, which can't be modified once built, so values may share them
+type ArrayValue struct {
+	Elems []Value
+}
+
+// Array literals are never empty, so the first element gives the element type
+func (av *ArrayValue) GetType() Type {
+	return ArrayType(av.Elems[0].GetType(), len(av.Elems))
+}
+
+func (av *ArrayValue) String() string {
+	elemStrs := make([]string, len(av.Elems))
+	for i, elem := range av.Elems {
+		elemStrs[i] = nestedString(elem)
+	}
+	return "[" + strings.Join(elemStrs, ", ") + "]"
+}
+
+// Formats a value inside a struct or array, where strings are quoted to tell them apart
+func nestedString(value Value) string {
+	if _, ok := value.(*StringValue); ok {
+		return strconv.Quote(value.String())
+	}
+	return value.String()
+}
+
+// Unwraps a value the analyser guarantees to be a bool, like a condition
+func isTrue(value Value) bool {
+	return value.(*BoolValue).Value
+}
+
+var errDivisionByZero = errors.New("Division by zero")
+
+// Applies a binary operator to two values, shared by the interpreter and the VM.
+// The logical operators short-circuit, so they are evaluated by their callers instead.
+func evaluateBinaryOperator(operator TokenType, lhs Value, rhs Value) (Value, error) {
+	lb, lok := lhs.(*BoolValue)
+	rb, rok := rhs.(*BoolValue)
+	if lok && rok {
+		switch operator {
+		case TokenType_EQ:
+			return &BoolValue{Value: lb.Value == rb.Value}, nil
+		case TokenType_NEQ:
+			return &BoolValue{Value: lb.Value != rb.Value}, nil
+		}
+	}
+
+	ls, lok := lhs.(*StringValue)
+	rs, rok := rhs.(*StringValue)
+	if lok && rok && operator == TokenType_PLUS {
+		return &StringValue{Value: ls.Value + rs.Value}, nil
+	}
+
+	l, lok := lhs.(*IntValue)
+	r, rok := rhs.(*IntValue)
+	if !lok || !rok {
+		return nil, fmt.Errorf("Operator %s expects int operands, got %s and %s", TokenTypeToOperator[operator], lhs.GetType(), rhs.GetType())
+	}
+
+	switch operator {
+	case TokenType_PLUS:
+		return &IntValue{Value: l.Value + r.Value}, nil
+	case TokenType_MINUS:
+		return &IntValue{Value: l.Value - r.Value}, nil
+	case TokenType_STAR:
+		return &IntValue{Value: l.Value * r.Value}, nil
+	case TokenType_SLASH, TokenType_PERCENT:
+		if r.Value == 0 {
+			return nil, errDivisionByZero
+		}
+		if operator == TokenType_SLASH {
+			return &IntValue{Value: l.Value / r.Value}, nil
+		}
+		return &IntValue{Value: l.Value % r.Value}, nil
+	case TokenType_EQ:
+		return &BoolValue{Value: l.Value == r.Value}, nil
+	case TokenType_NEQ:
+		return &BoolValue{Value: l.Value != r.Value}, nil
+	case TokenType_LT:
+		return &BoolValue{Value: l.Value < r.Value}, nil
+	case TokenType_LTE:
+		return &BoolValue{Value: l.Value <= r.Value}, nil
+	case TokenType_GT:
+		return &BoolValue{Value: l.Value > r.Value}, nil
+	case TokenType_GTE:
+		return &BoolValue{Value: l.Value >= r.Value}, nil
+	}
+	return nil, fmt.Errorf("Unknown binary operator %s", operator)
+}
+
+func evaluateUnaryOperator(operator TokenType, operand Value) (Value, error) {
+	switch operator {
+	case TokenType_MINUS:
+		o, ok := operand.(*IntValue)
+		if !ok {
+			return nil, fmt.Errorf("Operator %s expects an int operand, got %s", TokenTypeToOperator[operator], operand.GetType())
+		}
+		return &IntValue{Value: -o.Value}, nil
+	case TokenType_BANG:
+		o, ok := operand.(*BoolValue)
+		if !ok {
+			return nil, fmt.Errorf("Operator %s expects a bool operand, got %s", TokenTypeToOperator[operator], operand.GetType())
+		}
+		return &BoolValue{Value: !o.Value}, nil
+	}
+	return nil, fmt.Errorf("Unknown unary operator %s", operator)
+}
+
+// Reads a field of a struct value, shared by the interpreter and the VM
+func getField(value Value, index int) (Value, error) {
+	structValue, ok := value.(*StructValue)
+	if !ok {
+		return nil, fmt.Errorf("Cannot access a field of %s", value.GetType())
+	}
+	if index < 0 || index >= len(structValue.Fields) {
+		return nil, fmt.Errorf("Field %d out of range for %s", index, structValue.Name)
+	}
+	return structValue.Fields[index], nil
+}
+
+// Returns the index of the variant of an enum value, shared by the interpreter and the VM
+func getTag(value Value) (int, error) {
+	enumValue, ok := value.(*EnumValue)
+	if !ok {
+		return 0, fmt.Errorf("Cannot match %s", value.GetType())
+	}
+	return enumValue.Index, nil
+}
+
+// Reads a payload value of an enum value, shared by the interpreter and the VM
+func getPayload(value Value, index int) (Value, error) {
+	enumValue, ok := value.(*EnumValue)
+	if !ok {
+		return nil, fmt.Errorf("Cannot match %s", value.GetType())
+	}
+	if index < 0 || index >= len(enumValue.Payload) {
+		return nil, fmt.Errorf("Payload value %d out of range for %s", index, enumValue.Variant)
+	}
+	return enumValue.Payload[index], nil
+}
+
+// Reads an element of an array or slice value, shared by the interpreter and the VM.
+// The error leaves out where the indexing happened, which the callers add.
+func getElement(value Value, index Value) (Value, error) {
+	arrayValue, ok := value.(*ArrayValue)
+	if !ok {
+		return nil, fmt.Errorf("Cannot index %s", value.GetType())
+	}
+	i, ok := index.(*IntValue)
+	if !ok {
+		return nil, fmt.Errorf("Index must be int, got %s", index.GetType())
+	}
+	if i.Value < 0 || i.Value >= len(arrayValue.Elems) {
+		return nil, fmt.Errorf("Index %d out of range for length %d", i.Value, len(arrayValue.Elems))
+	}
+	return arrayValue.Elems[i.Value], nil
+}
+
+// Returns the number of elements of an array or slice value for len, shared by the interpreter and the VM
+func getLength(value Value) (Value, error) {
+	arrayValue, ok := value.(*ArrayValue)
+	if !ok {
+		return nil, fmt.Errorf("Builtin len expects an array or slice, got %s", value.GetType())
+	}
+	return &IntValue{Value: len(arrayValue.Elems)}, nil
+}
+
+// Holds the parameter and local variable bindings of a single function call.
+// Bindings are keyed by declaration, since a let in a nested block may shadow another of the same name.
+type frame map[ResolvedDeclaration]Value
+
+// Writes a value for print or println, shared by the interpreter and the VM
+func printValue(out io.Writer, value Value, newline bool) error {
+	text := value.String()
+	if newline {
+		text += "\n"
+	}
+	_, err := io.WriteString(out, text)
+	return err
+}
+
+// Executes resolved declarations by walking the tree, starting at main
+type Interpreter struct {
+	Declarations []ResolvedDeclaration
+	// Where print and println write, os.Stdout if nil
+	Output io.Writer
+
+	// Number of calls being run, limited like the VM's call frames
+	callDepth int
+}
+
+// A runtime error in a function, which the functions calling it pass on as it is
+type functionError struct {
+	function string
+	err      error
+}
+
+func (fe *functionError) Error() string {
+	return "Error in function " + fe.function + ": " + fe.err.Error()
+}
+
+func (fe *functionError) Unwrap() error {
+	return fe.err
+}
+
+func (in *Interpreter) FindFunction(id string) *ResolvedFunctionDeclaration {
+	for _, decl := range in.Declarations {
+		fn, ok := decl.(*ResolvedFunctionDeclaration)
+		if ok && fn.GetId() == id {
+			return fn
+		}
+	}
+	return nil
+}
+
+func (in *Interpreter) EvaluateExpr(expr ResolvedExpr, env frame) (Value, error) {
+	switch expr.(type) {
+	case *ResolvedValueExpr:
+		return &IntValue{Value: expr.(*ResolvedValueExpr).Value}, nil
+	case *ResolvedBoolExpr:
+		return &BoolValue{Value: expr.(*ResolvedBoolExpr).Value}, nil
+	case *ResolvedStringExpr:
+		return &StringValue{Value: expr.(*ResolvedStringExpr).Value}, nil
+	case *ResolvedStructExpr:
+		structExpr := expr.(*ResolvedStructExpr)
+		value := &StructValue{
+			Name:       structExpr.Struct.Id,
+			FieldNames: make([]string, len(structExpr.Fields)),
+			Fields:     make([]Value, len(structExpr.Fields)),
+		}
+		for i, field := range structExpr.Fields {
+			fieldValue, err := in.EvaluateExpr(field, env)
+			if err != nil {
+				return nil, err
+			}
+			value.FieldNames[i] = structExpr.Struct.Fields[i].Id
+			value.Fields[i] = fieldValue
+		}
+		return value, nil
+	case *ResolvedFieldExpr:
+		fieldExpr := expr.(*ResolvedFieldExpr)
+		value, err := in.EvaluateExpr(fieldExpr.Struct, env)
+		if err != nil {
+			return nil, err
+		}
+		return getField(value, fieldExpr.Index)
+	case *ResolvedVariantExpr:
+		variantExpr := expr.(*ResolvedVariantExpr)
+		value := &EnumValue{
+			Enum:    variantExpr.Variant.Enum,
+			Variant: variantExpr.Variant.Id,
+			Index:   variantExpr.Variant.Index,
+			Payload: make([]Value, len(variantExpr.Payload)),
+		}
+		for i, payload := range variantExpr.Payload {
+			payloadValue, err := in.EvaluateExpr(payload, env)
+			if err != nil {
+				return nil, err
+			}
+			value.Payload[i] = payloadValue
+		}
+		return value, nil
+	case *ResolvedMatchExpr:
+		matchExpr := expr.(*ResolvedMatchExpr)
+		value, err := in.EvaluateMatch(matchExpr, env)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			return nil, fmt.Errorf("Match on %s does not return a value", matchExpr.Enum.Id)
+		}
+		return value, nil
+	case *ResolvedArrayExpr:
+		arrayExpr := expr.(*ResolvedArrayExpr)
+		value := &ArrayValue{
+			Elems: make([]Value, len(arrayExpr.Elems)),
+		}
+		for i, elem := range arrayExpr.Elems {
+			elemValue, err := in.EvaluateExpr(elem, env)
+			if err != nil {
+				return nil, err
+			}
+			value.Elems[i] = elemValue
+		}
+		return value, nil
+	case *ResolvedIndexExpr:
+		indexExpr := expr.(*ResolvedIndexExpr)
+		array, err := in.EvaluateExpr(indexExpr.Array, env)
+		if err != nil {
+			return nil, err
+		}
+		index, err := in.EvaluateExpr(indexExpr.Index, env)
+		if err != nil {
+			return nil, err
+		}
+		value, err := getElement(array, index)
+		if err != nil {
+			location := indexExpr.Location
+			return nil, fmt.Errorf("%s at %d:%d in %s", err, location.Line, location.Column, location.Path)
+		}
+		return value, nil
+	case *ResolvedBuiltinCallExpr:
+		builtinCall := expr.(*ResolvedBuiltinCallExpr)
+		value, err := in.EvaluateCall(builtinCall, env)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			return nil, fmt.Errorf("Builtin %s does not return a value", builtinCall.Builtin)
+		}
+		return value, nil
+	case *ResolvedRefExpr:
+		refExpr := expr.(*ResolvedRefExpr)
+		decl := *refExpr.Value
+		if refExpr.IsCall {
+			value, err := in.EvaluateCall(refExpr, env)
+			if err != nil {
+				return nil, err
+			}
+			if value == nil {
+				return nil, fmt.Errorf("Function %s does not return a value", decl.GetId())
+			}
+			return value, nil
+		}
+
+		value, ok := env[decl]
+		if !ok {
+			return nil, fmt.Errorf("Unbound variable %s", decl.GetId())
+		}
+		return value, nil
+	case *ResolvedBinaryExpr:
+		binaryExpr := expr.(*ResolvedBinaryExpr)
+		lhs, err := in.EvaluateExpr(binaryExpr.Lhs, env)
+		if err != nil {
+			return nil, err
+		}
+		// The right operand of a logical operator is only evaluated if the left one doesn't decide the result
+		if binaryExpr.Operator == TokenType_AND || binaryExpr.Operator == TokenType_OR {
+			if isTrue(lhs) == (binaryExpr.Operator == TokenType_OR) {
+				return lhs, nil
+			}
+			return in.EvaluateExpr(binaryExpr.Rhs, env)
+		}
+
+		rhs, err := in.EvaluateExpr(binaryExpr.Rhs, env)
+		if err != nil {
+			return nil, err
+		}
+		value, err := evaluateBinaryOperator(binaryExpr.Operator, lhs, rhs)
+		if err != nil {
+			location := binaryExpr.Location
+			return nil, fmt.Errorf("%s at %d:%d in %s", err, location.Line, location.Column, location.Path)
+		}
+		return value, nil
+	case *ResolvedUnaryExpr:
+		unaryExpr := expr.(*ResolvedUnaryExpr)
+		operand, err := in.EvaluateExpr(unaryExpr.Operand, env)
+		if err != nil {
+			return nil, err
+		}
+		return evaluateUnaryOperator(unaryExpr.Operator, operand)
+	}
+	return nil, fmt.Errorf("Unknown expression type %d", expr.GetExprType())
+}
+
+// Evaluates the arm of a match taken for the matched value's variant, returning nil if the arms are void
+func (in *Interpreter) EvaluateMatch(matchExpr *ResolvedMatchExpr, env frame) (Value, error) {
+	value, err := in.EvaluateExpr(matchExpr.Value.Value, env)
+	if err != nil {
+		return nil, err
+	}
+	env[matchExpr.Value] = value
+	tag, err := getTag(value)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, arm := range matchExpr.Arms {
+		if arm.Variant != nil && arm.Variant.Index != tag {
+			continue
+		}
+		for i, binding := range arm.Bindings {
+			if binding == nil {
+				continue
+			}
+			payload, err := getPayload(value, i)
+			if err != nil {
+				return nil, err
+			}
+			env[binding] = payload
+		}
+		return in.EvaluateStatementExpr(arm.Value, env)
+	}
+	return nil, fmt.Errorf("No arm of the match on %s is for %s", matchExpr.Enum.Id, value)
+}
+
+// Evaluates an expression used as a statement, which may be void if it is a call or a match
+func (in *Interpreter) EvaluateStatementExpr(expr ResolvedExpr, env frame) (Value, error) {
+	switch expr.(type) {
+	case *ResolvedBuiltinCallExpr:
+		return in.EvaluateCall(expr, env)
+	case *ResolvedRefExpr:
+		if expr.(*ResolvedRefExpr).IsCall {
+			return in.EvaluateCall(expr, env)
+		}
+	case *ResolvedMatchExpr:
+		return in.EvaluateMatch(expr.(*ResolvedMatchExpr), env)
+	}
+	return in.EvaluateExpr(expr, env)
+}
+
+// Evaluates a call to a function or builtin, returning nil if it doesn't return a value
+func (in *Interpreter) EvaluateCall(call ResolvedExpr, env frame) (Value, error) {
+	var argExprs []ResolvedExpr
+	switch call.(type) {
+	case *ResolvedRefExpr:
+		argExprs = call.(*ResolvedRefExpr).Args
+	case *ResolvedBuiltinCallExpr:
+		argExprs = call.(*ResolvedBuiltinCallExpr).Args
+	default:
+		return nil, fmt.Errorf("Expression type %d is not a call", call.GetExprType())
+	}
+
+	args := make([]Value, len(argExprs))
+	for i, arg := range argExprs {
+		value, err := in.EvaluateExpr(arg, env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+
+	builtinCall, ok := call.(*ResolvedBuiltinCallExpr)
+	if ok {
+		if builtinCall.Builtin == Builtin_LEN {
+			return getLength(args[0])
+		}
+		output := in.Output
+		if output == nil {
+			output = os.Stdout
+		}
+		return nil, printValue(output, args[0], builtinCall.Builtin == Builtin_PRINTLN)
+	}
+
+	decl := *call.(*ResolvedRefExpr).Value
+	fn, ok := decl.(*ResolvedFunctionDeclaration)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a function", decl.GetId())
+	}
+	return in.CallFunction(fn, args)
+}
+
+// How control leaves a block
+type control int
+
+const (
+	control_NEXT control = iota
+	control_RETURN
+	control_BREAK
+	control_CONTINUE
+)
+
+// Executes the statements of a block, returning how control left it and, for a return,
+// the returned value, which is nil for void returns
+func (in *Interpreter) ExecuteBlock(block *ResolvedBlock, env frame) (Value, control, error) {
+	for _, stmt := range block.Stmts {
+		switch stmt.(type) {
+		case *ResolvedReturnStatement:
+			returnStmt := stmt.(*ResolvedReturnStatement)
+			if returnStmt.Expr == nil {
+				return nil, control_RETURN, nil
+			}
+			value, err := in.EvaluateExpr(returnStmt.Expr, env)
+			return value, control_RETURN, err
+		case *ResolvedLetStatement:
+			variable := stmt.(*ResolvedLetStatement).Variable
+			value, err := in.EvaluateExpr(variable.Value, env)
+			if err != nil {
+				return nil, control_NEXT, err
+			}
+			env[variable] = value
+		case *ResolvedAssignStatement:
+			assignStmt := stmt.(*ResolvedAssignStatement)
+			value, err := in.EvaluateExpr(assignStmt.Expr, env)
+			if err != nil {
+				return nil, control_NEXT, err
+			}
+			env[assignStmt.Variable] = value
+		case *ResolvedExprStatement:
+			_, err := in.EvaluateStatementExpr(stmt.(*ResolvedExprStatement).Expr, env)
+			if err != nil {
+				return nil, control_NEXT, err
+			}
+		case *ResolvedIfStatement:
+			ifStmt := stmt.(*ResolvedIfStatement)
+			cond, err := in.EvaluateExpr(ifStmt.Cond, env)
+			if err != nil {
+				return nil, control_NEXT, err
+			}
+
+			branch := ifStmt.Else
+			if isTrue(cond) {
+				branch = ifStmt.Then
+			}
+			if branch == nil {
+				continue
+			}
+			value, flow, err := in.ExecuteBlock(branch, env)
+			if err != nil || flow != control_NEXT {
+				return value, flow, err
+			}
+		case *ResolvedWhileStatement:
+			whileStmt := stmt.(*ResolvedWhileStatement)
+			for {
+				cond, err := in.EvaluateExpr(whileStmt.Cond, env)
+				if err != nil {
+					return nil, control_NEXT, err
+				}
+				if !isTrue(cond) {
+					break
+				}
+
+				value, flow, err := in.ExecuteBlock(whileStmt.Body, env)
+				if err != nil || flow == control_RETURN {
+					return value, flow, err
+				}
+				if flow == control_BREAK {
+					break
+				}
+			}
+		case *ResolvedForStatement:
+			forStmt := stmt.(*ResolvedForStatement)
+			start, err := in.EvaluateExpr(forStmt.Variable.Value, env)
+			if err != nil {
+				return nil, control_NEXT, err
+			}
+			limit, err := in.EvaluateExpr(forStmt.Limit.Value, env)
+			if err != nil {
+				return nil, control_NEXT, err
+			}
+
+			// The body may assign to the loop variable, so it is read back before each step
+			env[forStmt.Variable] = start
+			for env[forStmt.Variable].(*IntValue).Value < limit.(*IntValue).Value {
+				value, flow, err := in.ExecuteBlock(forStmt.Body, env)
+				if err != nil || flow == control_RETURN {
+					return value, flow, err
+				}
+				if flow == control_BREAK {
+					break
+				}
+				env[forStmt.Variable] = &IntValue{Value: env[forStmt.Variable].(*IntValue).Value + 1}
+			}
+		case *ResolvedBreakStatement:
+			return nil, control_BREAK, nil
+		case *ResolvedContinueStatement:
+			return nil, control_CONTINUE, nil
+		default:
+			return nil, control_NEXT, fmt.Errorf("Unknown statement type %s", stmt.GetStmtType())
+		}
+	}
+	return nil, control_NEXT, nil
+}
+
+func (in *Interpreter) CallFunction(fn *ResolvedFunctionDeclaration, args []Value) (Value, error) {
+	if len(args) != len(fn.Params) {
+		return nil, fmt.Errorf("Function %s expects %d arguments, got %d", fn.GetId(), len(fn.Params), len(args))
+	}
+
+	if in.callDepth >= maxCallDepth {
+		return nil, fmt.Errorf("Call stack overflow")
+	}
+	in.callDepth++
+	defer func() {
+		in.callDepth--
+	}()
+
+	env := make(frame, len(args))
+	for i, param := range fn.Params {
+		env[param] = args[i]
+	}
+
+	value, _, err := in.ExecuteBlock(fn.Body, env)
+	var calleeErr *functionError
+	if errors.As(err, &calleeErr) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, &functionError{function: fn.GetId(), err: err}
+	}
+	return value, nil
+}
+
+// Runs main and returns its result, which is nil if main returns void
+func (in *Interpreter) Run() (Value, error) {
+	main := in.FindFunction("main")
+	if main == nil {
+		return nil, fmt.Errorf("No main function found")
+	}
+
+	return in.CallFunction(main, []Value{})
+}
@@ -0,0 +1,175 @@
+package baisl_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/frodi-karlsson/baisl"
+)
+
+type interpreterTest struct {
+	path string
+	// Empty if main returns void
+	expected string
+}
+
+// A program whose printed output is checked, run on every backend able to print
+type outputTest struct {
+	path     string
+	expected string
+}
+
+type failInterpreterTest struct {
+	// Program to analyse and run, used instead of declarations when set
+	path          string
+	declarations  []baisl.ResolvedDeclaration
+	errorContains string
+	name          string
+}
+
+var interpreterTests = []interpreterTest{
+	{"raw/mainVoid.baisl", ""},
+	{"raw/emptyVoid.baisl", ""},
+	{"raw/ret2.baisl", ""},
+	{"raw/fnCall.baisl", "5"},
+	{"raw/params.baisl", "2"},
+	{"raw/manyParams.baisl", "8"},
+	{"raw/arithmetic.baisl", "13"},
+	{"raw/locals.baisl", "26"},
+	{"raw/ifElse.baisl", "39"},
+	{"raw/loops.baisl", "107"},
+	{"raw/loopControl.baisl", "13"},
+	{"raw/bool.baisl", "21"},
+	{"raw/matchShape.baisl", "2"},
+	{"raw/recursion.baisl", "20"},
+	{"raw/forwardCalls.baisl", "2"},
+}
+
+var outputTests = []outputTest{
+	{"raw/strings.baisl", "Hello, baisl!\ntab\there \"quoted\" back\\slash\nsum: 5\ntrue\n###\ncaf\u00e9 \U0001F600??=\n"},
+	{"raw/structs.baisl", "diag\n7\n6\n"},
+	{"raw/arrays.baisl", "4\n17\n5\n3\nblue\nc\n"},
+	{"raw/enums.baisl", "round\nnot round\nnot round\n24\ngreen\nyellow\nred\ngreen\n5\n"},
+	{"raw/generics.baisl", "42\ngeneric\ntrue\na\n7\n4\n9\n"},
+	{"raw/inferredReturns.baisl", "inferred\n42\n0\n55\n"},
+	{"raw/callBeforeReturn.baisl", "0\n4\ngo!!\n3\n4\n"},
+	{"raw/divisionOverflow.baisl", "-9223372036854775808\n0\n-9223372036854775808\n-3\n-1\n"},
+	{"raw/arithmeticOverflow.baisl", "-9223372036854775808\n9223372036854775807\n-2\n-9223372036854775808\n"},
+	{"raw/shadowing.baisl", "217\n"},
+	{"raw/evaluationOrder.baisl", "4 5 6 15\n1 2 12\n7 8 0 9\n<a>\n"},
+}
+
+var failInterpreterTests = []failInterpreterTest{
+	{
+		declarations:  []baisl.ResolvedDeclaration{},
+		errorContains: "No main function found",
+		name:          "No main",
+	},
+	{
+		path:          "raw/divByZero.baisl",
+		errorContains: "Error in function main: Division by zero at 2:12",
+		name:          "Division by zero",
+	},
+	{
+		path:          "raw/indexOutOfRange.baisl",
+		errorContains: "Error in function main: Index 3 out of range for length 3 at 4:12 in raw/indexOutOfRange.baisl",
+		name:          "Index out of range",
+	},
+	{
+		path:          "raw/unboundedRecursion.baisl",
+		errorContains: "Error in function main: Call stack overflow",
+		name:          "Unbounded recursion",
+	},
+}
+
+func getDeclarations(t *testing.T, path string) []baisl.Declaration {
+	sourceFile, err := baisl.GetSourceFile(path)
+	if err != nil {
+		t.Fatalf("Error reading file: %s", err)
+	}
+
+	parser := baisl.Parser{
+		SourceFile: &sourceFile,
+	}
+	declarations, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Error parsing file %s: %s", path, err)
+	}
+
+	return declarations
+}
+
+func getResolvedDeclarations(t *testing.T, path string) []baisl.ResolvedDeclaration {
+	declarations := getDeclarations(t, path)
+
+	analyser := baisl.SemanticAnalyser{}
+	resolved, err := analyser.Analyse(declarations)
+	if err != nil {
+		t.Fatalf("Error analysing file %s: %s", path, err)
+	}
+
+	return resolved
+}
+
+func TestInterpreter(t *testing.T) {
+	for _, test := range interpreterTests {
+		interpreter := baisl.Interpreter{
+			Declarations: getResolvedDeclarations(t, test.path),
+		}
+
+		result, err := interpreter.Run()
+		if err != nil {
+			t.Errorf("Error running %s: %s", test.path, err)
+			continue
+		}
+
+		got := ""
+		if result != nil {
+			got = result.String()
+		}
+		if got != test.expected {
+			t.Errorf("Running %s, expected <%s>, got <%s>", test.path, test.expected, got)
+		}
+	}
+
+	for _, test := range failInterpreterTests {
+		declarations := test.declarations
+		if test.path != "" {
+			declarations = getResolvedDeclarations(t, test.path)
+		}
+		interpreter := baisl.Interpreter{
+			Declarations: declarations,
+		}
+
+		_, err := interpreter.Run()
+		if err == nil {
+			t.Errorf("Expected error in %s, got none", test.name)
+			continue
+		}
+
+		if !strings.Contains(err.Error(), test.errorContains) {
+			t.Errorf("Expected error containing <%s>, got <%s>", test.errorContains, err)
+		}
+	}
+}
+
+func TestInterpreterOutput(t *testing.T) {
+	for _, test := range outputTests {
+		output := bytes.Buffer{}
+		interpreter := baisl.Interpreter{
+			Declarations: getResolvedDeclarations(t, test.path),
+			Output:       &output,
+		}
+
+		_, err := interpreter.Run()
+		if err != nil {
+			t.Errorf("Error running %s: %s", test.path, err)
+			continue
+		}
+
+		if output.String() != test.expected {
+			t.Errorf("Running %s, expected output <%s>, got <%s>", test.path, test.expected, output.String())
+		}
+	}
+}
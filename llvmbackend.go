@@ -0,0 +1,1040 @@
+package baisl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Generates textual LLVM IR from resolved declarations. Each function becomes a define,
+// and a C-style main wrapper returns the program's main result as the exit code.
+type LlvmBackend struct {
+	Declarations []ResolvedDeclaration
+
+	out strings.Builder
+	// Counters for the temporaries and basic block labels of the current function
+	temporaries int
+	labels      int
+	// Label of the current basic block and whether it already ends in a terminator
+	block      string
+	terminated bool
+	// LLVM return type of the current function
+	returnType string
+	// Numbers telling apart the variables of the current function that share an id
+	variables map[ResolvedDeclaration]int
+	// Blocks continue and break branch to, for each loop enclosing the current statement
+	loops []llvmLoop
+	// Contents of the string literals, each a global constant numbered by its index
+	strings       []string
+	stringIndices map[string]int
+	// Whether the program uses strings, printing, arrays or division, which need llvmRuntime
+	usesRuntime bool
+	// Enum declarations by name
+	enums map[string]*ResolvedEnumDeclaration
+}
+
+// Support code for strings, printing and arrays, only included in programs using them.
+// Printing writes straight to the file descriptor, so output is never left in a buffer.
+// The string type is declared separately, since it has to precede its first use.
+const llvmStringType = "\n%baisl.string = type { i64, i8* }\n"
+
+const llvmRuntime = `
+@baisl.true = private unnamed_addr constant [4 x i8] c"true"
+@baisl.false = private unnamed_addr constant [5 x i8] c"false"
+@baisl.newline = private unnamed_addr constant [1 x i8] c"\0A"
+@baisl.format.int = private unnamed_addr constant [5 x i8] c"%lld\00"
+@baisl.format.index = private unnamed_addr constant [54 x i8] c"Index %lld out of range for length %lld at %lld:%lld\0A\00"
+@baisl.format.division = private unnamed_addr constant [31 x i8] c"Division by zero at %lld:%lld\0A\00"
+
+declare i8* @malloc(i64)
+declare void @llvm.memcpy.p0i8.p0i8.i64(i8*, i8*, i64, i1)
+declare i64 @write(i32, i8*, i64)
+declare i32 @snprintf(i8*, i64, i8*, ...)
+declare void @exit(i32)
+
+define private %baisl.string @baisl.concat(%baisl.string %a, %baisl.string %b) {
+entry:
+  %a.len = extractvalue %baisl.string %a, 0
+  %a.data = extractvalue %baisl.string %a, 1
+  %b.len = extractvalue %baisl.string %b, 0
+  %b.data = extractvalue %baisl.string %b, 1
+  %len = add i64 %a.len, %b.len
+  %data = call i8* @malloc(i64 %len)
+  call void @llvm.memcpy.p0i8.p0i8.i64(i8* %data, i8* %a.data, i64 %a.len, i1 false)
+  %tail = getelementptr i8, i8* %data, i64 %a.len
+  call void @llvm.memcpy.p0i8.p0i8.i64(i8* %tail, i8* %b.data, i64 %b.len, i1 false)
+  %partial = insertvalue %baisl.string undef, i64 %len, 0
+  %result = insertvalue %baisl.string %partial, i8* %data, 1
+  ret %baisl.string %result
+}
+
+define private void @baisl.print.string(%baisl.string %value, i1 %newline) {
+entry:
+  %len = extractvalue %baisl.string %value, 0
+  %data = extractvalue %baisl.string %value, 1
+  call i64 @write(i32 1, i8* %data, i64 %len)
+  br i1 %newline, label %print.newline, label %done
+print.newline:
+  call i64 @write(i32 1, i8* getelementptr inbounds ([1 x i8], [1 x i8]* @baisl.newline, i64 0, i64 0), i64 1)
+  br label %done
+done:
+  ret void
+}
+
+define private void @baisl.print.int(i64 %value, i1 %newline) {
+entry:
+  %buffer = alloca [21 x i8]
+  %data = getelementptr inbounds [21 x i8], [21 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [5 x i8], [5 x i8]* @baisl.format.int, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 21, i8* %format, i64 %value)
+  %len = sext i32 %written to i64
+  %partial = insertvalue %baisl.string undef, i64 %len, 0
+  %text = insertvalue %baisl.string %partial, i8* %data, 1
+  call void @baisl.print.string(%baisl.string %text, i1 %newline)
+  ret void
+}
+
+define private void @baisl.print.bool(i1 %value, i1 %newline) {
+entry:
+  %true = insertvalue %baisl.string { i64 4, i8* undef }, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @baisl.true, i64 0, i64 0), 1
+  %false = insertvalue %baisl.string { i64 5, i8* undef }, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @baisl.false, i64 0, i64 0), 1
+  %text = select i1 %value, %baisl.string %true, %baisl.string %false
+  call void @baisl.print.string(%baisl.string %text, i1 %newline)
+  ret void
+}
+
+define private void @baisl.index.fail(i64 %index, i64 %len, i64 %line, i64 %column) {
+entry:
+  %buffer = alloca [128 x i8]
+  %data = getelementptr inbounds [128 x i8], [128 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [54 x i8], [54 x i8]* @baisl.format.index, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 128, i8* %format, i64 %index, i64 %len, i64 %line, i64 %column)
+  %size = sext i32 %written to i64
+  call i64 @write(i32 2, i8* %data, i64 %size)
+  call void @exit(i32 1)
+  unreachable
+}
+
+define private void @baisl.division.fail(i64 %line, i64 %column) {
+entry:
+  %buffer = alloca [64 x i8]
+  %data = getelementptr inbounds [64 x i8], [64 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [31 x i8], [31 x i8]* @baisl.format.division, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 64, i8* %format, i64 %line, i64 %column)
+  %size = sext i32 %written to i64
+  call i64 @write(i32 2, i8* %data, i64 %size)
+  call void @exit(i32 1)
+  unreachable
+}
+`
+
+// Names of the runtime functions printing each type
+var llvmPrintFunctions = map[TypeKind]string{
+	TypeType_INT:    "@baisl.print.int",
+	TypeType_BOOL:   "@baisl.print.bool",
+	TypeType_STRING: "@baisl.print.string",
+}
+
+type llvmLoop struct {
+	continueLabel string
+	breakLabel    string
+}
+
+func llvmFunctionName(id string) string {
+	return "@baisl_" + symbolName(id)
+}
+
+// Structs and enums are named LLVM types, so they may be used before their definition
+func llvmStructName(id string) string {
+	return "%baisl_" + symbolName(id)
+}
+
+// Variables get a prefix with a character baisl identifiers can't contain, so they never clash with temporaries.
+// Every variable lives in a stack slot, which mem2reg turns back into registers.
+// Variables shadowing others of the same function get a number, since slots are function wide.
+func llvmVariableName(id string, number int) string {
+	if number == 0 {
+		return "%v." + id
+	}
+	return "%v" + strconv.Itoa(number) + "." + id
+}
+
+func (lb *LlvmBackend) variableName(decl ResolvedDeclaration) string {
+	return llvmVariableName(decl.GetId(), lb.variables[decl])
+}
+
+// Parameters arrive as values, which are stored into their variable's slot on entry
+func llvmParamName(id string) string {
+	return "%p." + id
+}
+
+func llvmType(t Type) (string, error) {
+	switch t.Kind {
+	case TypeType_INT:
+		return "i64", nil
+	case TypeType_BOOL:
+		return "i1", nil
+	case TypeType_STRING:
+		return "%baisl.string", nil
+	case TypeType_VOID:
+		return "void", nil
+	case TypeType_CUSTOM:
+		return llvmStructName(t.Name), nil
+	case TypeType_ARRAY, TypeType_SLICE:
+		// Arrays and slices are both a length and a pointer to the elements
+		elemType, err := llvmType(*t.Elem)
+		if err != nil {
+			return "", err
+		}
+		return "{ i64, " + elemType + "* }", nil
+	}
+	return "", fmt.Errorf("Type %s is not supported by the LLVM backend", t)
+}
+
+var llvmArithmeticInstructions = map[TokenType]string{
+	TokenType_PLUS:  "add",
+	TokenType_MINUS: "sub",
+	TokenType_STAR:  "mul",
+	// Only for divisors checksDivisor leaves out
+	TokenType_SLASH:   "sdiv",
+	TokenType_PERCENT: "srem",
+}
+
+var llvmConditions = map[TokenType]string{
+	TokenType_EQ:  "eq",
+	TokenType_NEQ: "ne",
+	TokenType_LT:  "slt",
+	TokenType_LTE: "sle",
+	TokenType_GT:  "sgt",
+	TokenType_GTE: "sge",
+}
+
+func (lb *LlvmBackend) emit(format string, args ...any) {
+	lb.out.WriteString("  " + fmt.Sprintf(format, args...) + "\n")
+}
+
+func (lb *LlvmBackend) newTemporary() string {
+	name := "%t" + strconv.Itoa(lb.temporaries)
+	lb.temporaries++
+	return name
+}
+
+func (lb *LlvmBackend) newLabel(prefix string) string {
+	name := prefix + "." + strconv.Itoa(lb.labels)
+	lb.labels++
+	return name
+}
+
+// Starts a new basic block, which the previous one must already have branched away from
+func (lb *LlvmBackend) startBlock(label string) {
+	lb.out.WriteString(label + ":\n")
+	lb.block = label
+	lb.terminated = false
+}
+
+// Generates the instructions for an expression and returns the operand holding its value
+func (lb *LlvmBackend) GenerateExpr(expr ResolvedExpr) (string, error) {
+	switch expr.(type) {
+	case *ResolvedValueExpr:
+		return strconv.Itoa(expr.(*ResolvedValueExpr).Value), nil
+	case *ResolvedBoolExpr:
+		return strconv.FormatBool(expr.(*ResolvedBoolExpr).Value), nil
+	case *ResolvedStringExpr:
+		return lb.stringConstant(expr.(*ResolvedStringExpr).Value), nil
+	case *ResolvedBuiltinCallExpr:
+		builtinCall := expr.(*ResolvedBuiltinCallExpr)
+		arg := builtinCall.Args[0]
+		operand, err := lb.GenerateExpr(arg)
+		if err != nil {
+			return "", err
+		}
+		if builtinCall.Builtin == Builtin_LEN {
+			arrayType, err := llvmType(arg.GetType())
+			if err != nil {
+				return "", err
+			}
+			result := lb.newTemporary()
+			lb.emit("%s = extractvalue %s %s, 0", result, arrayType, operand)
+			return result, nil
+		}
+		function, ok := llvmPrintFunctions[arg.GetType().Kind]
+		if !ok {
+			return "", fmt.Errorf("Type %s is not supported by the LLVM backend", arg.GetType())
+		}
+		argType, _ := llvmType(arg.GetType())
+		lb.usesRuntime = true
+		lb.emit("call void %s(%s %s, i1 %t)", function, argType, operand, builtinCall.Builtin == Builtin_PRINTLN)
+		return "", nil
+	case *ResolvedStructExpr:
+		structExpr := expr.(*ResolvedStructExpr)
+		structType, _ := llvmType(structExpr.GetType())
+		// The fields are inserted one by one into an undefined aggregate
+		result := "undef"
+		for i, field := range structExpr.Fields {
+			operand, err := lb.GenerateExpr(field)
+			if err != nil {
+				return "", err
+			}
+			fieldType, err := llvmType(field.GetType())
+			if err != nil {
+				return "", err
+			}
+			next := lb.newTemporary()
+			lb.emit("%s = insertvalue %s %s, %s %s, %d", next, structType, result, fieldType, operand, i)
+			result = next
+		}
+		return result, nil
+	case *ResolvedVariantExpr:
+		variantExpr := expr.(*ResolvedVariantExpr)
+		variant := variantExpr.Variant
+		enumType, _ := llvmType(variantExpr.GetType())
+		result := lb.newTemporary()
+		lb.emit("%s = insertvalue %s undef, i64 %d, 0", result, enumType, variant.Index)
+		offset := lb.payloadOffset(variant)
+		for i, value := range variantExpr.Payload {
+			operand, err := lb.GenerateExpr(value)
+			if err != nil {
+				return "", err
+			}
+			valueType, err := llvmType(variant.Payload[i])
+			if err != nil {
+				return "", err
+			}
+			next := lb.newTemporary()
+			lb.emit("%s = insertvalue %s %s, %s %s, %d", next, enumType, result, valueType, operand, offset+i)
+			result = next
+		}
+		return result, nil
+	case *ResolvedMatchExpr:
+		return lb.generateMatch(expr.(*ResolvedMatchExpr))
+	case *ResolvedArrayExpr:
+		return lb.generateArray(expr.(*ResolvedArrayExpr))
+	case *ResolvedIndexExpr:
+		return lb.generateIndex(expr.(*ResolvedIndexExpr))
+	case *ResolvedFieldExpr:
+		fieldExpr := expr.(*ResolvedFieldExpr)
+		operand, err := lb.GenerateExpr(fieldExpr.Struct)
+		if err != nil {
+			return "", err
+		}
+		structType, _ := llvmType(fieldExpr.Struct.GetType())
+		result := lb.newTemporary()
+		lb.emit("%s = extractvalue %s %s, %d", result, structType, operand, fieldExpr.Index)
+		return result, nil
+	case *ResolvedRefExpr:
+		refExpr := expr.(*ResolvedRefExpr)
+		decl := *refExpr.Value
+		if !refExpr.IsCall {
+			varType, err := llvmType(refExpr.GetType())
+			if err != nil {
+				return "", err
+			}
+			result := lb.newTemporary()
+			lb.emit("%s = load %s, %s* %s", result, varType, varType, lb.variableName(decl))
+			return result, nil
+		}
+
+		fn := decl.(*ResolvedFunctionDeclaration)
+		args := make([]string, len(refExpr.Args))
+		for i, arg := range refExpr.Args {
+			operand, err := lb.GenerateExpr(arg)
+			if err != nil {
+				return "", err
+			}
+			argType, err := llvmType(arg.GetType())
+			if err != nil {
+				return "", err
+			}
+			args[i] = argType + " " + operand
+		}
+
+		returnType, err := llvmType(fn.ReturnType)
+		if err != nil {
+			return "", err
+		}
+		call := "call " + returnType + " " + llvmFunctionName(fn.GetId()) + "(" + strings.Join(args, ", ") + ")"
+		if fn.ReturnType.Kind == TypeType_VOID {
+			lb.emit("%s", call)
+			return "", nil
+		}
+
+		result := lb.newTemporary()
+		lb.emit("%s = %s", result, call)
+		return result, nil
+	case *ResolvedBinaryExpr:
+		binaryExpr := expr.(*ResolvedBinaryExpr)
+		if binaryExpr.Operator == TokenType_AND || binaryExpr.Operator == TokenType_OR {
+			return lb.generateLogical(binaryExpr)
+		}
+
+		lhs, err := lb.GenerateExpr(binaryExpr.Lhs)
+		if err != nil {
+			return "", err
+		}
+		rhs, err := lb.GenerateExpr(binaryExpr.Rhs)
+		if err != nil {
+			return "", err
+		}
+
+		if binaryExpr.Type == Type_STRING {
+			result := lb.newTemporary()
+			lb.usesRuntime = true
+			lb.emit("%s = call %%baisl.string @baisl.concat(%%baisl.string %s, %%baisl.string %s)", result, lhs, rhs)
+			return result, nil
+		}
+
+		if checksDivisor(binaryExpr) {
+			return lb.generateDivision(binaryExpr, lhs, rhs), nil
+		}
+		instruction, ok := llvmArithmeticInstructions[binaryExpr.Operator]
+		if ok {
+			result := lb.newTemporary()
+			lb.emit("%s = %s i64 %s, %s", result, instruction, lhs, rhs)
+			return result, nil
+		}
+
+		condition, ok := llvmConditions[binaryExpr.Operator]
+		if !ok {
+			return "", fmt.Errorf("Unknown binary operator %s", binaryExpr.Operator)
+		}
+		operandType, err := llvmType(binaryExpr.Lhs.GetType())
+		if err != nil {
+			return "", err
+		}
+		result := lb.newTemporary()
+		lb.emit("%s = icmp %s %s %s, %s", result, condition, operandType, lhs, rhs)
+		return result, nil
+	case *ResolvedUnaryExpr:
+		unaryExpr := expr.(*ResolvedUnaryExpr)
+		operand, err := lb.GenerateExpr(unaryExpr.Operand)
+		if err != nil {
+			return "", err
+		}
+
+		switch unaryExpr.Operator {
+		case TokenType_MINUS:
+			result := lb.newTemporary()
+			lb.emit("%s = sub i64 0, %s", result, operand)
+			return result, nil
+		case TokenType_BANG:
+			result := lb.newTemporary()
+			lb.emit("%s = xor i1 %s, true", result, operand)
+			return result, nil
+		}
+		return "", fmt.Errorf("Unknown unary operator %s", unaryExpr.Operator)
+	}
+	return "", fmt.Errorf("Unknown expression type %d", expr.GetExprType())
+}
+
+// Returns a constant string value pointing at the global holding the literal's contents
+func (lb *LlvmBackend) stringConstant(value string) string {
+	index, ok := lb.stringIndices[value]
+	if !ok {
+		index = len(lb.strings)
+		lb.stringIndices[value] = index
+		lb.strings = append(lb.strings, value)
+	}
+	lb.usesRuntime = true
+
+	arrayType := "[" + strconv.Itoa(len(value)) + " x i8]"
+	pointer := "getelementptr inbounds (" + arrayType + ", " + arrayType + "* @str." + strconv.Itoa(index) + ", i64 0, i64 0)"
+	return "{ i64 " + strconv.Itoa(len(value)) + ", i8* " + pointer + " }"
+}
+
+// Quotes a string for an LLVM c"..." constant, escaping anything but printable ASCII as hex
+func llvmStringLiteral(value string) string {
+	out := strings.Builder{}
+	out.WriteString("c\"")
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c >= ' ' && c <= '~' && c != '"' && c != '\\' {
+			out.WriteByte(c)
+		} else {
+			fmt.Fprintf(&out, "\\%02X", c)
+		}
+	}
+	out.WriteByte('"')
+	return out.String()
+}
+
+// Only evaluates the right operand of && and || if the left one doesn't decide the result.
+// A phi picks the result depending on which block control came from.
+func (lb *LlvmBackend) generateLogical(binaryExpr *ResolvedBinaryExpr) (string, error) {
+	lhs, err := lb.GenerateExpr(binaryExpr.Lhs)
+	if err != nil {
+		return "", err
+	}
+	lhsBlock := lb.block
+	rhsLabel := lb.newLabel("rhs")
+	endLabel := lb.newLabel("end")
+	shortCircuit := "false"
+	if binaryExpr.Operator == TokenType_AND {
+		lb.emit("br i1 %s, label %%%s, label %%%s", lhs, rhsLabel, endLabel)
+	} else {
+		shortCircuit = "true"
+		lb.emit("br i1 %s, label %%%s, label %%%s", lhs, endLabel, rhsLabel)
+	}
+
+	lb.startBlock(rhsLabel)
+	rhs, err := lb.GenerateExpr(binaryExpr.Rhs)
+	if err != nil {
+		return "", err
+	}
+	rhsBlock := lb.block
+	lb.emit("br label %%%s", endLabel)
+
+	lb.startBlock(endLabel)
+	result := lb.newTemporary()
+	lb.emit("%s = phi i1 [ %s, %%%s ], [ %s, %%%s ]", result, shortCircuit, lhsBlock, rhs, rhsBlock)
+	return result, nil
+}
+
+// Enums are a variant index followed by the payloads of every variant, so a variant's payload starts
+// after the tag and the payloads of the variants before it
+func (lb *LlvmBackend) payloadOffset(variant *ResolvedVariantDeclaration) int {
+	offset := 1
+	for _, other := range lb.enums[variant.Enum].Variants[:variant.Index] {
+		offset += len(other.Payload)
+	}
+	return offset
+}
+
+// Switches on the matched value's tag to a block per arm, with the last arm as the default, which the
+// analyser guarantees is taken for every variant left. A phi picks the result of the arm taken.
+func (lb *LlvmBackend) generateMatch(matchExpr *ResolvedMatchExpr) (string, error) {
+	value, err := lb.GenerateExpr(matchExpr.Value.Value)
+	if err != nil {
+		return "", err
+	}
+	enumType, err := llvmType(matchExpr.Value.Type)
+	if err != nil {
+		return "", err
+	}
+	tag := lb.newTemporary()
+	lb.emit("%s = extractvalue %s %s, 0", tag, enumType, value)
+
+	labels := make([]string, len(matchExpr.Arms))
+	for i := range matchExpr.Arms {
+		labels[i] = lb.newLabel("arm")
+	}
+	endLabel := lb.newLabel("end")
+	cases := make([]string, 0)
+	for i, arm := range matchExpr.Arms[:len(matchExpr.Arms)-1] {
+		cases = append(cases, fmt.Sprintf("i64 %d, label %%%s", arm.Variant.Index, labels[i]))
+	}
+	lb.emit("switch i64 %s, label %%%s [ %s ]", tag, labels[len(labels)-1], strings.Join(cases, " "))
+
+	incoming := make([]string, 0)
+	for i, arm := range matchExpr.Arms {
+		lb.startBlock(labels[i])
+		for j, binding := range arm.Bindings {
+			if binding == nil {
+				continue
+			}
+			bindingType, err := llvmType(binding.Type)
+			if err != nil {
+				return "", err
+			}
+			payload := lb.newTemporary()
+			lb.emit("%s = extractvalue %s %s, %d", payload, enumType, value, lb.payloadOffset(arm.Variant)+j)
+			lb.emit("store %s %s, %s* %s", bindingType, payload, bindingType, lb.variableName(binding))
+		}
+		operand, err := lb.GenerateExpr(arm.Value)
+		if err != nil {
+			return "", err
+		}
+		incoming = append(incoming, fmt.Sprintf("[ %s, %%%s ]", operand, lb.block))
+		lb.emit("br label %%%s", endLabel)
+	}
+
+	lb.startBlock(endLabel)
+	if matchExpr.Type.Kind == TypeType_VOID {
+		return "", nil
+	}
+	resultType, err := llvmType(matchExpr.Type)
+	if err != nil {
+		return "", err
+	}
+	result := lb.newTemporary()
+	lb.emit("%s = phi %s %s", result, resultType, strings.Join(incoming, ", "))
+	return result, nil
+}
+
+// Stores the elements of an array literal in memory from malloc, so slices of it may outlive the function building it
+func (lb *LlvmBackend) generateArray(arrayExpr *ResolvedArrayExpr) (string, error) {
+	arrayType, err := llvmType(arrayExpr.Type)
+	if err != nil {
+		return "", err
+	}
+	elemType, err := llvmType(*arrayExpr.Type.Elem)
+	if err != nil {
+		return "", err
+	}
+
+	// The size of the elements is the address of the element past them, counting from null
+	end := lb.newTemporary()
+	lb.emit("%s = getelementptr %s, %s* null, i64 %d", end, elemType, elemType, len(arrayExpr.Elems))
+	size := lb.newTemporary()
+	lb.emit("%s = ptrtoint %s* %s to i64", size, elemType, end)
+	memory := lb.newTemporary()
+	lb.usesRuntime = true
+	lb.emit("%s = call i8* @malloc(i64 %s)", memory, size)
+	data := lb.newTemporary()
+	lb.emit("%s = bitcast i8* %s to %s*", data, memory, elemType)
+
+	for i, elem := range arrayExpr.Elems {
+		operand, err := lb.GenerateExpr(elem)
+		if err != nil {
+			return "", err
+		}
+		pointer := lb.newTemporary()
+		lb.emit("%s = getelementptr %s, %s* %s, i64 %d", pointer, elemType, elemType, data, i)
+		lb.emit("store %s %s, %s* %s", elemType, operand, elemType, pointer)
+	}
+
+	partial := lb.newTemporary()
+	lb.emit("%s = insertvalue %s undef, i64 %d, 0", partial, arrayType, len(arrayExpr.Elems))
+	result := lb.newTemporary()
+	lb.emit("%s = insertvalue %s %s, %s* %s, 1", result, arrayType, partial, elemType, data)
+	return result, nil
+}
+
+// Divides ints, branching to a block reporting the failure if the divisor is zero. Dividing the smallest
+// i64 by -1 overflows, which sdiv and srem leave undefined, so the divisor is 1 then and the result
+// negated for sdiv, wrapping around like in Go, and 0 for srem.
+func (lb *LlvmBackend) generateDivision(binaryExpr *ResolvedBinaryExpr, lhs string, rhs string) string {
+	isZero := lb.newTemporary()
+	lb.emit("%s = icmp eq i64 %s, 0", isZero, rhs)
+	okLabel := lb.newLabel("division.ok")
+	failLabel := lb.newLabel("division.fail")
+	lb.emit("br i1 %s, label %%%s, label %%%s", isZero, failLabel, okLabel)
+
+	lb.startBlock(failLabel)
+	lb.usesRuntime = true
+	location := binaryExpr.Location
+	lb.emit("call void @baisl.division.fail(i64 %d, i64 %d)", location.Line, location.Column)
+	lb.emit("unreachable")
+
+	lb.startBlock(okLabel)
+	isMinusOne := lb.newTemporary()
+	lb.emit("%s = icmp eq i64 %s, -1", isMinusOne, rhs)
+	divisor := lb.newTemporary()
+	lb.emit("%s = select i1 %s, i64 1, i64 %s", divisor, isMinusOne, rhs)
+	result := lb.newTemporary()
+	overflowResult := "0"
+	if binaryExpr.Operator == TokenType_SLASH {
+		lb.emit("%s = sdiv i64 %s, %s", result, lhs, divisor)
+		overflowResult = lb.newTemporary()
+		lb.emit("%s = sub i64 0, %s", overflowResult, lhs)
+	} else {
+		lb.emit("%s = srem i64 %s, %s", result, lhs, divisor)
+	}
+	checked := lb.newTemporary()
+	lb.emit("%s = select i1 %s, i64 %s, i64 %s", checked, isMinusOne, overflowResult, result)
+	return checked
+}
+
+// Loads an element of an array or slice, branching to a block reporting the failure if the index is out of range.
+// Comparing unsigned catches negative indexes too.
+func (lb *LlvmBackend) generateIndex(indexExpr *ResolvedIndexExpr) (string, error) {
+	array, err := lb.GenerateExpr(indexExpr.Array)
+	if err != nil {
+		return "", err
+	}
+	index, err := lb.GenerateExpr(indexExpr.Index)
+	if err != nil {
+		return "", err
+	}
+	arrayType, err := llvmType(indexExpr.Array.GetType())
+	if err != nil {
+		return "", err
+	}
+	elemType, err := llvmType(indexExpr.Type)
+	if err != nil {
+		return "", err
+	}
+
+	length := lb.newTemporary()
+	lb.emit("%s = extractvalue %s %s, 0", length, arrayType, array)
+	inRange := lb.newTemporary()
+	lb.emit("%s = icmp ult i64 %s, %s", inRange, index, length)
+	okLabel := lb.newLabel("index.ok")
+	failLabel := lb.newLabel("index.fail")
+	lb.emit("br i1 %s, label %%%s, label %%%s", inRange, okLabel, failLabel)
+
+	lb.startBlock(failLabel)
+	lb.usesRuntime = true
+	location := indexExpr.Location
+	lb.emit("call void @baisl.index.fail(i64 %s, i64 %s, i64 %d, i64 %d)", index, length, location.Line, location.Column)
+	lb.emit("unreachable")
+
+	lb.startBlock(okLabel)
+	data := lb.newTemporary()
+	lb.emit("%s = extractvalue %s %s, 1", data, arrayType, array)
+	pointer := lb.newTemporary()
+	lb.emit("%s = getelementptr %s, %s* %s, i64 %s", pointer, elemType, elemType, data, index)
+	result := lb.newTemporary()
+	lb.emit("%s = load %s, %s* %s", result, elemType, elemType, pointer)
+	return result, nil
+}
+
+func (lb *LlvmBackend) generateStore(variable *ResolvedVariableDeclaration, expr ResolvedExpr) error {
+	operand, err := lb.GenerateExpr(expr)
+	if err != nil {
+		return err
+	}
+	varType, err := llvmType(variable.Type)
+	if err != nil {
+		return err
+	}
+	lb.emit("store %s %s, %s* %s", varType, operand, varType, lb.variableName(variable))
+	return nil
+}
+
+// The end block is left out when both branches terminate, in which case the if terminates as well
+func (lb *LlvmBackend) generateIf(ifStmt *ResolvedIfStatement) error {
+	cond, err := lb.GenerateExpr(ifStmt.Cond)
+	if err != nil {
+		return err
+	}
+
+	thenLabel := lb.newLabel("then")
+	elseLabel := ""
+	if ifStmt.Else != nil {
+		elseLabel = lb.newLabel("else")
+	}
+	endLabel := lb.newLabel("end")
+	if ifStmt.Else == nil {
+		elseLabel = endLabel
+	}
+	lb.emit("br i1 %s, label %%%s, label %%%s", cond, thenLabel, elseLabel)
+
+	lb.startBlock(thenLabel)
+	err = lb.GenerateBlock(ifStmt.Then)
+	if err != nil {
+		return err
+	}
+	thenTerminated := lb.terminated
+	if !thenTerminated {
+		lb.emit("br label %%%s", endLabel)
+	}
+
+	if ifStmt.Else != nil {
+		lb.startBlock(elseLabel)
+		err = lb.GenerateBlock(ifStmt.Else)
+		if err != nil {
+			return err
+		}
+		if lb.terminated && thenTerminated {
+			return nil
+		}
+		if !lb.terminated {
+			lb.emit("br label %%%s", endLabel)
+		}
+	}
+
+	lb.startBlock(endLabel)
+	return nil
+}
+
+// Generates a loop body, which branches back to continueLabel if it falls off its end
+func (lb *LlvmBackend) generateLoopBody(body *ResolvedBlock, loop llvmLoop) error {
+	lb.loops = append(lb.loops, loop)
+	err := lb.GenerateBlock(body)
+	lb.loops = lb.loops[:len(lb.loops)-1]
+	if err != nil {
+		return err
+	}
+	if !lb.terminated {
+		lb.emit("br label %%%s", loop.continueLabel)
+	}
+	return nil
+}
+
+func (lb *LlvmBackend) generateWhile(whileStmt *ResolvedWhileStatement) error {
+	condLabel := lb.newLabel("cond")
+	bodyLabel := lb.newLabel("body")
+	endLabel := lb.newLabel("end")
+
+	lb.emit("br label %%%s", condLabel)
+	lb.startBlock(condLabel)
+	cond, err := lb.GenerateExpr(whileStmt.Cond)
+	if err != nil {
+		return err
+	}
+	lb.emit("br i1 %s, label %%%s, label %%%s", cond, bodyLabel, endLabel)
+
+	lb.startBlock(bodyLabel)
+	err = lb.generateLoopBody(whileStmt.Body, llvmLoop{condLabel, endLabel})
+	if err != nil {
+		return err
+	}
+	lb.startBlock(endLabel)
+	return nil
+}
+
+func (lb *LlvmBackend) generateFor(forStmt *ResolvedForStatement) error {
+	err := lb.generateStore(forStmt.Variable, forStmt.Variable.Value)
+	if err != nil {
+		return err
+	}
+	err = lb.generateStore(forStmt.Limit, forStmt.Limit.Value)
+	if err != nil {
+		return err
+	}
+
+	condLabel := lb.newLabel("cond")
+	bodyLabel := lb.newLabel("body")
+	stepLabel := lb.newLabel("step")
+	endLabel := lb.newLabel("end")
+	variable := lb.variableName(forStmt.Variable)
+
+	lb.emit("br label %%%s", condLabel)
+	lb.startBlock(condLabel)
+	current := lb.newTemporary()
+	lb.emit("%s = load i64, i64* %s", current, variable)
+	limit := lb.newTemporary()
+	lb.emit("%s = load i64, i64* %s", limit, lb.variableName(forStmt.Limit))
+	flag := lb.newTemporary()
+	lb.emit("%s = icmp slt i64 %s, %s", flag, current, limit)
+	lb.emit("br i1 %s, label %%%s, label %%%s", flag, bodyLabel, endLabel)
+
+	lb.startBlock(bodyLabel)
+	err = lb.generateLoopBody(forStmt.Body, llvmLoop{stepLabel, endLabel})
+	if err != nil {
+		return err
+	}
+
+	lb.startBlock(stepLabel)
+	previous := lb.newTemporary()
+	lb.emit("%s = load i64, i64* %s", previous, variable)
+	next := lb.newTemporary()
+	lb.emit("%s = add i64 %s, 1", next, previous)
+	lb.emit("store i64 %s, i64* %s", next, variable)
+	lb.emit("br label %%%s", condLabel)
+	lb.startBlock(endLabel)
+	return nil
+}
+
+func (lb *LlvmBackend) GenerateBlock(block *ResolvedBlock) error {
+	for _, stmt := range block.Stmts {
+		// Code following a terminator needs a basic block of its own, even if it is unreachable
+		if lb.terminated {
+			lb.startBlock(lb.newLabel("dead"))
+		}
+
+		switch stmt.(type) {
+		case *ResolvedReturnStatement:
+			returnStmt := stmt.(*ResolvedReturnStatement)
+			if returnStmt.Expr == nil {
+				lb.emit("ret void")
+			} else {
+				operand, err := lb.GenerateExpr(returnStmt.Expr)
+				if err != nil {
+					return err
+				}
+				lb.emit("ret %s %s", lb.returnType, operand)
+			}
+			lb.terminated = true
+		case *ResolvedLetStatement:
+			variable := stmt.(*ResolvedLetStatement).Variable
+			err := lb.generateStore(variable, variable.Value)
+			if err != nil {
+				return err
+			}
+		case *ResolvedAssignStatement:
+			assignStmt := stmt.(*ResolvedAssignStatement)
+			err := lb.generateStore(assignStmt.Variable, assignStmt.Expr)
+			if err != nil {
+				return err
+			}
+		case *ResolvedExprStatement:
+			_, err := lb.GenerateExpr(stmt.(*ResolvedExprStatement).Expr)
+			if err != nil {
+				return err
+			}
+		case *ResolvedIfStatement:
+			err := lb.generateIf(stmt.(*ResolvedIfStatement))
+			if err != nil {
+				return err
+			}
+		case *ResolvedWhileStatement:
+			err := lb.generateWhile(stmt.(*ResolvedWhileStatement))
+			if err != nil {
+				return err
+			}
+		case *ResolvedForStatement:
+			err := lb.generateFor(stmt.(*ResolvedForStatement))
+			if err != nil {
+				return err
+			}
+		case *ResolvedBreakStatement:
+			lb.emit("br label %%%s", lb.loops[len(lb.loops)-1].breakLabel)
+			lb.terminated = true
+		case *ResolvedContinueStatement:
+			lb.emit("br label %%%s", lb.loops[len(lb.loops)-1].continueLabel)
+			lb.terminated = true
+		default:
+			return fmt.Errorf("Unknown statement type %s", stmt.GetStmtType())
+		}
+	}
+	return nil
+}
+
+func (lb *LlvmBackend) GenerateFunction(fn *ResolvedFunctionDeclaration) error {
+	lb.temporaries = 0
+	lb.labels = 0
+	lb.block = "entry"
+	lb.terminated = false
+
+	returnType, err := llvmType(fn.ReturnType)
+	if err != nil {
+		return fmt.Errorf("Error generating function %s: %s", fn.GetId(), err)
+	}
+	lb.returnType = returnType
+	lb.variables = numberVariables(fn)
+
+	params := make([]string, len(fn.Params))
+	for i, param := range fn.Params {
+		paramType, err := llvmType(param.(*ResolvedVariableDeclaration).Type)
+		if err != nil {
+			return fmt.Errorf("Error generating parameter %s of %s: %s", param.GetId(), fn.GetId(), err)
+		}
+		params[i] = paramType + " " + llvmParamName(param.GetId())
+	}
+
+	lb.out.WriteString("\ndefine " + returnType + " " + llvmFunctionName(fn.GetId()) + "(" + strings.Join(params, ", ") + ") {\nentry:\n")
+
+	// Slots are allocated up front, so they dominate every use
+	for _, param := range fn.Params {
+		paramType, _ := llvmType(param.(*ResolvedVariableDeclaration).Type)
+		lb.emit("%s = alloca %s", lb.variableName(param), paramType)
+		lb.emit("store %s %s, %s* %s", paramType, llvmParamName(param.GetId()), paramType, lb.variableName(param))
+	}
+	for _, local := range fn.Body.Locals() {
+		localType, err := llvmType(local.Type)
+		if err != nil {
+			return fmt.Errorf("Error generating local %s of %s: %s", local.GetId(), fn.GetId(), err)
+		}
+		lb.emit("%s = alloca %s", lb.variableName(local), localType)
+	}
+
+	err = lb.GenerateBlock(fn.Body)
+	if err != nil {
+		return fmt.Errorf("Error generating function %s: %s", fn.GetId(), err)
+	}
+
+	// Only void functions may fall off the end of their body
+	if !lb.terminated {
+		if fn.ReturnType.Kind == TypeType_VOID {
+			lb.emit("ret void")
+		} else {
+			lb.emit("unreachable")
+		}
+	}
+	lb.out.WriteString("}\n")
+	return nil
+}
+
+func (lb *LlvmBackend) Generate() (string, error) {
+	lb.out.Reset()
+	lb.strings = nil
+	lb.stringIndices = make(map[string]int)
+	lb.usesRuntime = false
+
+	var main *ResolvedFunctionDeclaration
+	for _, decl := range lb.Declarations {
+		fn, ok := decl.(*ResolvedFunctionDeclaration)
+		if ok && fn.GetId() == "main" {
+			main = fn
+		}
+	}
+	if main == nil {
+		return "", fmt.Errorf("No main function found")
+	}
+
+	header := "; ModuleID = 'baisl'\nsource_filename = \"baisl\"\n"
+	types := ""
+	lb.enums = make(map[string]*ResolvedEnumDeclaration)
+	for _, decl := range lb.Declarations {
+		switch decl.(type) {
+		case *ResolvedStructDeclaration:
+			structDecl := decl.(*ResolvedStructDeclaration)
+			fieldTypes := make([]string, len(structDecl.Fields))
+			for i, field := range structDecl.Fields {
+				fieldType, err := llvmType(field.Type)
+				if err != nil {
+					return "", fmt.Errorf("Error generating field %s of %s: %s", field.GetId(), structDecl.GetId(), err)
+				}
+				fieldTypes[i] = fieldType
+			}
+			types += llvmStructName(structDecl.GetId()) + " = type { " + strings.Join(fieldTypes, ", ") + " }\n"
+		case *ResolvedEnumDeclaration:
+			enumDecl := decl.(*ResolvedEnumDeclaration)
+			lb.enums[enumDecl.Id] = enumDecl
+			fieldTypes := []string{"i64"}
+			for _, variant := range enumDecl.Variants {
+				for _, t := range variant.Payload {
+					fieldType, err := llvmType(t)
+					if err != nil {
+						return "", fmt.Errorf("Error generating variant %s of %s: %s", variant.Id, enumDecl.Id, err)
+					}
+					fieldTypes = append(fieldTypes, fieldType)
+				}
+			}
+			types += llvmStructName(enumDecl.Id) + " = type { " + strings.Join(fieldTypes, ", ") + " }\n"
+		}
+	}
+	if types != "" {
+		types = "\n" + types
+	}
+
+	for _, decl := range lb.Declarations {
+		fn, ok := decl.(*ResolvedFunctionDeclaration)
+		if !ok {
+			continue
+		}
+
+		err := lb.GenerateFunction(fn)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	lb.out.WriteString("\ndefine i32 @main() {\nentry:\n")
+	// Only a main returning an int or bool sets the exit code
+	switch main.ReturnType.Kind {
+	case TypeType_VOID:
+		lb.emit("call void %s()", llvmFunctionName(main.GetId()))
+		lb.emit("ret i32 0")
+	case TypeType_STRING, TypeType_CUSTOM, TypeType_ARRAY, TypeType_SLICE:
+		returnType, _ := llvmType(main.ReturnType)
+		lb.emit("call %s %s()", returnType, llvmFunctionName(main.GetId()))
+		lb.emit("ret i32 0")
+	case TypeType_BOOL:
+		lb.emit("%%result = call i1 %s()", llvmFunctionName(main.GetId()))
+		lb.emit("%%exitcode = zext i1 %%result to i32")
+		lb.emit("ret i32 %%exitcode")
+	default:
+		lb.emit("%%result = call i64 %s()", llvmFunctionName(main.GetId()))
+		lb.emit("%%exitcode = trunc i64 %%result to i32")
+		lb.emit("ret i32 %%exitcode")
+	}
+	lb.out.WriteString("}\n")
+
+	if len(lb.strings) > 0 {
+		lb.out.WriteString("\n")
+	}
+	for i, value := range lb.strings {
+		lb.out.WriteString(fmt.Sprintf("@str.%d = private unnamed_addr constant [%d x i8] %s\n", i, len(value), llvmStringLiteral(value)))
+	}
+	if lb.usesRuntime {
+		lb.out.WriteString(llvmRuntime)
+	}
+	// Strings may be passed around without the runtime, so the type is declared whenever it is referenced
+	body := lb.out.String()
+	if strings.Contains(types+body, "%baisl.string") {
+		header += llvmStringType
+	}
+	return header + types + body, nil
+}
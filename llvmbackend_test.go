@@ -0,0 +1,149 @@
+package baisl_test
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/frodi-karlsson/baisl"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files with the current output")
+
+// Each program is compared with the .ll file next to it
+var llvmBackendTests = []string{
+	"raw/fnCall.baisl",
+	"raw/ret2.baisl",
+	"raw/manyParams.baisl",
+	"raw/arithmetic.baisl",
+	"raw/locals.baisl",
+	"raw/ifElse.baisl",
+	"raw/loops.baisl",
+	"raw/bool.baisl",
+	"raw/strings.baisl",
+	"raw/structs.baisl",
+	"raw/arrays.baisl",
+	"raw/enums.baisl",
+	"raw/generics.baisl",
+}
+
+func generateLlvm(t *testing.T, path string) (string, bool) {
+	backend := baisl.LlvmBackend{
+		Declarations: getResolvedDeclarations(t, path),
+	}
+
+	result, err := backend.Generate()
+	if err != nil {
+		t.Errorf("Error generating LLVM IR for %s: %s", path, err)
+		return "", false
+	}
+	return result, true
+}
+
+func TestLlvmBackend(t *testing.T) {
+	for _, path := range llvmBackendTests {
+		result, ok := generateLlvm(t, path)
+		if !ok {
+			continue
+		}
+
+		goldenPath := strings.TrimSuffix(path, ".baisl") + ".ll"
+		if *updateGolden {
+			err := os.WriteFile(goldenPath, []byte(result), 0644)
+			if err != nil {
+				t.Fatalf("Error writing %s: %s", goldenPath, err)
+			}
+		}
+
+		expected, err := os.ReadFile(goldenPath)
+		if err != nil {
+			t.Errorf("Error reading golden file: %s", err)
+			continue
+		}
+
+		if result != string(expected) {
+			t.Errorf("\nExpected <%s>, got <%s>", expected, result)
+		}
+	}
+}
+
+func TestLlvmBackendRuns(t *testing.T) {
+	lli, err := exec.LookPath("lli")
+	if err != nil {
+		t.Skip("No LLVM interpreter found")
+	}
+
+	dir := t.TempDir()
+	for _, test := range compiledProgramTests {
+		result, ok := generateLlvm(t, test.path)
+		if !ok {
+			continue
+		}
+
+		source := filepath.Join(dir, filepath.Base(test.path)+".ll")
+		err = os.WriteFile(source, []byte(result), 0644)
+		if err != nil {
+			t.Fatalf("Error writing %s: %s", source, err)
+		}
+
+		exitCode := runProgram(t, lli, source)
+		if exitCode != test.exitCode {
+			t.Errorf("Running %s, expected exit code %d, got %d", test.path, test.exitCode, exitCode)
+		}
+	}
+}
+
+func TestLlvmBackendOutput(t *testing.T) {
+	lli, err := exec.LookPath("lli")
+	if err != nil {
+		t.Skip("No LLVM interpreter found")
+	}
+
+	dir := t.TempDir()
+	for _, test := range outputTests {
+		result, ok := generateLlvm(t, test.path)
+		if !ok {
+			continue
+		}
+
+		source := filepath.Join(dir, filepath.Base(test.path)+".ll")
+		err = os.WriteFile(source, []byte(result), 0644)
+		if err != nil {
+			t.Fatalf("Error writing %s: %s", source, err)
+		}
+
+		got := programOutput(t, lli, source)
+		if got != test.expected {
+			t.Errorf("Running %s, expected output <%s>, got <%s>", test.path, test.expected, got)
+		}
+	}
+}
+
+func TestLlvmBackendRuntimeErrors(t *testing.T) {
+	lli, err := exec.LookPath("lli")
+	if err != nil {
+		t.Skip("No LLVM interpreter found")
+	}
+
+	dir := t.TempDir()
+	for _, test := range runtimeErrorTests {
+		result, ok := generateLlvm(t, test.path)
+		if !ok {
+			continue
+		}
+
+		source := filepath.Join(dir, filepath.Base(test.path)+".ll")
+		err = os.WriteFile(source, []byte(result), 0644)
+		if err != nil {
+			t.Fatalf("Error writing %s: %s", source, err)
+		}
+
+		got := programError(t, lli, source)
+		if got != test.expected {
+			t.Errorf("Running %s, expected error <%s>, got <%s>", test.path, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package baisl
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// The declarations of the .baisl files in a directory, which share one scope, as if they were one file
+// in the order of their names. The main module may also be a single file.
+type Module struct {
+	// The path the module is imported by, or the path of the file for a main module that's a single file
+	Path         string
+	Declarations []Declaration
+	// The modules its imports name, whose pub declarations its own may refer to
+	Imports []*Module
+}
+
+// Loads a module and every module it imports, directly or not, parsing each file once
+type ModuleLoader struct {
+	// Directory import paths are relative to, the one the main module is in by default
+	Root string
+	// Contents of every file read by path, which diagnostics show the lines of
+	Sources map[string][]byte
+
+	// Modules loaded or being loaded, by directory
+	modules map[string]*Module
+	// The imports being loaded, each in the module the one before it imports
+	importing []moduleImport
+	// Modules loaded so far, each after the modules it imports
+	loaded []*Module
+	errors Diagnostics
+}
+
+// An import being loaded, in the module it's in
+type moduleImport struct {
+	decl     *ImportDecl
+	importer *Module
+}
+
+// Loads the module at path, a directory or a single file, and the modules it imports. They're returned
+// with every module after those it imports, so the main module comes last. With syntax errors or
+// imports that can't be loaded, every error is returned together with what could be loaded.
+func (l *ModuleLoader) Load(path string) ([]*Module, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	dir := path
+	files := []string{path}
+	if info.IsDir() {
+		files, err = baislFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("No .baisl files in %s", path)
+		}
+	} else {
+		dir = filepath.Dir(path)
+	}
+	if l.Root == "" {
+		l.Root = filepath.Dir(filepath.Clean(path))
+	}
+	if l.Sources == nil {
+		l.Sources = make(map[string][]byte)
+	}
+	l.modules = make(map[string]*Module)
+	l.importing = nil
+	l.loaded = nil
+	l.errors = nil
+
+	main := &Module{Path: path}
+	if info.IsDir() {
+		rel, err := filepath.Rel(l.Root, path)
+		if err == nil {
+			main.Path = filepath.ToSlash(rel)
+		}
+	}
+	l.modules[filepath.Clean(dir)] = main
+	err = l.loadModule(main, files)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(l.errors) > 0 {
+		return l.loaded, l.errors
+	}
+	return l.loaded, nil
+}
+
+// Returns the .baisl files in a directory, sorted by name
+func baislFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".baisl" {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return files, nil
+}
+
+// Parses the files of a module, then loads the modules it imports before adding it to the loaded ones.
+// Syntax errors are recorded, while an error reading a file stops loading.
+func (l *ModuleLoader) loadModule(module *Module, files []string) error {
+	for _, file := range files {
+		sourceFile, err := GetSourceFile(file)
+		if err != nil {
+			return err
+		}
+		l.Sources[sourceFile.Path()] = sourceFile.Content()
+
+		parser := Parser{
+			SourceFile: &sourceFile,
+		}
+		declarations, err := parser.Parse()
+		if err != nil {
+			l.errors = append(l.errors, DiagnosticsOf(err)...)
+		}
+		module.Declarations = append(module.Declarations, declarations...)
+	}
+
+	for _, decl := range module.Declarations {
+		importDecl, ok := decl.(*ImportDecl)
+		if !ok {
+			continue
+		}
+		imported, err := l.importModule(importDecl, module)
+		if err != nil {
+			return err
+		}
+		if imported != nil && !slices.Contains(module.Imports, imported) {
+			module.Imports = append(module.Imports, imported)
+		}
+	}
+
+	l.loaded = append(l.loaded, module)
+	return nil
+}
+
+// Loads the module an import names, unless it's been loaded already. An import that can't be loaded, of a
+// directory without .baisl files or of a module still being loaded, which would be a cycle, is reported and
+// returns nil.
+func (l *ModuleLoader) importModule(decl *ImportDecl, importer *Module) (*Module, error) {
+	dir := filepath.Join(l.Root, filepath.FromSlash(decl.Path))
+	module, ok := l.modules[dir]
+	if ok {
+		if !slices.Contains(l.loaded, module) {
+			l.errors = append(l.errors, l.importCycle(decl, importer, module))
+			return nil, nil
+		}
+		return module, nil
+	}
+
+	files, err := baislFiles(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if len(files) == 0 {
+		l.errors = append(l.errors, newDiagnostic(DiagnosticCode_UNKNOWN_MODULE, decl.Location, "Module %q not found", decl.Path).
+			withNote("No .baisl files in %s", dir))
+		return nil, nil
+	}
+
+	module = &Module{Path: decl.Path}
+	l.modules[dir] = module
+	l.importing = append(l.importing, moduleImport{decl: decl, importer: importer})
+	err = l.loadModule(module, files)
+	l.importing = l.importing[:len(l.importing)-1]
+	return module, err
+}
+
+// Reports an import of a module that is still being loaded, because it imports the importer, directly or
+// through the other imports being loaded. The cycle is shown from that module back to it, with a label
+// at each import on it.
+func (l *ModuleLoader) importCycle(decl *ImportDecl, importer *Module, module *Module) *Diagnostic {
+	start := slices.IndexFunc(l.importing, func(i moduleImport) bool {
+		return i.importer == module
+	})
+	if start < 0 {
+		// A module importing itself
+		start = len(l.importing)
+	}
+	cycle := append(slices.Clone(l.importing[start:]), moduleImport{decl: decl, importer: importer})
+
+	names := []string{module.Path}
+	for _, i := range cycle {
+		names = append(names, i.decl.Path)
+	}
+	diagnostic := newDiagnostic(DiagnosticCode_IMPORT_CYCLE, decl.Location, "Import cycle %s", strings.Join(names, " -> "))
+	for _, i := range cycle[:len(cycle)-1] {
+		diagnostic.withLabel(i.decl.Location, "%s imports %s", i.importer.Path, i.decl.Path)
+	}
+	return diagnostic
+}
+
+// Qualifies the id of a top-level declaration of a module other than the main one with the module's path.
+// The '.' separating them can't be part of a baisl identifier, so the id can't clash with the main module's.
+func qualifiedId(modulePath string, id string) string {
+	return modulePath + "." + id
+}
+
+// Spells a resolved id with only letters, digits and '_', for backends naming symbols after it. Ids of the
+// main module's declarations, and instances of its generic functions, already are and are kept. A qualified
+// id becomes the declaration's id, "__", and the module path, whose other characters become '_' and their
+// hex code. Neither id contains "__", and a symbol never starts with '_', so none clash.
+func symbolName(id string) string {
+	separator := strings.LastIndexByte(id, '.')
+	if separator < 0 {
+		return id
+	}
+	out := strings.Builder{}
+	out.WriteString(id[separator+1:])
+	out.WriteString("__")
+	for i := 0; i < separator; i++ {
+		c := id[i]
+		if isAlphaNumeric(c) {
+			out.WriteByte(c)
+		} else {
+			fmt.Fprintf(&out, "_%02x", c)
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,171 @@
+package baisl_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/frodi-karlsson/baisl"
+)
+
+type moduleLoaderTest struct {
+	path string
+	// Paths of the modules loaded, in the order they're returned
+	modules []string
+	errors  []string
+}
+
+var moduleLoaderTests = []moduleLoaderTest{
+	{
+		path:    "raw/modules/app",
+		modules: []string{"geometry", "text", "app"},
+	},
+	{
+		path:    "raw/modules/notImported.baisl",
+		modules: []string{"geometry", "report", "raw/modules/notImported.baisl"},
+	},
+	{
+		path:    "raw/modules/missing.baisl",
+		modules: []string{"geometry", "raw/modules/missing.baisl"},
+		errors:  []string{"Module \"nowhere\" not found at 2:1 in raw/modules/missing.baisl; No .baisl files in raw/modules/nowhere"},
+	},
+	{
+		path:    "raw/modules/cycle.baisl",
+		modules: []string{"cycle/b", "cycle/a", "raw/modules/cycle.baisl"},
+		errors:  []string{"Import cycle cycle/a -> cycle/b -> cycle/a at 1:1 in raw/modules/cycle/b/b.baisl; cycle/a imports cycle/b at 1:1 in raw/modules/cycle/a/a.baisl"},
+	},
+}
+
+func TestModuleLoader(t *testing.T) {
+	for _, test := range moduleLoaderTests {
+		loader := baisl.ModuleLoader{}
+		modules, err := loader.Load(test.path)
+
+		paths := make([]string, len(modules))
+		for i, module := range modules {
+			paths[i] = module.Path
+		}
+		if !slices.Equal(paths, test.modules) {
+			t.Errorf("%s: expected modules %q, got %q", test.path, test.modules, paths)
+		}
+
+		diagnostics := baisl.DiagnosticsOf(err)
+		errors := make([]string, len(diagnostics))
+		for i, diagnostic := range diagnostics {
+			errors[i] = diagnostic.Error()
+		}
+		if !slices.Equal(errors, test.errors) {
+			t.Errorf("%s: expected errors %q, got %q", test.path, test.errors, errors)
+		}
+	}
+}
+
+// The files of a directory make up one module, and each file is read once however many modules import it
+func TestModuleLoaderSources(t *testing.T) {
+	loader := baisl.ModuleLoader{}
+	_, err := loader.Load("raw/modules/app")
+	if err != nil {
+		t.Fatalf("Error loading modules: %s", err)
+	}
+
+	var paths []string
+	for path := range loader.Sources {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	expected := []string{
+		"raw/modules/app/format.baisl",
+		"raw/modules/app/main.baisl",
+		"raw/modules/geometry/helpers.baisl",
+		"raw/modules/geometry/measure.baisl",
+		"raw/modules/geometry/shapes.baisl",
+		"raw/modules/text/text.baisl",
+	}
+	if !slices.Equal(paths, expected) {
+		t.Errorf("Expected sources %q, got %q", expected, paths)
+	}
+}
+
+type moduleAnalyserFailTest struct {
+	path          string
+	errorContains string
+	code          baisl.DiagnosticCode
+	name          string
+}
+
+var moduleAnalyserFailTests = []moduleAnalyserFailTest{
+	{
+		path:          "raw/modules/private.baisl",
+		errorContains: "Function abs is private to module geometry at 5:10 in raw/modules/private.baisl; abs is declared at 1:4 in raw/modules/geometry/helpers.baisl",
+		code:          baisl.DiagnosticCode_PRIVATE,
+		name:          "Private function and struct",
+	},
+	{
+		path:          "raw/modules/peek.baisl",
+		errorContains: "Function edge is private to module text at 2:10 in raw/modules/peek/peek.baisl",
+		code:          baisl.DiagnosticCode_PRIVATE,
+		name:          "Private function of a module loaded later",
+	},
+	{
+		path:          "raw/modules/notImported.baisl",
+		errorContains: "Undeclared variable Square at 4:12 in raw/modules/notImported.baisl; Square is declared in module geometry at 3:18 in raw/modules/geometry/shapes.baisl; Import \"geometry\" to use it",
+		code:          baisl.DiagnosticCode_UNDECLARED,
+		name:          "Variant of a module that isn't imported",
+	},
+	{
+		path:          "raw/modules/conflict.baisl",
+		errorContains: "Duplicate declaration of banner at 3:4 in raw/modules/conflict.baisl; banner is first declared in module text at 9:8 in raw/modules/text/text.baisl",
+		code:          baisl.DiagnosticCode_DUPLICATE_DECLARATION,
+		name:          "Name declared by a module and one it imports",
+	},
+	{
+		path:          "raw/modules/ambiguous.baisl",
+		errorContains: "Imported modules text and labels both declare banner at 2:1 in raw/modules/ambiguous.baisl",
+		code:          baisl.DiagnosticCode_DUPLICATE_DECLARATION,
+		name:          "Name declared by two imported modules",
+	},
+}
+
+func TestAnalyseModules(t *testing.T) {
+	loader := baisl.ModuleLoader{}
+	modules, err := loader.Load("raw/modules/app")
+	if err != nil {
+		t.Fatalf("Error loading modules: %s", err)
+	}
+	analyser := baisl.SemanticAnalyser{}
+	resolved, err := analyser.AnalyseModules(modules)
+	if err != nil {
+		t.Fatalf("Error analysing modules: %s", err)
+	}
+	ids := make([]string, len(resolved))
+	for i, decl := range resolved {
+		ids[i] = decl.GetId()
+	}
+	// Declarations of imported modules are qualified with the module's path, so their names may be reused
+	expected := []string{"geometry.Point", "geometry.Shape", "geometry.Bounds", "geometry.abs", "geometry.edge", "geometry.area", "text.edge", "text.banner", "report", "text.first_0", "text.first_1", "main"}
+	if !slices.Equal(ids, expected) {
+		t.Errorf("Expected declarations %q, got %q", expected, ids)
+	}
+
+	for _, test := range moduleAnalyserFailTests {
+		loader := baisl.ModuleLoader{}
+		modules, err := loader.Load(test.path)
+		if err != nil {
+			t.Fatalf("Error loading modules: %s", err)
+		}
+		analyser := baisl.SemanticAnalyser{}
+		_, err = analyser.AnalyseModules(modules)
+		if err == nil {
+			t.Errorf("%s: expected error, got none", test.name)
+			continue
+		}
+
+		if !strings.Contains(err.Error(), test.errorContains) {
+			t.Errorf("%s: expected error containing <%s>, got <%s>", test.name, test.errorContains, err)
+		}
+		codes := baisl.DiagnosticsOf(err).Codes()
+		if !slices.Equal(codes, []baisl.DiagnosticCode{test.code}) {
+			t.Errorf("Expected %s to report %s, got %v", test.name, test.code, codes)
+		}
+	}
+}
@@ -1,20 +1,31 @@
 package baisl
 
 import (
-	"fmt"
 	"slices"
+	"strconv"
 )
 
-type symbolTable map[string](bool)
-
 type Parser struct {
 	nextToken  *Token
 	SourceFile *SourceFile
+	// Set while parsing an expression followed by a block, like a condition, where an
+	// identifier followed by a brace starts the block rather than a struct literal
+	noStructLiterals bool
+	// Syntax errors the parser recovered from, returned together once the whole file is parsed
+	errors Diagnostics
+	// How many braces up to the next token are open, which recovering from a syntax error skips to close
+	depth int
 }
 
 func (p *Parser) EatNextToken() *Token {
 	nextToken := p.SourceFile.GetNextToken()
 	p.nextToken = &nextToken
+	switch nextToken.TType {
+	case TokenType_LBRACE:
+		p.depth++
+	case TokenType_RBRACE:
+		p.depth--
+	}
 	return p.nextToken
 }
 
@@ -23,9 +34,9 @@ func assertTokenType(token *Token, ttypes ...TokenType) error {
 
 	if !match {
 		if len(ttypes) == 1 {
-			return fmt.Errorf("Expected token type %s, got %s at %d:%d in %s", ttypes[0].String(), token.TType.String(), token.Location.Line, token.Location.Column, token.Location.Path)
+			return newDiagnostic(DiagnosticCode_UNEXPECTED_TOKEN, token.Location, "Expected token type %s, got %s", ttypes[0].String(), token.TType.String())
 		} else {
-			return fmt.Errorf("Expected token type in %v, got %s at %d:%d in %s", ttypes, token.TType.String(), token.Location.Line, token.Location.Column, token.Location.Path)
+			return newDiagnostic(DiagnosticCode_UNEXPECTED_TOKEN, token.Location, "Expected token type in %v, got %s", ttypes, token.TType.String())
 		}
 	}
 
@@ -34,79 +45,467 @@ func assertTokenType(token *Token, ttypes ...TokenType) error {
 
 func assertNotTokenType(token *Token, ttype TokenType) error {
 	if token.TType == ttype {
-		return fmt.Errorf("Expected token type different from %s, got %s at %d:%d", ttype.String(), token.TType.String(), token.Location.Line, token.Location.Column)
+		return newDiagnostic(DiagnosticCode_UNEXPECTED_TOKEN, token.Location, "Expected token type different from %s, got %s", ttype.String(), token.TType.String())
 	}
 
 	return nil
 }
 
-func (p *Parser) ParseExpr() (*Expr, error) {
-	if p.nextToken.TType == TokenType_NUMBER {
-		expr := Expr{
-			Location: p.nextToken.Location,
-			Type:     ExprType_INT,
-			Value:    p.nextToken.Value,
+// Binding strength of binary operators, higher binds tighter
+var binaryPrecedence = map[TokenType]int{
+	TokenType_OR:      1,
+	TokenType_AND:     2,
+	TokenType_EQ:      3,
+	TokenType_NEQ:     3,
+	TokenType_LT:      4,
+	TokenType_LTE:     4,
+	TokenType_GT:      4,
+	TokenType_GTE:     4,
+	TokenType_PLUS:    5,
+	TokenType_MINUS:   5,
+	TokenType_STAR:    6,
+	TokenType_SLASH:   6,
+	TokenType_PERCENT: 6,
+}
+
+// The types written as keywords, where void is only valid as a return type
+var keywordTypes = map[TokenType]Type{
+	TokenType_KEYW_INT:    Type_INT,
+	TokenType_KEYW_BOOL:   Type_BOOL,
+	TokenType_KEYW_STRING: Type_STRING,
+	TokenType_KEYW_VOID:   Type_VOID,
+}
+
+// The tokens a value type can be written as, where an identifier names a struct or enum
+var valueTypeTokens = []TokenType{TokenType_KEYW_INT, TokenType_KEYW_BOOL, TokenType_KEYW_STRING, TokenType_IDENTIFIER}
+
+// Parses the type starting at the next token, which must be one of ttypes unless the type is an array
+// or slice, leaving the type's last token as the next one. The analyser checks that a type named by
+// an identifier exists.
+func (p *Parser) ParseType(ttypes ...TokenType) (Type, error) {
+	if p.nextToken.TType == TokenType_LBRACKET {
+		return p.ParseSequenceType()
+	}
+	err := assertTokenType(p.nextToken, ttypes...)
+	if err != nil {
+		return Type{}, err
+	}
+	if p.nextToken.TType == TokenType_IDENTIFIER {
+		return Type{Kind: TypeType_CUSTOM, Name: p.nextToken.Value}, nil
+	}
+	return keywordTypes[p.nextToken.TType], nil
+}
+
+// Parses an array type `[N]elem` or a slice type `[]elem`, starting at its LBRACKET
+func (p *Parser) ParseSequenceType() (Type, error) {
+	length := -1
+	if p.EatNextToken().TType == TokenType_NUMBER {
+		var err error
+		length, err = strconv.Atoi(p.nextToken.Value)
+		if err != nil {
+			return Type{}, newDiagnostic(DiagnosticCode_INVALID_ARRAY_LENGTH, p.nextToken.Location, "Invalid array length %s", p.nextToken.Value)
 		}
 		p.EatNextToken()
-		return &expr, nil
 	}
-	if p.nextToken.TType == TokenType_IDENTIFIER {
-		isCall := false
-		location := p.nextToken.Location
-		value := p.nextToken.Value
-		args := make([]*Expr, 0)
-		if p.EatNextToken().TType == TokenType_LPAREN {
-			err := assertTokenType(p.EatNextToken(), TokenType_RPAREN, TokenType_NUMBER, TokenType_IDENTIFIER)
+	err := assertTokenType(p.nextToken, TokenType_RBRACKET)
+	if err != nil {
+		return Type{}, err
+	}
+
+	p.EatNextToken()
+	elem, err := p.ParseType(valueTypeTokens...)
+	if err != nil {
+		return Type{}, err
+	}
+	if length < 0 {
+		return SliceType(elem), nil
+	}
+	return ArrayType(elem, length), nil
+}
+
+// Parses an expression in which struct literals are allowed or not, restoring the outer setting after
+func (p *Parser) parseExprAllowingStructLiterals(allowed bool) (*Expr, error) {
+	outer := p.noStructLiterals
+	p.noStructLiterals = !allowed
+	defer func() {
+		p.noStructLiterals = outer
+	}()
+	return p.ParseExpr()
+}
+
+// Parses the arguments of a call, starting at its LPAREN and consuming its RPAREN
+func (p *Parser) ParseArgs() ([]*Expr, error) {
+	return p.parseExprList(TokenType_RPAREN)
+}
+
+// Parses comma separated expressions, starting at the token opening the list and consuming the end token
+func (p *Parser) parseExprList(end TokenType) ([]*Expr, error) {
+	args := make([]*Expr, 0)
+	p.EatNextToken()
+	for p.nextToken.TType != end {
+		arg, err := p.parseExprAllowingStructLiterals(true)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+
+		err = assertTokenType(p.nextToken, TokenType_COMMA, end)
+		if err != nil {
+			return nil, err
+		}
+		if p.nextToken.TType == TokenType_COMMA {
+			err = assertNotTokenType(p.EatNextToken(), end)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	p.EatNextToken()
+
+	return args, nil
+}
+
+// Parses the fields of a struct literal `Name { id: expr, ... }`, starting at its LBRACE and consuming its RBRACE
+func (p *Parser) ParseStructLiteral(expr *Expr) error {
+	p.EatNextToken()
+	for p.nextToken.TType != TokenType_RBRACE {
+		err := assertTokenType(p.nextToken, TokenType_IDENTIFIER)
+		if err != nil {
+			return err
+		}
+		field := p.nextToken.Value
+		err = assertTokenType(p.EatNextToken(), TokenType_COLON)
+		if err != nil {
+			return err
+		}
+		p.EatNextToken()
+
+		value, err := p.parseExprAllowingStructLiterals(true)
+		if err != nil {
+			return err
+		}
+		expr.Fields = append(expr.Fields, field)
+		expr.Args = append(expr.Args, value)
+
+		err = assertTokenType(p.nextToken, TokenType_COMMA, TokenType_RBRACE)
+		if err != nil {
+			return err
+		}
+		if p.nextToken.TType == TokenType_COMMA {
+			err = assertNotTokenType(p.EatNextToken(), TokenType_RBRACE)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	p.EatNextToken()
+	return nil
+}
+
+// Parses `match expr { pattern => expr, ... }`, where a pattern is `_` or a variant with
+// a binding or `_` for each value of its payload, consuming the closing RBRACE
+func (p *Parser) ParseMatchExpr() (*Expr, error) {
+	err := assertTokenType(p.nextToken, TokenType_KEYW_MATCH)
+	if err != nil {
+		return nil, err
+	}
+	location := p.nextToken.Location
+	p.EatNextToken()
+
+	value, err := p.parseExprAllowingStructLiterals(false)
+	if err != nil {
+		return nil, err
+	}
+	err = assertTokenType(p.nextToken, TokenType_LBRACE)
+	if err != nil {
+		return nil, err
+	}
+
+	expr := Expr{
+		Location: location,
+		Type:     ExprType_MATCH,
+		Lhs:      value,
+		Arms:     make([]*MatchArm, 0),
+	}
+	p.EatNextToken()
+	for p.nextToken.TType != TokenType_RBRACE {
+		arm, err := p.ParseMatchArm()
+		if err != nil {
+			return nil, err
+		}
+		expr.Arms = append(expr.Arms, arm)
+
+		err = assertTokenType(p.nextToken, TokenType_COMMA, TokenType_RBRACE)
+		if err != nil {
+			return nil, err
+		}
+		if p.nextToken.TType == TokenType_COMMA {
+			err = assertNotTokenType(p.EatNextToken(), TokenType_RBRACE)
 			if err != nil {
 				return nil, err
 			}
+		}
+	}
+	p.EatNextToken()
+	return &expr, nil
+}
 
-			lastToken := p.nextToken
+// Parses an arm of a match, leaving the first token after its value as the next token
+func (p *Parser) ParseMatchArm() (*MatchArm, error) {
+	err := assertTokenType(p.nextToken, TokenType_IDENTIFIER, TokenType_UNDERSCORE)
+	if err != nil {
+		return nil, err
+	}
+	arm := MatchArm{
+		Location: p.nextToken.Location,
+		Bindings: make([]*VariableDecl, 0),
+	}
+
+	if p.nextToken.TType == TokenType_IDENTIFIER {
+		arm.Variant = p.nextToken.Value
+		if p.EatNextToken().TType == TokenType_LPAREN {
+			p.EatNextToken()
 			for p.nextToken.TType != TokenType_RPAREN {
+				err = assertTokenType(p.nextToken, TokenType_IDENTIFIER, TokenType_UNDERSCORE)
+				if err != nil {
+					return nil, err
+				}
+				var binding *VariableDecl
+				if p.nextToken.TType == TokenType_IDENTIFIER {
+					binding = &VariableDecl{
+						Decl: Decl{
+							Id:       p.nextToken.Value,
+							Location: p.nextToken.Location,
+						},
+						Type: Type_INFERRED,
+					}
+				}
+				arm.Bindings = append(arm.Bindings, binding)
+
+				err = assertTokenType(p.EatNextToken(), TokenType_COMMA, TokenType_RPAREN)
+				if err != nil {
+					return nil, err
+				}
 				if p.nextToken.TType == TokenType_COMMA {
-					assertTokenType(lastToken, TokenType_NUMBER, TokenType_IDENTIFIER)
-					lastToken = p.nextToken
-					p.EatNextToken()
-				} else {
-					expr, err := p.ParseExpr()
+					err = assertNotTokenType(p.EatNextToken(), TokenType_RPAREN)
 					if err != nil {
-						return nil, fmt.Errorf("Failed to parse expression argument: %v", err)
+						return nil, err
 					}
-					args = append(args, expr)
-					lastToken = p.nextToken
 				}
 			}
-
-			isCall = true
+			p.EatNextToken()
 		}
+	} else {
+		p.EatNextToken()
+	}
+
+	err = assertTokenType(p.nextToken, TokenType_FATARROW)
+	if err != nil {
+		return nil, err
+	}
+	p.EatNextToken()
+	arm.Value, err = p.parseExprAllowingStructLiterals(true)
+	if err != nil {
+		return nil, err
+	}
+	return &arm, nil
+}
 
+// Parses a literal, a reference, a call, a struct or array literal, a match or a parenthesized expression
+func (p *Parser) ParsePrimaryExpr() (*Expr, error) {
+	if p.nextToken.TType == TokenType_NUMBER {
 		expr := Expr{
-			IsCall:   isCall,
-			Location: location,
+			Location: p.nextToken.Location,
+			Type:     ExprType_INT,
+			Value:    p.nextToken.Value,
+		}
+		p.EatNextToken()
+		return &expr, nil
+	}
+	if p.nextToken.TType == TokenType_STRING {
+		expr := Expr{
+			Location: p.nextToken.Location,
+			Type:     ExprType_STRING,
+			Value:    p.nextToken.Value,
+		}
+		p.EatNextToken()
+		return &expr, nil
+	}
+	if p.nextToken.TType == TokenType_KEYW_TRUE || p.nextToken.TType == TokenType_KEYW_FALSE {
+		expr := Expr{
+			Location: p.nextToken.Location,
+			Type:     ExprType_BOOL,
+			Value:    TokenTypeToKeyword[p.nextToken.TType],
+		}
+		p.EatNextToken()
+		return &expr, nil
+	}
+	if p.nextToken.TType == TokenType_IDENTIFIER {
+		expr := Expr{
+			Location: p.nextToken.Location,
 			Type:     ExprType_DECL_REF,
-			Value:    value,
-			Args:     args,
+			Value:    p.nextToken.Value,
+			Args:     make([]*Expr, 0),
+		}
+		switch p.EatNextToken().TType {
+		case TokenType_LPAREN:
+			args, err := p.ParseArgs()
+			if err != nil {
+				return nil, err
+			}
+			expr.IsCall = true
+			expr.Args = args
+		case TokenType_LBRACE:
+			if p.noStructLiterals {
+				break
+			}
+			expr.Type = ExprType_STRUCT
+			err := p.ParseStructLiteral(&expr)
+			if err != nil {
+				return nil, err
+			}
 		}
 		return &expr, nil
 	}
-	return nil, fmt.Errorf("Unexpected token %s at %d:%d", p.nextToken.TType, p.nextToken.Location.Line, p.nextToken.Location.Column)
+	if p.nextToken.TType == TokenType_LBRACKET {
+		location := p.nextToken.Location
+		elems, err := p.parseExprList(TokenType_RBRACKET)
+		if err != nil {
+			return nil, err
+		}
+		return &Expr{
+			Location: location,
+			Type:     ExprType_ARRAY,
+			Args:     elems,
+		}, nil
+	}
+	if p.nextToken.TType == TokenType_KEYW_MATCH {
+		return p.ParseMatchExpr()
+	}
+	if p.nextToken.TType == TokenType_LPAREN {
+		p.EatNextToken()
+		expr, err := p.parseExprAllowingStructLiterals(true)
+		if err != nil {
+			return nil, err
+		}
+		err = assertTokenType(p.nextToken, TokenType_RPAREN)
+		if err != nil {
+			return nil, err
+		}
+		p.EatNextToken()
+		return expr, nil
+	}
+	return nil, newDiagnostic(DiagnosticCode_UNEXPECTED_TOKEN, p.nextToken.Location, "Unexpected token %s", p.nextToken.TType)
 }
 
-func (p *Parser) ParseReturnStmt() (Statement, error) {
-	err := assertTokenType(p.nextToken, TokenType_KEYW_RETURN)
+// Parses a primary expression followed by any number of `.field` accesses and `[index]` indexes
+func (p *Parser) ParsePostfixExpr() (*Expr, error) {
+	expr, err := p.ParsePrimaryExpr()
 	if err != nil {
 		return nil, err
 	}
-	err = assertTokenType(p.EatNextToken(), TokenType_NUMBER, TokenType_IDENTIFIER, TokenType_RBRACE)
+
+	for p.nextToken.TType == TokenType_DOT || p.nextToken.TType == TokenType_LBRACKET {
+		if p.nextToken.TType == TokenType_LBRACKET {
+			location := p.nextToken.Location
+			p.EatNextToken()
+			index, err := p.parseExprAllowingStructLiterals(true)
+			if err != nil {
+				return nil, err
+			}
+			err = assertTokenType(p.nextToken, TokenType_RBRACKET)
+			if err != nil {
+				return nil, err
+			}
+			p.EatNextToken()
+			expr = &Expr{
+				Location: location,
+				Type:     ExprType_INDEX,
+				Lhs:      expr,
+				Rhs:      index,
+			}
+			continue
+		}
+
+		err = assertTokenType(p.EatNextToken(), TokenType_IDENTIFIER)
+		if err != nil {
+			return nil, err
+		}
+		expr = &Expr{
+			Location: p.nextToken.Location,
+			Type:     ExprType_FIELD,
+			Value:    p.nextToken.Value,
+			Lhs:      expr,
+		}
+		p.EatNextToken()
+	}
+	return expr, nil
+}
+
+func (p *Parser) ParseUnaryExpr() (*Expr, error) {
+	if p.nextToken.TType == TokenType_MINUS || p.nextToken.TType == TokenType_BANG {
+		operator := p.nextToken
+		p.EatNextToken()
+		operand, err := p.ParseUnaryExpr()
+		if err != nil {
+			return nil, err
+		}
+		return &Expr{
+			Location: operator.Location,
+			Type:     ExprType_UNARY,
+			Operator: operator.TType,
+			Rhs:      operand,
+		}, nil
+	}
+	return p.ParsePostfixExpr()
+}
+
+// Parses binary operators binding at least as tightly as minPrecedence, by precedence climbing.
+// All binary operators are left associative.
+func (p *Parser) ParseBinaryExpr(minPrecedence int) (*Expr, error) {
+	lhs, err := p.ParseUnaryExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		operator := p.nextToken
+		precedence, ok := binaryPrecedence[operator.TType]
+		if !ok || precedence < minPrecedence {
+			return lhs, nil
+		}
+
+		p.EatNextToken()
+		rhs, err := p.ParseBinaryExpr(precedence + 1)
+		if err != nil {
+			return nil, err
+		}
+		lhs = &Expr{
+			Location: operator.Location,
+			Type:     ExprType_BINARY,
+			Operator: operator.TType,
+			Lhs:      lhs,
+			Rhs:      rhs,
+		}
+	}
+}
+
+// Parses an expression, leaving the first token after it as the next token
+func (p *Parser) ParseExpr() (*Expr, error) {
+	return p.ParseBinaryExpr(1)
+}
+
+func (p *Parser) ParseReturnStmt() (Statement, error) {
+	err := assertTokenType(p.nextToken, TokenType_KEYW_RETURN)
 	if err != nil {
 		return nil, err
 	}
+	location := p.nextToken.Location
 
-	var expr *Expr
-	if p.nextToken.TType == TokenType_RBRACE {
+	if p.EatNextToken().TType == TokenType_RBRACE {
 		returnStmt := ReturnStmt{
 			Stmt: Stmt{
-				Location: p.nextToken.Location,
+				Location: location,
 				Kind:     StmtType_RETURN,
 			},
 			Expr: nil,
@@ -115,63 +514,343 @@ func (p *Parser) ParseReturnStmt() (Statement, error) {
 		return &returnStmt, nil
 	}
 
-	expr, err = p.ParseExpr()
+	expr, err := p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+	returnStmt := ReturnStmt{
+		Stmt: Stmt{
+			Location: location,
+			Kind:     StmtType_RETURN,
+		},
+		Expr: expr,
+	}
+
+	return &returnStmt, nil
+}
+
+// Parses `let id: type = expr` or `let id = expr`, where the type is inferred
+func (p *Parser) ParseLetStmt() (Statement, error) {
+	err := assertTokenType(p.nextToken, TokenType_KEYW_LET)
+	if err != nil {
+		return nil, err
+	}
+	location := p.nextToken.Location
+
+	err = assertTokenType(p.EatNextToken(), TokenType_IDENTIFIER)
+	if err != nil {
+		return nil, err
+	}
+	id := p.nextToken.Value
+	idLocation := p.nextToken.Location
+
+	varType := Type_INFERRED
+	if p.EatNextToken().TType == TokenType_COLON {
+		p.EatNextToken()
+		varType, err = p.ParseType(valueTypeTokens...)
+		if err != nil {
+			return nil, err
+		}
+		p.EatNextToken()
+	}
+
+	err = assertTokenType(p.nextToken, TokenType_ASSIGN)
+	if err != nil {
+		return nil, err
+	}
+	p.EatNextToken()
+
+	expr, err := p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	return &LetStmt{
+		Stmt: Stmt{
+			Location: location,
+			Kind:     StmtType_LET,
+		},
+		Decl: &VariableDecl{
+			Decl: Decl{
+				Id:       id,
+				Location: idLocation,
+			},
+			Type:  varType,
+			Value: expr,
+		},
+	}, nil
+}
+
+// Parses `id = expr`, or a call `id(args)` used as a statement
+func (p *Parser) ParseIdentifierStmt() (Statement, error) {
+	err := assertTokenType(p.nextToken, TokenType_IDENTIFIER)
+	if err != nil {
+		return nil, err
+	}
+	id := p.nextToken.Value
+	location := p.nextToken.Location
+
+	if p.EatNextToken().TType == TokenType_LPAREN {
+		args, err := p.ParseArgs()
+		if err != nil {
+			return nil, err
+		}
+		return &ExprStmt{
+			Stmt: Stmt{
+				Location: location,
+				Kind:     StmtType_EXPR,
+			},
+			Expr: &Expr{
+				Location: location,
+				Type:     ExprType_DECL_REF,
+				Value:    id,
+				IsCall:   true,
+				Args:     args,
+			},
+		}, nil
+	}
+
+	err = assertTokenType(p.nextToken, TokenType_ASSIGN)
+	if err != nil {
+		return nil, err
+	}
+	p.EatNextToken()
+
+	expr, err := p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	return &AssignStmt{
+		Stmt: Stmt{
+			Location: location,
+			Kind:     StmtType_ASSIGN,
+		},
+		Id:   id,
+		Expr: expr,
+	}, nil
+}
+
+// Parses `if cond { ... }`, optionally followed by `else { ... }` or `else if ...`
+func (p *Parser) ParseIfStmt() (Statement, error) {
+	err := assertTokenType(p.nextToken, TokenType_KEYW_IF)
+	if err != nil {
+		return nil, err
+	}
+	location := p.nextToken.Location
+	p.EatNextToken()
+
+	cond, err := p.parseExprAllowingStructLiterals(false)
+	if err != nil {
+		return nil, err
+	}
+
+	then, err := p.ParseBlock()
+	if err != nil {
+		return nil, err
+	}
+
+	ifStmt := IfStmt{
+		Stmt: Stmt{
+			Location: location,
+			Kind:     StmtType_IF,
+		},
+		Cond: cond,
+		Then: then,
+	}
+	if p.EatNextToken().TType != TokenType_KEYW_ELSE {
+		return &ifStmt, nil
+	}
+
+	if p.EatNextToken().TType == TokenType_KEYW_IF {
+		elseLocation := p.nextToken.Location
+		elseIf, err := p.ParseIfStmt()
+		if err != nil {
+			return nil, err
+		}
+		ifStmt.Else = &Block{
+			Location: elseLocation,
+			Stmts:    []Statement{elseIf},
+		}
+		return &ifStmt, nil
+	}
+
+	ifStmt.Else, err = p.ParseBlock()
+	if err != nil {
+		return nil, err
+	}
+	p.EatNextToken()
+	return &ifStmt, nil
+}
+
+// Parses `while cond { ... }`
+func (p *Parser) ParseWhileStmt() (Statement, error) {
+	err := assertTokenType(p.nextToken, TokenType_KEYW_WHILE)
+	if err != nil {
+		return nil, err
+	}
+	location := p.nextToken.Location
+	p.EatNextToken()
+
+	cond, err := p.parseExprAllowingStructLiterals(false)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.ParseBlock()
+	if err != nil {
+		return nil, err
+	}
+	p.EatNextToken()
+
+	return &WhileStmt{
+		Stmt: Stmt{
+			Location: location,
+			Kind:     StmtType_WHILE,
+		},
+		Cond: cond,
+		Body: body,
+	}, nil
+}
+
+// Parses `for id in start..end { ... }`
+func (p *Parser) ParseForStmt() (Statement, error) {
+	err := assertTokenType(p.nextToken, TokenType_KEYW_FOR)
+	if err != nil {
+		return nil, err
+	}
+	location := p.nextToken.Location
+
+	err = assertTokenType(p.EatNextToken(), TokenType_IDENTIFIER)
+	if err != nil {
+		return nil, err
+	}
+	id := p.nextToken.Value
+	idLocation := p.nextToken.Location
+
+	err = assertTokenType(p.EatNextToken(), TokenType_KEYW_IN)
+	if err != nil {
+		return nil, err
+	}
+	p.EatNextToken()
+
+	start, err := p.parseExprAllowingStructLiterals(false)
+	if err != nil {
+		return nil, err
+	}
+	err = assertTokenType(p.nextToken, TokenType_DOTDOT)
+	if err != nil {
+		return nil, err
+	}
+	p.EatNextToken()
+
+	end, err := p.parseExprAllowingStructLiterals(false)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.ParseBlock()
+	if err != nil {
+		return nil, err
+	}
+	p.EatNextToken()
+
+	return &ForStmt{
+		Stmt: Stmt{
+			Location: location,
+			Kind:     StmtType_FOR,
+		},
+		Var: &VariableDecl{
+			Decl: Decl{
+				Id:       id,
+				Location: idLocation,
+			},
+			Type:  Type_INT,
+			Value: start,
+		},
+		End:  end,
+		Body: body,
+	}, nil
+}
+
+// The tokens a statement can start with
+var statementStarts = []TokenType{TokenType_KEYW_RETURN, TokenType_KEYW_LET, TokenType_KEYW_IF, TokenType_KEYW_WHILE, TokenType_KEYW_FOR, TokenType_KEYW_BREAK, TokenType_KEYW_CONTINUE, TokenType_KEYW_MATCH, TokenType_IDENTIFIER}
+
+// The tokens a declaration can start with. None of them can appear in a block, so after a syntax error
+// one starts the next declaration.
+var declarationStarts = []TokenType{TokenType_KEYW_FN, TokenType_KEYW_STRUCT, TokenType_KEYW_ENUM, TokenType_KEYW_IMPORT, TokenType_KEYW_PUB}
 
-	if err != nil {
-		return nil, fmt.Errorf("Failed to parse expression: %v", err)
+func (p *Parser) recordError(err error) {
+	p.errors = append(p.errors, DiagnosticsOf(err)...)
+}
+
+// A syntax error at a string literal the lexer couldn't lex is down to why it couldn't, which is reported
+// instead of the token being unexpected. Only the next token is checked, so it's called before skipping any.
+func (p *Parser) lexingError(err error) error {
+	token := p.nextToken
+	diagnostics := DiagnosticsOf(err)
+	if len(diagnostics) != 1 || diagnostics[0].Location != token.Location {
+		return err
 	}
-	returnStmt := ReturnStmt{
-		Stmt: Stmt{
-			Location: p.nextToken.Location,
-			Kind:     StmtType_RETURN,
-		},
-		Expr: expr,
+	switch token.TType {
+	case TokenType_INVALID_ESCAPE:
+		return newDiagnostic(DiagnosticCode_INVALID_ESCAPE, token.Location, "Invalid escape %s", token.Value).
+			withNote("Strings may contain the escapes \\n, \\t, \\\", \\\\ and \\u{...} with 1 to 6 hex digits")
+	case TokenType_UNTERMINATED_STRING:
+		return newDiagnostic(DiagnosticCode_UNTERMINATED_STRING, token.Location, "Unterminated string literal").
+			withNote("A string literal ends with a \" on the line it starts on")
 	}
+	return err
+}
 
-	for p.nextToken.TType != TokenType_RBRACE {
-		if p.nextToken.TType == TokenType_RBRACE {
-			break
-		}
-
-		err = assertNotTokenType(p.nextToken, TokenType_EOF)
-		if err != nil {
-			return nil, err
+// Skips the tokens after a syntax error in a block up to the RBRACE closing it, where depth is the depth of
+// its LBRACE. Fails at a declaration or the end of the file, where the block turns out never to be closed.
+func (p *Parser) syncToBlockEnd(depth int) bool {
+	for p.nextToken.TType != TokenType_RBRACE || p.depth >= depth {
+		if p.nextToken.TType == TokenType_EOF || slices.Contains(declarationStarts, p.nextToken.TType) {
+			return false
 		}
-
 		p.EatNextToken()
 	}
+	return true
+}
 
-	return &returnStmt, nil
+// Skips the tokens after a syntax error in a declaration up to the start of the next one
+func (p *Parser) syncToDeclaration() *Token {
+	for p.nextToken.TType != TokenType_EOF && !slices.Contains(declarationStarts, p.nextToken.TType) {
+		p.EatNextToken()
+	}
+	return p.nextToken
 }
 
+// Parses a block starting at its LBRACE, leaving its RBRACE as the next token. A statement with a syntax
+// error becomes an ErrorStmt ending the block, and the error is recorded, unless the block is never closed.
 func (p *Parser) ParseBlock() (*Block, error) {
-	p.EatNextToken()
 	err := assertTokenType(p.nextToken, TokenType_LBRACE)
 	if err != nil {
 		return nil, err
 	}
 
+	depth := p.depth
 	stmts := make([]Statement, 0)
-	for p.EatNextToken().TType != TokenType_RBRACE {
-		err = assertTokenType(p.nextToken, TokenType_KEYW_RETURN, TokenType_RBRACE)
+	p.EatNextToken()
+	for p.nextToken.TType != TokenType_RBRACE {
+		location := p.nextToken.Location
+		stmt, err := p.ParseStatement()
 		if err != nil {
-			return nil, err
-		}
-
-		if p.nextToken.TType == TokenType_KEYW_RETURN {
-			returnStmt, err := p.ParseReturnStmt()
-			if err != nil {
+			err = p.lexingError(err)
+			if !p.syncToBlockEnd(depth) {
 				return nil, err
 			}
-
-			stmts = append(stmts, returnStmt)
-			err = assertTokenType(p.nextToken, TokenType_RBRACE)
-			if err != nil {
-				return nil, err
+			p.recordError(err)
+			stmt = &ErrorStmt{
+				Stmt: Stmt{
+					Location: location,
+					Kind:     StmtType_ERROR,
+				},
 			}
-
-			break
 		}
+		stmts = append(stmts, stmt)
 	}
 
 	return &Block{
@@ -180,53 +859,120 @@ func (p *Parser) ParseBlock() (*Block, error) {
 	}, nil
 }
 
+// Parses the statement starting at the next token, leaving its last token as the next one, or the
+// RBRACE after it for a statement that must end its block
+func (p *Parser) ParseStatement() (Statement, error) {
+	err := assertTokenType(p.nextToken, statementStarts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var stmt Statement
+	switch p.nextToken.TType {
+	case TokenType_KEYW_RETURN:
+		stmt, err = p.ParseReturnStmt()
+		if err != nil {
+			return nil, err
+		}
+
+		// Nothing may follow a return in its block
+		err = assertTokenType(p.nextToken, TokenType_RBRACE)
+		if err != nil {
+			return nil, err
+		}
+	case TokenType_KEYW_LET:
+		stmt, err = p.ParseLetStmt()
+	case TokenType_KEYW_IF:
+		stmt, err = p.ParseIfStmt()
+	case TokenType_KEYW_WHILE:
+		stmt, err = p.ParseWhileStmt()
+	case TokenType_KEYW_FOR:
+		stmt, err = p.ParseForStmt()
+	case TokenType_KEYW_BREAK, TokenType_KEYW_CONTINUE:
+		location := p.nextToken.Location
+		if p.nextToken.TType == TokenType_KEYW_BREAK {
+			stmt = &BreakStmt{
+				Stmt: Stmt{
+					Location: location,
+					Kind:     StmtType_BREAK,
+				},
+			}
+		} else {
+			stmt = &ContinueStmt{
+				Stmt: Stmt{
+					Location: location,
+					Kind:     StmtType_CONTINUE,
+				},
+			}
+		}
+
+		// Like a return, a break or continue must end its block
+		err = assertTokenType(p.EatNextToken(), TokenType_RBRACE)
+	case TokenType_KEYW_MATCH:
+		// A match used as a statement, whose arms are evaluated for their effects
+		location := p.nextToken.Location
+		var expr *Expr
+		expr, err = p.ParseMatchExpr()
+		stmt = &ExprStmt{
+			Stmt: Stmt{
+				Location: location,
+				Kind:     StmtType_EXPR,
+			},
+			Expr: expr,
+		}
+	case TokenType_IDENTIFIER:
+		stmt, err = p.ParseIdentifierStmt()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+// Parses `(id: type, ...)`, starting at its LPAREN and leaving its RPAREN as the next token. A comma may
+// follow the last parameter.
 func (p *Parser) ParseParameterList() ([]*VariableDecl, error) {
-	assertTokenType(p.nextToken, TokenType_LPAREN)
+	err := assertTokenType(p.nextToken, TokenType_LPAREN)
+	if err != nil {
+		return nil, err
+	}
 
 	variables := make([]*VariableDecl, 0)
-	lastToken := p.nextToken
 	for p.EatNextToken().TType != TokenType_RPAREN {
-		err := assertNotTokenType(p.nextToken, TokenType_EOF)
+		err = assertTokenType(p.nextToken, TokenType_IDENTIFIER)
 		if err != nil {
 			return nil, err
 		}
 
-		if lastToken.TType == TokenType_LPAREN || lastToken.TType == TokenType_COMMA {
-			err = assertTokenType(p.nextToken, TokenType_IDENTIFIER)
-			if err != nil {
-				return nil, err
-			}
-
-			id := p.nextToken.Value
-			initialLocation := p.nextToken.Location
-			err = assertTokenType(p.EatNextToken(), TokenType_COLON)
-			if err != nil {
-				return nil, err
-			}
+		id := p.nextToken.Value
+		initialLocation := p.nextToken.Location
+		err = assertTokenType(p.EatNextToken(), TokenType_COLON)
+		if err != nil {
+			return nil, err
+		}
 
-			err = assertTokenType(p.EatNextToken(), TokenType_KEYW_INT)
-			if err != nil {
-				return nil, err
-			}
+		p.EatNextToken()
+		paramType, err := p.ParseType(valueTypeTokens...)
+		if err != nil {
+			return nil, err
+		}
 
-			decl := VariableDecl{
-				Decl: Decl{
-					Id:       id,
-					Location: initialLocation,
-				},
-				Type: Type_INT,
-			}
+		decl := VariableDecl{
+			Decl: Decl{
+				Id:       id,
+				Location: initialLocation,
+			},
+			Type: paramType,
+		}
+		variables = append(variables, &decl)
 
-			variables = append(variables, &decl)
-		} else if lastToken.TType == TokenType_IDENTIFIER {
-			err = assertTokenType(p.nextToken, TokenType_COMMA, TokenType_RPAREN)
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			panic(fmt.Sprintf("Unexpected token at %d:%d", p.nextToken.Location.Line, p.nextToken.Location.Column))
+		err = assertTokenType(p.EatNextToken(), TokenType_COMMA, TokenType_RPAREN)
+		if err != nil {
+			return nil, err
+		}
+		if p.nextToken.TType == TokenType_RPAREN {
+			break
 		}
-		lastToken = p.nextToken
 	}
 
 	return variables, nil
@@ -242,8 +988,22 @@ func (p *Parser) ParseFunction() (*FunctionDecl, error) {
 		return nil, err
 	}
 	fnName := p.nextToken.Value
+	location := p.nextToken.Location
+
+	var typeParams []*Decl
+	if p.EatNextToken().TType == TokenType_LT {
+		typeParams, err = p.ParseTypeParameterList()
+		if err != nil {
+			return nil, err
+		}
+		// The type arguments are inferred from the arguments, so a generic function needs parameters
+		err = assertTokenType(p.EatNextToken(), TokenType_LPAREN)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	err = assertTokenType(p.EatNextToken(), TokenType_LPAREN, TokenType_COLON)
+	err = assertTokenType(p.nextToken, TokenType_LPAREN, TokenType_COLON, TokenType_LBRACE)
 	if err != nil {
 		return nil, err
 	}
@@ -259,61 +1019,297 @@ func (p *Parser) ParseFunction() (*FunctionDecl, error) {
 		var err error
 		parameters, err = p.ParseParameterList()
 		if err != nil {
-			return nil, fmt.Errorf("Failed to parse parameter list: %v", err)
+			return nil, err
 		}
 
-		err = assertTokenType(p.EatNextToken(), TokenType_COLON)
+		err = assertTokenType(p.EatNextToken(), TokenType_COLON, TokenType_LBRACE)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	err = assertTokenType(p.EatNextToken(), TokenType_KEYW_INT, TokenType_KEYW_VOID)
-	if err != nil {
-		return nil, err
-	}
-	returnType := Type_INT
-	if p.nextToken.TType == TokenType_KEYW_VOID {
-		returnType = Type_VOID
+	// Without an annotation, the return type is inferred from the return statements
+	returnType := Type_INFERRED
+	if p.nextToken.TType == TokenType_COLON {
+		p.EatNextToken()
+		returnType, err = p.ParseType(append(valueTypeTokens, TokenType_KEYW_VOID)...)
+		if err != nil {
+			return nil, err
+		}
+		p.EatNextToken()
 	}
 
 	block, err := p.ParseBlock()
 
 	if err != nil {
-		return nil, fmt.Errorf("Failed to parse block: %v", err)
+		return nil, err
 	}
 
 	return &FunctionDecl{
 		Decl: Decl{
 			Id:       fnName,
-			Location: p.nextToken.Location,
+			Location: location,
 		},
 		ReturnType: returnType,
 		Body:       block,
 		Params:     parameters,
+		TypeParams: typeParams,
+	}, nil
+}
+
+// Parses `<T, ...>`, starting at its LT and leaving its GT as the next token
+func (p *Parser) ParseTypeParameterList() ([]*Decl, error) {
+	typeParams := make([]*Decl, 0)
+	for {
+		err := assertTokenType(p.EatNextToken(), TokenType_IDENTIFIER)
+		if err != nil {
+			return nil, err
+		}
+		typeParams = append(typeParams, &Decl{
+			Id:       p.nextToken.Value,
+			Location: p.nextToken.Location,
+		})
+
+		err = assertTokenType(p.EatNextToken(), TokenType_COMMA, TokenType_GT)
+		if err != nil {
+			return nil, err
+		}
+		if p.nextToken.TType == TokenType_GT {
+			return typeParams, nil
+		}
+	}
+}
+
+// Parses `struct Name { id: type, ... }`, leaving its RBRACE as the next token
+func (p *Parser) ParseStruct() (*StructDecl, error) {
+	err := assertTokenType(p.nextToken, TokenType_KEYW_STRUCT)
+	if err != nil {
+		return nil, err
+	}
+	err = assertTokenType(p.EatNextToken(), TokenType_IDENTIFIER)
+	if err != nil {
+		return nil, err
+	}
+	structDecl := StructDecl{
+		Decl: Decl{
+			Id:       p.nextToken.Value,
+			Location: p.nextToken.Location,
+		},
+		Fields: make([]*VariableDecl, 0),
+	}
+
+	err = assertTokenType(p.EatNextToken(), TokenType_LBRACE)
+	if err != nil {
+		return nil, err
+	}
+	p.EatNextToken()
+	for p.nextToken.TType != TokenType_RBRACE {
+		err = assertTokenType(p.nextToken, TokenType_IDENTIFIER)
+		if err != nil {
+			return nil, err
+		}
+		field := VariableDecl{
+			Decl: Decl{
+				Id:       p.nextToken.Value,
+				Location: p.nextToken.Location,
+			},
+		}
+		err = assertTokenType(p.EatNextToken(), TokenType_COLON)
+		if err != nil {
+			return nil, err
+		}
+		p.EatNextToken()
+		field.Type, err = p.ParseType(valueTypeTokens...)
+		if err != nil {
+			return nil, err
+		}
+		structDecl.Fields = append(structDecl.Fields, &field)
+
+		err = assertTokenType(p.EatNextToken(), TokenType_COMMA, TokenType_RBRACE)
+		if err != nil {
+			return nil, err
+		}
+		if p.nextToken.TType == TokenType_COMMA {
+			err = assertNotTokenType(p.EatNextToken(), TokenType_RBRACE)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &structDecl, nil
+}
+
+// Parses `enum Name { Variant(type, ...), Variant, ... }`, leaving its RBRACE as the next token
+func (p *Parser) ParseEnum() (*EnumDecl, error) {
+	err := assertTokenType(p.nextToken, TokenType_KEYW_ENUM)
+	if err != nil {
+		return nil, err
+	}
+	err = assertTokenType(p.EatNextToken(), TokenType_IDENTIFIER)
+	if err != nil {
+		return nil, err
+	}
+	enumDecl := EnumDecl{
+		Decl: Decl{
+			Id:       p.nextToken.Value,
+			Location: p.nextToken.Location,
+		},
+		Variants: make([]*VariantDecl, 0),
+	}
+
+	err = assertTokenType(p.EatNextToken(), TokenType_LBRACE)
+	if err != nil {
+		return nil, err
+	}
+	p.EatNextToken()
+	for p.nextToken.TType != TokenType_RBRACE {
+		err = assertTokenType(p.nextToken, TokenType_IDENTIFIER)
+		if err != nil {
+			return nil, err
+		}
+		variant := VariantDecl{
+			Decl: Decl{
+				Id:       p.nextToken.Value,
+				Location: p.nextToken.Location,
+			},
+			Payload: make([]Type, 0),
+		}
+		if p.EatNextToken().TType == TokenType_LPAREN {
+			p.EatNextToken()
+			for p.nextToken.TType != TokenType_RPAREN {
+				payloadType, err := p.ParseType(valueTypeTokens...)
+				if err != nil {
+					return nil, err
+				}
+				variant.Payload = append(variant.Payload, payloadType)
+
+				err = assertTokenType(p.EatNextToken(), TokenType_COMMA, TokenType_RPAREN)
+				if err != nil {
+					return nil, err
+				}
+				if p.nextToken.TType == TokenType_COMMA {
+					err = assertNotTokenType(p.EatNextToken(), TokenType_RPAREN)
+					if err != nil {
+						return nil, err
+					}
+				}
+			}
+			p.EatNextToken()
+		}
+		enumDecl.Variants = append(enumDecl.Variants, &variant)
+
+		err = assertTokenType(p.nextToken, TokenType_COMMA, TokenType_RBRACE)
+		if err != nil {
+			return nil, err
+		}
+		if p.nextToken.TType == TokenType_COMMA {
+			err = assertNotTokenType(p.EatNextToken(), TokenType_RBRACE)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &enumDecl, nil
+}
+
+// Parses `import "path"`, leaving its path as the next token
+func (p *Parser) ParseImport() (*ImportDecl, error) {
+	err := assertTokenType(p.nextToken, TokenType_KEYW_IMPORT)
+	if err != nil {
+		return nil, err
+	}
+	location := p.nextToken.Location
+	err = assertTokenType(p.EatNextToken(), TokenType_STRING)
+	if err != nil {
+		return nil, err
+	}
+	// Diagnostics about the import underline its path too
+	location.End = p.nextToken.Location.End
+	return &ImportDecl{
+		Decl: Decl{
+			Location: location,
+		},
+		Path: p.nextToken.Value,
 	}, nil
 }
 
+// Parses a function, struct or enum declaration marked pub, starting at its pub
+func (p *Parser) ParsePubDeclaration() (Declaration, error) {
+	err := assertTokenType(p.nextToken, TokenType_KEYW_PUB)
+	if err != nil {
+		return nil, err
+	}
+	switch p.EatNextToken().TType {
+	case TokenType_KEYW_FN:
+		fn, err := p.ParseFunction()
+		if err != nil {
+			return nil, err
+		}
+		fn.Pub = true
+		return fn, nil
+	case TokenType_KEYW_STRUCT:
+		structDecl, err := p.ParseStruct()
+		if err != nil {
+			return nil, err
+		}
+		structDecl.Pub = true
+		return structDecl, nil
+	case TokenType_KEYW_ENUM:
+		enumDecl, err := p.ParseEnum()
+		if err != nil {
+			return nil, err
+		}
+		enumDecl.Pub = true
+		return enumDecl, nil
+	}
+	return nil, assertTokenType(p.nextToken, TokenType_KEYW_FN, TokenType_KEYW_STRUCT, TokenType_KEYW_ENUM)
+}
+
+// Parses the whole file. A declaration with a syntax error becomes an ErrorDecl, and parsing goes on from
+// the next declaration, so every error is returned, together with what could be parsed.
 func (p *Parser) Parse() ([]Declaration, error) {
 	declarations := make([]Declaration, 0)
 
-	var lastToken *Token
 	next := p.EatNextToken()
 	for next.TType != TokenType_EOF {
-
-		if lastToken == nil && next.TType != TokenType_KEYW_FN {
-			return nil, fmt.Errorf("Expected function declaration at %d:%d, found %v", next.Location.Line, next.Location.Column, next.TType)
+		// Braces left open by a declaration with a syntax error are never closed
+		p.depth = 0
+		location := next.Location
+		var decl Declaration
+		var err error
+		switch next.TType {
+		case TokenType_KEYW_FN:
+			decl, err = p.ParseFunction()
+		case TokenType_KEYW_STRUCT:
+			decl, err = p.ParseStruct()
+		case TokenType_KEYW_ENUM:
+			decl, err = p.ParseEnum()
+		case TokenType_KEYW_IMPORT:
+			decl, err = p.ParseImport()
+		case TokenType_KEYW_PUB:
+			decl, err = p.ParsePubDeclaration()
+		default:
+			err = newDiagnostic(DiagnosticCode_UNEXPECTED_TOKEN, next.Location, "Expected import, function, struct or enum declaration, found %v", next.TType)
 		}
-
-		fn, err := p.ParseFunction()
 		if err != nil {
-			return nil, fmt.Errorf("Failed to parse function: %v", err)
+			p.recordError(p.lexingError(err))
+			declarations = append(declarations, &ErrorDecl{
+				Decl: Decl{
+					Location: location,
+				},
+			})
+			next = p.syncToDeclaration()
+			continue
 		}
-		declarations = append(declarations, fn)
 
-		lastToken = next
+		declarations = append(declarations, decl)
 		next = p.EatNextToken()
 	}
 
+	if len(p.errors) > 0 {
+		return declarations, p.errors
+	}
 	return declarations, nil
 }
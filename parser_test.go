@@ -1,6 +1,7 @@
 package baisl_test
 
 import (
+	"slices"
 	"strings"
 	"testing"
 
@@ -15,15 +16,71 @@ type parserTest struct {
 type failParserTest struct {
 	path          string
 	errorContains string
+	code          baisl.DiagnosticCode
+}
+
+// A file with syntax errors, for which every error is reported and what could be parsed is returned
+type recoveryParserTest struct {
+	path     string
+	errors   []string
+	expected string
 }
 
 var parserTests = []parserTest{
 	{"raw/ret2.baisl", "Function main(): void:\n  Block:\n    Return\n\nFunction return2(): int:\n  Block:\n    Return 2\n\n"}, // annoying extra newline i haven't dealt with
 	{"raw/retParam.baisl", "Function main(): void:\n  Block:\n    Return\n\nFunction returnParam(a: int): int:\n  Block:\n    Return a\n\n"},
 	{"raw/fnCall.baisl", "Function returnParam(a: int): int:\n  Block:\n    Return a\n\nFunction main(): int:\n  Block:\n    Return Call returnParam(5)\n\n"},
+	{"raw/params.baisl", "Function pick(a: int, b: int, c: int): int:\n  Block:\n    Return b\n\nFunction main(): int:\n  Block:\n    Return Call pick(1, 2, 3)\n\n"},
+	{"raw/locals.baisl", "Function addOne(a: int): int:\n  Block:\n    Assign a = (a + 1)\n    Return a\n\nFunction square(a: int): int:\n  Block:\n    Let result: int = (a * a)\n    Return result\n\nFunction main(): int:\n  Block:\n    Let x = 3\n    Let y: int = Call square(x)\n    Assign x = Call addOne((x + y))\n    Let square = 2\n    Return (x * square)\n\n"},
+	{"raw/arithmetic.baisl", "Function calc(a: int, b: int): int:\n  Block:\n    Return ((((a + b) * 2) - ((a / b) % 3)) + (-a))\n\nFunction main(): int:\n  Block:\n    Return (Call calc(7, 2) + 2)\n\n"},
+	{"raw/ifElse.baisl", "Function sign(a: int): int:\n  Block:\n    If (a < 0):\n      Block:\n        Return (-1)\n    Else:\n      Block:\n        If (a == 0):\n          Block:\n            Return 0\n        Else:\n          Block:\n            Return 1\n\nFunction clamp(a: int, max: int): int:\n  Block:\n    If (a > max):\n      Block:\n        Assign a = max\n    Return a\n\nFunction early(a: int): void:\n  Block:\n    If (a != 0):\n      Block:\n        Return\n    Let b = a\n\nFunction main(): int:\n  Block:\n    Let x = 10\n    If (x > 5):\n      Block:\n        Let x = (x * 2)\n        Assign x = (x + 1)\n    Let y = Call sign((-x))\n    Return (((x + Call clamp(40, 30)) + (y * 2)) + Call sign(x))\n\n"},
+	{"raw/loops.baisl", "Function sumTo(n: int): int:\n  Block:\n    Let total = 0\n    For i in 0..(n + 1):\n      Block:\n        Assign total = (total + i)\n    Return total\n\nFunction firstMultiple(a: int, b: int): int:\n  Block:\n    Let i = a\n    While true:\n      Block:\n        If ((i % b) == 0):\n          Block:\n            Break\n        Assign i = (i + 1)\n    Return i\n\nFunction sumOdd(n: int): int:\n  Block:\n    Let total = 0\n    For i in 0..n:\n      Block:\n        If ((i % 2) == 0):\n          Block:\n            Continue\n        Assign total = (total + i)\n    Return total\n\nFunction nested(): int:\n  Block:\n    Let count = 0\n    For i in 0..4:\n      Block:\n        For j in 0..4:\n          Block:\n            If (j > i):\n              Block:\n                Break\n            Assign count = (count + 1)\n    Return count\n\nFunction onceLimit(): int:\n  Block:\n    Let n = 3\n    Let runs = 0\n    For i in 0..n:\n      Block:\n        Assign n = (n + 1)\n        Assign runs = (runs + 1)\n    Return runs\n\nFunction main(): int:\n  Block:\n    Return ((((Call sumTo(10) + Call firstMultiple(10, 7)) + Call sumOdd(10)) + Call nested()) + Call onceLimit())\n\n"},
+	{"raw/bool.baisl", "Function inRange(a: int, min: int, max: int): bool:\n  Block:\n    Return ((a >= min) && (a < max))\n\nFunction xor(a: bool, b: bool): bool:\n  Block:\n    Return (a != b)\n\nFunction main(): int:\n  Block:\n    Let n = 0\n    Let d = 0\n    If (((d != 0) && ((10 / d) > 1)) || (!Call inRange(d, 0, 5))):\n      Block:\n        Assign n = 100\n    Let found: bool = false\n    For i in 0..10:\n      Block:\n        If (Call inRange(i, 3, 6) || (i == 8)):\n          Block:\n            Assign n = (n + i)\n        Assign found = (found || Call xor((i == 4), (true == false)))\n    If (found && (!Call xor(true, true))):\n      Block:\n        Assign n = (n + 1)\n    Return n\n\n"},
+	{"raw/strings.baisl", "Function greet(name: string): string:\n  Block:\n    Return ((\"Hello, \" + name) + \"!\")\n\nFunction main(): int:\n  Block:\n    Expr Call println(Call greet(\"baisl\"))\n    Let line: string = \"tab\\there \\\"quoted\\\" back\\\\slash\"\n    Expr Call println(line)\n    Expr Call print(\"sum: \")\n    Expr Call println((2 + 3))\n    Expr Call println((1 < 2))\n    Let bar = \"\"\n    For i in 0..3:\n      Block:\n        Assign bar = (bar + \"#\")\n    Expr Call println(bar)\n    Expr Call println(\"café 😀??=\")\n    Expr Call greet(\"unused\")\n    Return 0\n\n"},
+	{"raw/structs.baisl", "Struct Point:\n  x: int\n  y: int\n\nStruct Line:\n  from: Point\n  to: Point\n  name: string\n\nFunction length(l: Line): int:\n  Block:\n    Return (((l.to.x - l.from.x) + l.to.y) - l.from.y)\n\nFunction origin(): Point:\n  Block:\n    Return Point{y: 0, x: 0}\n\nFunction main(): int:\n  Block:\n    Let l = Line{name: \"diag\", from: Call origin(), to: Point{x: 3, y: 4}}\n    Let p: Point = l.to\n    If (p.x < p.y):\n      Block:\n        Expr Call println(l.name)\n    While (p.x > 100):\n      Block:\n    Expr Call println((Call length(l) + Call origin().x))\n    Expr Call println(Point{x: 5, y: 6}.y)\n    Return 0\n\n"},
+	{"raw/arrays.baisl", "Struct Team:\n  name: string\n  scores: []int\n\nFunction sum(xs: []int): int:\n  Block:\n    Let total = 0\n    For i in 0..Call len(xs):\n      Block:\n        Assign total = (total + xs[i])\n    Return total\n\nFunction best(teams: []Team): string:\n  Block:\n    Let winner = teams[0]\n    For i in 1..Call len(teams):\n      Block:\n        If (Call sum(teams[i].scores) > Call sum(winner.scores)):\n          Block:\n            Assign winner = teams[i]\n    Return winner.name\n\nFunction main(): int:\n  Block:\n    Let primes: [4]int = [2, 3, 5, 7]\n    Expr Call println(Call len(primes))\n    Expr Call println(Call sum(primes))\n    Let firstTwo: []int = [primes[0], primes[1]]\n    Expr Call println(Call sum(firstTwo))\n    Let grid = [[1, 2, 3], [4, 5, 6]]\n    Expr Call println((grid[1][2] - grid[0][(Call len(grid[0]) - 1)]))\n    Let teams = [Team{name: \"red\", scores: [3, 4]}, Team{name: \"blue\", scores: [5, 1, 2]}]\n    Expr Call println(Call best(teams))\n    Expr Call println([\"a\", \"b\", \"c\"][2])\n    Return 0\n\n"},
+	{"raw/enums.baisl", "Enum Shape:\n  Circle(int)\n  Rect(int, int)\n  Empty\n\nEnum Light:\n  Red\n  Yellow\n  Green\n\nFunction area(shape: Shape): int:\n  Block:\n    Return match shape {Circle(r) => ((3 * r) * r), Rect(w, h) => (w * h), Empty => 0}\n\nFunction next(light: Light): Light:\n  Block:\n    Return match light {Red => Green, Green => Yellow, Yellow => Red}\n\nFunction describe(shape: Shape): void:\n  Block:\n    Expr match shape {Circle(_) => Call println(\"round\"), _ => Call println(\"not round\")}\n\nFunction main(): int:\n  Block:\n    Let shapes = [Call Circle(2), Call Rect(3, 4), Empty]\n    Let total = 0\n    For i in 0..Call len(shapes):\n      Block:\n        Assign total = (total + Call area(shapes[i]))\n        Expr Call describe(shapes[i])\n    Expr Call println(total)\n    Let light = Red\n    For i in 0..4:\n      Block:\n        Assign light = Call next(light)\n        Expr Call println(match light {Red => \"red\", Yellow => \"yellow\", Green => \"green\"})\n    Let width = match Call Rect(5, 6) {Rect(w, _) => w, _ => 0}\n    Expr Call println(width)\n    Return 0\n\n"},
+	{"raw/generics.baisl", "Struct Pair:\n  left: int\n  right: int\n\nFunction id<T>(x: T): T:\n  Block:\n    Return x\n\nFunction first<T>(xs: []T): T:\n  Block:\n    Return xs[0]\n\nFunction pick<T>(cond: bool, a: T, b: T): T:\n  Block:\n    If cond:\n      Block:\n        Return a\n    Return b\n\nFunction count<T>(xs: []T, i: int): int:\n  Block:\n    If (i >= Call len(xs)):\n      Block:\n        Return 0\n    Return (1 + Call count(xs, (i + 1)))\n\nFunction wrap<T>(x: T): [1]T:\n  Block:\n    Let wrapped: [1]T = [x]\n    Return wrapped\n\nFunction main(): int:\n  Block:\n    Expr Call println((Call id(41) + 1))\n    Expr Call println(Call id(\"generic\"))\n    Expr Call println(Call first([true, false]))\n    Expr Call println(Call first([\"a\", \"b\"]))\n    Let p = Call pick(false, Pair{left: 1, right: 2}, Pair{left: 3, right: 4})\n    Expr Call println((p.left + p.right))\n    Expr Call println((Call count([1, 2, 3], 0) + Call count([\"x\"], 0)))\n    Expr Call println(Call first(Call wrap(Call id(9))))\n    Return 0\n\n"},
+	{"raw/inferredReturns.baisl", "Struct Point:\n  x: int\n  y: int\n\nFunction double(x: int):\n  Block:\n    Return (x * 2)\n\nFunction sign(x: int):\n  Block:\n    If (x < 0):\n      Block:\n        Return (-1)\n    If (x == 0):\n      Block:\n        Return 0\n    Return 1\n\nFunction fib(n: int):\n  Block:\n    If (n < 2):\n      Block:\n        Return n\n    Return (Call fib((n - 1)) + Call fib((n - 2)))\n\nFunction origin():\n  Block:\n    Return Point{x: 0, y: 0}\n\nFunction greet(name: string):\n  Block:\n    Expr Call println(name)\n\nFunction main(): int:\n  Block:\n    Expr Call greet(\"inferred\")\n    Let p = Call origin()\n    Expr Call println((p.x + Call double(21)))\n    Expr Call println(((Call sign((-5)) + Call sign(0)) + Call sign(9)))\n    Expr Call println(Call fib(10))\n    Return 0\n\n"},
+	{"raw/shadowing.baisl", "Function value(): int:\n  Block:\n    Return 1\n\nFunction offset(value: int): int:\n  Block:\n    If (value > 10):\n      Block:\n        Let value = (value - 10)\n        Return value\n    Return value\n\nFunction main(): int:\n  Block:\n    Let total = Call value()\n    For value in 0..3:\n      Block:\n        Assign total = (total + value)\n    Let x = 100\n    If true:\n      Block:\n        Let x = (x + 1)\n        Assign total = (total + x)\n    Assign total = ((total + Call offset(15)) + Call offset(7))\n    Expr Call println((total + x))\n    Return 0\n\n"},
+	{"raw/modules/report/report.baisl", "Import \"geometry\"\nPub Function describe(shape: Shape):\n  Block:\n    Expr Call println(Call area(shape))\n\n"},
 }
 
-var failParserTests = []failParserTest{}
+var failParserTests = []failParserTest{
+	{"raw/unclosedParen.baisl", "Expected token type RPAREN, got RBRACE at 3:1", baisl.DiagnosticCode_UNEXPECTED_TOKEN},
+	{"raw/letWithoutValue.baisl", "Expected token type ASSIGN, got KEYW_RETURN at 3:3", baisl.DiagnosticCode_UNEXPECTED_TOKEN},
+	{"raw/statementAfterBreak.baisl", "Expected token type RBRACE, got KEYW_LET at 4:5", baisl.DiagnosticCode_UNEXPECTED_TOKEN},
+	{"raw/structLiteralCondition.baisl", "Expected token type ASSIGN, got COLON at 4:15", baisl.DiagnosticCode_UNEXPECTED_TOKEN},
+	{"raw/badArrayType.baisl", "Expected token type RBRACKET, got IDENTIFIER at 2:12", baisl.DiagnosticCode_UNEXPECTED_TOKEN},
+	{"raw/matchWithoutArrow.baisl", "Expected token type FATARROW, got COLON at 4:25", baisl.DiagnosticCode_UNEXPECTED_TOKEN},
+	{"raw/genericWithoutParams.baisl", "Expected token type LPAREN, got COLON at 1:11", baisl.DiagnosticCode_UNEXPECTED_TOKEN},
+	{"raw/trailingTypeParamComma.baisl", "Expected token type IDENTIFIER, got GT at 1:9", baisl.DiagnosticCode_UNEXPECTED_TOKEN},
+	{"raw/pubImport.baisl", "Expected token type in [KEYW_FN KEYW_STRUCT KEYW_ENUM], got KEYW_IMPORT at 1:5", baisl.DiagnosticCode_UNEXPECTED_TOKEN},
+	{"raw/invalidEscape.baisl", "Invalid escape \\q at 2:15", baisl.DiagnosticCode_INVALID_ESCAPE},
+	{"raw/unterminatedString.baisl", "Unterminated string literal at 2:11", baisl.DiagnosticCode_UNTERMINATED_STRING},
+}
+
+var recoveryParserTests = []recoveryParserTest{
+	{
+		"raw/multipleErrors.baisl",
+		[]string{
+			"Unexpected token KEYW_RETURN at 4:3",
+			"Expected token type COLON, got KEYW_INT at 7:13",
+			"Expected token type in [COMMA RBRACE], got IDENTIFIER at 11:23",
+			"Expected token type RPAREN, got RBRACE at 16:3",
+			"Expected token type FATARROW, got COLON at 17:26",
+		},
+		"Function first(): int:\n  Block:\n    Let a = 1\n    Error\n\nError\nError\nFunction main(): int:\n  Block:\n    While true:\n      Block:\n        Error\n    Error\n\n",
+	},
+	{
+		"raw/stringErrors.baisl",
+		[]string{
+			"Invalid escape \\q at 3:15 in raw/stringErrors.baisl; Strings may contain the escapes \\n, \\t, \\\", \\\\ and \\u{...} with 1 to 6 hex digits",
+			"Unterminated string literal at 7:17 in raw/stringErrors.baisl; A string literal ends with a \" on the line it starts on",
+		},
+		"Function shout(s: string): string:\n  Block:\n    Error\n\nFunction main(): int:\n  Block:\n    Error\n\n",
+	},
+}
 
 func TestParse(t *testing.T) {
 	for _, test := range parserTests {
@@ -68,5 +125,39 @@ func TestParse(t *testing.T) {
 		if !strings.Contains(err.Error(), test.errorContains) {
 			t.Errorf("Expected error containing <%s>, got <%s>", test.errorContains, err.Error())
 		}
+		codes := baisl.DiagnosticsOf(err).Codes()
+		if !slices.Equal(codes, []baisl.DiagnosticCode{test.code}) {
+			t.Errorf("Expected %s to report %s, got %v", test.path, test.code, codes)
+		}
+	}
+}
+
+func TestParseRecovery(t *testing.T) {
+	for _, test := range recoveryParserTests {
+		sourceFile, err := baisl.GetSourceFile(test.path)
+		if err != nil {
+			t.Fatalf("Error reading file: %s", err)
+		}
+		parser := baisl.Parser{
+			SourceFile: &sourceFile,
+		}
+		result, err := parser.Parse()
+
+		diagnostics := baisl.DiagnosticsOf(err)
+		errors := make([]string, len(diagnostics))
+		for i, diagnostic := range diagnostics {
+			errors[i] = strings.TrimSuffix(diagnostic.Error(), " in "+test.path)
+		}
+		if !slices.Equal(errors, test.errors) {
+			t.Errorf("%s: expected errors %q, got %q", test.path, test.errors, errors)
+		}
+
+		joined := ""
+		for _, line := range result {
+			joined += line.String(0) + "\n"
+		}
+		if joined != test.expected {
+			t.Errorf("%s: expected <%s>, got <%s>", test.path, test.expected, joined)
+		}
 	}
 }
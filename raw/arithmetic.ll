@@ -0,0 +1,137 @@
+; ModuleID = 'baisl'
+source_filename = "baisl"
+
+%baisl.string = type { i64, i8* }
+
+define i64 @baisl_calc(i64 %p.a, i64 %p.b) {
+entry:
+  %v.a = alloca i64
+  store i64 %p.a, i64* %v.a
+  %v.b = alloca i64
+  store i64 %p.b, i64* %v.b
+  %t0 = load i64, i64* %v.a
+  %t1 = load i64, i64* %v.b
+  %t2 = add i64 %t0, %t1
+  %t3 = mul i64 %t2, 2
+  %t4 = load i64, i64* %v.a
+  %t5 = load i64, i64* %v.b
+  %t6 = icmp eq i64 %t5, 0
+  br i1 %t6, label %division.fail.1, label %division.ok.0
+division.fail.1:
+  call void @baisl.division.fail(i64 3, i64 26)
+  unreachable
+division.ok.0:
+  %t7 = icmp eq i64 %t5, -1
+  %t8 = select i1 %t7, i64 1, i64 %t5
+  %t9 = sdiv i64 %t4, %t8
+  %t10 = sub i64 0, %t4
+  %t11 = select i1 %t7, i64 %t10, i64 %t9
+  %t12 = srem i64 %t11, 3
+  %t13 = sub i64 %t3, %t12
+  %t14 = load i64, i64* %v.a
+  %t15 = sub i64 0, %t14
+  %t16 = add i64 %t13, %t15
+  ret i64 %t16
+}
+
+define i64 @baisl_main() {
+entry:
+  %t0 = call i64 @baisl_calc(i64 7, i64 2)
+  %t1 = add i64 %t0, 2
+  ret i64 %t1
+}
+
+define i32 @main() {
+entry:
+  %result = call i64 @baisl_main()
+  %exitcode = trunc i64 %result to i32
+  ret i32 %exitcode
+}
+
+@baisl.true = private unnamed_addr constant [4 x i8] c"true"
+@baisl.false = private unnamed_addr constant [5 x i8] c"false"
+@baisl.newline = private unnamed_addr constant [1 x i8] c"\0A"
+@baisl.format.int = private unnamed_addr constant [5 x i8] c"%lld\00"
+@baisl.format.index = private unnamed_addr constant [54 x i8] c"Index %lld out of range for length %lld at %lld:%lld\0A\00"
+@baisl.format.division = private unnamed_addr constant [31 x i8] c"Division by zero at %lld:%lld\0A\00"
+
+declare i8* @malloc(i64)
+declare void @llvm.memcpy.p0i8.p0i8.i64(i8*, i8*, i64, i1)
+declare i64 @write(i32, i8*, i64)
+declare i32 @snprintf(i8*, i64, i8*, ...)
+declare void @exit(i32)
+
+define private %baisl.string @baisl.concat(%baisl.string %a, %baisl.string %b) {
+entry:
+  %a.len = extractvalue %baisl.string %a, 0
+  %a.data = extractvalue %baisl.string %a, 1
+  %b.len = extractvalue %baisl.string %b, 0
+  %b.data = extractvalue %baisl.string %b, 1
+  %len = add i64 %a.len, %b.len
+  %data = call i8* @malloc(i64 %len)
+  call void @llvm.memcpy.p0i8.p0i8.i64(i8* %data, i8* %a.data, i64 %a.len, i1 false)
+  %tail = getelementptr i8, i8* %data, i64 %a.len
+  call void @llvm.memcpy.p0i8.p0i8.i64(i8* %tail, i8* %b.data, i64 %b.len, i1 false)
+  %partial = insertvalue %baisl.string undef, i64 %len, 0
+  %result = insertvalue %baisl.string %partial, i8* %data, 1
+  ret %baisl.string %result
+}
+
+define private void @baisl.print.string(%baisl.string %value, i1 %newline) {
+entry:
+  %len = extractvalue %baisl.string %value, 0
+  %data = extractvalue %baisl.string %value, 1
+  call i64 @write(i32 1, i8* %data, i64 %len)
+  br i1 %newline, label %print.newline, label %done
+print.newline:
+  call i64 @write(i32 1, i8* getelementptr inbounds ([1 x i8], [1 x i8]* @baisl.newline, i64 0, i64 0), i64 1)
+  br label %done
+done:
+  ret void
+}
+
+define private void @baisl.print.int(i64 %value, i1 %newline) {
+entry:
+  %buffer = alloca [21 x i8]
+  %data = getelementptr inbounds [21 x i8], [21 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [5 x i8], [5 x i8]* @baisl.format.int, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 21, i8* %format, i64 %value)
+  %len = sext i32 %written to i64
+  %partial = insertvalue %baisl.string undef, i64 %len, 0
+  %text = insertvalue %baisl.string %partial, i8* %data, 1
+  call void @baisl.print.string(%baisl.string %text, i1 %newline)
+  ret void
+}
+
+define private void @baisl.print.bool(i1 %value, i1 %newline) {
+entry:
+  %true = insertvalue %baisl.string { i64 4, i8* undef }, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @baisl.true, i64 0, i64 0), 1
+  %false = insertvalue %baisl.string { i64 5, i8* undef }, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @baisl.false, i64 0, i64 0), 1
+  %text = select i1 %value, %baisl.string %true, %baisl.string %false
+  call void @baisl.print.string(%baisl.string %text, i1 %newline)
+  ret void
+}
+
+define private void @baisl.index.fail(i64 %index, i64 %len, i64 %line, i64 %column) {
+entry:
+  %buffer = alloca [128 x i8]
+  %data = getelementptr inbounds [128 x i8], [128 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [54 x i8], [54 x i8]* @baisl.format.index, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 128, i8* %format, i64 %index, i64 %len, i64 %line, i64 %column)
+  %size = sext i32 %written to i64
+  call i64 @write(i32 2, i8* %data, i64 %size)
+  call void @exit(i32 1)
+  unreachable
+}
+
+define private void @baisl.division.fail(i64 %line, i64 %column) {
+entry:
+  %buffer = alloca [64 x i8]
+  %data = getelementptr inbounds [64 x i8], [64 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [31 x i8], [31 x i8]* @baisl.format.division, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 64, i8* %format, i64 %line, i64 %column)
+  %size = sext i32 %written to i64
+  call i64 @write(i32 2, i8* %data, i64 %size)
+  call void @exit(i32 1)
+  unreachable
+}
@@ -0,0 +1,450 @@
+; ModuleID = 'baisl'
+source_filename = "baisl"
+
+%baisl.string = type { i64, i8* }
+
+%baisl_Team = type { %baisl.string, { i64, i64* } }
+
+define i64 @baisl_sum({ i64, i64* } %p.xs) {
+entry:
+  %v.xs = alloca { i64, i64* }
+  store { i64, i64* } %p.xs, { i64, i64* }* %v.xs
+  %v.total = alloca i64
+  %v.i = alloca i64
+  %v.i_end = alloca i64
+  store i64 0, i64* %v.total
+  store i64 0, i64* %v.i
+  %t0 = load { i64, i64* }, { i64, i64* }* %v.xs
+  %t1 = extractvalue { i64, i64* } %t0, 0
+  store i64 %t1, i64* %v.i_end
+  br label %cond.0
+cond.0:
+  %t2 = load i64, i64* %v.i
+  %t3 = load i64, i64* %v.i_end
+  %t4 = icmp slt i64 %t2, %t3
+  br i1 %t4, label %body.1, label %end.3
+body.1:
+  %t5 = load i64, i64* %v.total
+  %t6 = load { i64, i64* }, { i64, i64* }* %v.xs
+  %t7 = load i64, i64* %v.i
+  %t8 = extractvalue { i64, i64* } %t6, 0
+  %t9 = icmp ult i64 %t7, %t8
+  br i1 %t9, label %index.ok.4, label %index.fail.5
+index.fail.5:
+  call void @baisl.index.fail(i64 %t7, i64 %t8, i64 6, i64 23)
+  unreachable
+index.ok.4:
+  %t10 = extractvalue { i64, i64* } %t6, 1
+  %t11 = getelementptr i64, i64* %t10, i64 %t7
+  %t12 = load i64, i64* %t11
+  %t13 = add i64 %t5, %t12
+  store i64 %t13, i64* %v.total
+  br label %step.2
+step.2:
+  %t14 = load i64, i64* %v.i
+  %t15 = add i64 %t14, 1
+  store i64 %t15, i64* %v.i
+  br label %cond.0
+end.3:
+  %t16 = load i64, i64* %v.total
+  ret i64 %t16
+}
+
+define %baisl.string @baisl_best({ i64, %baisl_Team* } %p.teams) {
+entry:
+  %v.teams = alloca { i64, %baisl_Team* }
+  store { i64, %baisl_Team* } %p.teams, { i64, %baisl_Team* }* %v.teams
+  %v.winner = alloca %baisl_Team
+  %v.i = alloca i64
+  %v.i_end = alloca i64
+  %t0 = load { i64, %baisl_Team* }, { i64, %baisl_Team* }* %v.teams
+  %t1 = extractvalue { i64, %baisl_Team* } %t0, 0
+  %t2 = icmp ult i64 0, %t1
+  br i1 %t2, label %index.ok.0, label %index.fail.1
+index.fail.1:
+  call void @baisl.index.fail(i64 0, i64 %t1, i64 12, i64 21)
+  unreachable
+index.ok.0:
+  %t3 = extractvalue { i64, %baisl_Team* } %t0, 1
+  %t4 = getelementptr %baisl_Team, %baisl_Team* %t3, i64 0
+  %t5 = load %baisl_Team, %baisl_Team* %t4
+  store %baisl_Team %t5, %baisl_Team* %v.winner
+  store i64 1, i64* %v.i
+  %t6 = load { i64, %baisl_Team* }, { i64, %baisl_Team* }* %v.teams
+  %t7 = extractvalue { i64, %baisl_Team* } %t6, 0
+  store i64 %t7, i64* %v.i_end
+  br label %cond.2
+cond.2:
+  %t8 = load i64, i64* %v.i
+  %t9 = load i64, i64* %v.i_end
+  %t10 = icmp slt i64 %t8, %t9
+  br i1 %t10, label %body.3, label %end.5
+body.3:
+  %t11 = load { i64, %baisl_Team* }, { i64, %baisl_Team* }* %v.teams
+  %t12 = load i64, i64* %v.i
+  %t13 = extractvalue { i64, %baisl_Team* } %t11, 0
+  %t14 = icmp ult i64 %t12, %t13
+  br i1 %t14, label %index.ok.6, label %index.fail.7
+index.fail.7:
+  call void @baisl.index.fail(i64 %t12, i64 %t13, i64 14, i64 17)
+  unreachable
+index.ok.6:
+  %t15 = extractvalue { i64, %baisl_Team* } %t11, 1
+  %t16 = getelementptr %baisl_Team, %baisl_Team* %t15, i64 %t12
+  %t17 = load %baisl_Team, %baisl_Team* %t16
+  %t18 = extractvalue %baisl_Team %t17, 1
+  %t19 = call i64 @baisl_sum({ i64, i64* } %t18)
+  %t20 = load %baisl_Team, %baisl_Team* %v.winner
+  %t21 = extractvalue %baisl_Team %t20, 1
+  %t22 = call i64 @baisl_sum({ i64, i64* } %t21)
+  %t23 = icmp sgt i64 %t19, %t22
+  br i1 %t23, label %then.8, label %end.9
+then.8:
+  %t24 = load { i64, %baisl_Team* }, { i64, %baisl_Team* }* %v.teams
+  %t25 = load i64, i64* %v.i
+  %t26 = extractvalue { i64, %baisl_Team* } %t24, 0
+  %t27 = icmp ult i64 %t25, %t26
+  br i1 %t27, label %index.ok.10, label %index.fail.11
+index.fail.11:
+  call void @baisl.index.fail(i64 %t25, i64 %t26, i64 15, i64 21)
+  unreachable
+index.ok.10:
+  %t28 = extractvalue { i64, %baisl_Team* } %t24, 1
+  %t29 = getelementptr %baisl_Team, %baisl_Team* %t28, i64 %t25
+  %t30 = load %baisl_Team, %baisl_Team* %t29
+  store %baisl_Team %t30, %baisl_Team* %v.winner
+  br label %end.9
+end.9:
+  br label %step.4
+step.4:
+  %t31 = load i64, i64* %v.i
+  %t32 = add i64 %t31, 1
+  store i64 %t32, i64* %v.i
+  br label %cond.2
+end.5:
+  %t33 = load %baisl_Team, %baisl_Team* %v.winner
+  %t34 = extractvalue %baisl_Team %t33, 0
+  ret %baisl.string %t34
+}
+
+define i64 @baisl_main() {
+entry:
+  %v.primes = alloca { i64, i64* }
+  %v.firstTwo = alloca { i64, i64* }
+  %v.grid = alloca { i64, { i64, i64* }* }
+  %v.teams = alloca { i64, %baisl_Team* }
+  %t0 = getelementptr i64, i64* null, i64 4
+  %t1 = ptrtoint i64* %t0 to i64
+  %t2 = call i8* @malloc(i64 %t1)
+  %t3 = bitcast i8* %t2 to i64*
+  %t4 = getelementptr i64, i64* %t3, i64 0
+  store i64 2, i64* %t4
+  %t5 = getelementptr i64, i64* %t3, i64 1
+  store i64 3, i64* %t5
+  %t6 = getelementptr i64, i64* %t3, i64 2
+  store i64 5, i64* %t6
+  %t7 = getelementptr i64, i64* %t3, i64 3
+  store i64 7, i64* %t7
+  %t8 = insertvalue { i64, i64* } undef, i64 4, 0
+  %t9 = insertvalue { i64, i64* } %t8, i64* %t3, 1
+  store { i64, i64* } %t9, { i64, i64* }* %v.primes
+  %t10 = load { i64, i64* }, { i64, i64* }* %v.primes
+  %t11 = extractvalue { i64, i64* } %t10, 0
+  call void @baisl.print.int(i64 %t11, i1 true)
+  %t12 = load { i64, i64* }, { i64, i64* }* %v.primes
+  %t13 = call i64 @baisl_sum({ i64, i64* } %t12)
+  call void @baisl.print.int(i64 %t13, i1 true)
+  %t14 = getelementptr i64, i64* null, i64 2
+  %t15 = ptrtoint i64* %t14 to i64
+  %t16 = call i8* @malloc(i64 %t15)
+  %t17 = bitcast i8* %t16 to i64*
+  %t18 = load { i64, i64* }, { i64, i64* }* %v.primes
+  %t19 = extractvalue { i64, i64* } %t18, 0
+  %t20 = icmp ult i64 0, %t19
+  br i1 %t20, label %index.ok.0, label %index.fail.1
+index.fail.1:
+  call void @baisl.index.fail(i64 0, i64 %t19, i64 26, i64 32)
+  unreachable
+index.ok.0:
+  %t21 = extractvalue { i64, i64* } %t18, 1
+  %t22 = getelementptr i64, i64* %t21, i64 0
+  %t23 = load i64, i64* %t22
+  %t24 = getelementptr i64, i64* %t17, i64 0
+  store i64 %t23, i64* %t24
+  %t25 = load { i64, i64* }, { i64, i64* }* %v.primes
+  %t26 = extractvalue { i64, i64* } %t25, 0
+  %t27 = icmp ult i64 1, %t26
+  br i1 %t27, label %index.ok.2, label %index.fail.3
+index.fail.3:
+  call void @baisl.index.fail(i64 1, i64 %t26, i64 26, i64 43)
+  unreachable
+index.ok.2:
+  %t28 = extractvalue { i64, i64* } %t25, 1
+  %t29 = getelementptr i64, i64* %t28, i64 1
+  %t30 = load i64, i64* %t29
+  %t31 = getelementptr i64, i64* %t17, i64 1
+  store i64 %t30, i64* %t31
+  %t32 = insertvalue { i64, i64* } undef, i64 2, 0
+  %t33 = insertvalue { i64, i64* } %t32, i64* %t17, 1
+  store { i64, i64* } %t33, { i64, i64* }* %v.firstTwo
+  %t34 = load { i64, i64* }, { i64, i64* }* %v.firstTwo
+  %t35 = call i64 @baisl_sum({ i64, i64* } %t34)
+  call void @baisl.print.int(i64 %t35, i1 true)
+  %t36 = getelementptr { i64, i64* }, { i64, i64* }* null, i64 2
+  %t37 = ptrtoint { i64, i64* }* %t36 to i64
+  %t38 = call i8* @malloc(i64 %t37)
+  %t39 = bitcast i8* %t38 to { i64, i64* }*
+  %t40 = getelementptr i64, i64* null, i64 3
+  %t41 = ptrtoint i64* %t40 to i64
+  %t42 = call i8* @malloc(i64 %t41)
+  %t43 = bitcast i8* %t42 to i64*
+  %t44 = getelementptr i64, i64* %t43, i64 0
+  store i64 1, i64* %t44
+  %t45 = getelementptr i64, i64* %t43, i64 1
+  store i64 2, i64* %t45
+  %t46 = getelementptr i64, i64* %t43, i64 2
+  store i64 3, i64* %t46
+  %t47 = insertvalue { i64, i64* } undef, i64 3, 0
+  %t48 = insertvalue { i64, i64* } %t47, i64* %t43, 1
+  %t49 = getelementptr { i64, i64* }, { i64, i64* }* %t39, i64 0
+  store { i64, i64* } %t48, { i64, i64* }* %t49
+  %t50 = getelementptr i64, i64* null, i64 3
+  %t51 = ptrtoint i64* %t50 to i64
+  %t52 = call i8* @malloc(i64 %t51)
+  %t53 = bitcast i8* %t52 to i64*
+  %t54 = getelementptr i64, i64* %t53, i64 0
+  store i64 4, i64* %t54
+  %t55 = getelementptr i64, i64* %t53, i64 1
+  store i64 5, i64* %t55
+  %t56 = getelementptr i64, i64* %t53, i64 2
+  store i64 6, i64* %t56
+  %t57 = insertvalue { i64, i64* } undef, i64 3, 0
+  %t58 = insertvalue { i64, i64* } %t57, i64* %t53, 1
+  %t59 = getelementptr { i64, i64* }, { i64, i64* }* %t39, i64 1
+  store { i64, i64* } %t58, { i64, i64* }* %t59
+  %t60 = insertvalue { i64, { i64, i64* }* } undef, i64 2, 0
+  %t61 = insertvalue { i64, { i64, i64* }* } %t60, { i64, i64* }* %t39, 1
+  store { i64, { i64, i64* }* } %t61, { i64, { i64, i64* }* }* %v.grid
+  %t62 = load { i64, { i64, i64* }* }, { i64, { i64, i64* }* }* %v.grid
+  %t63 = extractvalue { i64, { i64, i64* }* } %t62, 0
+  %t64 = icmp ult i64 1, %t63
+  br i1 %t64, label %index.ok.4, label %index.fail.5
+index.fail.5:
+  call void @baisl.index.fail(i64 1, i64 %t63, i64 30, i64 15)
+  unreachable
+index.ok.4:
+  %t65 = extractvalue { i64, { i64, i64* }* } %t62, 1
+  %t66 = getelementptr { i64, i64* }, { i64, i64* }* %t65, i64 1
+  %t67 = load { i64, i64* }, { i64, i64* }* %t66
+  %t68 = extractvalue { i64, i64* } %t67, 0
+  %t69 = icmp ult i64 2, %t68
+  br i1 %t69, label %index.ok.6, label %index.fail.7
+index.fail.7:
+  call void @baisl.index.fail(i64 2, i64 %t68, i64 30, i64 18)
+  unreachable
+index.ok.6:
+  %t70 = extractvalue { i64, i64* } %t67, 1
+  %t71 = getelementptr i64, i64* %t70, i64 2
+  %t72 = load i64, i64* %t71
+  %t73 = load { i64, { i64, i64* }* }, { i64, { i64, i64* }* }* %v.grid
+  %t74 = extractvalue { i64, { i64, i64* }* } %t73, 0
+  %t75 = icmp ult i64 0, %t74
+  br i1 %t75, label %index.ok.8, label %index.fail.9
+index.fail.9:
+  call void @baisl.index.fail(i64 0, i64 %t74, i64 30, i64 28)
+  unreachable
+index.ok.8:
+  %t76 = extractvalue { i64, { i64, i64* }* } %t73, 1
+  %t77 = getelementptr { i64, i64* }, { i64, i64* }* %t76, i64 0
+  %t78 = load { i64, i64* }, { i64, i64* }* %t77
+  %t79 = load { i64, { i64, i64* }* }, { i64, { i64, i64* }* }* %v.grid
+  %t80 = extractvalue { i64, { i64, i64* }* } %t79, 0
+  %t81 = icmp ult i64 0, %t80
+  br i1 %t81, label %index.ok.10, label %index.fail.11
+index.fail.11:
+  call void @baisl.index.fail(i64 0, i64 %t80, i64 30, i64 40)
+  unreachable
+index.ok.10:
+  %t82 = extractvalue { i64, { i64, i64* }* } %t79, 1
+  %t83 = getelementptr { i64, i64* }, { i64, i64* }* %t82, i64 0
+  %t84 = load { i64, i64* }, { i64, i64* }* %t83
+  %t85 = extractvalue { i64, i64* } %t84, 0
+  %t86 = sub i64 %t85, 1
+  %t87 = extractvalue { i64, i64* } %t78, 0
+  %t88 = icmp ult i64 %t86, %t87
+  br i1 %t88, label %index.ok.12, label %index.fail.13
+index.fail.13:
+  call void @baisl.index.fail(i64 %t86, i64 %t87, i64 30, i64 31)
+  unreachable
+index.ok.12:
+  %t89 = extractvalue { i64, i64* } %t78, 1
+  %t90 = getelementptr i64, i64* %t89, i64 %t86
+  %t91 = load i64, i64* %t90
+  %t92 = sub i64 %t72, %t91
+  call void @baisl.print.int(i64 %t92, i1 true)
+  %t93 = getelementptr %baisl_Team, %baisl_Team* null, i64 2
+  %t94 = ptrtoint %baisl_Team* %t93 to i64
+  %t95 = call i8* @malloc(i64 %t94)
+  %t96 = bitcast i8* %t95 to %baisl_Team*
+  %t97 = insertvalue %baisl_Team undef, %baisl.string { i64 3, i8* getelementptr inbounds ([3 x i8], [3 x i8]* @str.0, i64 0, i64 0) }, 0
+  %t98 = getelementptr i64, i64* null, i64 2
+  %t99 = ptrtoint i64* %t98 to i64
+  %t100 = call i8* @malloc(i64 %t99)
+  %t101 = bitcast i8* %t100 to i64*
+  %t102 = getelementptr i64, i64* %t101, i64 0
+  store i64 3, i64* %t102
+  %t103 = getelementptr i64, i64* %t101, i64 1
+  store i64 4, i64* %t103
+  %t104 = insertvalue { i64, i64* } undef, i64 2, 0
+  %t105 = insertvalue { i64, i64* } %t104, i64* %t101, 1
+  %t106 = insertvalue %baisl_Team %t97, { i64, i64* } %t105, 1
+  %t107 = getelementptr %baisl_Team, %baisl_Team* %t96, i64 0
+  store %baisl_Team %t106, %baisl_Team* %t107
+  %t108 = insertvalue %baisl_Team undef, %baisl.string { i64 4, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @str.1, i64 0, i64 0) }, 0
+  %t109 = getelementptr i64, i64* null, i64 3
+  %t110 = ptrtoint i64* %t109 to i64
+  %t111 = call i8* @malloc(i64 %t110)
+  %t112 = bitcast i8* %t111 to i64*
+  %t113 = getelementptr i64, i64* %t112, i64 0
+  store i64 5, i64* %t113
+  %t114 = getelementptr i64, i64* %t112, i64 1
+  store i64 1, i64* %t114
+  %t115 = getelementptr i64, i64* %t112, i64 2
+  store i64 2, i64* %t115
+  %t116 = insertvalue { i64, i64* } undef, i64 3, 0
+  %t117 = insertvalue { i64, i64* } %t116, i64* %t112, 1
+  %t118 = insertvalue %baisl_Team %t108, { i64, i64* } %t117, 1
+  %t119 = getelementptr %baisl_Team, %baisl_Team* %t96, i64 1
+  store %baisl_Team %t118, %baisl_Team* %t119
+  %t120 = insertvalue { i64, %baisl_Team* } undef, i64 2, 0
+  %t121 = insertvalue { i64, %baisl_Team* } %t120, %baisl_Team* %t96, 1
+  store { i64, %baisl_Team* } %t121, { i64, %baisl_Team* }* %v.teams
+  %t122 = load { i64, %baisl_Team* }, { i64, %baisl_Team* }* %v.teams
+  %t123 = call %baisl.string @baisl_best({ i64, %baisl_Team* } %t122)
+  call void @baisl.print.string(%baisl.string %t123, i1 true)
+  %t124 = getelementptr %baisl.string, %baisl.string* null, i64 3
+  %t125 = ptrtoint %baisl.string* %t124 to i64
+  %t126 = call i8* @malloc(i64 %t125)
+  %t127 = bitcast i8* %t126 to %baisl.string*
+  %t128 = getelementptr %baisl.string, %baisl.string* %t127, i64 0
+  store %baisl.string { i64 1, i8* getelementptr inbounds ([1 x i8], [1 x i8]* @str.2, i64 0, i64 0) }, %baisl.string* %t128
+  %t129 = getelementptr %baisl.string, %baisl.string* %t127, i64 1
+  store %baisl.string { i64 1, i8* getelementptr inbounds ([1 x i8], [1 x i8]* @str.3, i64 0, i64 0) }, %baisl.string* %t129
+  %t130 = getelementptr %baisl.string, %baisl.string* %t127, i64 2
+  store %baisl.string { i64 1, i8* getelementptr inbounds ([1 x i8], [1 x i8]* @str.4, i64 0, i64 0) }, %baisl.string* %t130
+  %t131 = insertvalue { i64, %baisl.string* } undef, i64 3, 0
+  %t132 = insertvalue { i64, %baisl.string* } %t131, %baisl.string* %t127, 1
+  %t133 = extractvalue { i64, %baisl.string* } %t132, 0
+  %t134 = icmp ult i64 2, %t133
+  br i1 %t134, label %index.ok.14, label %index.fail.15
+index.fail.15:
+  call void @baisl.index.fail(i64 2, i64 %t133, i64 34, i64 26)
+  unreachable
+index.ok.14:
+  %t135 = extractvalue { i64, %baisl.string* } %t132, 1
+  %t136 = getelementptr %baisl.string, %baisl.string* %t135, i64 2
+  %t137 = load %baisl.string, %baisl.string* %t136
+  call void @baisl.print.string(%baisl.string %t137, i1 true)
+  ret i64 0
+}
+
+define i32 @main() {
+entry:
+  %result = call i64 @baisl_main()
+  %exitcode = trunc i64 %result to i32
+  ret i32 %exitcode
+}
+
+@str.0 = private unnamed_addr constant [3 x i8] c"red"
+@str.1 = private unnamed_addr constant [4 x i8] c"blue"
+@str.2 = private unnamed_addr constant [1 x i8] c"a"
+@str.3 = private unnamed_addr constant [1 x i8] c"b"
+@str.4 = private unnamed_addr constant [1 x i8] c"c"
+
+@baisl.true = private unnamed_addr constant [4 x i8] c"true"
+@baisl.false = private unnamed_addr constant [5 x i8] c"false"
+@baisl.newline = private unnamed_addr constant [1 x i8] c"\0A"
+@baisl.format.int = private unnamed_addr constant [5 x i8] c"%lld\00"
+@baisl.format.index = private unnamed_addr constant [54 x i8] c"Index %lld out of range for length %lld at %lld:%lld\0A\00"
+@baisl.format.division = private unnamed_addr constant [31 x i8] c"Division by zero at %lld:%lld\0A\00"
+
+declare i8* @malloc(i64)
+declare void @llvm.memcpy.p0i8.p0i8.i64(i8*, i8*, i64, i1)
+declare i64 @write(i32, i8*, i64)
+declare i32 @snprintf(i8*, i64, i8*, ...)
+declare void @exit(i32)
+
+define private %baisl.string @baisl.concat(%baisl.string %a, %baisl.string %b) {
+entry:
+  %a.len = extractvalue %baisl.string %a, 0
+  %a.data = extractvalue %baisl.string %a, 1
+  %b.len = extractvalue %baisl.string %b, 0
+  %b.data = extractvalue %baisl.string %b, 1
+  %len = add i64 %a.len, %b.len
+  %data = call i8* @malloc(i64 %len)
+  call void @llvm.memcpy.p0i8.p0i8.i64(i8* %data, i8* %a.data, i64 %a.len, i1 false)
+  %tail = getelementptr i8, i8* %data, i64 %a.len
+  call void @llvm.memcpy.p0i8.p0i8.i64(i8* %tail, i8* %b.data, i64 %b.len, i1 false)
+  %partial = insertvalue %baisl.string undef, i64 %len, 0
+  %result = insertvalue %baisl.string %partial, i8* %data, 1
+  ret %baisl.string %result
+}
+
+define private void @baisl.print.string(%baisl.string %value, i1 %newline) {
+entry:
+  %len = extractvalue %baisl.string %value, 0
+  %data = extractvalue %baisl.string %value, 1
+  call i64 @write(i32 1, i8* %data, i64 %len)
+  br i1 %newline, label %print.newline, label %done
+print.newline:
+  call i64 @write(i32 1, i8* getelementptr inbounds ([1 x i8], [1 x i8]* @baisl.newline, i64 0, i64 0), i64 1)
+  br label %done
+done:
+  ret void
+}
+
+define private void @baisl.print.int(i64 %value, i1 %newline) {
+entry:
+  %buffer = alloca [21 x i8]
+  %data = getelementptr inbounds [21 x i8], [21 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [5 x i8], [5 x i8]* @baisl.format.int, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 21, i8* %format, i64 %value)
+  %len = sext i32 %written to i64
+  %partial = insertvalue %baisl.string undef, i64 %len, 0
+  %text = insertvalue %baisl.string %partial, i8* %data, 1
+  call void @baisl.print.string(%baisl.string %text, i1 %newline)
+  ret void
+}
+
+define private void @baisl.print.bool(i1 %value, i1 %newline) {
+entry:
+  %true = insertvalue %baisl.string { i64 4, i8* undef }, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @baisl.true, i64 0, i64 0), 1
+  %false = insertvalue %baisl.string { i64 5, i8* undef }, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @baisl.false, i64 0, i64 0), 1
+  %text = select i1 %value, %baisl.string %true, %baisl.string %false
+  call void @baisl.print.string(%baisl.string %text, i1 %newline)
+  ret void
+}
+
+define private void @baisl.index.fail(i64 %index, i64 %len, i64 %line, i64 %column) {
+entry:
+  %buffer = alloca [128 x i8]
+  %data = getelementptr inbounds [128 x i8], [128 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [54 x i8], [54 x i8]* @baisl.format.index, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 128, i8* %format, i64 %index, i64 %len, i64 %line, i64 %column)
+  %size = sext i32 %written to i64
+  call i64 @write(i32 2, i8* %data, i64 %size)
+  call void @exit(i32 1)
+  unreachable
+}
+
+define private void @baisl.division.fail(i64 %line, i64 %column) {
+entry:
+  %buffer = alloca [64 x i8]
+  %data = getelementptr inbounds [64 x i8], [64 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [31 x i8], [31 x i8]* @baisl.format.division, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 64, i8* %format, i64 %line, i64 %column)
+  %size = sext i32 %written to i64
+  call i64 @write(i32 2, i8* %data, i64 %size)
+  call void @exit(i32 1)
+  unreachable
+}
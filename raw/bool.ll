@@ -0,0 +1,239 @@
+; ModuleID = 'baisl'
+source_filename = "baisl"
+
+%baisl.string = type { i64, i8* }
+
+define i1 @baisl_inRange(i64 %p.a, i64 %p.min, i64 %p.max) {
+entry:
+  %v.a = alloca i64
+  store i64 %p.a, i64* %v.a
+  %v.min = alloca i64
+  store i64 %p.min, i64* %v.min
+  %v.max = alloca i64
+  store i64 %p.max, i64* %v.max
+  %t0 = load i64, i64* %v.a
+  %t1 = load i64, i64* %v.min
+  %t2 = icmp sge i64 %t0, %t1
+  br i1 %t2, label %rhs.0, label %end.1
+rhs.0:
+  %t3 = load i64, i64* %v.a
+  %t4 = load i64, i64* %v.max
+  %t5 = icmp slt i64 %t3, %t4
+  br label %end.1
+end.1:
+  %t6 = phi i1 [ false, %entry ], [ %t5, %rhs.0 ]
+  ret i1 %t6
+}
+
+define i1 @baisl_xor(i1 %p.a, i1 %p.b) {
+entry:
+  %v.a = alloca i1
+  store i1 %p.a, i1* %v.a
+  %v.b = alloca i1
+  store i1 %p.b, i1* %v.b
+  %t0 = load i1, i1* %v.a
+  %t1 = load i1, i1* %v.b
+  %t2 = icmp ne i1 %t0, %t1
+  ret i1 %t2
+}
+
+define i64 @baisl_main() {
+entry:
+  %v.n = alloca i64
+  %v.d = alloca i64
+  %v.found = alloca i1
+  %v.i = alloca i64
+  %v.i_end = alloca i64
+  store i64 0, i64* %v.n
+  store i64 0, i64* %v.d
+  %t0 = load i64, i64* %v.d
+  %t1 = icmp ne i64 %t0, 0
+  br i1 %t1, label %rhs.0, label %end.1
+rhs.0:
+  %t2 = load i64, i64* %v.d
+  %t3 = icmp eq i64 %t2, 0
+  br i1 %t3, label %division.fail.3, label %division.ok.2
+division.fail.3:
+  call void @baisl.division.fail(i64 13, i64 19)
+  unreachable
+division.ok.2:
+  %t4 = icmp eq i64 %t2, -1
+  %t5 = select i1 %t4, i64 1, i64 %t2
+  %t6 = sdiv i64 10, %t5
+  %t7 = sub i64 0, 10
+  %t8 = select i1 %t4, i64 %t7, i64 %t6
+  %t9 = icmp sgt i64 %t8, 1
+  br label %end.1
+end.1:
+  %t10 = phi i1 [ false, %entry ], [ %t9, %division.ok.2 ]
+  br i1 %t10, label %end.5, label %rhs.4
+rhs.4:
+  %t11 = load i64, i64* %v.d
+  %t12 = call i1 @baisl_inRange(i64 %t11, i64 0, i64 5)
+  %t13 = xor i1 %t12, true
+  br label %end.5
+end.5:
+  %t14 = phi i1 [ true, %end.1 ], [ %t13, %rhs.4 ]
+  br i1 %t14, label %then.6, label %end.7
+then.6:
+  store i64 100, i64* %v.n
+  br label %end.7
+end.7:
+  store i1 false, i1* %v.found
+  store i64 0, i64* %v.i
+  store i64 10, i64* %v.i_end
+  br label %cond.8
+cond.8:
+  %t15 = load i64, i64* %v.i
+  %t16 = load i64, i64* %v.i_end
+  %t17 = icmp slt i64 %t15, %t16
+  br i1 %t17, label %body.9, label %end.11
+body.9:
+  %t18 = load i64, i64* %v.i
+  %t19 = call i1 @baisl_inRange(i64 %t18, i64 3, i64 6)
+  br i1 %t19, label %end.13, label %rhs.12
+rhs.12:
+  %t20 = load i64, i64* %v.i
+  %t21 = icmp eq i64 %t20, 8
+  br label %end.13
+end.13:
+  %t22 = phi i1 [ true, %body.9 ], [ %t21, %rhs.12 ]
+  br i1 %t22, label %then.14, label %end.15
+then.14:
+  %t23 = load i64, i64* %v.n
+  %t24 = load i64, i64* %v.i
+  %t25 = add i64 %t23, %t24
+  store i64 %t25, i64* %v.n
+  br label %end.15
+end.15:
+  %t26 = load i1, i1* %v.found
+  br i1 %t26, label %end.17, label %rhs.16
+rhs.16:
+  %t27 = load i64, i64* %v.i
+  %t28 = icmp eq i64 %t27, 4
+  %t29 = icmp eq i1 true, false
+  %t30 = call i1 @baisl_xor(i1 %t28, i1 %t29)
+  br label %end.17
+end.17:
+  %t31 = phi i1 [ true, %end.15 ], [ %t30, %rhs.16 ]
+  store i1 %t31, i1* %v.found
+  br label %step.10
+step.10:
+  %t32 = load i64, i64* %v.i
+  %t33 = add i64 %t32, 1
+  store i64 %t33, i64* %v.i
+  br label %cond.8
+end.11:
+  %t34 = load i1, i1* %v.found
+  br i1 %t34, label %rhs.18, label %end.19
+rhs.18:
+  %t35 = call i1 @baisl_xor(i1 true, i1 true)
+  %t36 = xor i1 %t35, true
+  br label %end.19
+end.19:
+  %t37 = phi i1 [ false, %end.11 ], [ %t36, %rhs.18 ]
+  br i1 %t37, label %then.20, label %end.21
+then.20:
+  %t38 = load i64, i64* %v.n
+  %t39 = add i64 %t38, 1
+  store i64 %t39, i64* %v.n
+  br label %end.21
+end.21:
+  %t40 = load i64, i64* %v.n
+  ret i64 %t40
+}
+
+define i32 @main() {
+entry:
+  %result = call i64 @baisl_main()
+  %exitcode = trunc i64 %result to i32
+  ret i32 %exitcode
+}
+
+@baisl.true = private unnamed_addr constant [4 x i8] c"true"
+@baisl.false = private unnamed_addr constant [5 x i8] c"false"
+@baisl.newline = private unnamed_addr constant [1 x i8] c"\0A"
+@baisl.format.int = private unnamed_addr constant [5 x i8] c"%lld\00"
+@baisl.format.index = private unnamed_addr constant [54 x i8] c"Index %lld out of range for length %lld at %lld:%lld\0A\00"
+@baisl.format.division = private unnamed_addr constant [31 x i8] c"Division by zero at %lld:%lld\0A\00"
+
+declare i8* @malloc(i64)
+declare void @llvm.memcpy.p0i8.p0i8.i64(i8*, i8*, i64, i1)
+declare i64 @write(i32, i8*, i64)
+declare i32 @snprintf(i8*, i64, i8*, ...)
+declare void @exit(i32)
+
+define private %baisl.string @baisl.concat(%baisl.string %a, %baisl.string %b) {
+entry:
+  %a.len = extractvalue %baisl.string %a, 0
+  %a.data = extractvalue %baisl.string %a, 1
+  %b.len = extractvalue %baisl.string %b, 0
+  %b.data = extractvalue %baisl.string %b, 1
+  %len = add i64 %a.len, %b.len
+  %data = call i8* @malloc(i64 %len)
+  call void @llvm.memcpy.p0i8.p0i8.i64(i8* %data, i8* %a.data, i64 %a.len, i1 false)
+  %tail = getelementptr i8, i8* %data, i64 %a.len
+  call void @llvm.memcpy.p0i8.p0i8.i64(i8* %tail, i8* %b.data, i64 %b.len, i1 false)
+  %partial = insertvalue %baisl.string undef, i64 %len, 0
+  %result = insertvalue %baisl.string %partial, i8* %data, 1
+  ret %baisl.string %result
+}
+
+define private void @baisl.print.string(%baisl.string %value, i1 %newline) {
+entry:
+  %len = extractvalue %baisl.string %value, 0
+  %data = extractvalue %baisl.string %value, 1
+  call i64 @write(i32 1, i8* %data, i64 %len)
+  br i1 %newline, label %print.newline, label %done
+print.newline:
+  call i64 @write(i32 1, i8* getelementptr inbounds ([1 x i8], [1 x i8]* @baisl.newline, i64 0, i64 0), i64 1)
+  br label %done
+done:
+  ret void
+}
+
+define private void @baisl.print.int(i64 %value, i1 %newline) {
+entry:
+  %buffer = alloca [21 x i8]
+  %data = getelementptr inbounds [21 x i8], [21 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [5 x i8], [5 x i8]* @baisl.format.int, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 21, i8* %format, i64 %value)
+  %len = sext i32 %written to i64
+  %partial = insertvalue %baisl.string undef, i64 %len, 0
+  %text = insertvalue %baisl.string %partial, i8* %data, 1
+  call void @baisl.print.string(%baisl.string %text, i1 %newline)
+  ret void
+}
+
+define private void @baisl.print.bool(i1 %value, i1 %newline) {
+entry:
+  %true = insertvalue %baisl.string { i64 4, i8* undef }, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @baisl.true, i64 0, i64 0), 1
+  %false = insertvalue %baisl.string { i64 5, i8* undef }, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @baisl.false, i64 0, i64 0), 1
+  %text = select i1 %value, %baisl.string %true, %baisl.string %false
+  call void @baisl.print.string(%baisl.string %text, i1 %newline)
+  ret void
+}
+
+define private void @baisl.index.fail(i64 %index, i64 %len, i64 %line, i64 %column) {
+entry:
+  %buffer = alloca [128 x i8]
+  %data = getelementptr inbounds [128 x i8], [128 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [54 x i8], [54 x i8]* @baisl.format.index, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 128, i8* %format, i64 %index, i64 %len, i64 %line, i64 %column)
+  %size = sext i32 %written to i64
+  call i64 @write(i32 2, i8* %data, i64 %size)
+  call void @exit(i32 1)
+  unreachable
+}
+
+define private void @baisl.division.fail(i64 %line, i64 %column) {
+entry:
+  %buffer = alloca [64 x i8]
+  %data = getelementptr inbounds [64 x i8], [64 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [31 x i8], [31 x i8]* @baisl.format.division, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 64, i8* %format, i64 %line, i64 %column)
+  %size = sext i32 %written to i64
+  call i64 @write(i32 2, i8* %data, i64 %size)
+  call void @exit(i32 1)
+  unreachable
+}
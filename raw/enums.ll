@@ -0,0 +1,318 @@
+; ModuleID = 'baisl'
+source_filename = "baisl"
+
+%baisl.string = type { i64, i8* }
+
+%baisl_Shape = type { i64, i64, i64, i64 }
+%baisl_Light = type { i64 }
+
+define i64 @baisl_area(%baisl_Shape %p.shape) {
+entry:
+  %v.shape = alloca %baisl_Shape
+  store %baisl_Shape %p.shape, %baisl_Shape* %v.shape
+  %v.match = alloca %baisl_Shape
+  %v.r = alloca i64
+  %v.w = alloca i64
+  %v.h = alloca i64
+  %t0 = load %baisl_Shape, %baisl_Shape* %v.shape
+  %t1 = extractvalue %baisl_Shape %t0, 0
+  switch i64 %t1, label %arm.2 [ i64 0, label %arm.0 i64 1, label %arm.1 ]
+arm.0:
+  %t2 = extractvalue %baisl_Shape %t0, 1
+  store i64 %t2, i64* %v.r
+  %t3 = load i64, i64* %v.r
+  %t4 = mul i64 3, %t3
+  %t5 = load i64, i64* %v.r
+  %t6 = mul i64 %t4, %t5
+  br label %end.3
+arm.1:
+  %t7 = extractvalue %baisl_Shape %t0, 2
+  store i64 %t7, i64* %v.w
+  %t8 = extractvalue %baisl_Shape %t0, 3
+  store i64 %t8, i64* %v.h
+  %t9 = load i64, i64* %v.w
+  %t10 = load i64, i64* %v.h
+  %t11 = mul i64 %t9, %t10
+  br label %end.3
+arm.2:
+  br label %end.3
+end.3:
+  %t12 = phi i64 [ %t6, %arm.0 ], [ %t11, %arm.1 ], [ 0, %arm.2 ]
+  ret i64 %t12
+}
+
+define %baisl_Light @baisl_next(%baisl_Light %p.light) {
+entry:
+  %v.light = alloca %baisl_Light
+  store %baisl_Light %p.light, %baisl_Light* %v.light
+  %v.match = alloca %baisl_Light
+  %t0 = load %baisl_Light, %baisl_Light* %v.light
+  %t1 = extractvalue %baisl_Light %t0, 0
+  switch i64 %t1, label %arm.2 [ i64 0, label %arm.0 i64 2, label %arm.1 ]
+arm.0:
+  %t2 = insertvalue %baisl_Light undef, i64 2, 0
+  br label %end.3
+arm.1:
+  %t3 = insertvalue %baisl_Light undef, i64 1, 0
+  br label %end.3
+arm.2:
+  %t4 = insertvalue %baisl_Light undef, i64 0, 0
+  br label %end.3
+end.3:
+  %t5 = phi %baisl_Light [ %t2, %arm.0 ], [ %t3, %arm.1 ], [ %t4, %arm.2 ]
+  ret %baisl_Light %t5
+}
+
+define void @baisl_describe(%baisl_Shape %p.shape) {
+entry:
+  %v.shape = alloca %baisl_Shape
+  store %baisl_Shape %p.shape, %baisl_Shape* %v.shape
+  %v.match = alloca %baisl_Shape
+  %t0 = load %baisl_Shape, %baisl_Shape* %v.shape
+  %t1 = extractvalue %baisl_Shape %t0, 0
+  switch i64 %t1, label %arm.1 [ i64 0, label %arm.0 ]
+arm.0:
+  call void @baisl.print.string(%baisl.string { i64 5, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @str.0, i64 0, i64 0) }, i1 true)
+  br label %end.2
+arm.1:
+  call void @baisl.print.string(%baisl.string { i64 9, i8* getelementptr inbounds ([9 x i8], [9 x i8]* @str.1, i64 0, i64 0) }, i1 true)
+  br label %end.2
+end.2:
+  ret void
+}
+
+define i64 @baisl_main() {
+entry:
+  %v.shapes = alloca { i64, %baisl_Shape* }
+  %v.total = alloca i64
+  %v.i = alloca i64
+  %v.i_end = alloca i64
+  %v.light = alloca %baisl_Light
+  %v1.i = alloca i64
+  %v1.i_end = alloca i64
+  %v.match = alloca %baisl_Light
+  %v1.match = alloca %baisl_Shape
+  %v.w = alloca i64
+  %v.width = alloca i64
+  %t0 = getelementptr %baisl_Shape, %baisl_Shape* null, i64 3
+  %t1 = ptrtoint %baisl_Shape* %t0 to i64
+  %t2 = call i8* @malloc(i64 %t1)
+  %t3 = bitcast i8* %t2 to %baisl_Shape*
+  %t4 = insertvalue %baisl_Shape undef, i64 0, 0
+  %t5 = insertvalue %baisl_Shape %t4, i64 2, 1
+  %t6 = getelementptr %baisl_Shape, %baisl_Shape* %t3, i64 0
+  store %baisl_Shape %t5, %baisl_Shape* %t6
+  %t7 = insertvalue %baisl_Shape undef, i64 1, 0
+  %t8 = insertvalue %baisl_Shape %t7, i64 3, 2
+  %t9 = insertvalue %baisl_Shape %t8, i64 4, 3
+  %t10 = getelementptr %baisl_Shape, %baisl_Shape* %t3, i64 1
+  store %baisl_Shape %t9, %baisl_Shape* %t10
+  %t11 = insertvalue %baisl_Shape undef, i64 2, 0
+  %t12 = getelementptr %baisl_Shape, %baisl_Shape* %t3, i64 2
+  store %baisl_Shape %t11, %baisl_Shape* %t12
+  %t13 = insertvalue { i64, %baisl_Shape* } undef, i64 3, 0
+  %t14 = insertvalue { i64, %baisl_Shape* } %t13, %baisl_Shape* %t3, 1
+  store { i64, %baisl_Shape* } %t14, { i64, %baisl_Shape* }* %v.shapes
+  store i64 0, i64* %v.total
+  store i64 0, i64* %v.i
+  %t15 = load { i64, %baisl_Shape* }, { i64, %baisl_Shape* }* %v.shapes
+  %t16 = extractvalue { i64, %baisl_Shape* } %t15, 0
+  store i64 %t16, i64* %v.i_end
+  br label %cond.0
+cond.0:
+  %t17 = load i64, i64* %v.i
+  %t18 = load i64, i64* %v.i_end
+  %t19 = icmp slt i64 %t17, %t18
+  br i1 %t19, label %body.1, label %end.3
+body.1:
+  %t20 = load i64, i64* %v.total
+  %t21 = load { i64, %baisl_Shape* }, { i64, %baisl_Shape* }* %v.shapes
+  %t22 = load i64, i64* %v.i
+  %t23 = extractvalue { i64, %baisl_Shape* } %t21, 0
+  %t24 = icmp ult i64 %t22, %t23
+  br i1 %t24, label %index.ok.4, label %index.fail.5
+index.fail.5:
+  call void @baisl.index.fail(i64 %t22, i64 %t23, i64 40, i64 32)
+  unreachable
+index.ok.4:
+  %t25 = extractvalue { i64, %baisl_Shape* } %t21, 1
+  %t26 = getelementptr %baisl_Shape, %baisl_Shape* %t25, i64 %t22
+  %t27 = load %baisl_Shape, %baisl_Shape* %t26
+  %t28 = call i64 @baisl_area(%baisl_Shape %t27)
+  %t29 = add i64 %t20, %t28
+  store i64 %t29, i64* %v.total
+  %t30 = load { i64, %baisl_Shape* }, { i64, %baisl_Shape* }* %v.shapes
+  %t31 = load i64, i64* %v.i
+  %t32 = extractvalue { i64, %baisl_Shape* } %t30, 0
+  %t33 = icmp ult i64 %t31, %t32
+  br i1 %t33, label %index.ok.6, label %index.fail.7
+index.fail.7:
+  call void @baisl.index.fail(i64 %t31, i64 %t32, i64 41, i64 20)
+  unreachable
+index.ok.6:
+  %t34 = extractvalue { i64, %baisl_Shape* } %t30, 1
+  %t35 = getelementptr %baisl_Shape, %baisl_Shape* %t34, i64 %t31
+  %t36 = load %baisl_Shape, %baisl_Shape* %t35
+  call void @baisl_describe(%baisl_Shape %t36)
+  br label %step.2
+step.2:
+  %t37 = load i64, i64* %v.i
+  %t38 = add i64 %t37, 1
+  store i64 %t38, i64* %v.i
+  br label %cond.0
+end.3:
+  %t39 = load i64, i64* %v.total
+  call void @baisl.print.int(i64 %t39, i1 true)
+  %t40 = insertvalue %baisl_Light undef, i64 0, 0
+  store %baisl_Light %t40, %baisl_Light* %v.light
+  store i64 0, i64* %v1.i
+  store i64 4, i64* %v1.i_end
+  br label %cond.8
+cond.8:
+  %t41 = load i64, i64* %v1.i
+  %t42 = load i64, i64* %v1.i_end
+  %t43 = icmp slt i64 %t41, %t42
+  br i1 %t43, label %body.9, label %end.11
+body.9:
+  %t44 = load %baisl_Light, %baisl_Light* %v.light
+  %t45 = call %baisl_Light @baisl_next(%baisl_Light %t44)
+  store %baisl_Light %t45, %baisl_Light* %v.light
+  %t46 = load %baisl_Light, %baisl_Light* %v.light
+  %t47 = extractvalue %baisl_Light %t46, 0
+  switch i64 %t47, label %arm.14 [ i64 0, label %arm.12 i64 1, label %arm.13 ]
+arm.12:
+  br label %end.15
+arm.13:
+  br label %end.15
+arm.14:
+  br label %end.15
+end.15:
+  %t48 = phi %baisl.string [ { i64 3, i8* getelementptr inbounds ([3 x i8], [3 x i8]* @str.2, i64 0, i64 0) }, %arm.12 ], [ { i64 6, i8* getelementptr inbounds ([6 x i8], [6 x i8]* @str.3, i64 0, i64 0) }, %arm.13 ], [ { i64 5, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @str.4, i64 0, i64 0) }, %arm.14 ]
+  call void @baisl.print.string(%baisl.string %t48, i1 true)
+  br label %step.10
+step.10:
+  %t49 = load i64, i64* %v1.i
+  %t50 = add i64 %t49, 1
+  store i64 %t50, i64* %v1.i
+  br label %cond.8
+end.11:
+  %t51 = insertvalue %baisl_Shape undef, i64 1, 0
+  %t52 = insertvalue %baisl_Shape %t51, i64 5, 2
+  %t53 = insertvalue %baisl_Shape %t52, i64 6, 3
+  %t54 = extractvalue %baisl_Shape %t53, 0
+  switch i64 %t54, label %arm.17 [ i64 1, label %arm.16 ]
+arm.16:
+  %t55 = extractvalue %baisl_Shape %t53, 2
+  store i64 %t55, i64* %v.w
+  %t56 = load i64, i64* %v.w
+  br label %end.18
+arm.17:
+  br label %end.18
+end.18:
+  %t57 = phi i64 [ %t56, %arm.16 ], [ 0, %arm.17 ]
+  store i64 %t57, i64* %v.width
+  %t58 = load i64, i64* %v.width
+  call void @baisl.print.int(i64 %t58, i1 true)
+  ret i64 0
+}
+
+define i32 @main() {
+entry:
+  %result = call i64 @baisl_main()
+  %exitcode = trunc i64 %result to i32
+  ret i32 %exitcode
+}
+
+@str.0 = private unnamed_addr constant [5 x i8] c"round"
+@str.1 = private unnamed_addr constant [9 x i8] c"not round"
+@str.2 = private unnamed_addr constant [3 x i8] c"red"
+@str.3 = private unnamed_addr constant [6 x i8] c"yellow"
+@str.4 = private unnamed_addr constant [5 x i8] c"green"
+
+@baisl.true = private unnamed_addr constant [4 x i8] c"true"
+@baisl.false = private unnamed_addr constant [5 x i8] c"false"
+@baisl.newline = private unnamed_addr constant [1 x i8] c"\0A"
+@baisl.format.int = private unnamed_addr constant [5 x i8] c"%lld\00"
+@baisl.format.index = private unnamed_addr constant [54 x i8] c"Index %lld out of range for length %lld at %lld:%lld\0A\00"
+@baisl.format.division = private unnamed_addr constant [31 x i8] c"Division by zero at %lld:%lld\0A\00"
+
+declare i8* @malloc(i64)
+declare void @llvm.memcpy.p0i8.p0i8.i64(i8*, i8*, i64, i1)
+declare i64 @write(i32, i8*, i64)
+declare i32 @snprintf(i8*, i64, i8*, ...)
+declare void @exit(i32)
+
+define private %baisl.string @baisl.concat(%baisl.string %a, %baisl.string %b) {
+entry:
+  %a.len = extractvalue %baisl.string %a, 0
+  %a.data = extractvalue %baisl.string %a, 1
+  %b.len = extractvalue %baisl.string %b, 0
+  %b.data = extractvalue %baisl.string %b, 1
+  %len = add i64 %a.len, %b.len
+  %data = call i8* @malloc(i64 %len)
+  call void @llvm.memcpy.p0i8.p0i8.i64(i8* %data, i8* %a.data, i64 %a.len, i1 false)
+  %tail = getelementptr i8, i8* %data, i64 %a.len
+  call void @llvm.memcpy.p0i8.p0i8.i64(i8* %tail, i8* %b.data, i64 %b.len, i1 false)
+  %partial = insertvalue %baisl.string undef, i64 %len, 0
+  %result = insertvalue %baisl.string %partial, i8* %data, 1
+  ret %baisl.string %result
+}
+
+define private void @baisl.print.string(%baisl.string %value, i1 %newline) {
+entry:
+  %len = extractvalue %baisl.string %value, 0
+  %data = extractvalue %baisl.string %value, 1
+  call i64 @write(i32 1, i8* %data, i64 %len)
+  br i1 %newline, label %print.newline, label %done
+print.newline:
+  call i64 @write(i32 1, i8* getelementptr inbounds ([1 x i8], [1 x i8]* @baisl.newline, i64 0, i64 0), i64 1)
+  br label %done
+done:
+  ret void
+}
+
+define private void @baisl.print.int(i64 %value, i1 %newline) {
+entry:
+  %buffer = alloca [21 x i8]
+  %data = getelementptr inbounds [21 x i8], [21 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [5 x i8], [5 x i8]* @baisl.format.int, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 21, i8* %format, i64 %value)
+  %len = sext i32 %written to i64
+  %partial = insertvalue %baisl.string undef, i64 %len, 0
+  %text = insertvalue %baisl.string %partial, i8* %data, 1
+  call void @baisl.print.string(%baisl.string %text, i1 %newline)
+  ret void
+}
+
+define private void @baisl.print.bool(i1 %value, i1 %newline) {
+entry:
+  %true = insertvalue %baisl.string { i64 4, i8* undef }, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @baisl.true, i64 0, i64 0), 1
+  %false = insertvalue %baisl.string { i64 5, i8* undef }, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @baisl.false, i64 0, i64 0), 1
+  %text = select i1 %value, %baisl.string %true, %baisl.string %false
+  call void @baisl.print.string(%baisl.string %text, i1 %newline)
+  ret void
+}
+
+define private void @baisl.index.fail(i64 %index, i64 %len, i64 %line, i64 %column) {
+entry:
+  %buffer = alloca [128 x i8]
+  %data = getelementptr inbounds [128 x i8], [128 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [54 x i8], [54 x i8]* @baisl.format.index, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 128, i8* %format, i64 %index, i64 %len, i64 %line, i64 %column)
+  %size = sext i32 %written to i64
+  call i64 @write(i32 2, i8* %data, i64 %size)
+  call void @exit(i32 1)
+  unreachable
+}
+
+define private void @baisl.division.fail(i64 %line, i64 %column) {
+entry:
+  %buffer = alloca [64 x i8]
+  %data = getelementptr inbounds [64 x i8], [64 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [31 x i8], [31 x i8]* @baisl.format.division, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 64, i8* %format, i64 %line, i64 %column)
+  %size = sext i32 %written to i64
+  call i64 @write(i32 2, i8* %data, i64 %size)
+  call void @exit(i32 1)
+  unreachable
+}
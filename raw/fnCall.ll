@@ -0,0 +1,23 @@
+; ModuleID = 'baisl'
+source_filename = "baisl"
+
+define i64 @baisl_returnParam(i64 %p.a) {
+entry:
+  %v.a = alloca i64
+  store i64 %p.a, i64* %v.a
+  %t0 = load i64, i64* %v.a
+  ret i64 %t0
+}
+
+define i64 @baisl_main() {
+entry:
+  %t0 = call i64 @baisl_returnParam(i64 5)
+  ret i64 %t0
+}
+
+define i32 @main() {
+entry:
+  %result = call i64 @baisl_main()
+  %exitcode = trunc i64 %result to i32
+  ret i32 %exitcode
+}
@@ -0,0 +1,332 @@
+; ModuleID = 'baisl'
+source_filename = "baisl"
+
+%baisl.string = type { i64, i8* }
+
+%baisl_Pair = type { i64, i64 }
+
+define i64 @baisl_id_0(i64 %p.x) {
+entry:
+  %v.x = alloca i64
+  store i64 %p.x, i64* %v.x
+  %t0 = load i64, i64* %v.x
+  ret i64 %t0
+}
+
+define %baisl.string @baisl_id_1(%baisl.string %p.x) {
+entry:
+  %v.x = alloca %baisl.string
+  store %baisl.string %p.x, %baisl.string* %v.x
+  %t0 = load %baisl.string, %baisl.string* %v.x
+  ret %baisl.string %t0
+}
+
+define i1 @baisl_first_0({ i64, i1* } %p.xs) {
+entry:
+  %v.xs = alloca { i64, i1* }
+  store { i64, i1* } %p.xs, { i64, i1* }* %v.xs
+  %t0 = load { i64, i1* }, { i64, i1* }* %v.xs
+  %t1 = extractvalue { i64, i1* } %t0, 0
+  %t2 = icmp ult i64 0, %t1
+  br i1 %t2, label %index.ok.0, label %index.fail.1
+index.fail.1:
+  call void @baisl.index.fail(i64 0, i64 %t1, i64 8, i64 12)
+  unreachable
+index.ok.0:
+  %t3 = extractvalue { i64, i1* } %t0, 1
+  %t4 = getelementptr i1, i1* %t3, i64 0
+  %t5 = load i1, i1* %t4
+  ret i1 %t5
+}
+
+define %baisl.string @baisl_first_1({ i64, %baisl.string* } %p.xs) {
+entry:
+  %v.xs = alloca { i64, %baisl.string* }
+  store { i64, %baisl.string* } %p.xs, { i64, %baisl.string* }* %v.xs
+  %t0 = load { i64, %baisl.string* }, { i64, %baisl.string* }* %v.xs
+  %t1 = extractvalue { i64, %baisl.string* } %t0, 0
+  %t2 = icmp ult i64 0, %t1
+  br i1 %t2, label %index.ok.0, label %index.fail.1
+index.fail.1:
+  call void @baisl.index.fail(i64 0, i64 %t1, i64 8, i64 12)
+  unreachable
+index.ok.0:
+  %t3 = extractvalue { i64, %baisl.string* } %t0, 1
+  %t4 = getelementptr %baisl.string, %baisl.string* %t3, i64 0
+  %t5 = load %baisl.string, %baisl.string* %t4
+  ret %baisl.string %t5
+}
+
+define %baisl_Pair @baisl_pick_0(i1 %p.cond, %baisl_Pair %p.a, %baisl_Pair %p.b) {
+entry:
+  %v.cond = alloca i1
+  store i1 %p.cond, i1* %v.cond
+  %v.a = alloca %baisl_Pair
+  store %baisl_Pair %p.a, %baisl_Pair* %v.a
+  %v.b = alloca %baisl_Pair
+  store %baisl_Pair %p.b, %baisl_Pair* %v.b
+  %t0 = load i1, i1* %v.cond
+  br i1 %t0, label %then.0, label %end.1
+then.0:
+  %t1 = load %baisl_Pair, %baisl_Pair* %v.a
+  ret %baisl_Pair %t1
+end.1:
+  %t2 = load %baisl_Pair, %baisl_Pair* %v.b
+  ret %baisl_Pair %t2
+}
+
+define i64 @baisl_count_0({ i64, i64* } %p.xs, i64 %p.i) {
+entry:
+  %v.xs = alloca { i64, i64* }
+  store { i64, i64* } %p.xs, { i64, i64* }* %v.xs
+  %v.i = alloca i64
+  store i64 %p.i, i64* %v.i
+  %t0 = load i64, i64* %v.i
+  %t1 = load { i64, i64* }, { i64, i64* }* %v.xs
+  %t2 = extractvalue { i64, i64* } %t1, 0
+  %t3 = icmp sge i64 %t0, %t2
+  br i1 %t3, label %then.0, label %end.1
+then.0:
+  ret i64 0
+end.1:
+  %t4 = load { i64, i64* }, { i64, i64* }* %v.xs
+  %t5 = load i64, i64* %v.i
+  %t6 = add i64 %t5, 1
+  %t7 = call i64 @baisl_count_0({ i64, i64* } %t4, i64 %t6)
+  %t8 = add i64 1, %t7
+  ret i64 %t8
+}
+
+define i64 @baisl_count_1({ i64, %baisl.string* } %p.xs, i64 %p.i) {
+entry:
+  %v.xs = alloca { i64, %baisl.string* }
+  store { i64, %baisl.string* } %p.xs, { i64, %baisl.string* }* %v.xs
+  %v.i = alloca i64
+  store i64 %p.i, i64* %v.i
+  %t0 = load i64, i64* %v.i
+  %t1 = load { i64, %baisl.string* }, { i64, %baisl.string* }* %v.xs
+  %t2 = extractvalue { i64, %baisl.string* } %t1, 0
+  %t3 = icmp sge i64 %t0, %t2
+  br i1 %t3, label %then.0, label %end.1
+then.0:
+  ret i64 0
+end.1:
+  %t4 = load { i64, %baisl.string* }, { i64, %baisl.string* }* %v.xs
+  %t5 = load i64, i64* %v.i
+  %t6 = add i64 %t5, 1
+  %t7 = call i64 @baisl_count_1({ i64, %baisl.string* } %t4, i64 %t6)
+  %t8 = add i64 1, %t7
+  ret i64 %t8
+}
+
+define { i64, i64* } @baisl_wrap_0(i64 %p.x) {
+entry:
+  %v.x = alloca i64
+  store i64 %p.x, i64* %v.x
+  %v.wrapped = alloca { i64, i64* }
+  %t0 = getelementptr i64, i64* null, i64 1
+  %t1 = ptrtoint i64* %t0 to i64
+  %t2 = call i8* @malloc(i64 %t1)
+  %t3 = bitcast i8* %t2 to i64*
+  %t4 = load i64, i64* %v.x
+  %t5 = getelementptr i64, i64* %t3, i64 0
+  store i64 %t4, i64* %t5
+  %t6 = insertvalue { i64, i64* } undef, i64 1, 0
+  %t7 = insertvalue { i64, i64* } %t6, i64* %t3, 1
+  store { i64, i64* } %t7, { i64, i64* }* %v.wrapped
+  %t8 = load { i64, i64* }, { i64, i64* }* %v.wrapped
+  ret { i64, i64* } %t8
+}
+
+define i64 @baisl_first_2({ i64, i64* } %p.xs) {
+entry:
+  %v.xs = alloca { i64, i64* }
+  store { i64, i64* } %p.xs, { i64, i64* }* %v.xs
+  %t0 = load { i64, i64* }, { i64, i64* }* %v.xs
+  %t1 = extractvalue { i64, i64* } %t0, 0
+  %t2 = icmp ult i64 0, %t1
+  br i1 %t2, label %index.ok.0, label %index.fail.1
+index.fail.1:
+  call void @baisl.index.fail(i64 0, i64 %t1, i64 8, i64 12)
+  unreachable
+index.ok.0:
+  %t3 = extractvalue { i64, i64* } %t0, 1
+  %t4 = getelementptr i64, i64* %t3, i64 0
+  %t5 = load i64, i64* %t4
+  ret i64 %t5
+}
+
+define i64 @baisl_main() {
+entry:
+  %v.p = alloca %baisl_Pair
+  %t0 = call i64 @baisl_id_0(i64 41)
+  %t1 = add i64 %t0, 1
+  call void @baisl.print.int(i64 %t1, i1 true)
+  %t2 = call %baisl.string @baisl_id_1(%baisl.string { i64 7, i8* getelementptr inbounds ([7 x i8], [7 x i8]* @str.0, i64 0, i64 0) })
+  call void @baisl.print.string(%baisl.string %t2, i1 true)
+  %t3 = getelementptr i1, i1* null, i64 2
+  %t4 = ptrtoint i1* %t3 to i64
+  %t5 = call i8* @malloc(i64 %t4)
+  %t6 = bitcast i8* %t5 to i1*
+  %t7 = getelementptr i1, i1* %t6, i64 0
+  store i1 true, i1* %t7
+  %t8 = getelementptr i1, i1* %t6, i64 1
+  store i1 false, i1* %t8
+  %t9 = insertvalue { i64, i1* } undef, i64 2, 0
+  %t10 = insertvalue { i64, i1* } %t9, i1* %t6, 1
+  %t11 = call i1 @baisl_first_0({ i64, i1* } %t10)
+  call void @baisl.print.bool(i1 %t11, i1 true)
+  %t12 = getelementptr %baisl.string, %baisl.string* null, i64 2
+  %t13 = ptrtoint %baisl.string* %t12 to i64
+  %t14 = call i8* @malloc(i64 %t13)
+  %t15 = bitcast i8* %t14 to %baisl.string*
+  %t16 = getelementptr %baisl.string, %baisl.string* %t15, i64 0
+  store %baisl.string { i64 1, i8* getelementptr inbounds ([1 x i8], [1 x i8]* @str.1, i64 0, i64 0) }, %baisl.string* %t16
+  %t17 = getelementptr %baisl.string, %baisl.string* %t15, i64 1
+  store %baisl.string { i64 1, i8* getelementptr inbounds ([1 x i8], [1 x i8]* @str.2, i64 0, i64 0) }, %baisl.string* %t17
+  %t18 = insertvalue { i64, %baisl.string* } undef, i64 2, 0
+  %t19 = insertvalue { i64, %baisl.string* } %t18, %baisl.string* %t15, 1
+  %t20 = call %baisl.string @baisl_first_1({ i64, %baisl.string* } %t19)
+  call void @baisl.print.string(%baisl.string %t20, i1 true)
+  %t21 = insertvalue %baisl_Pair undef, i64 1, 0
+  %t22 = insertvalue %baisl_Pair %t21, i64 2, 1
+  %t23 = insertvalue %baisl_Pair undef, i64 3, 0
+  %t24 = insertvalue %baisl_Pair %t23, i64 4, 1
+  %t25 = call %baisl_Pair @baisl_pick_0(i1 false, %baisl_Pair %t22, %baisl_Pair %t24)
+  store %baisl_Pair %t25, %baisl_Pair* %v.p
+  %t26 = load %baisl_Pair, %baisl_Pair* %v.p
+  %t27 = extractvalue %baisl_Pair %t26, 0
+  %t28 = load %baisl_Pair, %baisl_Pair* %v.p
+  %t29 = extractvalue %baisl_Pair %t28, 1
+  %t30 = add i64 %t27, %t29
+  call void @baisl.print.int(i64 %t30, i1 true)
+  %t31 = getelementptr i64, i64* null, i64 3
+  %t32 = ptrtoint i64* %t31 to i64
+  %t33 = call i8* @malloc(i64 %t32)
+  %t34 = bitcast i8* %t33 to i64*
+  %t35 = getelementptr i64, i64* %t34, i64 0
+  store i64 1, i64* %t35
+  %t36 = getelementptr i64, i64* %t34, i64 1
+  store i64 2, i64* %t36
+  %t37 = getelementptr i64, i64* %t34, i64 2
+  store i64 3, i64* %t37
+  %t38 = insertvalue { i64, i64* } undef, i64 3, 0
+  %t39 = insertvalue { i64, i64* } %t38, i64* %t34, 1
+  %t40 = call i64 @baisl_count_0({ i64, i64* } %t39, i64 0)
+  %t41 = getelementptr %baisl.string, %baisl.string* null, i64 1
+  %t42 = ptrtoint %baisl.string* %t41 to i64
+  %t43 = call i8* @malloc(i64 %t42)
+  %t44 = bitcast i8* %t43 to %baisl.string*
+  %t45 = getelementptr %baisl.string, %baisl.string* %t44, i64 0
+  store %baisl.string { i64 1, i8* getelementptr inbounds ([1 x i8], [1 x i8]* @str.3, i64 0, i64 0) }, %baisl.string* %t45
+  %t46 = insertvalue { i64, %baisl.string* } undef, i64 1, 0
+  %t47 = insertvalue { i64, %baisl.string* } %t46, %baisl.string* %t44, 1
+  %t48 = call i64 @baisl_count_1({ i64, %baisl.string* } %t47, i64 0)
+  %t49 = add i64 %t40, %t48
+  call void @baisl.print.int(i64 %t49, i1 true)
+  %t50 = call i64 @baisl_id_0(i64 9)
+  %t51 = call { i64, i64* } @baisl_wrap_0(i64 %t50)
+  %t52 = call i64 @baisl_first_2({ i64, i64* } %t51)
+  call void @baisl.print.int(i64 %t52, i1 true)
+  ret i64 0
+}
+
+define i32 @main() {
+entry:
+  %result = call i64 @baisl_main()
+  %exitcode = trunc i64 %result to i32
+  ret i32 %exitcode
+}
+
+@str.0 = private unnamed_addr constant [7 x i8] c"generic"
+@str.1 = private unnamed_addr constant [1 x i8] c"a"
+@str.2 = private unnamed_addr constant [1 x i8] c"b"
+@str.3 = private unnamed_addr constant [1 x i8] c"x"
+
+@baisl.true = private unnamed_addr constant [4 x i8] c"true"
+@baisl.false = private unnamed_addr constant [5 x i8] c"false"
+@baisl.newline = private unnamed_addr constant [1 x i8] c"\0A"
+@baisl.format.int = private unnamed_addr constant [5 x i8] c"%lld\00"
+@baisl.format.index = private unnamed_addr constant [54 x i8] c"Index %lld out of range for length %lld at %lld:%lld\0A\00"
+@baisl.format.division = private unnamed_addr constant [31 x i8] c"Division by zero at %lld:%lld\0A\00"
+
+declare i8* @malloc(i64)
+declare void @llvm.memcpy.p0i8.p0i8.i64(i8*, i8*, i64, i1)
+declare i64 @write(i32, i8*, i64)
+declare i32 @snprintf(i8*, i64, i8*, ...)
+declare void @exit(i32)
+
+define private %baisl.string @baisl.concat(%baisl.string %a, %baisl.string %b) {
+entry:
+  %a.len = extractvalue %baisl.string %a, 0
+  %a.data = extractvalue %baisl.string %a, 1
+  %b.len = extractvalue %baisl.string %b, 0
+  %b.data = extractvalue %baisl.string %b, 1
+  %len = add i64 %a.len, %b.len
+  %data = call i8* @malloc(i64 %len)
+  call void @llvm.memcpy.p0i8.p0i8.i64(i8* %data, i8* %a.data, i64 %a.len, i1 false)
+  %tail = getelementptr i8, i8* %data, i64 %a.len
+  call void @llvm.memcpy.p0i8.p0i8.i64(i8* %tail, i8* %b.data, i64 %b.len, i1 false)
+  %partial = insertvalue %baisl.string undef, i64 %len, 0
+  %result = insertvalue %baisl.string %partial, i8* %data, 1
+  ret %baisl.string %result
+}
+
+define private void @baisl.print.string(%baisl.string %value, i1 %newline) {
+entry:
+  %len = extractvalue %baisl.string %value, 0
+  %data = extractvalue %baisl.string %value, 1
+  call i64 @write(i32 1, i8* %data, i64 %len)
+  br i1 %newline, label %print.newline, label %done
+print.newline:
+  call i64 @write(i32 1, i8* getelementptr inbounds ([1 x i8], [1 x i8]* @baisl.newline, i64 0, i64 0), i64 1)
+  br label %done
+done:
+  ret void
+}
+
+define private void @baisl.print.int(i64 %value, i1 %newline) {
+entry:
+  %buffer = alloca [21 x i8]
+  %data = getelementptr inbounds [21 x i8], [21 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [5 x i8], [5 x i8]* @baisl.format.int, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 21, i8* %format, i64 %value)
+  %len = sext i32 %written to i64
+  %partial = insertvalue %baisl.string undef, i64 %len, 0
+  %text = insertvalue %baisl.string %partial, i8* %data, 1
+  call void @baisl.print.string(%baisl.string %text, i1 %newline)
+  ret void
+}
+
+define private void @baisl.print.bool(i1 %value, i1 %newline) {
+entry:
+  %true = insertvalue %baisl.string { i64 4, i8* undef }, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @baisl.true, i64 0, i64 0), 1
+  %false = insertvalue %baisl.string { i64 5, i8* undef }, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @baisl.false, i64 0, i64 0), 1
+  %text = select i1 %value, %baisl.string %true, %baisl.string %false
+  call void @baisl.print.string(%baisl.string %text, i1 %newline)
+  ret void
+}
+
+define private void @baisl.index.fail(i64 %index, i64 %len, i64 %line, i64 %column) {
+entry:
+  %buffer = alloca [128 x i8]
+  %data = getelementptr inbounds [128 x i8], [128 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [54 x i8], [54 x i8]* @baisl.format.index, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 128, i8* %format, i64 %index, i64 %len, i64 %line, i64 %column)
+  %size = sext i32 %written to i64
+  call i64 @write(i32 2, i8* %data, i64 %size)
+  call void @exit(i32 1)
+  unreachable
+}
+
+define private void @baisl.division.fail(i64 %line, i64 %column) {
+entry:
+  %buffer = alloca [64 x i8]
+  %data = getelementptr inbounds [64 x i8], [64 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [31 x i8], [31 x i8]* @baisl.format.division, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 64, i8* %format, i64 %line, i64 %column)
+  %size = sext i32 %written to i64
+  call i64 @write(i32 2, i8* %data, i64 %size)
+  call void @exit(i32 1)
+  unreachable
+}
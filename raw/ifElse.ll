@@ -0,0 +1,98 @@
+; ModuleID = 'baisl'
+source_filename = "baisl"
+
+define i64 @baisl_sign(i64 %p.a) {
+entry:
+  %v.a = alloca i64
+  store i64 %p.a, i64* %v.a
+  %t0 = load i64, i64* %v.a
+  %t1 = icmp slt i64 %t0, 0
+  br i1 %t1, label %then.0, label %else.1
+then.0:
+  %t2 = sub i64 0, 1
+  ret i64 %t2
+else.1:
+  %t3 = load i64, i64* %v.a
+  %t4 = icmp eq i64 %t3, 0
+  br i1 %t4, label %then.3, label %else.4
+then.3:
+  ret i64 0
+else.4:
+  ret i64 1
+}
+
+define i64 @baisl_clamp(i64 %p.a, i64 %p.max) {
+entry:
+  %v.a = alloca i64
+  store i64 %p.a, i64* %v.a
+  %v.max = alloca i64
+  store i64 %p.max, i64* %v.max
+  %t0 = load i64, i64* %v.a
+  %t1 = load i64, i64* %v.max
+  %t2 = icmp sgt i64 %t0, %t1
+  br i1 %t2, label %then.0, label %end.1
+then.0:
+  %t3 = load i64, i64* %v.max
+  store i64 %t3, i64* %v.a
+  br label %end.1
+end.1:
+  %t4 = load i64, i64* %v.a
+  ret i64 %t4
+}
+
+define void @baisl_early(i64 %p.a) {
+entry:
+  %v.a = alloca i64
+  store i64 %p.a, i64* %v.a
+  %v.b = alloca i64
+  %t0 = load i64, i64* %v.a
+  %t1 = icmp ne i64 %t0, 0
+  br i1 %t1, label %then.0, label %end.1
+then.0:
+  ret void
+end.1:
+  %t2 = load i64, i64* %v.a
+  store i64 %t2, i64* %v.b
+  ret void
+}
+
+define i64 @baisl_main() {
+entry:
+  %v.x = alloca i64
+  %v1.x = alloca i64
+  %v.y = alloca i64
+  store i64 10, i64* %v.x
+  %t0 = load i64, i64* %v.x
+  %t1 = icmp sgt i64 %t0, 5
+  br i1 %t1, label %then.0, label %end.1
+then.0:
+  %t2 = load i64, i64* %v.x
+  %t3 = mul i64 %t2, 2
+  store i64 %t3, i64* %v1.x
+  %t4 = load i64, i64* %v1.x
+  %t5 = add i64 %t4, 1
+  store i64 %t5, i64* %v1.x
+  br label %end.1
+end.1:
+  %t6 = load i64, i64* %v.x
+  %t7 = sub i64 0, %t6
+  %t8 = call i64 @baisl_sign(i64 %t7)
+  store i64 %t8, i64* %v.y
+  %t9 = load i64, i64* %v.x
+  %t10 = call i64 @baisl_clamp(i64 40, i64 30)
+  %t11 = add i64 %t9, %t10
+  %t12 = load i64, i64* %v.y
+  %t13 = mul i64 %t12, 2
+  %t14 = add i64 %t11, %t13
+  %t15 = load i64, i64* %v.x
+  %t16 = call i64 @baisl_sign(i64 %t15)
+  %t17 = add i64 %t14, %t16
+  ret i64 %t17
+}
+
+define i32 @main() {
+entry:
+  %result = call i64 @baisl_main()
+  %exitcode = trunc i64 %result to i32
+  ret i32 %exitcode
+}
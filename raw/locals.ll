@@ -0,0 +1,54 @@
+; ModuleID = 'baisl'
+source_filename = "baisl"
+
+define i64 @baisl_addOne(i64 %p.a) {
+entry:
+  %v.a = alloca i64
+  store i64 %p.a, i64* %v.a
+  %t0 = load i64, i64* %v.a
+  %t1 = add i64 %t0, 1
+  store i64 %t1, i64* %v.a
+  %t2 = load i64, i64* %v.a
+  ret i64 %t2
+}
+
+define i64 @baisl_square(i64 %p.a) {
+entry:
+  %v.a = alloca i64
+  store i64 %p.a, i64* %v.a
+  %v.result = alloca i64
+  %t0 = load i64, i64* %v.a
+  %t1 = load i64, i64* %v.a
+  %t2 = mul i64 %t0, %t1
+  store i64 %t2, i64* %v.result
+  %t3 = load i64, i64* %v.result
+  ret i64 %t3
+}
+
+define i64 @baisl_main() {
+entry:
+  %v.x = alloca i64
+  %v.y = alloca i64
+  %v.square = alloca i64
+  store i64 3, i64* %v.x
+  %t0 = load i64, i64* %v.x
+  %t1 = call i64 @baisl_square(i64 %t0)
+  store i64 %t1, i64* %v.y
+  %t2 = load i64, i64* %v.x
+  %t3 = load i64, i64* %v.y
+  %t4 = add i64 %t2, %t3
+  %t5 = call i64 @baisl_addOne(i64 %t4)
+  store i64 %t5, i64* %v.x
+  store i64 2, i64* %v.square
+  %t6 = load i64, i64* %v.x
+  %t7 = load i64, i64* %v.square
+  %t8 = mul i64 %t6, %t7
+  ret i64 %t8
+}
+
+define i32 @main() {
+entry:
+  %result = call i64 @baisl_main()
+  %exitcode = trunc i64 %result to i32
+  ret i32 %exitcode
+}
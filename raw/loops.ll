@@ -0,0 +1,315 @@
+; ModuleID = 'baisl'
+source_filename = "baisl"
+
+%baisl.string = type { i64, i8* }
+
+define i64 @baisl_sumTo(i64 %p.n) {
+entry:
+  %v.n = alloca i64
+  store i64 %p.n, i64* %v.n
+  %v.total = alloca i64
+  %v.i = alloca i64
+  %v.i_end = alloca i64
+  store i64 0, i64* %v.total
+  store i64 0, i64* %v.i
+  %t0 = load i64, i64* %v.n
+  %t1 = add i64 %t0, 1
+  store i64 %t1, i64* %v.i_end
+  br label %cond.0
+cond.0:
+  %t2 = load i64, i64* %v.i
+  %t3 = load i64, i64* %v.i_end
+  %t4 = icmp slt i64 %t2, %t3
+  br i1 %t4, label %body.1, label %end.3
+body.1:
+  %t5 = load i64, i64* %v.total
+  %t6 = load i64, i64* %v.i
+  %t7 = add i64 %t5, %t6
+  store i64 %t7, i64* %v.total
+  br label %step.2
+step.2:
+  %t8 = load i64, i64* %v.i
+  %t9 = add i64 %t8, 1
+  store i64 %t9, i64* %v.i
+  br label %cond.0
+end.3:
+  %t10 = load i64, i64* %v.total
+  ret i64 %t10
+}
+
+define i64 @baisl_firstMultiple(i64 %p.a, i64 %p.b) {
+entry:
+  %v.a = alloca i64
+  store i64 %p.a, i64* %v.a
+  %v.b = alloca i64
+  store i64 %p.b, i64* %v.b
+  %v.i = alloca i64
+  %t0 = load i64, i64* %v.a
+  store i64 %t0, i64* %v.i
+  br label %cond.0
+cond.0:
+  br i1 true, label %body.1, label %end.2
+body.1:
+  %t1 = load i64, i64* %v.i
+  %t2 = load i64, i64* %v.b
+  %t3 = icmp eq i64 %t2, 0
+  br i1 %t3, label %division.fail.4, label %division.ok.3
+division.fail.4:
+  call void @baisl.division.fail(i64 12, i64 10)
+  unreachable
+division.ok.3:
+  %t4 = icmp eq i64 %t2, -1
+  %t5 = select i1 %t4, i64 1, i64 %t2
+  %t6 = srem i64 %t1, %t5
+  %t7 = select i1 %t4, i64 0, i64 %t6
+  %t8 = icmp eq i64 %t7, 0
+  br i1 %t8, label %then.5, label %end.6
+then.5:
+  br label %end.2
+end.6:
+  %t9 = load i64, i64* %v.i
+  %t10 = add i64 %t9, 1
+  store i64 %t10, i64* %v.i
+  br label %cond.0
+end.2:
+  %t11 = load i64, i64* %v.i
+  ret i64 %t11
+}
+
+define i64 @baisl_sumOdd(i64 %p.n) {
+entry:
+  %v.n = alloca i64
+  store i64 %p.n, i64* %v.n
+  %v.total = alloca i64
+  %v.i = alloca i64
+  %v.i_end = alloca i64
+  store i64 0, i64* %v.total
+  store i64 0, i64* %v.i
+  %t0 = load i64, i64* %v.n
+  store i64 %t0, i64* %v.i_end
+  br label %cond.0
+cond.0:
+  %t1 = load i64, i64* %v.i
+  %t2 = load i64, i64* %v.i_end
+  %t3 = icmp slt i64 %t1, %t2
+  br i1 %t3, label %body.1, label %end.3
+body.1:
+  %t4 = load i64, i64* %v.i
+  %t5 = srem i64 %t4, 2
+  %t6 = icmp eq i64 %t5, 0
+  br i1 %t6, label %then.4, label %end.5
+then.4:
+  br label %step.2
+end.5:
+  %t7 = load i64, i64* %v.total
+  %t8 = load i64, i64* %v.i
+  %t9 = add i64 %t7, %t8
+  store i64 %t9, i64* %v.total
+  br label %step.2
+step.2:
+  %t10 = load i64, i64* %v.i
+  %t11 = add i64 %t10, 1
+  store i64 %t11, i64* %v.i
+  br label %cond.0
+end.3:
+  %t12 = load i64, i64* %v.total
+  ret i64 %t12
+}
+
+define i64 @baisl_nested() {
+entry:
+  %v.count = alloca i64
+  %v.i = alloca i64
+  %v.i_end = alloca i64
+  %v.j = alloca i64
+  %v.j_end = alloca i64
+  store i64 0, i64* %v.count
+  store i64 0, i64* %v.i
+  store i64 4, i64* %v.i_end
+  br label %cond.0
+cond.0:
+  %t0 = load i64, i64* %v.i
+  %t1 = load i64, i64* %v.i_end
+  %t2 = icmp slt i64 %t0, %t1
+  br i1 %t2, label %body.1, label %end.3
+body.1:
+  store i64 0, i64* %v.j
+  store i64 4, i64* %v.j_end
+  br label %cond.4
+cond.4:
+  %t3 = load i64, i64* %v.j
+  %t4 = load i64, i64* %v.j_end
+  %t5 = icmp slt i64 %t3, %t4
+  br i1 %t5, label %body.5, label %end.7
+body.5:
+  %t6 = load i64, i64* %v.j
+  %t7 = load i64, i64* %v.i
+  %t8 = icmp sgt i64 %t6, %t7
+  br i1 %t8, label %then.8, label %end.9
+then.8:
+  br label %end.7
+end.9:
+  %t9 = load i64, i64* %v.count
+  %t10 = add i64 %t9, 1
+  store i64 %t10, i64* %v.count
+  br label %step.6
+step.6:
+  %t11 = load i64, i64* %v.j
+  %t12 = add i64 %t11, 1
+  store i64 %t12, i64* %v.j
+  br label %cond.4
+end.7:
+  br label %step.2
+step.2:
+  %t13 = load i64, i64* %v.i
+  %t14 = add i64 %t13, 1
+  store i64 %t14, i64* %v.i
+  br label %cond.0
+end.3:
+  %t15 = load i64, i64* %v.count
+  ret i64 %t15
+}
+
+define i64 @baisl_onceLimit() {
+entry:
+  %v.n = alloca i64
+  %v.runs = alloca i64
+  %v.i = alloca i64
+  %v.i_end = alloca i64
+  store i64 3, i64* %v.n
+  store i64 0, i64* %v.runs
+  store i64 0, i64* %v.i
+  %t0 = load i64, i64* %v.n
+  store i64 %t0, i64* %v.i_end
+  br label %cond.0
+cond.0:
+  %t1 = load i64, i64* %v.i
+  %t2 = load i64, i64* %v.i_end
+  %t3 = icmp slt i64 %t1, %t2
+  br i1 %t3, label %body.1, label %end.3
+body.1:
+  %t4 = load i64, i64* %v.n
+  %t5 = add i64 %t4, 1
+  store i64 %t5, i64* %v.n
+  %t6 = load i64, i64* %v.runs
+  %t7 = add i64 %t6, 1
+  store i64 %t7, i64* %v.runs
+  br label %step.2
+step.2:
+  %t8 = load i64, i64* %v.i
+  %t9 = add i64 %t8, 1
+  store i64 %t9, i64* %v.i
+  br label %cond.0
+end.3:
+  %t10 = load i64, i64* %v.runs
+  ret i64 %t10
+}
+
+define i64 @baisl_main() {
+entry:
+  %t0 = call i64 @baisl_sumTo(i64 10)
+  %t1 = call i64 @baisl_firstMultiple(i64 10, i64 7)
+  %t2 = add i64 %t0, %t1
+  %t3 = call i64 @baisl_sumOdd(i64 10)
+  %t4 = add i64 %t2, %t3
+  %t5 = call i64 @baisl_nested()
+  %t6 = add i64 %t4, %t5
+  %t7 = call i64 @baisl_onceLimit()
+  %t8 = add i64 %t6, %t7
+  ret i64 %t8
+}
+
+define i32 @main() {
+entry:
+  %result = call i64 @baisl_main()
+  %exitcode = trunc i64 %result to i32
+  ret i32 %exitcode
+}
+
+@baisl.true = private unnamed_addr constant [4 x i8] c"true"
+@baisl.false = private unnamed_addr constant [5 x i8] c"false"
+@baisl.newline = private unnamed_addr constant [1 x i8] c"\0A"
+@baisl.format.int = private unnamed_addr constant [5 x i8] c"%lld\00"
+@baisl.format.index = private unnamed_addr constant [54 x i8] c"Index %lld out of range for length %lld at %lld:%lld\0A\00"
+@baisl.format.division = private unnamed_addr constant [31 x i8] c"Division by zero at %lld:%lld\0A\00"
+
+declare i8* @malloc(i64)
+declare void @llvm.memcpy.p0i8.p0i8.i64(i8*, i8*, i64, i1)
+declare i64 @write(i32, i8*, i64)
+declare i32 @snprintf(i8*, i64, i8*, ...)
+declare void @exit(i32)
+
+define private %baisl.string @baisl.concat(%baisl.string %a, %baisl.string %b) {
+entry:
+  %a.len = extractvalue %baisl.string %a, 0
+  %a.data = extractvalue %baisl.string %a, 1
+  %b.len = extractvalue %baisl.string %b, 0
+  %b.data = extractvalue %baisl.string %b, 1
+  %len = add i64 %a.len, %b.len
+  %data = call i8* @malloc(i64 %len)
+  call void @llvm.memcpy.p0i8.p0i8.i64(i8* %data, i8* %a.data, i64 %a.len, i1 false)
+  %tail = getelementptr i8, i8* %data, i64 %a.len
+  call void @llvm.memcpy.p0i8.p0i8.i64(i8* %tail, i8* %b.data, i64 %b.len, i1 false)
+  %partial = insertvalue %baisl.string undef, i64 %len, 0
+  %result = insertvalue %baisl.string %partial, i8* %data, 1
+  ret %baisl.string %result
+}
+
+define private void @baisl.print.string(%baisl.string %value, i1 %newline) {
+entry:
+  %len = extractvalue %baisl.string %value, 0
+  %data = extractvalue %baisl.string %value, 1
+  call i64 @write(i32 1, i8* %data, i64 %len)
+  br i1 %newline, label %print.newline, label %done
+print.newline:
+  call i64 @write(i32 1, i8* getelementptr inbounds ([1 x i8], [1 x i8]* @baisl.newline, i64 0, i64 0), i64 1)
+  br label %done
+done:
+  ret void
+}
+
+define private void @baisl.print.int(i64 %value, i1 %newline) {
+entry:
+  %buffer = alloca [21 x i8]
+  %data = getelementptr inbounds [21 x i8], [21 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [5 x i8], [5 x i8]* @baisl.format.int, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 21, i8* %format, i64 %value)
+  %len = sext i32 %written to i64
+  %partial = insertvalue %baisl.string undef, i64 %len, 0
+  %text = insertvalue %baisl.string %partial, i8* %data, 1
+  call void @baisl.print.string(%baisl.string %text, i1 %newline)
+  ret void
+}
+
+define private void @baisl.print.bool(i1 %value, i1 %newline) {
+entry:
+  %true = insertvalue %baisl.string { i64 4, i8* undef }, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @baisl.true, i64 0, i64 0), 1
+  %false = insertvalue %baisl.string { i64 5, i8* undef }, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @baisl.false, i64 0, i64 0), 1
+  %text = select i1 %value, %baisl.string %true, %baisl.string %false
+  call void @baisl.print.string(%baisl.string %text, i1 %newline)
+  ret void
+}
+
+define private void @baisl.index.fail(i64 %index, i64 %len, i64 %line, i64 %column) {
+entry:
+  %buffer = alloca [128 x i8]
+  %data = getelementptr inbounds [128 x i8], [128 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [54 x i8], [54 x i8]* @baisl.format.index, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 128, i8* %format, i64 %index, i64 %len, i64 %line, i64 %column)
+  %size = sext i32 %written to i64
+  call i64 @write(i32 2, i8* %data, i64 %size)
+  call void @exit(i32 1)
+  unreachable
+}
+
+define private void @baisl.division.fail(i64 %line, i64 %column) {
+entry:
+  %buffer = alloca [64 x i8]
+  %data = getelementptr inbounds [64 x i8], [64 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [31 x i8], [31 x i8]* @baisl.format.division, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 64, i8* %format, i64 %line, i64 %column)
+  %size = sext i32 %written to i64
+  call i64 @write(i32 2, i8* %data, i64 %size)
+  call void @exit(i32 1)
+  unreachable
+}
@@ -0,0 +1,37 @@
+; ModuleID = 'baisl'
+source_filename = "baisl"
+
+define i64 @baisl_eighth(i64 %p.a, i64 %p.b, i64 %p.c, i64 %p.d, i64 %p.e, i64 %p.f, i64 %p.g, i64 %p.h) {
+entry:
+  %v.a = alloca i64
+  store i64 %p.a, i64* %v.a
+  %v.b = alloca i64
+  store i64 %p.b, i64* %v.b
+  %v.c = alloca i64
+  store i64 %p.c, i64* %v.c
+  %v.d = alloca i64
+  store i64 %p.d, i64* %v.d
+  %v.e = alloca i64
+  store i64 %p.e, i64* %v.e
+  %v.f = alloca i64
+  store i64 %p.f, i64* %v.f
+  %v.g = alloca i64
+  store i64 %p.g, i64* %v.g
+  %v.h = alloca i64
+  store i64 %p.h, i64* %v.h
+  %t0 = load i64, i64* %v.h
+  ret i64 %t0
+}
+
+define i64 @baisl_main() {
+entry:
+  %t0 = call i64 @baisl_eighth(i64 1, i64 2, i64 3, i64 4, i64 5, i64 6, i64 7, i64 8)
+  ret i64 %t0
+}
+
+define i32 @main() {
+entry:
+  %result = call i64 @baisl_main()
+  %exitcode = trunc i64 %result to i32
+  ret i32 %exitcode
+}
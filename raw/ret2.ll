@@ -0,0 +1,18 @@
+; ModuleID = 'baisl'
+source_filename = "baisl"
+
+define void @baisl_main() {
+entry:
+  ret void
+}
+
+define i64 @baisl_return2() {
+entry:
+  ret i64 2
+}
+
+define i32 @main() {
+entry:
+  call void @baisl_main()
+  ret i32 0
+}
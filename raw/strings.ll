@@ -0,0 +1,162 @@
+; ModuleID = 'baisl'
+source_filename = "baisl"
+
+%baisl.string = type { i64, i8* }
+
+define %baisl.string @baisl_greet(%baisl.string %p.name) {
+entry:
+  %v.name = alloca %baisl.string
+  store %baisl.string %p.name, %baisl.string* %v.name
+  %t0 = load %baisl.string, %baisl.string* %v.name
+  %t1 = call %baisl.string @baisl.concat(%baisl.string { i64 7, i8* getelementptr inbounds ([7 x i8], [7 x i8]* @str.0, i64 0, i64 0) }, %baisl.string %t0)
+  %t2 = call %baisl.string @baisl.concat(%baisl.string %t1, %baisl.string { i64 1, i8* getelementptr inbounds ([1 x i8], [1 x i8]* @str.1, i64 0, i64 0) })
+  ret %baisl.string %t2
+}
+
+define i64 @baisl_main() {
+entry:
+  %v.line = alloca %baisl.string
+  %v.bar = alloca %baisl.string
+  %v.i = alloca i64
+  %v.i_end = alloca i64
+  %t0 = call %baisl.string @baisl_greet(%baisl.string { i64 5, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @str.2, i64 0, i64 0) })
+  call void @baisl.print.string(%baisl.string %t0, i1 true)
+  store %baisl.string { i64 28, i8* getelementptr inbounds ([28 x i8], [28 x i8]* @str.3, i64 0, i64 0) }, %baisl.string* %v.line
+  %t1 = load %baisl.string, %baisl.string* %v.line
+  call void @baisl.print.string(%baisl.string %t1, i1 true)
+  call void @baisl.print.string(%baisl.string { i64 5, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @str.4, i64 0, i64 0) }, i1 false)
+  %t2 = add i64 2, 3
+  call void @baisl.print.int(i64 %t2, i1 true)
+  %t3 = icmp slt i64 1, 2
+  call void @baisl.print.bool(i1 %t3, i1 true)
+  store %baisl.string { i64 0, i8* getelementptr inbounds ([0 x i8], [0 x i8]* @str.5, i64 0, i64 0) }, %baisl.string* %v.bar
+  store i64 0, i64* %v.i
+  store i64 3, i64* %v.i_end
+  br label %cond.0
+cond.0:
+  %t4 = load i64, i64* %v.i
+  %t5 = load i64, i64* %v.i_end
+  %t6 = icmp slt i64 %t4, %t5
+  br i1 %t6, label %body.1, label %end.3
+body.1:
+  %t7 = load %baisl.string, %baisl.string* %v.bar
+  %t8 = call %baisl.string @baisl.concat(%baisl.string %t7, %baisl.string { i64 1, i8* getelementptr inbounds ([1 x i8], [1 x i8]* @str.6, i64 0, i64 0) })
+  store %baisl.string %t8, %baisl.string* %v.bar
+  br label %step.2
+step.2:
+  %t9 = load i64, i64* %v.i
+  %t10 = add i64 %t9, 1
+  store i64 %t10, i64* %v.i
+  br label %cond.0
+end.3:
+  %t11 = load %baisl.string, %baisl.string* %v.bar
+  call void @baisl.print.string(%baisl.string %t11, i1 true)
+  call void @baisl.print.string(%baisl.string { i64 13, i8* getelementptr inbounds ([13 x i8], [13 x i8]* @str.7, i64 0, i64 0) }, i1 true)
+  %t12 = call %baisl.string @baisl_greet(%baisl.string { i64 6, i8* getelementptr inbounds ([6 x i8], [6 x i8]* @str.8, i64 0, i64 0) })
+  ret i64 0
+}
+
+define i32 @main() {
+entry:
+  %result = call i64 @baisl_main()
+  %exitcode = trunc i64 %result to i32
+  ret i32 %exitcode
+}
+
+@str.0 = private unnamed_addr constant [7 x i8] c"Hello, "
+@str.1 = private unnamed_addr constant [1 x i8] c"!"
+@str.2 = private unnamed_addr constant [5 x i8] c"baisl"
+@str.3 = private unnamed_addr constant [28 x i8] c"tab\09here \22quoted\22 back\5Cslash"
+@str.4 = private unnamed_addr constant [5 x i8] c"sum: "
+@str.5 = private unnamed_addr constant [0 x i8] c""
+@str.6 = private unnamed_addr constant [1 x i8] c"#"
+@str.7 = private unnamed_addr constant [13 x i8] c"caf\C3\A9 \F0\9F\98\80??="
+@str.8 = private unnamed_addr constant [6 x i8] c"unused"
+
+@baisl.true = private unnamed_addr constant [4 x i8] c"true"
+@baisl.false = private unnamed_addr constant [5 x i8] c"false"
+@baisl.newline = private unnamed_addr constant [1 x i8] c"\0A"
+@baisl.format.int = private unnamed_addr constant [5 x i8] c"%lld\00"
+@baisl.format.index = private unnamed_addr constant [54 x i8] c"Index %lld out of range for length %lld at %lld:%lld\0A\00"
+@baisl.format.division = private unnamed_addr constant [31 x i8] c"Division by zero at %lld:%lld\0A\00"
+
+declare i8* @malloc(i64)
+declare void @llvm.memcpy.p0i8.p0i8.i64(i8*, i8*, i64, i1)
+declare i64 @write(i32, i8*, i64)
+declare i32 @snprintf(i8*, i64, i8*, ...)
+declare void @exit(i32)
+
+define private %baisl.string @baisl.concat(%baisl.string %a, %baisl.string %b) {
+entry:
+  %a.len = extractvalue %baisl.string %a, 0
+  %a.data = extractvalue %baisl.string %a, 1
+  %b.len = extractvalue %baisl.string %b, 0
+  %b.data = extractvalue %baisl.string %b, 1
+  %len = add i64 %a.len, %b.len
+  %data = call i8* @malloc(i64 %len)
+  call void @llvm.memcpy.p0i8.p0i8.i64(i8* %data, i8* %a.data, i64 %a.len, i1 false)
+  %tail = getelementptr i8, i8* %data, i64 %a.len
+  call void @llvm.memcpy.p0i8.p0i8.i64(i8* %tail, i8* %b.data, i64 %b.len, i1 false)
+  %partial = insertvalue %baisl.string undef, i64 %len, 0
+  %result = insertvalue %baisl.string %partial, i8* %data, 1
+  ret %baisl.string %result
+}
+
+define private void @baisl.print.string(%baisl.string %value, i1 %newline) {
+entry:
+  %len = extractvalue %baisl.string %value, 0
+  %data = extractvalue %baisl.string %value, 1
+  call i64 @write(i32 1, i8* %data, i64 %len)
+  br i1 %newline, label %print.newline, label %done
+print.newline:
+  call i64 @write(i32 1, i8* getelementptr inbounds ([1 x i8], [1 x i8]* @baisl.newline, i64 0, i64 0), i64 1)
+  br label %done
+done:
+  ret void
+}
+
+define private void @baisl.print.int(i64 %value, i1 %newline) {
+entry:
+  %buffer = alloca [21 x i8]
+  %data = getelementptr inbounds [21 x i8], [21 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [5 x i8], [5 x i8]* @baisl.format.int, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 21, i8* %format, i64 %value)
+  %len = sext i32 %written to i64
+  %partial = insertvalue %baisl.string undef, i64 %len, 0
+  %text = insertvalue %baisl.string %partial, i8* %data, 1
+  call void @baisl.print.string(%baisl.string %text, i1 %newline)
+  ret void
+}
+
+define private void @baisl.print.bool(i1 %value, i1 %newline) {
+entry:
+  %true = insertvalue %baisl.string { i64 4, i8* undef }, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @baisl.true, i64 0, i64 0), 1
+  %false = insertvalue %baisl.string { i64 5, i8* undef }, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @baisl.false, i64 0, i64 0), 1
+  %text = select i1 %value, %baisl.string %true, %baisl.string %false
+  call void @baisl.print.string(%baisl.string %text, i1 %newline)
+  ret void
+}
+
+define private void @baisl.index.fail(i64 %index, i64 %len, i64 %line, i64 %column) {
+entry:
+  %buffer = alloca [128 x i8]
+  %data = getelementptr inbounds [128 x i8], [128 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [54 x i8], [54 x i8]* @baisl.format.index, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 128, i8* %format, i64 %index, i64 %len, i64 %line, i64 %column)
+  %size = sext i32 %written to i64
+  call i64 @write(i32 2, i8* %data, i64 %size)
+  call void @exit(i32 1)
+  unreachable
+}
+
+define private void @baisl.division.fail(i64 %line, i64 %column) {
+entry:
+  %buffer = alloca [64 x i8]
+  %data = getelementptr inbounds [64 x i8], [64 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [31 x i8], [31 x i8]* @baisl.format.division, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 64, i8* %format, i64 %line, i64 %column)
+  %size = sext i32 %written to i64
+  call i64 @write(i32 2, i8* %data, i64 %size)
+  call void @exit(i32 1)
+  unreachable
+}
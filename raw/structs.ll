@@ -0,0 +1,181 @@
+; ModuleID = 'baisl'
+source_filename = "baisl"
+
+%baisl.string = type { i64, i8* }
+
+%baisl_Point = type { i64, i64 }
+%baisl_Line = type { %baisl_Point, %baisl_Point, %baisl.string }
+
+define i64 @baisl_length(%baisl_Line %p.l) {
+entry:
+  %v.l = alloca %baisl_Line
+  store %baisl_Line %p.l, %baisl_Line* %v.l
+  %t0 = load %baisl_Line, %baisl_Line* %v.l
+  %t1 = extractvalue %baisl_Line %t0, 1
+  %t2 = extractvalue %baisl_Point %t1, 0
+  %t3 = load %baisl_Line, %baisl_Line* %v.l
+  %t4 = extractvalue %baisl_Line %t3, 0
+  %t5 = extractvalue %baisl_Point %t4, 0
+  %t6 = sub i64 %t2, %t5
+  %t7 = load %baisl_Line, %baisl_Line* %v.l
+  %t8 = extractvalue %baisl_Line %t7, 1
+  %t9 = extractvalue %baisl_Point %t8, 1
+  %t10 = add i64 %t6, %t9
+  %t11 = load %baisl_Line, %baisl_Line* %v.l
+  %t12 = extractvalue %baisl_Line %t11, 0
+  %t13 = extractvalue %baisl_Point %t12, 1
+  %t14 = sub i64 %t10, %t13
+  ret i64 %t14
+}
+
+define %baisl_Point @baisl_origin() {
+entry:
+  %t0 = insertvalue %baisl_Point undef, i64 0, 0
+  %t1 = insertvalue %baisl_Point %t0, i64 0, 1
+  ret %baisl_Point %t1
+}
+
+define i64 @baisl_main() {
+entry:
+  %v.l = alloca %baisl_Line
+  %v.p = alloca %baisl_Point
+  %t0 = call %baisl_Point @baisl_origin()
+  %t1 = insertvalue %baisl_Line undef, %baisl_Point %t0, 0
+  %t2 = insertvalue %baisl_Point undef, i64 3, 0
+  %t3 = insertvalue %baisl_Point %t2, i64 4, 1
+  %t4 = insertvalue %baisl_Line %t1, %baisl_Point %t3, 1
+  %t5 = insertvalue %baisl_Line %t4, %baisl.string { i64 4, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @str.0, i64 0, i64 0) }, 2
+  store %baisl_Line %t5, %baisl_Line* %v.l
+  %t6 = load %baisl_Line, %baisl_Line* %v.l
+  %t7 = extractvalue %baisl_Line %t6, 1
+  store %baisl_Point %t7, %baisl_Point* %v.p
+  %t8 = load %baisl_Point, %baisl_Point* %v.p
+  %t9 = extractvalue %baisl_Point %t8, 0
+  %t10 = load %baisl_Point, %baisl_Point* %v.p
+  %t11 = extractvalue %baisl_Point %t10, 1
+  %t12 = icmp slt i64 %t9, %t11
+  br i1 %t12, label %then.0, label %end.1
+then.0:
+  %t13 = load %baisl_Line, %baisl_Line* %v.l
+  %t14 = extractvalue %baisl_Line %t13, 2
+  call void @baisl.print.string(%baisl.string %t14, i1 true)
+  br label %end.1
+end.1:
+  br label %cond.2
+cond.2:
+  %t15 = load %baisl_Point, %baisl_Point* %v.p
+  %t16 = extractvalue %baisl_Point %t15, 0
+  %t17 = icmp sgt i64 %t16, 100
+  br i1 %t17, label %body.3, label %end.4
+body.3:
+  br label %cond.2
+end.4:
+  %t18 = load %baisl_Line, %baisl_Line* %v.l
+  %t19 = call i64 @baisl_length(%baisl_Line %t18)
+  %t20 = call %baisl_Point @baisl_origin()
+  %t21 = extractvalue %baisl_Point %t20, 0
+  %t22 = add i64 %t19, %t21
+  call void @baisl.print.int(i64 %t22, i1 true)
+  %t23 = insertvalue %baisl_Point undef, i64 5, 0
+  %t24 = insertvalue %baisl_Point %t23, i64 6, 1
+  %t25 = extractvalue %baisl_Point %t24, 1
+  call void @baisl.print.int(i64 %t25, i1 true)
+  ret i64 0
+}
+
+define i32 @main() {
+entry:
+  %result = call i64 @baisl_main()
+  %exitcode = trunc i64 %result to i32
+  ret i32 %exitcode
+}
+
+@str.0 = private unnamed_addr constant [4 x i8] c"diag"
+
+@baisl.true = private unnamed_addr constant [4 x i8] c"true"
+@baisl.false = private unnamed_addr constant [5 x i8] c"false"
+@baisl.newline = private unnamed_addr constant [1 x i8] c"\0A"
+@baisl.format.int = private unnamed_addr constant [5 x i8] c"%lld\00"
+@baisl.format.index = private unnamed_addr constant [54 x i8] c"Index %lld out of range for length %lld at %lld:%lld\0A\00"
+@baisl.format.division = private unnamed_addr constant [31 x i8] c"Division by zero at %lld:%lld\0A\00"
+
+declare i8* @malloc(i64)
+declare void @llvm.memcpy.p0i8.p0i8.i64(i8*, i8*, i64, i1)
+declare i64 @write(i32, i8*, i64)
+declare i32 @snprintf(i8*, i64, i8*, ...)
+declare void @exit(i32)
+
+define private %baisl.string @baisl.concat(%baisl.string %a, %baisl.string %b) {
+entry:
+  %a.len = extractvalue %baisl.string %a, 0
+  %a.data = extractvalue %baisl.string %a, 1
+  %b.len = extractvalue %baisl.string %b, 0
+  %b.data = extractvalue %baisl.string %b, 1
+  %len = add i64 %a.len, %b.len
+  %data = call i8* @malloc(i64 %len)
+  call void @llvm.memcpy.p0i8.p0i8.i64(i8* %data, i8* %a.data, i64 %a.len, i1 false)
+  %tail = getelementptr i8, i8* %data, i64 %a.len
+  call void @llvm.memcpy.p0i8.p0i8.i64(i8* %tail, i8* %b.data, i64 %b.len, i1 false)
+  %partial = insertvalue %baisl.string undef, i64 %len, 0
+  %result = insertvalue %baisl.string %partial, i8* %data, 1
+  ret %baisl.string %result
+}
+
+define private void @baisl.print.string(%baisl.string %value, i1 %newline) {
+entry:
+  %len = extractvalue %baisl.string %value, 0
+  %data = extractvalue %baisl.string %value, 1
+  call i64 @write(i32 1, i8* %data, i64 %len)
+  br i1 %newline, label %print.newline, label %done
+print.newline:
+  call i64 @write(i32 1, i8* getelementptr inbounds ([1 x i8], [1 x i8]* @baisl.newline, i64 0, i64 0), i64 1)
+  br label %done
+done:
+  ret void
+}
+
+define private void @baisl.print.int(i64 %value, i1 %newline) {
+entry:
+  %buffer = alloca [21 x i8]
+  %data = getelementptr inbounds [21 x i8], [21 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [5 x i8], [5 x i8]* @baisl.format.int, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 21, i8* %format, i64 %value)
+  %len = sext i32 %written to i64
+  %partial = insertvalue %baisl.string undef, i64 %len, 0
+  %text = insertvalue %baisl.string %partial, i8* %data, 1
+  call void @baisl.print.string(%baisl.string %text, i1 %newline)
+  ret void
+}
+
+define private void @baisl.print.bool(i1 %value, i1 %newline) {
+entry:
+  %true = insertvalue %baisl.string { i64 4, i8* undef }, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @baisl.true, i64 0, i64 0), 1
+  %false = insertvalue %baisl.string { i64 5, i8* undef }, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @baisl.false, i64 0, i64 0), 1
+  %text = select i1 %value, %baisl.string %true, %baisl.string %false
+  call void @baisl.print.string(%baisl.string %text, i1 %newline)
+  ret void
+}
+
+define private void @baisl.index.fail(i64 %index, i64 %len, i64 %line, i64 %column) {
+entry:
+  %buffer = alloca [128 x i8]
+  %data = getelementptr inbounds [128 x i8], [128 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [54 x i8], [54 x i8]* @baisl.format.index, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 128, i8* %format, i64 %index, i64 %len, i64 %line, i64 %column)
+  %size = sext i32 %written to i64
+  call i64 @write(i32 2, i8* %data, i64 %size)
+  call void @exit(i32 1)
+  unreachable
+}
+
+define private void @baisl.division.fail(i64 %line, i64 %column) {
+entry:
+  %buffer = alloca [64 x i8]
+  %data = getelementptr inbounds [64 x i8], [64 x i8]* %buffer, i64 0, i64 0
+  %format = getelementptr inbounds [31 x i8], [31 x i8]* @baisl.format.division, i64 0, i64 0
+  %written = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %data, i64 64, i8* %format, i64 %line, i64 %column)
+  %size = sext i32 %written to i64
+  call i64 @write(i32 2, i8* %data, i64 %size)
+  call void @exit(i32 1)
+  unreachable
+}
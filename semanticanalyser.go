@@ -1,21 +1,78 @@
 package baisl
 
 import (
-	"fmt"
+	"maps"
+	"path"
+	"slices"
 	"strconv"
+	"strings"
 )
 
+// Declarations of a scope by name
+type symbolTable map[string]Declaration
+
 type Scope struct {
-	name         string
-	parent       *Scope
-	children     []*Scope
-	declarations []Declaration
+	name     string
+	parent   *Scope
+	children []*Scope
+	symbols  symbolTable
+	// Set for the scope of a module's top-level declarations, whose parent holds the pub declarations
+	// of the modules it imports
+	module *Module
+}
+
+// The module a top-level declaration is in
+type topLevelDeclaration struct {
+	module *Module
+	// The id backends know it by, qualified with the module's path outside the main module, since
+	// declarations of different modules may share ids
+	name string
 }
 
 type SemanticAnalyser struct {
-	currentScope         *Scope
-	scopes               []*Scope
+	currentScope *Scope
+	// The modules of the program, each after those it imports, with the main module last
+	modules []*Module
+	// Scopes of the top-level declarations of each module
+	moduleScopes map[*Module]*Scope
+	// The module of every top-level declaration, and the id it's resolved with
+	topLevel map[Declaration]topLevelDeclaration
+	// Scopes of function bodies, nested blocks and match arms, built while analysing symbols and
+	// entered again while resolving
+	blockScopes          map[*Block]*Scope
+	armScopes            map[*MatchArm]*Scope
 	resolvedDeclarations []ResolvedDeclaration
+	// Resolved global declarations, and parameters and let bindings of the function being resolved,
+	// by the declaration they resolve
+	globals map[Declaration]ResolvedDeclaration
+	locals  map[Declaration]ResolvedDeclaration
+	// The function being resolved, whose return type every return statement must match
+	currentFunction *FunctionDecl
+	// Its resolved declaration, whose return type return statements give if it has no annotation
+	currentResolved *ResolvedFunctionDeclaration
+	// Without an annotation, the types its return statements return so far, and whether it calls itself
+	// before they're unified
+	returns      []returnedType
+	calledItself bool
+	// Type arguments of the generic function being instantiated by type parameter name
+	typeArgs map[string]Type
+	// Generic functions, which are only resolved as instances for the type arguments of their calls
+	generics map[*FunctionDecl]bool
+	// Functions declared with their signature whose body isn't resolved yet
+	pending map[*ResolvedFunctionDeclaration]*FunctionDecl
+	// Instances of generic functions by the function's name and type arguments, and the number of each one's
+	instances      map[string]*ResolvedFunctionDeclaration
+	instanceCounts map[string]int
+	// Number of instances being resolved, each within a call of the one before
+	instantiationDepth int
+	// Number of loops enclosing the statement being resolved, which break and continue require
+	loopDepth int
+	// Struct and enum declarations by resolved id, resolved before any function so every type can be checked
+	structs map[string]*ResolvedStructDeclaration
+	enums   map[string]*ResolvedEnumDeclaration
+	// Errors found so far. Analysing goes on after one, with the error type standing in for whatever
+	// failed to resolve.
+	diagnostics Diagnostics
 }
 
 type ResolvedRefExpr struct {
@@ -30,6 +87,110 @@ type ResolvedValueExpr struct {
 	Value    int
 }
 
+type ResolvedBoolExpr struct {
+	ExprType ExprType // Always ExprType_BOOL
+	Value    bool
+}
+
+type ResolvedStringExpr struct {
+	ExprType ExprType // Always ExprType_STRING
+	Value    string
+}
+
+// A call to a builtin, which has no declaration to refer to
+type ResolvedBuiltinCallExpr struct {
+	ExprType ExprType // Always ExprType_DECL_REF
+	Location SourceLocation
+	Builtin  Builtin
+	Args     []ResolvedExpr
+}
+
+// Builds a struct from the values of its fields, in declaration order
+type ResolvedStructExpr struct {
+	ExprType ExprType // Always ExprType_STRUCT
+	Location SourceLocation
+	Struct   *ResolvedStructDeclaration
+	Fields   []ResolvedExpr
+}
+
+// Builds an array from its elements, which all have the array's element type
+type ResolvedArrayExpr struct {
+	ExprType ExprType // Always ExprType_ARRAY
+	Location SourceLocation
+	Elems    []ResolvedExpr
+	Type     Type
+}
+
+// Reads the element at Index of an array or slice, failing at runtime if it is out of range
+type ResolvedIndexExpr struct {
+	ExprType ExprType // Always ExprType_INDEX
+	Location SourceLocation
+	Array    ResolvedExpr
+	Index    ResolvedExpr
+	Type     Type
+}
+
+// Builds a value of Variant's enum from the values of its payload
+type ResolvedVariantExpr struct {
+	ExprType ExprType // Always ExprType_DECL_REF
+	Location SourceLocation
+	Variant  *ResolvedVariantDeclaration
+	Payload  []ResolvedExpr
+}
+
+// Evaluates the value of the first arm matching the variant of Value's value. Arms are in source
+// order, and the analyser makes sure the last one is taken for every variant no other arm is for.
+type ResolvedMatchExpr struct {
+	ExprType ExprType // Always ExprType_MATCH
+	Location SourceLocation
+	// Holds the matched value as its Value, evaluated once before any arm is chosen
+	Value *ResolvedVariableDeclaration
+	Enum  *ResolvedEnumDeclaration
+	Arms  []*ResolvedMatchArm
+	Type  Type
+}
+
+type ResolvedMatchArm struct {
+	// Nil for the `_` arm
+	Variant *ResolvedVariantDeclaration
+	// The variables the payload values are bound to before Value is evaluated, nil for those skipped with `_`
+	Bindings []*ResolvedVariableDeclaration
+	Value    ResolvedExpr
+}
+
+// Reads the field at Index in the declaration of Struct's type
+type ResolvedFieldExpr struct {
+	ExprType ExprType // Always ExprType_FIELD
+	Location SourceLocation
+	Struct   ResolvedExpr
+	Index    int
+	Type     Type
+}
+
+type ResolvedBinaryExpr struct {
+	ExprType ExprType // Always ExprType_BINARY
+	Location SourceLocation
+	Operator TokenType
+	Lhs      ResolvedExpr
+	Rhs      ResolvedExpr
+	Type     Type
+}
+
+type ResolvedUnaryExpr struct {
+	ExprType ExprType // Always ExprType_UNARY
+	Location SourceLocation
+	Operator TokenType
+	Operand  ResolvedExpr
+	Type     Type
+}
+
+// Stands in for an expression the analyser reported an error for, keeping the ExprType of the expression.
+// Its type is the error type, so the expressions around it are still checked without further errors.
+type ResolvedErrorExpr struct {
+	ExprType ExprType
+	Location SourceLocation
+}
+
 type ResolvedExpr interface {
 	GetExprType() ExprType
 	GetType() Type
@@ -45,17 +206,20 @@ func (rr *ResolvedRefExpr) GetType() Type {
 	case *ResolvedVariableDeclaration:
 		return value.(*ResolvedVariableDeclaration).Type
 	case *ResolvedFunctionDeclaration:
-		returnStmt := value.(*ResolvedFunctionDeclaration).Body.Stmts[len(value.(*ResolvedFunctionDeclaration).Body.Stmts)-1]
-		if returnStmt.Expr == nil {
-			return Type_VOID
-		}
-
-		return returnStmt.Expr.GetType()
+		return value.(*ResolvedFunctionDeclaration).ReturnType
 	}
 
 	return Type_VOID
 }
 
+func (re *ResolvedErrorExpr) GetExprType() ExprType {
+	return re.ExprType
+}
+
+func (re *ResolvedErrorExpr) GetType() Type {
+	return Type_ERROR
+}
+
 func (rv *ResolvedValueExpr) GetExprType() ExprType {
 	return rv.ExprType
 }
@@ -64,13 +228,328 @@ func (rv *ResolvedValueExpr) GetType() Type {
 	return Type_INT
 }
 
-type ResolvedStatement struct {
-	StmtType StmtType
+func (rb *ResolvedBoolExpr) GetExprType() ExprType {
+	return rb.ExprType
+}
+
+func (rb *ResolvedBoolExpr) GetType() Type {
+	return Type_BOOL
+}
+
+func (rs *ResolvedStringExpr) GetExprType() ExprType {
+	return rs.ExprType
+}
+
+func (rs *ResolvedStringExpr) GetType() Type {
+	return Type_STRING
+}
+
+func (rb *ResolvedBuiltinCallExpr) GetExprType() ExprType {
+	return rb.ExprType
+}
+
+// len returns an int, while print and println don't return a value
+func (rb *ResolvedBuiltinCallExpr) GetType() Type {
+	if rb.Builtin == Builtin_LEN {
+		return Type_INT
+	}
+	return Type_VOID
+}
+
+func (rs *ResolvedStructExpr) GetExprType() ExprType {
+	return rs.ExprType
+}
+
+func (rs *ResolvedStructExpr) GetType() Type {
+	return rs.Struct.Type()
+}
+
+func (ra *ResolvedArrayExpr) GetExprType() ExprType {
+	return ra.ExprType
+}
+
+func (ra *ResolvedArrayExpr) GetType() Type {
+	return ra.Type
+}
+
+func (ri *ResolvedIndexExpr) GetExprType() ExprType {
+	return ri.ExprType
+}
+
+func (ri *ResolvedIndexExpr) GetType() Type {
+	return ri.Type
+}
+
+func (rv *ResolvedVariantExpr) GetExprType() ExprType {
+	return rv.ExprType
+}
+
+func (rv *ResolvedVariantExpr) GetType() Type {
+	return Type{Kind: TypeType_CUSTOM, Name: rv.Variant.Enum}
+}
+
+func (rm *ResolvedMatchExpr) GetExprType() ExprType {
+	return rm.ExprType
+}
+
+func (rm *ResolvedMatchExpr) GetType() Type {
+	return rm.Type
+}
+
+func (rf *ResolvedFieldExpr) GetExprType() ExprType {
+	return rf.ExprType
+}
+
+func (rf *ResolvedFieldExpr) GetType() Type {
+	return rf.Type
+}
+
+func (rb *ResolvedBinaryExpr) GetExprType() ExprType {
+	return rb.ExprType
+}
+
+func (rb *ResolvedBinaryExpr) GetType() Type {
+	return rb.Type
+}
+
+func (ru *ResolvedUnaryExpr) GetExprType() ExprType {
+	return ru.ExprType
+}
+
+func (ru *ResolvedUnaryExpr) GetType() Type {
+	return ru.Type
+}
+
+type ResolvedStatement interface {
+	GetStmtType() StmtType
+}
+
+type ResolvedReturnStatement struct {
+	StmtType StmtType // Always StmtType_RETURN
+	Expr     ResolvedExpr
+}
+
+type ResolvedLetStatement struct {
+	StmtType StmtType // Always StmtType_LET
+	// Holds the initializer as its Value
+	Variable *ResolvedVariableDeclaration
+}
+
+type ResolvedAssignStatement struct {
+	StmtType StmtType // Always StmtType_ASSIGN
+	Variable *ResolvedVariableDeclaration
+	Expr     ResolvedExpr
+}
+
+type ResolvedIfStatement struct {
+	StmtType StmtType // Always StmtType_IF
+	Cond     ResolvedExpr
+	Then     *ResolvedBlock
+	// Nil if there is no else branch
+	Else *ResolvedBlock
+}
+
+func (rr *ResolvedReturnStatement) GetStmtType() StmtType {
+	return rr.StmtType
+}
+
+func (rl *ResolvedLetStatement) GetStmtType() StmtType {
+	return rl.StmtType
+}
+
+func (ra *ResolvedAssignStatement) GetStmtType() StmtType {
+	return ra.StmtType
+}
+
+func (ri *ResolvedIfStatement) GetStmtType() StmtType {
+	return ri.StmtType
+}
+
+type ResolvedWhileStatement struct {
+	StmtType StmtType // Always StmtType_WHILE
+	Cond     ResolvedExpr
+	Body     *ResolvedBlock
+}
+
+func (rw *ResolvedWhileStatement) GetStmtType() StmtType {
+	return rw.StmtType
+}
+
+// Counts Variable up from its Value while it is below Limit. Limit is a variable no source
+// can refer to, holding the end of the range as evaluated once before the loop.
+type ResolvedForStatement struct {
+	StmtType StmtType // Always StmtType_FOR
+	Variable *ResolvedVariableDeclaration
+	Limit    *ResolvedVariableDeclaration
+	Body     *ResolvedBlock
+}
+
+func (rf *ResolvedForStatement) GetStmtType() StmtType {
+	return rf.StmtType
+}
+
+type ResolvedBreakStatement struct {
+	StmtType StmtType // Always StmtType_BREAK
+}
+
+func (rb *ResolvedBreakStatement) GetStmtType() StmtType {
+	return rb.StmtType
+}
+
+type ResolvedContinueStatement struct {
+	StmtType StmtType // Always StmtType_CONTINUE
+}
+
+func (rc *ResolvedContinueStatement) GetStmtType() StmtType {
+	return rc.StmtType
+}
+
+// Evaluates a call and discards its result, if it has one
+type ResolvedExprStatement struct {
+	StmtType StmtType // Always StmtType_EXPR
 	Expr     ResolvedExpr
 }
 
+func (re *ResolvedExprStatement) GetStmtType() StmtType {
+	return re.StmtType
+}
+
 type ResolvedBlock struct {
-	Stmts []*ResolvedStatement
+	Stmts []ResolvedStatement
+}
+
+// Returns the variables declared by let statements and match expressions in the block and the blocks
+// nested in it, in declaration order
+func (rb *ResolvedBlock) Locals() []*ResolvedVariableDeclaration {
+	locals := make([]*ResolvedVariableDeclaration, 0)
+	for _, stmt := range rb.Stmts {
+		locals = append(locals, StatementMatchLocals(stmt)...)
+		switch stmt.(type) {
+		case *ResolvedLetStatement:
+			locals = append(locals, stmt.(*ResolvedLetStatement).Variable)
+		case *ResolvedIfStatement:
+			ifStmt := stmt.(*ResolvedIfStatement)
+			locals = append(locals, ifStmt.Then.Locals()...)
+			if ifStmt.Else != nil {
+				locals = append(locals, ifStmt.Else.Locals()...)
+			}
+		case *ResolvedWhileStatement:
+			locals = append(locals, stmt.(*ResolvedWhileStatement).Body.Locals()...)
+		case *ResolvedForStatement:
+			forStmt := stmt.(*ResolvedForStatement)
+			locals = append(locals, forStmt.Variable, forStmt.Limit)
+			locals = append(locals, forStmt.Body.Locals()...)
+		}
+	}
+	return locals
+}
+
+// Returns the variables declared by match expressions in the statement's own expressions,
+// leaving out those of nested blocks
+func StatementMatchLocals(stmt ResolvedStatement) []*ResolvedVariableDeclaration {
+	var exprs []ResolvedExpr
+	switch stmt.(type) {
+	case *ResolvedReturnStatement:
+		exprs = []ResolvedExpr{stmt.(*ResolvedReturnStatement).Expr}
+	case *ResolvedLetStatement:
+		exprs = []ResolvedExpr{stmt.(*ResolvedLetStatement).Variable.Value}
+	case *ResolvedAssignStatement:
+		exprs = []ResolvedExpr{stmt.(*ResolvedAssignStatement).Expr}
+	case *ResolvedExprStatement:
+		exprs = []ResolvedExpr{stmt.(*ResolvedExprStatement).Expr}
+	case *ResolvedIfStatement:
+		exprs = []ResolvedExpr{stmt.(*ResolvedIfStatement).Cond}
+	case *ResolvedWhileStatement:
+		exprs = []ResolvedExpr{stmt.(*ResolvedWhileStatement).Cond}
+	case *ResolvedForStatement:
+		forStmt := stmt.(*ResolvedForStatement)
+		exprs = []ResolvedExpr{forStmt.Variable.Value, forStmt.Limit.Value}
+	}
+
+	locals := make([]*ResolvedVariableDeclaration, 0)
+	for _, expr := range exprs {
+		locals = append(locals, matchLocals(expr)...)
+	}
+	return locals
+}
+
+// Returns the matched values and bindings of the match expressions in expr, in declaration order
+func matchLocals(expr ResolvedExpr) []*ResolvedVariableDeclaration {
+	var operands []ResolvedExpr
+	switch expr.(type) {
+	case *ResolvedRefExpr:
+		operands = expr.(*ResolvedRefExpr).Args
+	case *ResolvedBuiltinCallExpr:
+		operands = expr.(*ResolvedBuiltinCallExpr).Args
+	case *ResolvedStructExpr:
+		operands = expr.(*ResolvedStructExpr).Fields
+	case *ResolvedVariantExpr:
+		operands = expr.(*ResolvedVariantExpr).Payload
+	case *ResolvedArrayExpr:
+		operands = expr.(*ResolvedArrayExpr).Elems
+	case *ResolvedIndexExpr:
+		operands = []ResolvedExpr{expr.(*ResolvedIndexExpr).Array, expr.(*ResolvedIndexExpr).Index}
+	case *ResolvedFieldExpr:
+		operands = []ResolvedExpr{expr.(*ResolvedFieldExpr).Struct}
+	case *ResolvedBinaryExpr:
+		operands = []ResolvedExpr{expr.(*ResolvedBinaryExpr).Lhs, expr.(*ResolvedBinaryExpr).Rhs}
+	case *ResolvedUnaryExpr:
+		operands = []ResolvedExpr{expr.(*ResolvedUnaryExpr).Operand}
+	case *ResolvedMatchExpr:
+		matchExpr := expr.(*ResolvedMatchExpr)
+		locals := matchLocals(matchExpr.Value.Value)
+		locals = append(locals, matchExpr.Value)
+		for _, arm := range matchExpr.Arms {
+			for _, binding := range arm.Bindings {
+				if binding != nil {
+					locals = append(locals, binding)
+				}
+			}
+			locals = append(locals, matchLocals(arm.Value)...)
+		}
+		return locals
+	}
+
+	locals := make([]*ResolvedVariableDeclaration, 0)
+	for _, operand := range operands {
+		if operand != nil {
+			locals = append(locals, matchLocals(operand)...)
+		}
+	}
+	return locals
+}
+
+// Reports whether every path through the block ends in a return statement.
+// Loops are assumed to possibly run zero times, so returns inside them don't count.
+func (rb *ResolvedBlock) AlwaysReturns() bool {
+	for _, stmt := range rb.Stmts {
+		switch stmt.(type) {
+		case *ResolvedReturnStatement:
+			return true
+		case *ResolvedIfStatement:
+			ifStmt := stmt.(*ResolvedIfStatement)
+			if ifStmt.Else != nil && ifStmt.Then.AlwaysReturns() && ifStmt.Else.AlwaysReturns() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Numbers the variables of a function that share an id in declaration order, starting at 0,
+// so backends can give variables that shadow others distinct names
+func numberVariables(fn *ResolvedFunctionDeclaration) map[ResolvedDeclaration]int {
+	numbers := make(map[ResolvedDeclaration]int)
+	counts := make(map[string]int)
+	for _, param := range fn.Params {
+		numbers[param] = counts[param.GetId()]
+		counts[param.GetId()]++
+	}
+	for _, local := range fn.Body.Locals() {
+		numbers[local] = counts[local.GetId()]
+		counts[local.GetId()]++
+	}
+	return numbers
 }
 
 type ResolvedDeclaration interface {
@@ -79,10 +558,13 @@ type ResolvedDeclaration interface {
 }
 
 type ResolvedFunctionDeclaration struct {
-	Id       string
-	DeclType DeclType
-	Params   []ResolvedDeclaration
-	Body     *ResolvedBlock
+	Id         string
+	DeclType   DeclType
+	Params     []ResolvedDeclaration
+	ReturnType Type
+	Body       *ResolvedBlock
+	// Where the function is declared, for diagnostics about its calls
+	Location SourceLocation `json:"-"`
 }
 
 func (rfd *ResolvedFunctionDeclaration) GetDeclType() DeclType {
@@ -98,6 +580,7 @@ type ResolvedVariableDeclaration struct {
 	DeclType DeclType
 	Type     Type
 	Value    ResolvedExpr
+	Location SourceLocation `json:"-"`
 }
 
 func (rvd *ResolvedVariableDeclaration) GetDeclType() DeclType {
@@ -108,10 +591,85 @@ func (rvd *ResolvedVariableDeclaration) GetId() string {
 	return rvd.Id
 }
 
+type ResolvedStructDeclaration struct {
+	Id       string
+	DeclType DeclType
+	Fields   []*ResolvedVariableDeclaration
+}
+
+func (rsd *ResolvedStructDeclaration) GetDeclType() DeclType {
+	return rsd.DeclType
+}
+
+func (rsd *ResolvedStructDeclaration) GetId() string {
+	return rsd.Id
+}
+
+func (rsd *ResolvedStructDeclaration) Type() Type {
+	return Type{Kind: TypeType_CUSTOM, Name: rsd.Id}
+}
+
+// Returns the index of the field with the given id, or -1 if there is none
+func (rsd *ResolvedStructDeclaration) FieldIndex(id string) int {
+	for i, field := range rsd.Fields {
+		if field.Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+type ResolvedEnumDeclaration struct {
+	Id       string
+	DeclType DeclType
+	Variants []*ResolvedVariantDeclaration
+}
+
+func (red *ResolvedEnumDeclaration) GetDeclType() DeclType {
+	return red.DeclType
+}
+
+func (red *ResolvedEnumDeclaration) GetId() string {
+	return red.Id
+}
+
+func (red *ResolvedEnumDeclaration) Type() Type {
+	return Type{Kind: TypeType_CUSTOM, Name: red.Id}
+}
+
+// Returns the index of the variant with the given id, or -1 if there is none
+func (red *ResolvedEnumDeclaration) VariantIndex(id string) int {
+	for i, variant := range red.Variants {
+		if variant.Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+type ResolvedVariantDeclaration struct {
+	Id       string
+	DeclType DeclType
+	// Names the variant's enum instead of pointing to it, which would make a cycle
+	Enum string
+	// Position of the variant in its enum, which values of the enum are tagged with
+	Index   int
+	Payload []Type
+}
+
+func (rvd *ResolvedVariantDeclaration) GetDeclType() DeclType {
+	return rvd.DeclType
+}
+
+func (rvd *ResolvedVariantDeclaration) GetId() string {
+	return rvd.Id
+}
+
 func (sa *SemanticAnalyser) EnterScope(name string) {
 	newScope := &Scope{
-		name:   name,
-		parent: sa.currentScope,
+		name:    name,
+		parent:  sa.currentScope,
+		symbols: make(symbolTable),
 	}
 	if sa.currentScope != nil {
 		sa.currentScope.children = append(sa.currentScope.children, newScope)
@@ -124,102 +682,474 @@ func (sa *SemanticAnalyser) ExitScope() {
 }
 
 func (sa *SemanticAnalyser) AddDeclaration(decl Declaration) error {
-	for _, d := range sa.currentScope.declarations {
-		if d.GetId() == decl.GetId() {
-			return fmt.Errorf("Duplicate declaration of %s at %d:%d in %s", decl.GetId(), decl.GetLocation().Line, decl.GetLocation().Column, sa.currentScope.name)
-		}
+	first, ok := sa.currentScope.symbols[decl.GetId()]
+	if ok {
+		return newDiagnostic(DiagnosticCode_DUPLICATE_DECLARATION, *decl.GetLocation(), "Duplicate declaration of %s", decl.GetId()).
+			withLabel(*first.GetLocation(), "%s is first declared", decl.GetId())
 	}
-	sa.currentScope.declarations = append(sa.currentScope.declarations, decl)
+	sa.currentScope.symbols[decl.GetId()] = decl
 	return nil
 }
 
 func (sa *SemanticAnalyser) FindDeclaration(id string) Declaration {
 	for scope := sa.currentScope; scope != nil; scope = scope.parent {
-		for _, decl := range scope.declarations {
-			if decl.GetId() == id {
-				return decl
-			}
+		decl, ok := scope.symbols[id]
+		if ok {
+			return decl
 		}
 	}
 	return nil
 }
 
-func (sa *SemanticAnalyser) AnalyseBlock(block *Block) error {
-	for _, stmt := range block.Stmts {
-		switch stmt.(type) {
-		case *ReturnStmt:
-			expr := stmt.(*ReturnStmt).Expr
-			if expr != nil && expr.Type == ExprType_DECL_REF {
-				found := sa.FindDeclaration(expr.Value)
-				if found == nil {
-					return fmt.Errorf("Undeclared variable %s at %d:%d in %s", expr.Value, expr.Location.Line, expr.Location.Column, sa.currentScope.name)
-				}
-			}
+// Records an error to be returned with every other once the whole program is analysed. An error found
+// twice, like an undeclared name that both phases look up, is recorded once.
+func (sa *SemanticAnalyser) report(err error) {
+	for _, diagnostic := range DiagnosticsOf(err) {
+		duplicate := slices.ContainsFunc(sa.diagnostics, func(other *Diagnostic) bool {
+			return other.Code == diagnostic.Code && other.Location == diagnostic.Location && other.Message == diagnostic.Message
+		})
+		if !duplicate {
+			sa.diagnostics = append(sa.diagnostics, diagnostic)
 		}
 	}
-	return nil
 }
 
-func (sa *SemanticAnalyser) AnalyseFunctionSymbols(decl *FunctionDecl) error {
-	sa.EnterScope(decl.GetId())
-	for _, param := range decl.Params {
-		err := sa.AddDeclaration(param)
-		if err != nil {
-			return fmt.Errorf("Error adding parameter %s: %s", param.GetId(), err)
+// Checks that a reference is declared or calls a builtin, leaving nested expressions to the resolve phase
+// apart from registering the bindings of match arms
+func (sa *SemanticAnalyser) AnalyseExpr(expr *Expr) {
+	if expr.Type == ExprType_DECL_REF {
+		found := sa.FindDeclaration(expr.Value)
+		_, isBuiltin := builtinNames[expr.Value]
+		if found == nil && !(isBuiltin && expr.IsCall) {
+			sa.report(sa.notVisible(expr.Value, newDiagnostic(DiagnosticCode_UNDECLARED, expr.Location, "Undeclared variable %s", expr.Value)))
 		}
 	}
-	err := sa.AnalyseBlock(decl.Body)
-	if err != nil {
-		return fmt.Errorf("Error analysing block: %s", err)
+
+	sa.analyseMatches(expr)
+}
+
+// Registers the bindings of each match arm within the expression in a scope of the arm's own,
+// so an arm can't bind a name twice
+func (sa *SemanticAnalyser) analyseMatches(expr *Expr) {
+	operands := append([]*Expr{expr.Lhs, expr.Rhs}, expr.Args...)
+	for _, operand := range operands {
+		if operand == nil {
+			continue
+		}
+		sa.analyseMatches(operand)
+	}
+	for _, arm := range expr.Arms {
+		sa.EnterScope(sa.currentScope.name)
+		sa.armScopes[arm] = sa.currentScope
+		for _, binding := range arm.Bindings {
+			if binding == nil {
+				continue
+			}
+			err := sa.AddDeclaration(binding)
+			if err != nil {
+				sa.report(err)
+			}
+		}
+		sa.analyseMatches(arm.Value)
+		sa.ExitScope()
 	}
-	sa.ExitScope()
-	sa.AddDeclaration(decl)
-	return nil
 }
 
-func (sa *SemanticAnalyser) AnalyseSymbols(declarations []Declaration) error {
-	// Scopes will
-	for _, decl := range declarations {
-		switch decl.(type) {
-		case *VariableDecl:
+// Registers the block's let bindings in the current scope, and those of nested blocks in scopes
+// of their own. A let is only visible after its initializer, so `let x = x` refers to an x from an
+// enclosing scope. Names in a scope shadow those of enclosing scopes, including global functions,
+// but may not be declared twice in one. A function's parameters share the scope of its body,
+// so a let can't redeclare a parameter, but one in a nested block can shadow it.
+func (sa *SemanticAnalyser) AnalyseBlock(block *Block) {
+	for _, stmt := range block.Stmts {
+		switch stmt.(type) {
+		case *ReturnStmt:
+			expr := stmt.(*ReturnStmt).Expr
+			if expr != nil {
+				sa.AnalyseExpr(expr)
+			}
+		case *LetStmt:
+			decl := stmt.(*LetStmt).Decl
+			sa.AnalyseExpr(decl.Value)
 			err := sa.AddDeclaration(decl)
 			if err != nil {
-				return fmt.Errorf("Error adding variable %s: %s", decl.GetId(), err)
+				sa.report(err)
 			}
-		case *FunctionDecl:
-			err := sa.AnalyseFunctionSymbols(decl.(*FunctionDecl))
+		case *AssignStmt:
+			assignStmt := stmt.(*AssignStmt)
+			found := sa.FindDeclaration(assignStmt.Id)
+			if found == nil {
+				sa.report(newDiagnostic(DiagnosticCode_UNDECLARED, assignStmt.Location, "Undeclared variable %s", assignStmt.Id))
+			}
+			sa.AnalyseExpr(assignStmt.Expr)
+		case *ExprStmt:
+			sa.AnalyseExpr(stmt.(*ExprStmt).Expr)
+		case *IfStmt:
+			ifStmt := stmt.(*IfStmt)
+			sa.AnalyseExpr(ifStmt.Cond)
+			sa.AnalyseNestedBlock(ifStmt.Then)
+			if ifStmt.Else != nil {
+				sa.AnalyseNestedBlock(ifStmt.Else)
+			}
+		case *WhileStmt:
+			whileStmt := stmt.(*WhileStmt)
+			sa.AnalyseExpr(whileStmt.Cond)
+			sa.AnalyseNestedBlock(whileStmt.Body)
+		case *ForStmt:
+			// The loop variable shares the scope of the body, like parameters do
+			forStmt := stmt.(*ForStmt)
+			sa.AnalyseExpr(forStmt.Var.Value)
+			sa.AnalyseExpr(forStmt.End)
+			sa.EnterScope(sa.currentScope.name)
+			sa.blockScopes[forStmt.Body] = sa.currentScope
+			err := sa.AddDeclaration(forStmt.Var)
 			if err != nil {
-				return fmt.Errorf("Error analysing function %s: %s", decl.GetId(), err)
+				sa.report(err)
 			}
+			sa.AnalyseBlock(forStmt.Body)
+			sa.ExitScope()
 		}
 	}
-	return nil
 }
 
-func (sa *SemanticAnalyser) FindResolvedDeclaration(id string) ResolvedDeclaration {
-	for _, decl := range sa.resolvedDeclarations {
-		if decl.GetId() == id {
-			return decl
-		}
+// Analyses a block in a new scope, named after the enclosing one
+func (sa *SemanticAnalyser) AnalyseNestedBlock(block *Block) {
+	sa.EnterScope(sa.currentScope.name)
+	sa.blockScopes[block] = sa.currentScope
+	sa.AnalyseBlock(block)
+	sa.ExitScope()
+}
+
+// Declares a top-level declaration in the scope of the module being analysed, reporting one with the
+// name of a pub declaration of a module it imports
+func (sa *SemanticAnalyser) declareTopLevel(decl Declaration) {
+	module := sa.currentScope.module
+	name := decl.GetId()
+	if module != sa.modules[len(sa.modules)-1] {
+		name = qualifiedId(module.Path, decl.GetId())
+	}
+	sa.topLevel[decl] = topLevelDeclaration{
+		module: module,
+		name:   name,
+	}
+
+	err := sa.AddDeclaration(decl)
+	if err != nil {
+		sa.report(err)
+		return
+	}
+	imported, ok := sa.currentScope.parent.symbols[decl.GetId()]
+	if ok {
+		sa.report(newDiagnostic(DiagnosticCode_DUPLICATE_DECLARATION, *decl.GetLocation(), "Duplicate declaration of %s", decl.GetId()).
+			withLabel(*imported.GetLocation(), "%s is first declared in module %s", decl.GetId(), sa.topLevel[imported].module.Path).
+			withNote("The pub declarations of imported modules share the scope of the module's own"))
 	}
-	return nil
 }
 
-func (sa *SemanticAnalyser) ResolveExpr(expr *Expr) (ResolvedExpr, error) {
+// Returns the id a top-level declaration is resolved with
+func (sa *SemanticAnalyser) resolvedId(decl Declaration) string {
+	return sa.topLevel[decl].name
+}
+
+// Declares the function before analysing its body, so the body may call the function itself
+func (sa *SemanticAnalyser) AnalyseFunctionSymbols(decl *FunctionDecl) {
+	sa.EnterScope(decl.GetId())
+	sa.blockScopes[decl.Body] = sa.currentScope
+	for _, param := range decl.Params {
+		err := sa.AddDeclaration(param)
+		if err != nil {
+			sa.report(err)
+		}
+	}
+	sa.AnalyseBlock(decl.Body)
+	sa.ExitScope()
+}
+
+// Registers every top-level declaration of a module and the local ones of each function, reporting names
+// declared twice in a scope and references to undeclared ones. Functions, structs and enums are declared
+// before any body is analysed, so they may be used above their declarations. A declaration with a syntax
+// error is skipped, since the parser reported it, as are imports, which the module loader followed.
+func (sa *SemanticAnalyser) AnalyseSymbols(declarations []Declaration) {
+	for _, decl := range declarations {
+		switch decl.(type) {
+		case *FunctionDecl, *StructDecl:
+			sa.declareTopLevel(decl)
+		case *EnumDecl:
+			enumDecl := decl.(*EnumDecl)
+			sa.declareTopLevel(enumDecl)
+			for _, variant := range enumDecl.Variants {
+				sa.declareTopLevel(variant)
+			}
+		}
+	}
+
+	for _, decl := range declarations {
+		switch decl.(type) {
+		case *VariableDecl:
+			err := sa.AddDeclaration(decl)
+			if err != nil {
+				sa.report(err)
+			}
+			value := decl.(*VariableDecl).Value
+			if value != nil {
+				sa.analyseMatches(value)
+			}
+		case *FunctionDecl:
+			sa.AnalyseFunctionSymbols(decl.(*FunctionDecl))
+		}
+	}
+}
+
+// Looks a name up in the scopes enclosing the one being resolved, innermost first. A declaration that
+// hasn't been resolved yet, like a let further down its block, is skipped for those of enclosing scopes.
+// Generic functions are never resolved themselves, so their names are only found as locals shadowing them.
+func (sa *SemanticAnalyser) FindResolvedDeclaration(id string) ResolvedDeclaration {
+	for scope := sa.currentScope; scope != nil; scope = scope.parent {
+		decl, ok := scope.symbols[id]
+		if !ok {
+			continue
+		}
+		resolved, ok := sa.locals[decl]
+		if ok {
+			return resolved
+		}
+		resolved, ok = sa.globals[decl]
+		if ok {
+			return resolved
+		}
+	}
+	return nil
+}
+
+// Returns the scope of the top-level declarations of the module the current scope is in
+func (sa *SemanticAnalyser) moduleScope() *Scope {
+	scope := sa.currentScope
+	for scope.module == nil {
+		scope = scope.parent
+	}
+	return scope
+}
+
+// Looks up the struct or enum a type written in the module being resolved names, among its own top-level
+// declarations and the pub ones of the modules it imports. Locals don't shadow types, so they're skipped.
+// Type parameters aren't looked up, as the type arguments of an instance replace them.
+func (sa *SemanticAnalyser) findType(id string) Declaration {
+	scope := sa.moduleScope()
+	decl, ok := scope.symbols[id]
+	if !ok {
+		decl, ok = scope.parent.symbols[id]
+	}
+	if !ok || (decl.GetKind() != DeclType_STRUCT && decl.GetKind() != DeclType_ENUM) {
+		return nil
+	}
+	return decl
+}
+
+// Explains why a name the module being resolved refers to isn't visible, if another module declares it:
+// either it isn't pub there, or that module isn't imported. The first module declaring it is picked if
+// several do. Returns notFound for a name no other module declares.
+func (sa *SemanticAnalyser) notVisible(id string, notFound *Diagnostic) *Diagnostic {
+	current := sa.moduleScope().module
+	for _, module := range sa.modules {
+		if module == current {
+			continue
+		}
+		decl, pub := findTopLevel(module, id)
+		if decl == nil {
+			continue
+		}
+		if !pub {
+			return newDiagnostic(DiagnosticCode_PRIVATE, notFound.Location, "%s %s is private to module %s", decl.GetKind(), id, module.Path).
+				withLabel(*decl.GetLocation(), "%s is declared", id).
+				withNote("Mark it pub to use it in other modules")
+		}
+		return notFound.
+			withLabel(*decl.GetLocation(), "%s is declared in module %s", id, module.Path).
+			withNote("Import %q to use it", module.Path)
+	}
+	return notFound
+}
+
+func isComparisonOperator(operator TokenType) bool {
+	switch operator {
+	case TokenType_EQ, TokenType_NEQ, TokenType_LT, TokenType_LTE, TokenType_GT, TokenType_GTE:
+		return true
+	}
+	return false
+}
+
+// Returns the type a binary operator produces from operands of the given types.
+// Arithmetic works on ints, + also concatenates strings, ordering compares ints,
+// equality compares two ints or two bools, and logical operators work on bools.
+func binaryOperatorType(operator TokenType, lhs Type, rhs Type, location SourceLocation) (Type, error) {
+	if lhs.IsError() || rhs.IsError() {
+		return Type_ERROR, nil
+	}
+	operatorStr := TokenTypeToOperator[operator]
+	switch operator {
+	case TokenType_PLUS:
+		if !lhs.Equals(rhs) || (lhs != Type_INT && lhs != Type_STRING) {
+			return Type{}, newDiagnostic(DiagnosticCode_INVALID_OPERAND, location, "Operator %s expects two int or two string operands, got %s and %s", operatorStr, lhs, rhs)
+		}
+		return lhs, nil
+	case TokenType_EQ, TokenType_NEQ:
+		if !lhs.Equals(rhs) || (lhs != Type_INT && lhs != Type_BOOL) {
+			return Type{}, newDiagnostic(DiagnosticCode_INVALID_OPERAND, location, "Operator %s expects two int or two bool operands, got %s and %s", operatorStr, lhs, rhs)
+		}
+		return Type_BOOL, nil
+	case TokenType_AND, TokenType_OR:
+		if lhs != Type_BOOL || rhs != Type_BOOL {
+			return Type{}, newDiagnostic(DiagnosticCode_INVALID_OPERAND, location, "Operator %s expects bool operands, got %s and %s", operatorStr, lhs, rhs)
+		}
+		return Type_BOOL, nil
+	}
+
+	if lhs != Type_INT || rhs != Type_INT {
+		return Type{}, newDiagnostic(DiagnosticCode_INVALID_OPERAND, location, "Operator %s expects int operands, got %s and %s", operatorStr, lhs, rhs)
+	}
+	if isComparisonOperator(operator) {
+		return Type_BOOL, nil
+	}
+	return Type_INT, nil
+}
+
+// Resolves a type written in the module being resolved. A struct or enum must have been declared, and is
+// named by its resolved id, while a type parameter of the instance being resolved is replaced by its type
+// argument.
+func (sa *SemanticAnalyser) ResolveType(t Type, location SourceLocation) (Type, error) {
+	switch t.Kind {
+	case TypeType_ARRAY, TypeType_SLICE:
+		elem, err := sa.ResolveType(*t.Elem, location)
+		if err != nil {
+			return Type{}, err
+		}
+		if t.Kind == TypeType_ARRAY {
+			return ArrayType(elem, t.Length), nil
+		}
+		return SliceType(elem), nil
+	case TypeType_CUSTOM:
+		typeArg, ok := sa.typeArgs[t.Name]
+		if ok {
+			return typeArg, nil
+		}
+		decl := sa.findType(t.Name)
+		if decl == nil {
+			return Type{}, sa.notVisible(t.Name, newDiagnostic(DiagnosticCode_UNKNOWN_TYPE, location, "Unknown type %s", t))
+		}
+		return Type{Kind: TypeType_CUSTOM, Name: sa.resolvedId(decl)}, nil
+	}
+	return t, nil
+}
+
+// Checks a call to a declaration that isn't generic, which must be a function taking as many arguments as
+// it's given, each assignable to its parameter. Errors point at the call or argument and name where the
+// callee is declared.
+func (sa *SemanticAnalyser) CheckCall(callee ResolvedDeclaration, expr *Expr, args []ResolvedExpr) error {
+	fn, ok := callee.(*ResolvedFunctionDeclaration)
+	if !ok {
+		variable := callee.(*ResolvedVariableDeclaration)
+		return newDiagnostic(DiagnosticCode_NOT_A_FUNCTION, expr.Location, "Variable %s is %s, not a function, but is called", variable.Id, variable.Type).withLabel(variable.Location, "%s is declared", variable.Id)
+	}
+	if len(args) != len(fn.Params) {
+		return newDiagnostic(DiagnosticCode_ARGUMENT_COUNT, expr.Location, "Function %s expects %d arguments, got %d", expr.Value, len(fn.Params), len(args)).withLabel(fn.Location, "%s is declared", expr.Value)
+	}
+	for i, arg := range args {
+		param := fn.Params[i].(*ResolvedVariableDeclaration)
+		if !arg.GetType().AssignableTo(param.Type) {
+			return newDiagnostic(DiagnosticCode_ARGUMENT_TYPE, expr.Args[i].Location, "Argument %d of %s is %s but parameter %s is %s", i+1, expr.Value, arg.GetType(), param.Id, param.Type).withLabel(fn.Location, "%s is declared", expr.Value)
+		}
+	}
+	if fn.ReturnType != Type_INFERRED {
+		return nil
+	}
+	// A function declared further down is resolved first, for its return type to be inferred
+	if _, ok := sa.pending[fn]; ok {
+		sa.resolveAside(nil, func() {
+			sa.ResolveFunctionDeclaration(fn)
+		})
+		return nil
+	}
+	return sa.callWhileInferring(fn, expr)
+}
+
+// Checks the arguments of a builtin call. print and println take a single int, bool or string,
+// and len a single array or slice.
+func (sa *SemanticAnalyser) ResolveBuiltinCall(builtin Builtin, expr *Expr, args []ResolvedExpr) (ResolvedExpr, error) {
+	if len(args) != 1 {
+		return nil, newDiagnostic(DiagnosticCode_ARGUMENT_COUNT, expr.Location, "Builtin %s expects 1 argument, got %d", builtin, len(args))
+	}
+	argType := args[0].GetType()
+	if argType.IsError() {
+		return errorExpr(expr), nil
+	}
+	if builtin == Builtin_LEN {
+		if !argType.IsSequence() {
+			return nil, newDiagnostic(DiagnosticCode_ARGUMENT_TYPE, expr.Location, "Builtin %s expects an array or slice, got %s", builtin, argType)
+		}
+	} else if argType != Type_INT && argType != Type_BOOL && argType != Type_STRING {
+		return nil, newDiagnostic(DiagnosticCode_ARGUMENT_TYPE, expr.Location, "Builtin %s cannot print a %s value", builtin, argType)
+	}
+	return &ResolvedBuiltinCallExpr{
+		ExprType: ExprType_DECL_REF,
+		Location: expr.Location,
+		Builtin:  builtin,
+		Args:     args,
+	}, nil
+}
+
+// Resolves an expression, reporting any error in it and returning a ResolvedErrorExpr in its place
+func (sa *SemanticAnalyser) ResolveExpr(expr *Expr) ResolvedExpr {
+	resolved, err := sa.resolveExpr(expr)
+	if err != nil {
+		sa.report(err)
+		return errorExpr(expr)
+	}
+	return resolved
+}
+
+// Stands in for an expression that can't be resolved, because of an error in it that was reported
+func errorExpr(expr *Expr) ResolvedExpr {
+	return &ResolvedErrorExpr{
+		ExprType: expr.Type,
+		Location: expr.Location,
+	}
+}
+
+func (sa *SemanticAnalyser) resolveExpr(expr *Expr) (ResolvedExpr, error) {
 	switch expr.Type {
 	case ExprType_DECL_REF:
 		var resolvedArgs []ResolvedExpr
 		for _, arg := range expr.Args {
-			resolvedArg, err := sa.ResolveExpr(arg)
-			if err != nil {
-				return nil, fmt.Errorf("Error resolving argument: %s", err)
-			}
+			resolvedArg := sa.ResolveExpr(arg)
 			resolvedArgs = append(resolvedArgs, resolvedArg)
 		}
 
 		found := sa.FindResolvedDeclaration(expr.Value)
+		generic, ok := sa.FindDeclaration(expr.Value).(*FunctionDecl)
+		if ok && found == nil && sa.generics[generic] {
+			return sa.ResolveGenericCall(generic, expr, resolvedArgs)
+		}
 		if found == nil {
-			return nil, fmt.Errorf("Undeclared variable %s at %d:%d in %s", expr.Value, expr.Location.Line, expr.Location.Column, sa.currentScope.name)
+			builtin, ok := builtinNames[expr.Value]
+			if ok && expr.IsCall {
+				return sa.ResolveBuiltinCall(builtin, expr, resolvedArgs)
+			}
+			return nil, sa.notVisible(expr.Value, newDiagnostic(DiagnosticCode_UNDECLARED, expr.Location, "Undeclared variable %s", expr.Value))
+		}
+		if found.GetDeclType() == DeclType_STRUCT || found.GetDeclType() == DeclType_ENUM {
+			return nil, newDiagnostic(DiagnosticCode_NOT_A_VALUE, expr.Location, "%s %s is not a value", found.GetDeclType(), expr.Value)
+		}
+		variant, ok := found.(*ResolvedVariantDeclaration)
+		if ok {
+			return sa.ResolveVariantExpr(variant, expr, resolvedArgs)
+		}
+		if expr.IsCall {
+			err := sa.CheckCall(found, expr, resolvedArgs)
+			if err != nil {
+				return nil, err
+			}
+		} else if found.GetDeclType() == DeclType_FUNCTION {
+			return nil, newDiagnostic(DiagnosticCode_NOT_A_VALUE, expr.Location, "Function %s must be called", expr.Value)
 		}
 		return &ResolvedRefExpr{
 			ExprType: ExprType_DECL_REF,
@@ -230,139 +1160,1231 @@ func (sa *SemanticAnalyser) ResolveExpr(expr *Expr) (ResolvedExpr, error) {
 	case ExprType_INT:
 		val, err := strconv.Atoi(expr.Value)
 		if err != nil {
-			return nil, fmt.Errorf("Error parsing integer %s at %d:%d in %s", expr.Value, expr.Location.Line, expr.Location.Column, sa.currentScope.name)
+			return nil, newDiagnostic(DiagnosticCode_INVALID_INTEGER, expr.Location, "Error parsing integer %s", expr.Value)
 		}
 		return &ResolvedValueExpr{
 			ExprType: ExprType_INT,
 			Value:    val,
 		}, nil
+	case ExprType_BOOL:
+		return &ResolvedBoolExpr{
+			ExprType: ExprType_BOOL,
+			Value:    expr.Value == "true",
+		}, nil
+	case ExprType_STRING:
+		return &ResolvedStringExpr{
+			ExprType: ExprType_STRING,
+			Value:    expr.Value,
+		}, nil
+	case ExprType_STRUCT:
+		return sa.ResolveStructExpr(expr)
+	case ExprType_FIELD:
+		structExpr := sa.ResolveExpr(expr.Lhs)
+		if structExpr.GetType().IsError() {
+			return errorExpr(expr), nil
+		}
+
+		structDecl, ok := sa.structs[structExpr.GetType().Name]
+		if structExpr.GetType().Kind != TypeType_CUSTOM || !ok {
+			return nil, newDiagnostic(DiagnosticCode_UNKNOWN_FIELD, expr.Location, "Cannot access field %s of %s", expr.Value, structExpr.GetType())
+		}
+		index := structDecl.FieldIndex(expr.Value)
+		if index < 0 {
+			return nil, newDiagnostic(DiagnosticCode_UNKNOWN_FIELD, expr.Location, "Struct %s has no field %s", structDecl.Id, expr.Value)
+		}
+		return &ResolvedFieldExpr{
+			ExprType: ExprType_FIELD,
+			Location: expr.Location,
+			Struct:   structExpr,
+			Index:    index,
+			Type:     structDecl.Fields[index].Type,
+		}, nil
+	case ExprType_ARRAY:
+		return sa.ResolveArrayExpr(expr)
+	case ExprType_INDEX:
+		return sa.ResolveIndexExpr(expr)
+	case ExprType_MATCH:
+		return sa.ResolveMatchExpr(expr)
+	case ExprType_BINARY:
+		lhs := sa.ResolveExpr(expr.Lhs)
+		rhs := sa.ResolveExpr(expr.Rhs)
+
+		resultType, err := binaryOperatorType(expr.Operator, lhs.GetType(), rhs.GetType(), expr.Location)
+		if err != nil {
+			return nil, err
+		}
+		return &ResolvedBinaryExpr{
+			ExprType: ExprType_BINARY,
+			Location: expr.Location,
+			Operator: expr.Operator,
+			Lhs:      lhs,
+			Rhs:      rhs,
+			Type:     resultType,
+		}, nil
+	case ExprType_UNARY:
+		operand := sa.ResolveExpr(expr.Rhs)
+
+		// Negation works on ints, logical not on bools, and both produce their operand's type
+		operator := TokenTypeToOperator[expr.Operator]
+		operandType := Type_INT
+		if expr.Operator == TokenType_BANG {
+			operandType = Type_BOOL
+		}
+		if !operand.GetType().Equals(operandType) {
+			return nil, newDiagnostic(DiagnosticCode_INVALID_OPERAND, expr.Location, "Operator %s expects a %s operand, got %s", operator, operandType, operand.GetType())
+		}
+		return &ResolvedUnaryExpr{
+			ExprType: ExprType_UNARY,
+			Location: expr.Location,
+			Operator: expr.Operator,
+			Operand:  operand,
+			Type:     operandType,
+		}, nil
+	}
+	return nil, newDiagnostic(DiagnosticCode_INTERNAL, expr.Location, "Unknown expression type %d", expr.Type)
+}
+
+// Resolves an array literal, whose elements must all have the type of the first one.
+// An empty literal has no element to take the type from, so it isn't allowed.
+func (sa *SemanticAnalyser) ResolveArrayExpr(expr *Expr) (ResolvedExpr, error) {
+	if len(expr.Args) == 0 {
+		return nil, newDiagnostic(DiagnosticCode_EMPTY_ARRAY, expr.Location, "Empty array literal")
+	}
+
+	elems := make([]ResolvedExpr, len(expr.Args))
+	for i, arg := range expr.Args {
+		elem := sa.ResolveExpr(arg)
+		if elem.GetType() == Type_VOID {
+			return nil, newDiagnostic(DiagnosticCode_VOID_VALUE, arg.Location, "Array element cannot be void")
+		}
+		if i > 0 && !elem.GetType().Equals(elems[0].GetType()) {
+			return nil, newDiagnostic(DiagnosticCode_TYPE_MISMATCH, arg.Location, "Array element is %s but the first element is %s", elem.GetType(), elems[0].GetType())
+		}
+		elems[i] = elem
+	}
+	return &ResolvedArrayExpr{
+		ExprType: ExprType_ARRAY,
+		Location: expr.Location,
+		Elems:    elems,
+		Type:     ArrayType(elems[0].GetType(), len(elems)),
+	}, nil
+}
+
+// Returns the value of an int literal or a negated one, which are the only constants the analyser evaluates
+func constantInt(expr ResolvedExpr) (int, bool) {
+	switch expr.(type) {
+	case *ResolvedValueExpr:
+		return expr.(*ResolvedValueExpr).Value, true
+	case *ResolvedUnaryExpr:
+		unaryExpr := expr.(*ResolvedUnaryExpr)
+		value, ok := constantInt(unaryExpr.Operand)
+		if unaryExpr.Operator != TokenType_MINUS || !ok {
+			return 0, false
+		}
+		return -value, true
+	}
+	return 0, false
+}
+
+// Resolves indexing an array or slice with an int. Indexes are checked at runtime, but constant ones
+// that are negative, or not below the length of an array, are rejected here already.
+func (sa *SemanticAnalyser) ResolveIndexExpr(expr *Expr) (ResolvedExpr, error) {
+	array := sa.ResolveExpr(expr.Lhs)
+	index := sa.ResolveExpr(expr.Rhs)
+
+	arrayType := array.GetType()
+	if arrayType.IsError() {
+		return errorExpr(expr), nil
+	}
+	if !arrayType.IsSequence() {
+		return nil, newDiagnostic(DiagnosticCode_NOT_INDEXABLE, expr.Location, "Cannot index %s", arrayType)
 	}
-	return nil, fmt.Errorf("Unknown expression type %d at %d:%d in %s", expr.Type, expr.Location.Line, expr.Location.Column, sa.currentScope.name)
+	if !index.GetType().Equals(Type_INT) {
+		return nil, newDiagnostic(DiagnosticCode_NON_INT_INDEX, expr.Location, "Index must be int, got %s", index.GetType())
+	}
+	value, ok := constantInt(index)
+	if ok && (value < 0 || (arrayType.Kind == TypeType_ARRAY && value >= arrayType.Length)) {
+		return nil, newDiagnostic(DiagnosticCode_INDEX_OUT_OF_RANGE, expr.Location, "Index %d out of range for %s", value, arrayType)
+	}
+	return &ResolvedIndexExpr{
+		ExprType: ExprType_INDEX,
+		Location: expr.Location,
+		Array:    array,
+		Index:    index,
+		Type:     *arrayType.Elem,
+	}, nil
 }
 
-func (sa *SemanticAnalyser) ResolveStatement(stmt Statement) (*ResolvedStatement, error) {
+// Resolves building a value of an enum, which needs a value of each type of the variant's payload.
+// A variant without a payload is written without parentheses.
+func (sa *SemanticAnalyser) ResolveVariantExpr(variant *ResolvedVariantDeclaration, expr *Expr, payload []ResolvedExpr) (ResolvedExpr, error) {
+	if len(payload) != len(variant.Payload) {
+		return nil, newDiagnostic(DiagnosticCode_PAYLOAD_COUNT, expr.Location, "Variant %s expects %d payload values, got %d", variant.Id, len(variant.Payload), len(payload))
+	}
+	for i, value := range payload {
+		if !value.GetType().AssignableTo(variant.Payload[i]) {
+			location := expr.Args[i].Location
+			return nil, newDiagnostic(DiagnosticCode_TYPE_MISMATCH, location, "Payload value %d of %s is %s but given %s", i+1, variant.Id, variant.Payload[i], value.GetType())
+		}
+	}
+	return &ResolvedVariantExpr{
+		ExprType: ExprType_DECL_REF,
+		Location: expr.Location,
+		Variant:  variant,
+		Payload:  payload,
+	}, nil
+}
+
+// Resolves a match on an enum value. Every variant needs an arm, unless a `_` arm follows the others
+// to catch the rest, and all arms must have the type of the first one, which is the match's type.
+func (sa *SemanticAnalyser) ResolveMatchExpr(expr *Expr) (ResolvedExpr, error) {
+	value := sa.ResolveExpr(expr.Lhs)
+	if value.GetType().IsError() {
+		return errorExpr(expr), nil
+	}
+	enumDecl, ok := sa.enums[value.GetType().Name]
+	if value.GetType().Kind != TypeType_CUSTOM || !ok {
+		return nil, newDiagnostic(DiagnosticCode_NOT_AN_ENUM, expr.Location, "Cannot match %s", value.GetType())
+	}
+
+	// match is a keyword, so no source can refer to the variable holding the matched value
+	matchExpr := &ResolvedMatchExpr{
+		ExprType: ExprType_MATCH,
+		Location: expr.Location,
+		Value: &ResolvedVariableDeclaration{
+			Id:       "match",
+			DeclType: DeclType_VARIABLE,
+			Type:     value.GetType(),
+			Value:    value,
+			Location: expr.Location,
+		},
+		Enum: enumDecl,
+	}
+	// The arm that first matched each variant
+	covered := make([]*MatchArm, len(enumDecl.Variants))
+	hasDefault := false
+	for _, arm := range expr.Arms {
+		if hasDefault {
+			return nil, newDiagnostic(DiagnosticCode_UNREACHABLE_ARM, arm.Location, "Match arm after _ is never taken")
+		}
+		resolvedArm, err := sa.ResolveMatchArm(enumDecl, arm)
+		if err != nil {
+			return nil, err
+		}
+
+		if resolvedArm.Variant == nil {
+			hasDefault = true
+		} else if first := covered[resolvedArm.Variant.Index]; first != nil {
+			return nil, newDiagnostic(DiagnosticCode_DUPLICATE_ARM, arm.Location, "Variant %s matched twice", arm.Variant).
+				withLabel(first.Location, "%s is first matched", arm.Variant)
+		} else {
+			covered[resolvedArm.Variant.Index] = arm
+		}
+
+		armType := resolvedArm.Value.GetType()
+		if len(matchExpr.Arms) > 0 && !armType.Equals(matchExpr.Type) {
+			return nil, newDiagnostic(DiagnosticCode_TYPE_MISMATCH, arm.Location, "Match arm is %s but the first arm is %s", armType, matchExpr.Type)
+		}
+		matchExpr.Type = armType
+		matchExpr.Arms = append(matchExpr.Arms, resolvedArm)
+	}
+
+	if !hasDefault {
+		missing := make([]string, 0)
+		for i, variant := range enumDecl.Variants {
+			if covered[i] == nil {
+				missing = append(missing, variant.Id)
+			}
+		}
+		if len(missing) > 0 {
+			return nil, newDiagnostic(DiagnosticCode_NON_EXHAUSTIVE_MATCH, expr.Location, "Match on %s does not cover %s", enumDecl.Id, strings.Join(missing, ", "))
+		}
+	}
+	return matchExpr, nil
+}
+
+// Resolves an arm of a match on a value of enumDecl, whose bindings take the types of the variant's payload
+// and are only visible to the arm's value
+func (sa *SemanticAnalyser) ResolveMatchArm(enumDecl *ResolvedEnumDeclaration, arm *MatchArm) (*ResolvedMatchArm, error) {
+	outerScope := sa.currentScope
+	sa.currentScope = sa.armScopes[arm]
+	defer func() {
+		sa.currentScope = outerScope
+	}()
+
+	resolvedArm := &ResolvedMatchArm{
+		Bindings: make([]*ResolvedVariableDeclaration, len(arm.Bindings)),
+	}
+	if arm.Variant != "" {
+		index := enumDecl.VariantIndex(arm.Variant)
+		if index < 0 {
+			return nil, newDiagnostic(DiagnosticCode_UNKNOWN_VARIANT, arm.Location, "Enum %s has no variant %s", enumDecl.Id, arm.Variant)
+		}
+		variant := enumDecl.Variants[index]
+		if len(arm.Bindings) != len(variant.Payload) {
+			return nil, newDiagnostic(DiagnosticCode_PAYLOAD_COUNT, arm.Location, "Variant %s expects %d payload values, got %d", variant.Id, len(variant.Payload), len(arm.Bindings))
+		}
+		resolvedArm.Variant = variant
+
+		for i, binding := range arm.Bindings {
+			if binding == nil {
+				continue
+			}
+			resolvedArm.Bindings[i] = &ResolvedVariableDeclaration{
+				Id:       binding.GetId(),
+				DeclType: DeclType_VARIABLE,
+				Type:     variant.Payload[i],
+				Location: binding.Location,
+			}
+			sa.locals[binding] = resolvedArm.Bindings[i]
+		}
+	}
+
+	value := sa.ResolveExpr(arm.Value)
+	resolvedArm.Value = value
+	return resolvedArm, nil
+}
+
+// Resolves a struct literal, which must give every field of the struct once, with a value of its type
+func (sa *SemanticAnalyser) ResolveStructExpr(expr *Expr) (ResolvedExpr, error) {
+	decl := sa.findType(expr.Value)
+	structDecl, ok := sa.structs[sa.resolvedId(decl)]
+	if decl == nil || !ok {
+		return nil, sa.notVisible(expr.Value, newDiagnostic(DiagnosticCode_UNKNOWN_TYPE, expr.Location, "Unknown struct %s", expr.Value))
+	}
+
+	fields := make([]ResolvedExpr, len(structDecl.Fields))
+	for i, id := range expr.Fields {
+		location := expr.Args[i].Location
+		index := structDecl.FieldIndex(id)
+		if index < 0 {
+			return nil, newDiagnostic(DiagnosticCode_UNKNOWN_FIELD, location, "Struct %s has no field %s", structDecl.Id, id)
+		}
+		if fields[index] != nil {
+			first := expr.Args[slices.Index(expr.Fields, id)].Location
+			return nil, newDiagnostic(DiagnosticCode_DUPLICATE_FIELD, location, "Field %s of %s given twice", id, structDecl.Id).
+				withLabel(first, "%s is first given", id)
+		}
+
+		value := sa.ResolveExpr(expr.Args[i])
+		fieldType := structDecl.Fields[index].Type
+		if !value.GetType().AssignableTo(fieldType) {
+			return nil, newDiagnostic(DiagnosticCode_TYPE_MISMATCH, location, "Field %s of %s is %s but given %s", id, structDecl.Id, fieldType, value.GetType())
+		}
+		fields[index] = value
+	}
+
+	for i, field := range structDecl.Fields {
+		if fields[i] == nil {
+			return nil, newDiagnostic(DiagnosticCode_MISSING_FIELD, expr.Location, "Missing field %s in %s literal", field.Id, structDecl.Id)
+		}
+	}
+	return &ResolvedStructExpr{
+		ExprType: ExprType_STRUCT,
+		Location: expr.Location,
+		Struct:   structDecl,
+		Fields:   fields,
+	}, nil
+}
+
+// Checks the type a return statement returns against the return type of the function being resolved.
+// Without an annotation it's collected instead, for inferReturnType to unify with the others.
+func (sa *SemanticAnalyser) unifyReturnType(valueType Type, location *SourceLocation) error {
+	fn := sa.currentResolved
+	if fn.ReturnType == Type_INFERRED {
+		sa.returns = append(sa.returns, returnedType{valueType, *location})
+		return nil
+	}
+	if valueType.AssignableTo(fn.ReturnType) {
+		return nil
+	}
+	if sa.currentFunction.ReturnType == Type_INFERRED {
+		return newDiagnostic(DiagnosticCode_TYPE_MISMATCH, *location, "Function %s returns %s but an earlier return statement returns %s", sa.currentFunction.GetId(), valueType, fn.ReturnType)
+	}
+	return newDiagnostic(DiagnosticCode_TYPE_MISMATCH, *location, "Function %s returns %s but declared as %s", sa.currentFunction.GetId(), valueType, fn.ReturnType)
+}
+
+// Resolves a statement, failing for one that can't be built. Errors after which it can be, like a condition
+// that isn't bool, are reported instead, so the rest of the statement is still checked.
+func (sa *SemanticAnalyser) ResolveStatement(stmt Statement) (ResolvedStatement, error) {
 	switch stmt.(type) {
 	case *ReturnStmt:
 		expr := stmt.(*ReturnStmt).Expr
 		if expr == nil {
-			return &ResolvedStatement{
+			err := sa.unifyReturnType(Type_VOID, stmt.GetLocation())
+			if err != nil {
+				sa.report(err)
+			}
+			return &ResolvedReturnStatement{
 				StmtType: StmtType_RETURN,
 			}, nil
 		}
-		resolvedExpr, err := sa.ResolveExpr(expr)
+		// A value of the wrong type is still returned, so the function isn't also reported as missing a return
+		resolvedExpr := sa.ResolveExpr(expr)
+		err := sa.unifyReturnType(resolvedExpr.GetType(), stmt.GetLocation())
 		if err != nil {
-			return nil, fmt.Errorf("Error resolving expression: %s", err)
+			sa.report(err)
 		}
-		return &ResolvedStatement{
+		return &ResolvedReturnStatement{
 			StmtType: StmtType_RETURN,
 			Expr:     resolvedExpr,
 		}, nil
+	case *LetStmt:
+		// The variable is declared whatever is wrong with it, with the error type if its type isn't known,
+		// so its uses don't report it as undeclared
+		decl := stmt.(*LetStmt).Decl
+		resolvedExpr := sa.ResolveExpr(decl.Value)
+
+		declType, err := sa.ResolveType(decl.Type, decl.Location)
+		if err != nil {
+			sa.report(err)
+			declType = Type_ERROR
+		}
+		valueType := resolvedExpr.GetType()
+		if valueType == Type_VOID {
+			sa.report(newDiagnostic(DiagnosticCode_VOID_VALUE, decl.Location, "Variable %s cannot be initialized with a void value", decl.GetId()))
+			valueType = Type_ERROR
+		}
+		if declType != Type_INFERRED {
+			if !valueType.AssignableTo(declType) {
+				sa.report(newDiagnostic(DiagnosticCode_TYPE_MISMATCH, decl.Location, "Variable %s declared as %s but initialized with %s", decl.GetId(), declType, valueType))
+			}
+			valueType = declType
+		}
+
+		// Locals are only visible to the rest of their function, so they stay out of resolvedDeclarations
+		variable := &ResolvedVariableDeclaration{
+			Id:       decl.GetId(),
+			DeclType: decl.GetKind(),
+			Type:     valueType,
+			Value:    resolvedExpr,
+			Location: decl.Location,
+		}
+		sa.locals[decl] = variable
+		return &ResolvedLetStatement{
+			StmtType: StmtType_LET,
+			Variable: variable,
+		}, nil
+	case *AssignStmt:
+		assignStmt := stmt.(*AssignStmt)
+		location := assignStmt.Location
+		resolvedExpr := sa.ResolveExpr(assignStmt.Expr)
+		found := sa.FindResolvedDeclaration(assignStmt.Id)
+		if found == nil {
+			return nil, newDiagnostic(DiagnosticCode_UNDECLARED, location, "Undeclared variable %s", assignStmt.Id)
+		}
+		variable, ok := found.(*ResolvedVariableDeclaration)
+		if !ok {
+			return nil, newDiagnostic(DiagnosticCode_NOT_ASSIGNABLE, location, "Cannot assign to %s %s", strings.ToLower(found.GetDeclType().String()), assignStmt.Id)
+		}
+		if !resolvedExpr.GetType().AssignableTo(variable.Type) {
+			return nil, newDiagnostic(DiagnosticCode_TYPE_MISMATCH, location, "Cannot assign %s to %s of type %s", resolvedExpr.GetType(), assignStmt.Id, variable.Type)
+		}
+		return &ResolvedAssignStatement{
+			StmtType: StmtType_ASSIGN,
+			Variable: variable,
+			Expr:     resolvedExpr,
+		}, nil
+	case *ExprStmt:
+		resolvedExpr := sa.ResolveExpr(stmt.(*ExprStmt).Expr)
+		return &ResolvedExprStatement{
+			StmtType: StmtType_EXPR,
+			Expr:     resolvedExpr,
+		}, nil
+	case *IfStmt:
+		ifStmt := stmt.(*IfStmt)
+		cond := sa.ResolveExpr(ifStmt.Cond)
+		if !cond.GetType().Equals(Type_BOOL) {
+			sa.report(newDiagnostic(DiagnosticCode_NON_BOOL_CONDITION, ifStmt.Location, "Condition must be bool, got %s", cond.GetType()))
+		}
+
+		then := sa.ResolveBlock(ifStmt.Then)
+		var elseBlock *ResolvedBlock
+		if ifStmt.Else != nil {
+			elseBlock = sa.ResolveBlock(ifStmt.Else)
+		}
+		return &ResolvedIfStatement{
+			StmtType: StmtType_IF,
+			Cond:     cond,
+			Then:     then,
+			Else:     elseBlock,
+		}, nil
+	case *WhileStmt:
+		whileStmt := stmt.(*WhileStmt)
+		cond := sa.ResolveExpr(whileStmt.Cond)
+		if !cond.GetType().Equals(Type_BOOL) {
+			sa.report(newDiagnostic(DiagnosticCode_NON_BOOL_CONDITION, whileStmt.Location, "Condition must be bool, got %s", cond.GetType()))
+		}
+
+		body := sa.ResolveLoopBody(whileStmt.Body)
+		return &ResolvedWhileStatement{
+			StmtType: StmtType_WHILE,
+			Cond:     cond,
+			Body:     body,
+		}, nil
+	case *ForStmt:
+		forStmt := stmt.(*ForStmt)
+		start := sa.ResolveExpr(forStmt.Var.Value)
+		end := sa.ResolveExpr(forStmt.End)
+		if !start.GetType().Equals(Type_INT) || !end.GetType().Equals(Type_INT) {
+			sa.report(newDiagnostic(DiagnosticCode_NON_INT_RANGE, forStmt.Location, "Range must be int, got %s..%s", start.GetType(), end.GetType()))
+		}
+
+		variable := &ResolvedVariableDeclaration{
+			Id:       forStmt.Var.GetId(),
+			DeclType: DeclType_VARIABLE,
+			Type:     Type_INT,
+			Value:    start,
+			Location: forStmt.Var.Location,
+		}
+		// Source identifiers can't contain '_', so the limit never clashes with a real variable
+		limit := &ResolvedVariableDeclaration{
+			Id:       forStmt.Var.GetId() + "_end",
+			DeclType: DeclType_VARIABLE,
+			Type:     Type_INT,
+			Value:    end,
+		}
+
+		sa.locals[forStmt.Var] = variable
+		body := sa.ResolveLoopBody(forStmt.Body)
+		return &ResolvedForStatement{
+			StmtType: StmtType_FOR,
+			Variable: variable,
+			Limit:    limit,
+			Body:     body,
+		}, nil
+	case *BreakStmt, *ContinueStmt:
+		location := stmt.GetLocation()
+		if sa.loopDepth == 0 {
+			return nil, newDiagnostic(DiagnosticCode_OUTSIDE_LOOP, *location, "%s outside of a loop", stmt.GetKind())
+		}
+		if stmt.GetKind() == StmtType_BREAK {
+			return &ResolvedBreakStatement{StmtType: StmtType_BREAK}, nil
+		}
+		return &ResolvedContinueStatement{StmtType: StmtType_CONTINUE}, nil
 	}
-	return nil, fmt.Errorf("Unknown statement type %d at %d:%d in %s", stmt.GetKind(), stmt.GetLocation().Line, stmt.GetLocation().Column, sa.currentScope.name)
+	return nil, newDiagnostic(DiagnosticCode_INTERNAL, *stmt.GetLocation(), "Unknown statement type %d", stmt.GetKind())
 }
 
-func (sa *SemanticAnalyser) ResolveBlock(block *Block) (*ResolvedBlock, error) {
+// Resolves the body of a loop, in which break and continue are allowed
+func (sa *SemanticAnalyser) ResolveLoopBody(block *Block) *ResolvedBlock {
+	sa.loopDepth++
+	defer func() {
+		sa.loopDepth--
+	}()
+	return sa.ResolveBlock(block)
+}
+
+// Resolves a block in the scope built for it, whose let bindings go out of scope at its end. A statement
+// that fails to resolve is reported and left out, as is one with a syntax error, which the parser reported.
+func (sa *SemanticAnalyser) ResolveBlock(block *Block) *ResolvedBlock {
+	outerScope := sa.currentScope
+	sa.currentScope = sa.blockScopes[block]
+	defer func() {
+		sa.currentScope = outerScope
+	}()
+
 	resolvedBlock := &ResolvedBlock{}
 	for _, stmt := range block.Stmts {
+		if stmt.GetKind() == StmtType_ERROR {
+			continue
+		}
 		resolvedStmt, err := sa.ResolveStatement(stmt)
 		if err != nil {
-			return nil, fmt.Errorf("Error resolving statement %s: %s", stmt.GetKind(), err)
+			sa.report(err)
+			continue
 		}
 		resolvedBlock.Stmts = append(resolvedBlock.Stmts, resolvedStmt)
 	}
-	return resolvedBlock, nil
+	return resolvedBlock
 }
 
-func (sa *SemanticAnalyser) ResolveVariableDeclaration(decl *VariableDecl) (*ResolvedVariableDeclaration, error) {
+// Resolves a global variable, which the declarations after it may refer to
+func (sa *SemanticAnalyser) ResolveVariableDeclaration(decl *VariableDecl) *ResolvedVariableDeclaration {
+	resolvedDeclaration := sa.resolveVariable(decl)
+	sa.resolvedDeclarations = append(sa.resolvedDeclarations, resolvedDeclaration)
+	sa.globals[decl] = resolvedDeclaration
+	return resolvedDeclaration
+}
+
+// Resolves the type and value of a global variable or parameter, whose type is the error type if it isn't known
+func (sa *SemanticAnalyser) resolveVariable(decl *VariableDecl) *ResolvedVariableDeclaration {
+	declType, err := sa.ResolveType(decl.Type, decl.Location)
+	if err != nil {
+		sa.report(err)
+		declType = Type_ERROR
+	}
+
 	var resolvedExpr ResolvedExpr
 	if decl.Value != nil {
-		var err error
-		resolvedExpr, err = sa.ResolveExpr(decl.Value)
-		if err != nil {
-			return nil, fmt.Errorf("Error resolving expression: %s", err)
-		}
+		resolvedExpr = sa.ResolveExpr(decl.Value)
 	}
-	resolvedDeclaration := &ResolvedVariableDeclaration{
+	return &ResolvedVariableDeclaration{
 		Id:       decl.GetId(),
 		DeclType: decl.GetKind(),
-		Type:     decl.Type,
+		Type:     declType,
 		Value:    resolvedExpr,
+		Location: decl.Location,
 	}
+}
 
-	sa.resolvedDeclarations = append(sa.resolvedDeclarations, resolvedDeclaration)
+// Registers a function with its signature, before the body of any function of its module is resolved, so
+// every one of them may call it. Its body is left pending, for ResolveSymbols or its first call to resolve.
+func (sa *SemanticAnalyser) DeclareFunction(decl *FunctionDecl) *ResolvedFunctionDeclaration {
+	functionDeclaration := &ResolvedFunctionDeclaration{
+		Id:       sa.resolvedId(decl),
+		DeclType: decl.GetKind(),
+		Location: decl.Location,
+	}
+	sa.globals[decl] = functionDeclaration
+	sa.resolveSignature(decl, functionDeclaration)
+	sa.pending[functionDeclaration] = decl
+	return functionDeclaration
+}
 
-	return resolvedDeclaration, nil
+// Resolves the body of a declared function whose body is still pending
+func (sa *SemanticAnalyser) ResolveFunctionDeclaration(functionDeclaration *ResolvedFunctionDeclaration) {
+	decl := sa.pending[functionDeclaration]
+	delete(sa.pending, functionDeclaration)
+	sa.resolveBody(decl, functionDeclaration)
+	sa.resolvedDeclarations = append(sa.resolvedDeclarations, functionDeclaration)
 }
 
-func (sa *SemanticAnalyser) ResolveFunctionDeclaration(decl *FunctionDecl) (*ResolvedFunctionDeclaration, error) {
+// Fills in the parameters and return type of a function, with the type arguments in sa.typeArgs for an
+// instance of a generic one. Without an annotation the return type stays Type_INFERRED until the body
+// is resolved. Parameters share the scope of the body, where the types of the signature are looked up,
+// so those of an instance are looked up in the generic function's module rather than the caller's.
+func (sa *SemanticAnalyser) resolveSignature(decl *FunctionDecl, functionDeclaration *ResolvedFunctionDeclaration) {
+	outerScope := sa.currentScope
+	sa.currentScope = sa.blockScopes[decl.Body]
+	defer func() {
+		sa.currentScope = outerScope
+	}()
+
+	returnType, err := sa.ResolveType(decl.ReturnType, decl.Location)
+	if err != nil {
+		sa.report(err)
+		returnType = Type_ERROR
+	}
+	functionDeclaration.ReturnType = returnType
+
 	var resolvedParams []ResolvedDeclaration
 	for _, param := range decl.Params {
-		resolvedParam, err := sa.ResolveVariableDeclaration(param)
+		resolvedParams = append(resolvedParams, sa.resolveVariable(param))
+	}
+	functionDeclaration.Params = resolvedParams
+}
+
+// Fills in the body of a function whose signature is resolved. Without a return type annotation, the
+// return type is inferred from the body's return statements, and void if there are none.
+func (sa *SemanticAnalyser) resolveBody(decl *FunctionDecl, functionDeclaration *ResolvedFunctionDeclaration) {
+	outerScope := sa.currentScope
+	sa.currentScope = sa.blockScopes[decl.Body]
+	defer func() {
+		sa.currentScope = outerScope
+	}()
+
+	sa.locals = make(map[Declaration]ResolvedDeclaration)
+	sa.currentFunction = decl
+	sa.currentResolved = functionDeclaration
+	for i, param := range decl.Params {
+		sa.locals[param] = functionDeclaration.Params[i]
+	}
+
+	var resolvedBlock *ResolvedBlock
+	if decl.ReturnType == Type_INFERRED {
+		resolvedBlock = sa.resolveInferredBody(decl, functionDeclaration)
+	} else {
+		resolvedBlock = sa.ResolveBlock(decl.Body)
+	}
+	// Return statements check their own types, but only void functions may fall off the end of their body
+	if !functionDeclaration.ReturnType.Equals(Type_VOID) && !resolvedBlock.AlwaysReturns() {
+		sa.report(newDiagnostic(DiagnosticCode_MISSING_RETURN, decl.Location, "Function %s does not return a value on all paths", decl.GetId()))
+	}
+	functionDeclaration.Body = resolvedBlock
+}
+
+// The type a return statement returns, with where it is
+type returnedType struct {
+	valueType Type
+	location  SourceLocation
+}
+
+// Resolves the body of a function without a return type annotation, unifying the types its return
+// statements return afterwards, so their order doesn't matter. A call of the function to itself has the
+// return type as a type variable meanwhile, which tells nothing about it, so returns of such calls are
+// left out. If there are any, the body is resolved again with the unified type, the calls having it.
+// Whatever the first pass reported or instantiated is dropped then, since it was found without it.
+func (sa *SemanticAnalyser) resolveInferredBody(decl *FunctionDecl, fn *ResolvedFunctionDeclaration) *ResolvedBlock {
+	reported, resolved := len(sa.diagnostics), len(sa.resolvedDeclarations)
+	instances, instanceCounts, pending := maps.Clone(sa.instances), maps.Clone(sa.instanceCounts), maps.Clone(sa.pending)
+	fn.ReturnType = Type_INFERRED
+	sa.returns = nil
+	sa.calledItself = false
+	resolvedBlock := sa.ResolveBlock(decl.Body)
+
+	returnType, errs := sa.inferReturnType(decl, sa.returns)
+	if !sa.calledItself {
+		for _, err := range errs {
+			sa.report(err)
+		}
+		// Every return statement returns a value with an error, which is reported
+		if returnType == Type_INFERRED {
+			returnType = Type_ERROR
+		}
+		fn.ReturnType = returnType
+		return resolvedBlock
+	}
+	sa.diagnostics = sa.diagnostics[:reported]
+	sa.resolvedDeclarations = sa.resolvedDeclarations[:resolved]
+	sa.instances, sa.instanceCounts, sa.pending = instances, instanceCounts, pending
+	// Return statements returning other types are reported again against the unified type
+	if returnType == Type_INFERRED {
+		sa.report(newDiagnostic(DiagnosticCode_UNKNOWN_RETURN_TYPE, decl.Location, "Function %s only returns the values of calls to itself, so its return type can't be inferred", decl.GetId()).
+			withNote("Annotating the return type of %s gives its calls a type", decl.GetId()))
+		returnType = Type_ERROR
+	}
+	fn.ReturnType = returnType
+	return sa.ResolveBlock(decl.Body)
+}
+
+// Unifies the types return statements return into the one every value returned can be used as, the
+// slice type for arrays of the same element type but different lengths, or for an array and a slice.
+// Returns of a call of the function to itself are left out, as are those of the error type, and without
+// any others the type stays Type_INFERRED. A return that doesn't unify is reported against the type of
+// those before it.
+func (sa *SemanticAnalyser) inferReturnType(decl *FunctionDecl, returns []returnedType) (Type, []error) {
+	if len(returns) == 0 {
+		return Type_VOID, nil
+	}
+	returnType := Type_INFERRED
+	var errs []error
+	for _, returned := range returns {
+		if returned.valueType.mentionsInferred() || returned.valueType.IsError() {
+			continue
+		}
+		if returnType == Type_INFERRED {
+			returnType = returned.valueType
+			continue
+		}
+		unified, ok := unifyTypes(returnType, returned.valueType)
+		if !ok {
+			errs = append(errs, newDiagnostic(DiagnosticCode_TYPE_MISMATCH, returned.location, "Function %s returns %s but an earlier return statement returns %s", decl.GetId(), returned.valueType, returnType))
+			continue
+		}
+		returnType = unified
+	}
+	return returnType, errs
+}
+
+// The type values of either type can be used as, if there's one
+func unifyTypes(a Type, b Type) (Type, bool) {
+	if a.AssignableTo(b) {
+		return b, true
+	}
+	if b.AssignableTo(a) {
+		return a, true
+	}
+	if a.Kind == TypeType_ARRAY && b.Kind == TypeType_ARRAY && a.Elem.Equals(*b.Elem) {
+		return SliceType(*a.Elem), true
+	}
+	return Type{}, false
+}
+
+// Reports whether a type mentions the type parameter with the given name
+func mentionsTypeParam(t Type, name string) bool {
+	if t.IsSequence() {
+		return mentionsTypeParam(*t.Elem, name)
+	}
+	return t.Kind == TypeType_CUSTOM && t.Name == name
+}
+
+// Registers a generic function, whose body is only resolved for the type arguments of each call. Every type
+// parameter has to appear in a parameter's type, or its type argument could never be inferred. The function
+// is registered even then, so calls to it aren't reported as undeclared.
+func (sa *SemanticAnalyser) RegisterGenericFunction(decl *FunctionDecl) error {
+	sa.generics[decl] = true
+	for i, typeParam := range decl.TypeParams {
+		for _, other := range decl.TypeParams[:i] {
+			if other.Id == typeParam.Id {
+				return newDiagnostic(DiagnosticCode_DUPLICATE_DECLARATION, typeParam.Location, "Duplicate type parameter %s of %s", typeParam.Id, decl.GetId()).
+					withLabel(other.Location, "%s is first declared", typeParam.Id)
+			}
+		}
+		used := slices.ContainsFunc(decl.Params, func(param *VariableDecl) bool {
+			return mentionsTypeParam(param.Type, typeParam.Id)
+		})
+		if !used {
+			return newDiagnostic(DiagnosticCode_UNUSED_TYPE_PARAMETER, typeParam.Location, "Type parameter %s of %s is not used by its parameters", typeParam.Id, decl.GetId())
+		}
+	}
+	return nil
+}
+
+// Binds the type parameters in a parameter's type to the parts of the argument's type they stand for.
+// Fails with a nil error for a mismatch, which the caller describes, since it knows the whole types.
+func inferTypeArgs(decl *FunctionDecl, param Type, arg Type, typeArgs map[string]Type, location SourceLocation) (bool, error) {
+	if param.IsSequence() {
+		if param.Kind != arg.Kind || param.Length != arg.Length {
+			return false, nil
+		}
+		return inferTypeArgs(decl, *param.Elem, *arg.Elem, typeArgs, location)
+	}
+
+	isTypeParam := slices.ContainsFunc(decl.TypeParams, func(typeParam *Decl) bool { return typeParam.Id == param.Name })
+	if param.Kind != TypeType_CUSTOM || !isTypeParam {
+		return arg.Equals(param), nil
+	}
+	if arg == Type_VOID {
+		return false, nil
+	}
+	typeArg, ok := typeArgs[param.Name]
+	if ok && !typeArg.Equals(arg) {
+		return false, newDiagnostic(DiagnosticCode_CONFLICTING_TYPE_ARGUMENT, location, "Type parameter %s of %s is inferred as both %s and %s", param.Name, decl.GetId(), typeArg, arg)
+	}
+	typeArgs[param.Name] = arg
+	return true, nil
+}
+
+// Resolves the parameter types of a generic function in its own module, keeping its type parameters. A type
+// that isn't known is left as the error type, for the instance to report.
+func (sa *SemanticAnalyser) genericParamTypes(decl *FunctionDecl) []Type {
+	outerScope, outerTypeArgs := sa.currentScope, sa.typeArgs
+	sa.currentScope = sa.blockScopes[decl.Body]
+	sa.typeArgs = make(map[string]Type)
+	for _, typeParam := range decl.TypeParams {
+		sa.typeArgs[typeParam.Id] = Type{Kind: TypeType_CUSTOM, Name: typeParam.Id}
+	}
+	defer func() {
+		sa.currentScope, sa.typeArgs = outerScope, outerTypeArgs
+	}()
+
+	types := make([]Type, len(decl.Params))
+	for i, param := range decl.Params {
+		paramType, err := sa.ResolveType(param.Type, param.Location)
 		if err != nil {
-			return nil, fmt.Errorf("Error resolving parameter in %s: %s", decl.GetId(), err)
+			paramType = Type_ERROR
 		}
-		resolvedParams = append(resolvedParams, resolvedParam)
-		sa.resolvedDeclarations = append(sa.resolvedDeclarations, resolvedParam)
+		types[i] = paramType
 	}
+	return types
+}
 
-	resolvedBlock, err := sa.ResolveBlock(decl.Body)
-	if err != nil {
-		return nil, fmt.Errorf("Error resolving block in %s: %s", decl.GetId(), err)
+// Resolves a call to a generic function, inferring its type arguments from the types of the arguments.
+// An array argument may be passed for a slice parameter, like for any other function.
+func (sa *SemanticAnalyser) ResolveGenericCall(decl *FunctionDecl, expr *Expr, args []ResolvedExpr) (ResolvedExpr, error) {
+	if !expr.IsCall {
+		return nil, newDiagnostic(DiagnosticCode_NOT_A_VALUE, expr.Location, "Generic function %s must be called", decl.GetId())
+	}
+	if len(args) != len(decl.Params) {
+		return nil, newDiagnostic(DiagnosticCode_ARGUMENT_COUNT, expr.Location, "Function %s expects %d arguments, got %d", decl.GetId(), len(decl.Params), len(args)).withLabel(decl.Location, "%s is declared", decl.GetId())
 	}
 
-	returnStatement := resolvedBlock.Stmts[len(resolvedBlock.Stmts)-1]
-	if returnStatement.StmtType != StmtType_RETURN {
-		return nil, fmt.Errorf("Function %s does not return a value", decl.GetId())
+	// Type arguments inferred from an argument with an error would only cause more in the instance
+	if slices.ContainsFunc(args, func(arg ResolvedExpr) bool { return arg.GetType().IsError() }) {
+		return errorExpr(expr), nil
 	}
 
-	if returnStatement.Expr == nil && decl.ReturnType != Type_VOID {
-		return nil, fmt.Errorf("Function %s returns void but declared as %s", decl.GetId(), decl.ReturnType)
-	} else if returnStatement.Expr != nil {
-		returnType := returnStatement.Expr.GetType()
-		if decl.ReturnType != returnType {
-			return nil, fmt.Errorf("Function %s returns %s but declared as %s", decl.GetId(), returnType, decl.ReturnType)
+	typeArgs := make(map[string]Type)
+	for i, paramType := range sa.genericParamTypes(decl) {
+		argType := args[i].GetType()
+		if paramType.Kind == TypeType_SLICE && argType.Kind == TypeType_ARRAY {
+			argType = SliceType(*argType.Elem)
+		}
+		location := expr.Args[i].Location
+		matches, err := inferTypeArgs(decl, paramType, argType, typeArgs, location)
+		if err != nil {
+			return nil, err
+		}
+		if !matches {
+			return nil, newDiagnostic(DiagnosticCode_ARGUMENT_TYPE, location, "Argument %d of %s is %s, which does not match %s", i+1, decl.GetId(), args[i].GetType(), paramType).withLabel(decl.Location, "%s is declared", decl.GetId())
 		}
 	}
 
-	functionDeclaration := &ResolvedFunctionDeclaration{
-		Id:       decl.GetId(),
+	typeArgStrs := make([]string, len(decl.TypeParams))
+	for i, typeParam := range decl.TypeParams {
+		typeArg, ok := typeArgs[typeParam.Id]
+		if !ok {
+			// A type parameter no parameter uses, which RegisterGenericFunction reported
+			return errorExpr(expr), nil
+		}
+		typeArgStrs[i] = typeArg.String()
+	}
+	key := sa.resolvedId(decl) + "<" + strings.Join(typeArgStrs, ", ") + ">"
+	instance, ok := sa.instances[key]
+	if !ok {
+		var err error
+		instance, err = sa.instantiate(decl, key, typeArgs, expr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if instance.ReturnType == Type_INFERRED {
+		err := sa.callWhileInferring(instance, expr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var value ResolvedDeclaration = instance
+	return &ResolvedRefExpr{
+		ExprType: ExprType_DECL_REF,
+		Value:    &value,
+		IsCall:   true,
+		Args:     args,
+	}, nil
+}
+
+// Checks a call to a function whose return type is still being inferred. The function may call itself,
+// the call's value having the return type as a type variable until the body is resolved again with it.
+// Others only call it meanwhile if it calls them in turn, which can't wait for its return type either.
+func (sa *SemanticAnalyser) callWhileInferring(fn *ResolvedFunctionDeclaration, expr *Expr) error {
+	if fn == sa.currentResolved {
+		sa.calledItself = true
+		return nil
+	}
+	return newDiagnostic(DiagnosticCode_UNKNOWN_RETURN_TYPE, expr.Location, "Function %s is called while its return type is inferred", expr.Value).withNote("Annotating the return type of %s lets the functions it calls call it", expr.Value)
+}
+
+// Resolves a function other than the one being resolved, which is set aside meanwhile, for a function
+// called before its body is resolved, with typeArgs for an instance of a generic one
+func (sa *SemanticAnalyser) resolveAside(typeArgs map[string]Type, resolve func()) {
+	locals, currentFunction, currentResolved, outerTypeArgs, loopDepth := sa.locals, sa.currentFunction, sa.currentResolved, sa.typeArgs, sa.loopDepth
+	returns, calledItself := sa.returns, sa.calledItself
+	sa.typeArgs = typeArgs
+	sa.loopDepth = 0
+	resolve()
+	sa.locals, sa.currentFunction, sa.currentResolved, sa.typeArgs, sa.loopDepth = locals, currentFunction, currentResolved, outerTypeArgs, loopDepth
+	sa.returns, sa.calledItself = returns, calledItself
+}
+
+// Limits how deep instances may call for new instances, which a generic function calling itself with
+// ever growing type arguments would do without end
+const maxInstantiationDepth = 16
+
+// Resolves a generic function for some type arguments as a function of its own, named after the generic
+// one and numbered, which a baisl identifier can't be. The instance is registered before its body is
+// resolved, so recursive calls with the same type arguments refer to it. The state of the function
+// being resolved is set aside meanwhile, since instances are resolved at their first call.
+func (sa *SemanticAnalyser) instantiate(decl *FunctionDecl, key string, typeArgs map[string]Type, expr *Expr) (*ResolvedFunctionDeclaration, error) {
+	if sa.instantiationDepth >= maxInstantiationDepth {
+		return nil, newDiagnostic(DiagnosticCode_INSTANTIATION_TOO_DEEP, expr.Location, "Instantiating %s nests more than %d instances", key, maxInstantiationDepth)
+	}
+
+	id := sa.resolvedId(decl)
+	instance := &ResolvedFunctionDeclaration{
+		Id:       id + "_" + strconv.Itoa(sa.instanceCounts[id]),
 		DeclType: decl.GetKind(),
-		Params:   resolvedParams,
-		Body:     resolvedBlock,
+		Location: decl.Location,
 	}
+	sa.instanceCounts[id]++
+	sa.instances[key] = instance
 
-	sa.resolvedDeclarations = append(sa.resolvedDeclarations, functionDeclaration)
-	return functionDeclaration, nil
+	// Errors in the instance are labelled with the call instantiating it, since they depend on its type arguments
+	reported := len(sa.diagnostics)
+	sa.instantiationDepth++
+	sa.resolveAside(typeArgs, func() {
+		sa.resolveSignature(decl, instance)
+		sa.resolveBody(decl, instance)
+	})
+	sa.instantiationDepth--
+	for _, diagnostic := range sa.diagnostics[reported:] {
+		diagnostic.withLabel(expr.Location, "%s is instantiated", key)
+	}
+
+	sa.resolvedDeclarations = append(sa.resolvedDeclarations, instance)
+	return instance, nil
 }
 
-func (sa *SemanticAnalyser) ResolveSymbols(declarations []Declaration) error {
+// Resolves every struct and enum declaration of a module, so they may refer to each other and be used by
+// functions in any order. A type may not contain itself, directly or through other types, since its values
+// would never end. A field or payload whose type isn't known gets the error type.
+func (sa *SemanticAnalyser) ResolveTypes(declarations []Declaration) {
+	structDecls := make([]*StructDecl, 0)
+	enumDecls := make([]*EnumDecl, 0)
 	for _, decl := range declarations {
 		switch decl.(type) {
-		case *VariableDecl:
-			_, err := sa.ResolveVariableDeclaration(decl.(*VariableDecl))
-			if err != nil {
-				return fmt.Errorf("Error resolving variable %s: %s", decl.GetId(), err)
+		case *StructDecl:
+			structDecls = append(structDecls, decl.(*StructDecl))
+			sa.structs[sa.resolvedId(decl)] = &ResolvedStructDeclaration{
+				Id:       sa.resolvedId(decl),
+				DeclType: DeclType_STRUCT,
 			}
-		case *FunctionDecl:
-			_, err := sa.ResolveFunctionDeclaration(decl.(*FunctionDecl))
+		case *EnumDecl:
+			enumDecls = append(enumDecls, decl.(*EnumDecl))
+			sa.enums[sa.resolvedId(decl)] = &ResolvedEnumDeclaration{
+				Id:       sa.resolvedId(decl),
+				DeclType: DeclType_ENUM,
+			}
+		}
+	}
+
+	for _, structDecl := range structDecls {
+		resolved := sa.structs[sa.resolvedId(structDecl)]
+		for _, field := range structDecl.Fields {
+			if index := resolved.FieldIndex(field.GetId()); index >= 0 {
+				sa.report(newDiagnostic(DiagnosticCode_DUPLICATE_DECLARATION, field.Location, "Duplicate field %s in struct %s", field.GetId(), structDecl.GetId()).
+					withLabel(structDecl.Fields[index].Location, "%s is first declared", field.GetId()))
+				continue
+			}
+			fieldType, err := sa.ResolveType(field.Type, field.Location)
 			if err != nil {
-				return fmt.Errorf("Error resolving function %s: %s", decl.GetId(), err)
+				sa.report(err)
+				fieldType = Type_ERROR
+			}
+			resolved.Fields = append(resolved.Fields, &ResolvedVariableDeclaration{
+				Id:       field.GetId(),
+				DeclType: DeclType_VARIABLE,
+				Type:     fieldType,
+			})
+		}
+	}
+
+	// A value of an enum without variants could never be built, so there would be nothing to match on
+	for _, enumDecl := range enumDecls {
+		if len(enumDecl.Variants) == 0 {
+			sa.report(newDiagnostic(DiagnosticCode_EMPTY_ENUM, enumDecl.Location, "Enum %s has no variants", enumDecl.GetId()))
+		}
+		resolved := sa.enums[sa.resolvedId(enumDecl)]
+		for i, variant := range enumDecl.Variants {
+			payload := make([]Type, len(variant.Payload))
+			for j, payloadType := range variant.Payload {
+				resolvedType, err := sa.ResolveType(payloadType, variant.Location)
+				if err != nil {
+					sa.report(err)
+					resolvedType = Type_ERROR
+				}
+				payload[j] = resolvedType
+			}
+			resolvedVariant := &ResolvedVariantDeclaration{
+				Id:       variant.GetId(),
+				DeclType: DeclType_VARIANT,
+				Enum:     resolved.Id,
+				Index:    i,
+				Payload:  payload,
+			}
+			resolved.Variants = append(resolved.Variants, resolvedVariant)
+			sa.globals[variant] = resolvedVariant
+		}
+	}
+
+	for _, decl := range declarations {
+		if decl.GetKind() != DeclType_STRUCT && decl.GetKind() != DeclType_ENUM {
+			continue
+		}
+		id := sa.resolvedId(decl)
+		err := sa.checkTypeCycle(id, []string{id}, *decl.GetLocation())
+		if err != nil {
+			sa.report(err)
+		}
+		var resolved ResolvedDeclaration = sa.structs[id]
+		if decl.GetKind() == DeclType_ENUM {
+			resolved = sa.enums[id]
+		}
+		sa.resolvedDeclarations = append(sa.resolvedDeclarations, resolved)
+		sa.globals[decl] = resolved
+	}
+}
+
+// Returns the declarations of a module marked pub, with the variants of its pub enums
+func publicDeclarations(module *Module) []Declaration {
+	var public []Declaration
+	for _, decl := range module.Declarations {
+		if !isPub(decl) {
+			continue
+		}
+		public = append(public, decl)
+		enumDecl, ok := decl.(*EnumDecl)
+		if ok {
+			for _, variant := range enumDecl.Variants {
+				public = append(public, variant)
 			}
 		}
 	}
+	return public
+}
+
+// Whether a top-level declaration is marked pub
+func isPub(decl Declaration) bool {
+	switch decl.(type) {
+	case *FunctionDecl:
+		return decl.(*FunctionDecl).Pub
+	case *StructDecl:
+		return decl.(*StructDecl).Pub
+	case *EnumDecl:
+		return decl.(*EnumDecl).Pub
+	}
+	return false
+}
+
+// Returns the top-level declaration of a module with the given id, or the variant of one of its enums,
+// with whether other modules may refer to it. Unlike a module's scope, the module's declarations can be
+// searched before its symbols have been analysed.
+func findTopLevel(module *Module, id string) (Declaration, bool) {
+	for _, decl := range module.Declarations {
+		if decl.GetKind() != DeclType_IMPORT && decl.GetId() == id {
+			return decl, isPub(decl)
+		}
+		enumDecl, ok := decl.(*EnumDecl)
+		if !ok {
+			continue
+		}
+		for _, variant := range enumDecl.Variants {
+			if variant.GetId() == id {
+				return variant, enumDecl.Pub
+			}
+		}
+	}
+	return nil, false
+}
+
+// Adds the pub declarations of an imported module to the scope of those a module imports, reporting one
+// with the name of a pub declaration of another module it imports at the import
+func (sa *SemanticAnalyser) importDeclarations(module *Module, imported *Module) {
+	location := SourceLocation{}
+	for _, decl := range module.Declarations {
+		importDecl, ok := decl.(*ImportDecl)
+		if ok && path.Clean(importDecl.Path) == path.Clean(imported.Path) {
+			location = importDecl.Location
+			break
+		}
+	}
+
+	for _, decl := range publicDeclarations(imported) {
+		first, ok := sa.currentScope.symbols[decl.GetId()]
+		if ok {
+			sa.report(newDiagnostic(DiagnosticCode_DUPLICATE_DECLARATION, location, "Imported modules %s and %s both declare %s", sa.topLevel[first].module.Path, imported.Path, decl.GetId()).
+				withLabel(*first.GetLocation(), "%s is declared in module %s", decl.GetId(), sa.topLevel[first].module.Path).
+				withLabel(*decl.GetLocation(), "%s is declared in module %s", decl.GetId(), imported.Path))
+			continue
+		}
+		sa.currentScope.symbols[decl.GetId()] = decl
+	}
+}
+
+// Returns the struct or enum a value of the type holds, directly or as the elements of arrays or slices
+func containedType(t Type) (string, bool) {
+	if t.IsSequence() {
+		return containedType(*t.Elem)
+	}
+	return t.Name, t.Kind == TypeType_CUSTOM
+}
+
+// Returns the types of the fields of the named struct, or of the payloads of the named enum's variants
+func (sa *SemanticAnalyser) memberTypes(name string) []Type {
+	types := make([]Type, 0)
+	structDecl, ok := sa.structs[name]
+	if ok {
+		for _, field := range structDecl.Fields {
+			types = append(types, field.Type)
+		}
+		return types
+	}
+	for _, variant := range sa.enums[name].Variants {
+		types = append(types, variant.Payload...)
+	}
+	return types
+}
+
+// Fails at location if the type starting path can be reached through the members of name, the last type on path.
+// Cycles not involving the first type are left to be reported for a type on them. Elements count as
+// contained too: without empty array literals a slice of a struct could never be built, and backends
+// lay out an enum's payloads before the enum itself.
+func (sa *SemanticAnalyser) checkTypeCycle(name string, path []string, location SourceLocation) error {
+	for _, member := range sa.memberTypes(name) {
+		contained, ok := containedType(member)
+		if !ok {
+			continue
+		}
+		if contained == path[0] {
+			kind := DeclType_STRUCT
+			if _, isEnum := sa.enums[path[0]]; isEnum {
+				kind = DeclType_ENUM
+			}
+			return newDiagnostic(DiagnosticCode_RECURSIVE_TYPE, location, "%s %s contains itself through %s", kind, path[0], strings.Join(append(path, contained), " -> "))
+		}
+		if slices.Contains(path, contained) {
+			continue
+		}
+		err := sa.checkTypeCycle(contained, append(path, contained), location)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// Resolves the declarations of a module, whose types come first. Every function is declared with its
+// signature before any body is resolved, so functions may call those declared after them. A module comes
+// after those it imports.
+func (sa *SemanticAnalyser) ResolveSymbols(declarations []Declaration) {
+	sa.ResolveTypes(declarations)
+
+	var functions []*ResolvedFunctionDeclaration
+	for _, decl := range declarations {
+		switch decl.(type) {
+		case *VariableDecl:
+			sa.ResolveVariableDeclaration(decl.(*VariableDecl))
+		case *FunctionDecl:
+			fn := decl.(*FunctionDecl)
+			if len(fn.TypeParams) > 0 {
+				err := sa.RegisterGenericFunction(fn)
+				if err != nil {
+					sa.report(err)
+				}
+				continue
+			}
+			functions = append(functions, sa.DeclareFunction(fn))
+		}
+	}
+
+	// Calls may have resolved functions with inferred return types already
+	for _, fn := range functions {
+		if _, ok := sa.pending[fn]; ok {
+			sa.ResolveFunctionDeclaration(fn)
+		}
+	}
+}
+
+// Analyses a program of a single module, returning its resolved declarations
 func (sa *SemanticAnalyser) Analyse(declarations []Declaration) ([]ResolvedDeclaration, error) {
-	sa.EnterScope("global")
-	err := sa.AnalyseSymbols(declarations)
-	if err != nil {
-		return nil, err
+	return sa.AnalyseModules([]*Module{{Declarations: declarations}})
+}
+
+// Analyses a program made of modules, each after the modules it imports, as the module loader returns
+// them. Analysing goes on after an error, so the error returned holds every diagnostic in the program,
+// sorted by location.
+func (sa *SemanticAnalyser) AnalyseModules(modules []*Module) ([]ResolvedDeclaration, error) {
+	sa.blockScopes = make(map[*Block]*Scope)
+	sa.armScopes = make(map[*MatchArm]*Scope)
+	sa.modules = modules
+	sa.moduleScopes = make(map[*Module]*Scope)
+	sa.topLevel = make(map[Declaration]topLevelDeclaration)
+	sa.diagnostics = nil
+
+	// Each module's scope is in one holding the pub declarations of the modules it imports
+	for _, module := range modules {
+		sa.currentScope = nil
+		sa.EnterScope("imports")
+		for _, imported := range module.Imports {
+			sa.importDeclarations(module, imported)
+		}
+		sa.EnterScope(module.Path)
+		sa.currentScope.module = module
+		sa.moduleScopes[module] = sa.currentScope
+		sa.AnalyseSymbols(module.Declarations)
 	}
 
-	err = sa.ResolveSymbols(declarations)
-	if err != nil {
-		return nil, err
+	sa.globals = make(map[Declaration]ResolvedDeclaration)
+	sa.structs = make(map[string]*ResolvedStructDeclaration)
+	sa.enums = make(map[string]*ResolvedEnumDeclaration)
+	sa.generics = make(map[*FunctionDecl]bool)
+	sa.instances = make(map[string]*ResolvedFunctionDeclaration)
+	sa.instanceCounts = make(map[string]int)
+	sa.pending = make(map[*ResolvedFunctionDeclaration]*FunctionDecl)
+	for _, module := range modules {
+		sa.currentScope = sa.moduleScopes[module]
+		sa.ResolveSymbols(module.Declarations)
 	}
 
 	hasMain := false
@@ -373,8 +2395,12 @@ func (sa *SemanticAnalyser) Analyse(declarations []Declaration) ([]ResolvedDecla
 		}
 	}
 	if !hasMain {
-		return nil, fmt.Errorf("No main function found")
+		sa.report(newDiagnostic(DiagnosticCode_MISSING_MAIN, SourceLocation{}, "No main function found"))
 	}
 
-	return sa.resolvedDeclarations, err
+	if sa.diagnostics.HasErrors() {
+		sa.diagnostics.Sort()
+		return nil, sa.diagnostics
+	}
+	return sa.resolvedDeclarations, nil
 }
@@ -2,6 +2,7 @@ package baisl_test
 
 import (
 	"encoding/json"
+	"slices"
 	"strings"
 	"testing"
 
@@ -15,11 +16,21 @@ type semanticAnalyserTest struct {
 }
 
 type semanticAnalyserFailTest struct {
+	// Program to parse and analyse, used instead of declarations when set
+	path          string
 	declarations  []baisl.Declaration
 	errorContains string
+	code          baisl.DiagnosticCode
 	name          string
 }
 
+// A program with several errors, each of which should be reported once and without the errors it would
+// cause in its uses
+type semanticAnalyserRecoveryTest struct {
+	path   string
+	errors []string
+}
+
 func getEmptyMainDeclarations() []baisl.Declaration {
 	decls := make([]baisl.Declaration, 0)
 	decls = append(decls, &baisl.FunctionDecl{
@@ -54,7 +65,8 @@ func getEmptyMainDeclarations() []baisl.Declaration {
 	return decls
 }
 
-func getReturnParamFuncDeclarations() []baisl.Declaration {
+// Declares returnParam(a: int) and a main returning returnParam(arg)
+func getReturnParamFuncDeclarations(arg *baisl.Expr) []baisl.Declaration {
 	decls := make([]baisl.Declaration, 0)
 
 	decls = append(decls, &baisl.FunctionDecl{
@@ -137,17 +149,7 @@ func getReturnParamFuncDeclarations() []baisl.Declaration {
 						Type:   baisl.ExprType_DECL_REF,
 						Value:  "returnParam",
 						IsCall: true,
-						Args: []*baisl.Expr{
-							{
-								Location: baisl.SourceLocation{
-									Line:   1,
-									Column: 1,
-								},
-								Type:   baisl.ExprType_DECL_REF,
-								Value:  "a",
-								IsCall: false,
-							},
-						},
+						Args:   []*baisl.Expr{arg},
 					},
 				},
 			},
@@ -157,6 +159,7 @@ func getReturnParamFuncDeclarations() []baisl.Declaration {
 	return decls
 }
 
+// Declares main(a: int) returning b
 func getReturnUndeclaredParamFuncDeclarations() []baisl.Declaration {
 	decls := []baisl.Declaration{
 		&baisl.FunctionDecl{
@@ -165,7 +168,7 @@ func getReturnUndeclaredParamFuncDeclarations() []baisl.Declaration {
 					Line:   1,
 					Column: 1,
 				},
-				Id: "returnParam",
+				Id: "main",
 			},
 			ReturnType: baisl.Type_INT,
 			Params: []*baisl.VariableDecl{
@@ -254,30 +257,562 @@ func getIncorrectReturnTypesFuncDeclarations() []baisl.Declaration {
 	return decls
 }
 
+func getVoidOperandDeclarations() []baisl.Declaration {
+	location := baisl.SourceLocation{
+		Line:   1,
+		Column: 1,
+	}
+	decls := []baisl.Declaration{
+		&baisl.FunctionDecl{
+			Decl: baisl.Decl{
+				Location: location,
+				Id:       "nothing",
+			},
+			ReturnType: baisl.Type_VOID,
+			Params:     make([]*baisl.VariableDecl, 0),
+			Body: &baisl.Block{
+				Location: location,
+				Stmts: []baisl.Statement{
+					&baisl.ReturnStmt{
+						Stmt: baisl.Stmt{
+							Location: location,
+							Kind:     baisl.StmtType_RETURN,
+						},
+					},
+				},
+			},
+		},
+		&baisl.FunctionDecl{
+			Decl: baisl.Decl{
+				Location: location,
+				Id:       "main",
+			},
+			ReturnType: baisl.Type_INT,
+			Params:     make([]*baisl.VariableDecl, 0),
+			Body: &baisl.Block{
+				Location: location,
+				Stmts: []baisl.Statement{
+					&baisl.ReturnStmt{
+						Stmt: baisl.Stmt{
+							Location: location,
+							Kind:     baisl.StmtType_RETURN,
+						},
+						Expr: &baisl.Expr{
+							Location: location,
+							Type:     baisl.ExprType_BINARY,
+							Operator: baisl.TokenType_PLUS,
+							Lhs: &baisl.Expr{
+								Location: location,
+								Type:     baisl.ExprType_INT,
+								Value:    "1",
+							},
+							Rhs: &baisl.Expr{
+								Location: location,
+								Type:     baisl.ExprType_DECL_REF,
+								Value:    "nothing",
+								IsCall:   true,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	return decls
+}
+
 var semanticAnalyserTests = []semanticAnalyserTest{
 	{
 		declarations: getEmptyMainDeclarations(),
-		expectedJson: "[{\"Id\":\"main\",\"DeclType\":0,\"Params\":null,\"Body\":{\"Stmts\":[{\"StmtType\":0,\"Expr\":null}]}}]",
+		expectedJson: "[{\"Id\":\"main\",\"DeclType\":0,\"Params\":null,\"ReturnType\":{\"Kind\":1,\"Name\":\"void\"},\"Body\":{\"Stmts\":[{\"StmtType\":0,\"Expr\":null}]}}]",
 		name:         "Empty main",
 	},
 	{
-		declarations: getReturnParamFuncDeclarations(),
-		expectedJson: `[{"Id":"a","DeclType":1,"Type":{"Kind":0,"Name":"int"},"Value":null},{"Id":"a","DeclType":1,"Type":{"Kind":0,"Name":"int"},"Value":null},{"Id":"returnParam","DeclType":0,"Params":[{"Id":"a","DeclType":1,"Type":{"Kind":0,"Name":"int"},"Value":null}],"Body":{"Stmts":[{"StmtType":0,"Expr":{"ExprType":0,"Value":{"Id":"a","DeclType":1,"Type":{"Kind":0,"Name":"int"},"Value":null},"IsCall":false,"Args":null}}]}},{"Id":"main","DeclType":0,"Params":null,"Body":{"Stmts":[{"StmtType":0,"Expr":{"ExprType":0,"Value":{"Id":"returnParam","DeclType":0,"Params":[{"Id":"a","DeclType":1,"Type":{"Kind":0,"Name":"int"},"Value":null}],"Body":{"Stmts":[{"StmtType":0,"Expr":{"ExprType":0,"Value":{"Id":"a","DeclType":1,"Type":{"Kind":0,"Name":"int"},"Value":null},"IsCall":false,"Args":null}}]}},"IsCall":true,"Args":[{"ExprType":0,"Value":{"Id":"a","DeclType":1,"Type":{"Kind":0,"Name":"int"},"Value":null},"IsCall":false,"Args":null}]}}]}}]`,
+		declarations: getReturnParamFuncDeclarations(&baisl.Expr{
+			Location: baisl.SourceLocation{
+				Line:   1,
+				Column: 1,
+			},
+			Type:  baisl.ExprType_INT,
+			Value: "1",
+		}),
+		expectedJson: `[{"Id":"returnParam","DeclType":0,"Params":[{"Id":"a","DeclType":1,"Type":{"Kind":0,"Name":"int"},"Value":null}],"ReturnType":{"Kind":0,"Name":"int"},"Body":{"Stmts":[{"StmtType":0,"Expr":{"ExprType":0,"Value":{"Id":"a","DeclType":1,"Type":{"Kind":0,"Name":"int"},"Value":null},"IsCall":false,"Args":null}}]}},{"Id":"main","DeclType":0,"Params":null,"ReturnType":{"Kind":0,"Name":"int"},"Body":{"Stmts":[{"StmtType":0,"Expr":{"ExprType":0,"Value":{"Id":"returnParam","DeclType":0,"Params":[{"Id":"a","DeclType":1,"Type":{"Kind":0,"Name":"int"},"Value":null}],"ReturnType":{"Kind":0,"Name":"int"},"Body":{"Stmts":[{"StmtType":0,"Expr":{"ExprType":0,"Value":{"Id":"a","DeclType":1,"Type":{"Kind":0,"Name":"int"},"Value":null},"IsCall":false,"Args":null}}]}},"IsCall":true,"Args":[{"ExprType":1,"Value":1}]}}]}}]`,
 		name:         "Return param",
 	},
 }
 
 var semanticAnalyserFailTests = []semanticAnalyserFailTest{
+	{
+		declarations: getReturnParamFuncDeclarations(&baisl.Expr{
+			Location: baisl.SourceLocation{
+				Line:   1,
+				Column: 1,
+			},
+			Type:  baisl.ExprType_DECL_REF,
+			Value: "a",
+		}),
+		errorContains: "Undeclared variable a at 1:1",
+		code:          baisl.DiagnosticCode_UNDECLARED,
+		name:          "Parameter of another function",
+	},
 	{
 		declarations:  getReturnUndeclaredParamFuncDeclarations(),
 		errorContains: "Undeclared variable b",
+		code:          baisl.DiagnosticCode_UNDECLARED,
 		name:          "Return undeclared param",
 	},
 	{
 		declarations:  getIncorrectReturnTypesFuncDeclarations(),
 		errorContains: "returns int but declared as void",
+		code:          baisl.DiagnosticCode_TYPE_MISMATCH,
 		name:          "Incorrect return type",
 	},
+	{
+		declarations:  getVoidOperandDeclarations(),
+		errorContains: "Operator + expects two int or two string operands, got int and void",
+		code:          baisl.DiagnosticCode_INVALID_OPERAND,
+		name:          "Void operand",
+	},
+	{
+		path:          "raw/duplicateLocal.baisl",
+		errorContains: "Duplicate declaration of x at 3:7",
+		code:          baisl.DiagnosticCode_DUPLICATE_DECLARATION,
+		name:          "Duplicate local",
+	},
+	{
+		path:          "raw/redeclareParam.baisl",
+		errorContains: "Duplicate declaration of a at 2:7",
+		code:          baisl.DiagnosticCode_DUPLICATE_DECLARATION,
+		name:          "Let redeclaring a parameter",
+	},
+	{
+		path:          "raw/selfReferencingLet.baisl",
+		errorContains: "Undeclared variable x at 2:11",
+		code:          baisl.DiagnosticCode_UNDECLARED,
+		name:          "Let referring to itself",
+	},
+	{
+		path:          "raw/assignUndeclared.baisl",
+		errorContains: "Undeclared variable y at 3:3",
+		code:          baisl.DiagnosticCode_UNDECLARED,
+		name:          "Assignment to undeclared variable",
+	},
+	{
+		path:          "raw/assignFunction.baisl",
+		errorContains: "Cannot assign to function one at 6:3",
+		code:          baisl.DiagnosticCode_NOT_ASSIGNABLE,
+		name:          "Assignment to function",
+	},
+	{
+		path:          "raw/assignStruct.baisl",
+		errorContains: "Cannot assign to struct Point at 6:3",
+		code:          baisl.DiagnosticCode_NOT_ASSIGNABLE,
+		name:          "Assignment to struct",
+	},
+	{
+		path:          "raw/voidLet.baisl",
+		errorContains: "Variable x cannot be initialized with a void value at 6:7",
+		code:          baisl.DiagnosticCode_VOID_VALUE,
+		name:          "Let with void initializer",
+	},
+	{
+		path:          "raw/missingReturnPath.baisl",
+		errorContains: "Function pick does not return a value on all paths",
+		code:          baisl.DiagnosticCode_MISSING_RETURN,
+		name:          "Missing return on one path",
+	},
+	{
+		path:          "raw/emptyNonVoid.baisl",
+		errorContains: "Function main does not return a value on all paths",
+		code:          baisl.DiagnosticCode_MISSING_RETURN,
+		name:          "Empty non-void body",
+	},
+	{
+		path:          "raw/blockScope.baisl",
+		errorContains: "Undeclared variable y at 5:10",
+		code:          baisl.DiagnosticCode_UNDECLARED,
+		name:          "Let used after its block",
+	},
+	{
+		path:          "raw/breakOutsideLoop.baisl",
+		errorContains: "Break outside of a loop at 3:5",
+		code:          baisl.DiagnosticCode_OUTSIDE_LOOP,
+		name:          "Break outside of a loop",
+	},
+	{
+		path:          "raw/continueAfterLoop.baisl",
+		errorContains: "Continue outside of a loop at 4:3",
+		code:          baisl.DiagnosticCode_OUTSIDE_LOOP,
+		name:          "Continue after a loop",
+	},
+	{
+		path:          "raw/intCondition.baisl",
+		errorContains: "Condition must be bool, got int at 3:3",
+		code:          baisl.DiagnosticCode_NON_BOOL_CONDITION,
+		name:          "Int condition",
+	},
+	{
+		path:          "raw/intLogical.baisl",
+		errorContains: "Operator && expects bool operands, got int and bool at 3:12",
+		code:          baisl.DiagnosticCode_INVALID_OPERAND,
+		name:          "Logical operator on an int",
+	},
+	{
+		path:          "raw/mismatchedEquality.baisl",
+		errorContains: "Operator == expects two int or two bool operands, got bool and int at 3:15",
+		code:          baisl.DiagnosticCode_INVALID_OPERAND,
+		name:          "Comparing a bool with an int",
+	},
+	{
+		path:          "raw/boolLetMismatch.baisl",
+		errorContains: "Variable flag declared as bool but initialized with int at 2:7",
+		code:          baisl.DiagnosticCode_TYPE_MISMATCH,
+		name:          "Int assigned to a bool let",
+	},
+	{
+		path:          "raw/boolReturnMismatch.baisl",
+		errorContains: "Function isZero returns int but declared as bool",
+		code:          baisl.DiagnosticCode_TYPE_MISMATCH,
+		name:          "Int returned from a bool function",
+	},
+	{
+		path:          "raw/printArity.baisl",
+		errorContains: "Builtin println expects 1 argument, got 2 at 2:3",
+		code:          baisl.DiagnosticCode_ARGUMENT_COUNT,
+		name:          "Println with two arguments",
+	},
+	{
+		path:          "raw/printVoid.baisl",
+		errorContains: "Builtin print cannot print a void value at 6:3",
+		code:          baisl.DiagnosticCode_ARGUMENT_TYPE,
+		name:          "Printing a void call",
+	},
+	{
+		path:          "raw/stringPlusInt.baisl",
+		errorContains: "Operator + expects two int or two string operands, got string and int at 2:20",
+		code:          baisl.DiagnosticCode_INVALID_OPERAND,
+		name:          "Concatenating a string with an int",
+	},
+	{
+		path:          "raw/unknownType.baisl",
+		errorContains: "Unknown type Vec at 2:7",
+		code:          baisl.DiagnosticCode_UNKNOWN_TYPE,
+		name:          "Let annotated with an unknown type",
+	},
+	{
+		path:          "raw/missingField.baisl",
+		errorContains: "Missing field y in Point literal at 4:11",
+		code:          baisl.DiagnosticCode_MISSING_FIELD,
+		name:          "Struct literal missing a field",
+	},
+	{
+		path:          "raw/unknownField.baisl",
+		errorContains: "Struct Point has no field z at 4:34",
+		code:          baisl.DiagnosticCode_UNKNOWN_FIELD,
+		name:          "Struct literal with an unknown field",
+	},
+	{
+		path:          "raw/duplicateField.baisl",
+		errorContains: "Duplicate field x in struct Point at 1:24",
+		code:          baisl.DiagnosticCode_DUPLICATE_DECLARATION,
+		name:          "Struct declaring a field twice",
+	},
+	{
+		path:          "raw/fieldTypeMismatch.baisl",
+		errorContains: "Field y of Point is int but given bool at 4:28",
+		code:          baisl.DiagnosticCode_TYPE_MISMATCH,
+		name:          "Struct literal field of the wrong type",
+	},
+	{
+		path:          "raw/fieldOfInt.baisl",
+		errorContains: "Cannot access field x of int at 3:12",
+		code:          baisl.DiagnosticCode_UNKNOWN_FIELD,
+		name:          "Field access on an int",
+	},
+	{
+		path:          "raw/structAsValue.baisl",
+		errorContains: "Struct Point is not a value at 4:11",
+		code:          baisl.DiagnosticCode_NOT_A_VALUE,
+		name:          "Struct name used as a value",
+	},
+	{
+		path:          "raw/recursiveStruct.baisl",
+		errorContains: "Struct Node contains itself through Node -> List -> Node at 1:8",
+		code:          baisl.DiagnosticCode_RECURSIVE_TYPE,
+		name:          "Structs containing each other",
+	},
+	{
+		path:          "raw/printStruct.baisl",
+		errorContains: "Builtin println cannot print a Point value at 4:3",
+		code:          baisl.DiagnosticCode_ARGUMENT_TYPE,
+		name:          "Printing a struct",
+	},
+	{
+		path:          "raw/constantIndexOutOfRange.baisl",
+		errorContains: "Index 3 out of range for [3]int at 3:12",
+		code:          baisl.DiagnosticCode_INDEX_OUT_OF_RANGE,
+		name:          "Constant index past the end of an array",
+	},
+	{
+		path:          "raw/negativeIndex.baisl",
+		errorContains: "Index -1 out of range for []int at 2:12",
+		code:          baisl.DiagnosticCode_INDEX_OUT_OF_RANGE,
+		name:          "Negative constant index into a slice",
+	},
+	{
+		path:          "raw/boolIndex.baisl",
+		errorContains: "Index must be int, got bool at 3:12",
+		code:          baisl.DiagnosticCode_NON_INT_INDEX,
+		name:          "Indexing with a bool",
+	},
+	{
+		path:          "raw/indexInt.baisl",
+		errorContains: "Cannot index int at 3:11",
+		code:          baisl.DiagnosticCode_NOT_INDEXABLE,
+		name:          "Indexing an int",
+	},
+	{
+		path:          "raw/lenInt.baisl",
+		errorContains: "Builtin len expects an array or slice, got int at 2:10",
+		code:          baisl.DiagnosticCode_ARGUMENT_TYPE,
+		name:          "Length of an int",
+	},
+	{
+		path:          "raw/mixedArray.baisl",
+		errorContains: "Array element is string but the first element is int at 2:16",
+		code:          baisl.DiagnosticCode_TYPE_MISMATCH,
+		name:          "Array literal mixing types",
+	},
+	{
+		path:          "raw/emptyArray.baisl",
+		errorContains: "Empty array literal at 2:12",
+		code:          baisl.DiagnosticCode_EMPTY_ARRAY,
+		name:          "Empty array literal",
+	},
+	{
+		path:          "raw/arrayLengthMismatch.baisl",
+		errorContains: "Variable xs declared as [2]int but initialized with [3]int at 2:7",
+		code:          baisl.DiagnosticCode_TYPE_MISMATCH,
+		name:          "Array literal of the wrong length",
+	},
+	{
+		path:          "raw/sliceToArray.baisl",
+		errorContains: "Variable ys declared as [2]int but initialized with []int at 3:7",
+		code:          baisl.DiagnosticCode_TYPE_MISMATCH,
+		name:          "Slice used as an array",
+	},
+	{
+		path:          "raw/nonExhaustiveMatch.baisl",
+		errorContains: "Match on Light does not cover Yellow at 5:10",
+		code:          baisl.DiagnosticCode_NON_EXHAUSTIVE_MATCH,
+		name:          "Match missing a variant",
+	},
+	{
+		path:          "raw/unknownVariant.baisl",
+		errorContains: "Enum Light has no variant Circle at 5:32",
+		code:          baisl.DiagnosticCode_UNKNOWN_VARIANT,
+		name:          "Matching a variant of another enum",
+	},
+	{
+		path:          "raw/variantPayloadCount.baisl",
+		errorContains: "Variant Rect expects 2 payload values, got 1 at 4:15",
+		code:          baisl.DiagnosticCode_PAYLOAD_COUNT,
+		name:          "Variant built with too few payload values",
+	},
+	{
+		path:          "raw/variantPayloadType.baisl",
+		errorContains: "Payload value 1 of Circle is int but given bool at 4:22",
+		code:          baisl.DiagnosticCode_TYPE_MISMATCH,
+		name:          "Variant built with the wrong payload type",
+	},
+	{
+		path:          "raw/variantValue.baisl",
+		errorContains: "Variant Circle expects 1 payload values, got 0 at 4:15",
+		code:          baisl.DiagnosticCode_PAYLOAD_COUNT,
+		name:          "Variant with a payload used without one",
+	},
+	{
+		path:          "raw/matchBindingCount.baisl",
+		errorContains: "Variant Rect expects 2 payload values, got 1 at 4:44",
+		code:          baisl.DiagnosticCode_PAYLOAD_COUNT,
+		name:          "Match arm binding too few payload values",
+	},
+	{
+		path:          "raw/matchArmTypes.baisl",
+		errorContains: "Match arm is bool but the first arm is int at 4:40",
+		code:          baisl.DiagnosticCode_TYPE_MISMATCH,
+		name:          "Match arms of different types",
+	},
+	{
+		path:          "raw/duplicateBinding.baisl",
+		errorContains: "Duplicate declaration of a at 4:37",
+		code:          baisl.DiagnosticCode_DUPLICATE_DECLARATION,
+		name:          "Match arm binding a name twice",
+	},
+	{
+		path:          "raw/armAfterWildcard.baisl",
+		errorContains: "Match arm after _ is never taken at 4:40",
+		code:          baisl.DiagnosticCode_UNREACHABLE_ARM,
+		name:          "Match arm after _",
+	},
+	{
+		path:          "raw/duplicateArm.baisl",
+		errorContains: "Variant Red matched twice at 4:32",
+		code:          baisl.DiagnosticCode_DUPLICATE_ARM,
+		name:          "Variant matched twice",
+	},
+	{
+		path:          "raw/matchInt.baisl",
+		errorContains: "Cannot match int at 2:10",
+		code:          baisl.DiagnosticCode_NOT_AN_ENUM,
+		name:          "Matching an int",
+	},
+	{
+		path:          "raw/recursiveEnum.baisl",
+		errorContains: "Enum List contains itself through List -> Node -> List at 1:6",
+		code:          baisl.DiagnosticCode_RECURSIVE_TYPE,
+		name:          "Enum containing itself",
+	},
+	{
+		path:          "raw/emptyEnum.baisl",
+		errorContains: "Enum Never has no variants at 1:6",
+		code:          baisl.DiagnosticCode_EMPTY_ENUM,
+		name:          "Enum without variants",
+	},
+	{
+		path:          "raw/conflictingTypeArgs.baisl",
+		errorContains: "Type parameter T of pick is inferred as both int and string at 9:24",
+		code:          baisl.DiagnosticCode_CONFLICTING_TYPE_ARGUMENT,
+		name:          "Type parameter inferred as two types",
+	},
+	{
+		path:          "raw/typeArgShape.baisl",
+		errorContains: "Argument 1 of first is int, which does not match []T at 6:16",
+		code:          baisl.DiagnosticCode_ARGUMENT_TYPE,
+		name:          "Argument not matching a generic parameter",
+	},
+	{
+		path:          "raw/voidTypeArg.baisl",
+		errorContains: "Argument 1 of id is void, which does not match T at 6:6",
+		code:          baisl.DiagnosticCode_ARGUMENT_TYPE,
+		name:          "Void argument for a type parameter",
+	},
+	{
+		path:          "raw/unusedTypeParam.baisl",
+		errorContains: "Type parameter T of make is not used by its parameters at 1:9",
+		code:          baisl.DiagnosticCode_UNUSED_TYPE_PARAMETER,
+		name:          "Type parameter that can't be inferred",
+	},
+	{
+		path:          "raw/duplicateTypeParam.baisl",
+		errorContains: "Duplicate type parameter T of swap at 1:12",
+		code:          baisl.DiagnosticCode_DUPLICATE_DECLARATION,
+		name:          "Duplicate type parameter",
+	},
+	{
+		path:          "raw/genericValue.baisl",
+		errorContains: "Generic function id must be called at 6:11",
+		code:          baisl.DiagnosticCode_NOT_A_VALUE,
+		name:          "Generic function used as a value",
+	},
+	{
+		path:          "raw/functionValue.baisl",
+		errorContains: "Function one must be called at 6:11",
+		code:          baisl.DiagnosticCode_NOT_A_VALUE,
+		name:          "Function used as a value",
+	},
+	{
+		path:          "raw/genericArgCount.baisl",
+		errorContains: "Function id expects 1 arguments, got 2 at 6:10 in raw/genericArgCount.baisl; id is declared at 1:4",
+		code:          baisl.DiagnosticCode_ARGUMENT_COUNT,
+		name:          "Generic call with too many arguments",
+	},
+	{
+		path:          "raw/growingInstantiation.baisl",
+		errorContains: "nests more than 16 instances at 5:10",
+		code:          baisl.DiagnosticCode_INSTANTIATION_TOO_DEEP,
+		name:          "Instances growing without end",
+	},
+	{
+		path:          "raw/badInstance.baisl",
+		errorContains: "Operator + expects two int or two string operands, got bool and bool at 2:12 in raw/badInstance.baisl; double<bool> is instantiated at 7:11",
+		code:          baisl.DiagnosticCode_INVALID_OPERAND,
+		name:          "Instance with an invalid body",
+	},
+	{
+		path:          "raw/conflictingReturns.baisl",
+		errorContains: "Function describe returns int but an earlier return statement returns string at 5:3",
+		code:          baisl.DiagnosticCode_TYPE_MISMATCH,
+		name:          "Return statements with different types",
+	},
+	{
+		path:          "raw/inferredCycle.baisl",
+		errorContains: "Function ping is called while its return type is inferred at 9:10",
+		code:          baisl.DiagnosticCode_UNKNOWN_RETURN_TYPE,
+		name:          "Functions inferring their return types from each other",
+	},
+	{
+		path:          "raw/onlySelfReturns.baisl",
+		errorContains: "Function forever only returns the values of calls to itself, so its return type can't be inferred at 1:4",
+		code:          baisl.DiagnosticCode_UNKNOWN_RETURN_TYPE,
+		name:          "Only recursion to infer the return type from",
+	},
+	{
+		path:          "raw/missingArgument.baisl",
+		errorContains: "Function returnParam expects 1 arguments, got 0 at 6:10 in raw/missingArgument.baisl; returnParam is declared at 1:4",
+		code:          baisl.DiagnosticCode_ARGUMENT_COUNT,
+		name:          "Call with too few arguments",
+	},
+	{
+		path:          "raw/extraArguments.baisl",
+		errorContains: "Function returnParam expects 1 arguments, got 3 at 6:10 in raw/extraArguments.baisl; returnParam is declared at 1:4",
+		code:          baisl.DiagnosticCode_ARGUMENT_COUNT,
+		name:          "Call with too many arguments",
+	},
+	{
+		path:          "raw/argumentTypeMismatch.baisl",
+		errorContains: "Argument 2 of repeat is bool but parameter times is int at 10:24 in raw/argumentTypeMismatch.baisl; repeat is declared at 1:4",
+		code:          baisl.DiagnosticCode_ARGUMENT_TYPE,
+		name:          "Argument of the wrong type",
+	},
+	{
+		path:          "raw/callVariable.baisl",
+		errorContains: "Variable a is int, not a function, but is called at 3:10 in raw/callVariable.baisl; a is declared at 2:7",
+		code:          baisl.DiagnosticCode_NOT_A_FUNCTION,
+		name:          "Calling a local variable",
+	},
+	{
+		path:          "raw/callParam.baisl",
+		errorContains: "Variable f is int, not a function, but is called at 2:10 in raw/callParam.baisl; f is declared at 1:10",
+		code:          baisl.DiagnosticCode_NOT_A_FUNCTION,
+		name:          "Calling a parameter",
+	},
+	{
+		path:          "raw/leakedParam.baisl",
+		errorContains: "Undeclared variable a at 6:14",
+		code:          baisl.DiagnosticCode_UNDECLARED,
+		name:          "Parameter used after its function",
+	},
+	{
+		path:          "raw/paramInLaterFunction.baisl",
+		errorContains: "Undeclared variable x at 6:23",
+		code:          baisl.DiagnosticCode_UNDECLARED,
+		name:          "Let used before it is declared, named like an earlier parameter",
+	},
+}
+
+var semanticAnalyserRecoveryTests = []semanticAnalyserRecoveryTest{
+	{
+		"raw/multipleSemanticErrors.baisl",
+		[]string{
+			"Unknown type Colour at 3:3",
+			"Unknown type nope at 10:9",
+			"Variable a declared as int but initialized with string at 15:7",
+			"Undeclared variable missing at 17:11",
+			"Operator + expects two int or two string operands, got int and bool at 24:18",
+		},
+	},
 }
 
 func TestSemanticAnalyser(t *testing.T) {
@@ -302,7 +837,11 @@ func TestSemanticAnalyser(t *testing.T) {
 	for _, test := range semanticAnalyserFailTests {
 		analyser := baisl.SemanticAnalyser{}
 
-		_, err := analyser.Analyse(test.declarations)
+		declarations := test.declarations
+		if test.path != "" {
+			declarations = getDeclarations(t, test.path)
+		}
+		_, err := analyser.Analyse(declarations)
 		if err == nil {
 			t.Errorf("Expected error, got none")
 		}
@@ -310,5 +849,25 @@ func TestSemanticAnalyser(t *testing.T) {
 		if !strings.Contains(err.Error(), test.errorContains) {
 			t.Errorf("Expected error containing <%s>, got <%s>", test.errorContains, err)
 		}
+		codes := baisl.DiagnosticsOf(err).Codes()
+		if !slices.Equal(codes, []baisl.DiagnosticCode{test.code}) {
+			t.Errorf("Expected %s to report %s, got %v", test.name, test.code, codes)
+		}
+	}
+}
+
+func TestSemanticAnalyserRecovery(t *testing.T) {
+	for _, test := range semanticAnalyserRecoveryTests {
+		analyser := baisl.SemanticAnalyser{}
+		_, err := analyser.Analyse(getDeclarations(t, test.path))
+
+		diagnostics := baisl.DiagnosticsOf(err)
+		errors := make([]string, len(diagnostics))
+		for i, diagnostic := range diagnostics {
+			errors[i] = strings.TrimSuffix(diagnostic.Error(), " in "+test.path)
+		}
+		if !slices.Equal(errors, test.errors) {
+			t.Errorf("%s: expected errors %q, got %q", test.path, test.errors, errors)
+		}
 	}
 }
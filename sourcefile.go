@@ -2,7 +2,9 @@ package baisl
 
 import (
 	"os"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 // Represents a source file that is being lexed
@@ -34,6 +36,15 @@ func GetSourceFile(path string) (SourceFile, error) {
 	}, nil
 }
 
+func (file *SourceFile) Path() string {
+	return file.path
+}
+
+// Returns the source as it was read, which diagnostics render snippets of
+func (file *SourceFile) Content() []byte {
+	return file.content
+}
+
 const spaceChars = " \t\n\r\f\v"
 
 func isSpace(c byte) bool {
@@ -55,14 +66,15 @@ func (file *SourceFile) PeekNextChar() (byte, bool) {
 	return file.content[file.index], true
 }
 
-// Returns the next character and increments the position
+// Returns the next character and increments the position. A \r\n pair is one line break, counted at the \n.
 func (file *SourceFile) EatNextChar() (byte, bool) {
 	c, ok := file.PeekNextChar()
 	if !ok {
 		return 0, false
 	}
 
-	if isNewLine(c) {
+	crlf := c == '\r' && file.index+1 < file.len && file.content[file.index+1] == '\n'
+	if isNewLine(c) && !crlf {
 		file.line++
 		file.column = 0
 	} else {
@@ -86,8 +98,45 @@ func isAlphaNumeric(c byte) bool {
 	return IsAlpha(c) || IsNumeric(c)
 }
 
+var singleCharOperators = map[byte]TokenType{
+	'+': TokenType_PLUS,
+	'-': TokenType_MINUS,
+	'*': TokenType_STAR,
+	'%': TokenType_PERCENT,
+	'.': TokenType_DOT,
+	'[': TokenType_LBRACKET,
+	']': TokenType_RBRACKET,
+	'_': TokenType_UNDERSCORE,
+}
+
+// The token types of operators made of a character twice. Besides '.', the character
+// on its own is unknown.
+var doubledOperators = map[byte]TokenType{
+	'.': TokenType_DOTDOT,
+	'&': TokenType_AND,
+	'|': TokenType_OR,
+}
+
+// The token types of a character on its own and followed by '='
+var equalsOperators = map[byte][2]TokenType{
+	'!': {TokenType_BANG, TokenType_NEQ},
+	'=': {TokenType_ASSIGN, TokenType_EQ},
+	'<': {TokenType_LT, TokenType_LTE},
+	'>': {TokenType_GT, TokenType_GTE},
+}
+
 // Returns the next token in the source file
 func (file *SourceFile) GetNextToken() Token {
+	token := file.lexToken()
+	// The lexer only peeks past the last character of a token, so it ends where the lexer stopped, besides
+	// an invalid escape, whose string literal is lexed to its end after it
+	if token.TType != TokenType_INVALID_ESCAPE {
+		token.Location.End = file.index
+	}
+	return token
+}
+
+func (file *SourceFile) lexToken() Token {
 	next, ok := file.EatNextChar()
 	if !ok {
 		return Token{
@@ -96,6 +145,7 @@ func (file *SourceFile) GetNextToken() Token {
 				Path:   file.path,
 				Line:   file.line,
 				Column: file.column,
+				Offset: file.index,
 			},
 			HasValue: false,
 		}
@@ -109,6 +159,7 @@ func (file *SourceFile) GetNextToken() Token {
 					Path:   file.path,
 					Line:   file.line,
 					Column: file.column,
+					Offset: file.index,
 				},
 				HasValue: false,
 			}
@@ -119,6 +170,7 @@ func (file *SourceFile) GetNextToken() Token {
 		Path:   file.path,
 		Line:   file.line,
 		Column: file.column,
+		Offset: file.index - 1,
 	}
 
 	// Single line token types, split into concrete branches for optimization (probably premature)
@@ -162,18 +214,91 @@ func (file *SourceFile) GetNextToken() Token {
 		}
 	}
 
+	if next == ',' {
+		return Token{
+			TType:    TokenType_COMMA,
+			Location: startLoc,
+			HasValue: false,
+		}
+	}
+
 	if next == '/' {
 		nextNext, ok := file.PeekNextChar()
 		if ok && nextNext == '/' {
 			nextNext, ok = file.EatNextChar()
 			for !isNewLine(nextNext) && ok {
-				file.EatNextChar()
+				nextNext, ok = file.EatNextChar()
+			}
+
+			return file.lexToken()
+		}
+
+		return Token{
+			TType:    TokenType_SLASH,
+			Location: startLoc,
+			HasValue: false,
+		}
+	}
+
+	// Operators made of a character repeated, like '..' and '&&'
+	tokenType, exists := doubledOperators[next]
+	if exists {
+		nextNext, ok := file.PeekNextChar()
+		if ok && nextNext == next {
+			_, _ = file.EatNextChar()
+			return Token{
+				TType:    tokenType,
+				Location: startLoc,
+				HasValue: false,
 			}
+		}
+	}
 
-			return file.GetNextToken()
+	tokenType, exists = singleCharOperators[next]
+	if exists {
+		return Token{
+			TType:    tokenType,
+			Location: startLoc,
+			HasValue: false,
 		}
 	}
 
+	if next == '=' {
+		nextNext, ok := file.PeekNextChar()
+		if ok && nextNext == '>' {
+			_, _ = file.EatNextChar()
+			return Token{
+				TType:    TokenType_FATARROW,
+				Location: startLoc,
+				HasValue: false,
+			}
+		}
+	}
+
+	// Operators that may be followed by '=', like '<' and '<='
+	tokenTypes, exists := equalsOperators[next]
+	if exists {
+		nextNext, ok := file.PeekNextChar()
+		if ok && nextNext == '=' {
+			_, _ = file.EatNextChar()
+			return Token{
+				TType:    tokenTypes[1],
+				Location: startLoc,
+				HasValue: false,
+			}
+		}
+
+		return Token{
+			TType:    tokenTypes[0],
+			Location: startLoc,
+			HasValue: false,
+		}
+	}
+
+	if next == '"' {
+		return file.lexString(startLoc)
+	}
+
 	if IsAlpha(next) {
 		value := string(next)
 		next, ok = file.PeekNextChar()
@@ -224,3 +349,118 @@ func (file *SourceFile) GetNextToken() Token {
 		Value:    string(next),
 	}
 }
+
+// Escapes in string literals that stand for a single character, besides \u{...}
+var stringEscapes = map[byte]byte{
+	'n':  '\n',
+	't':  '\t',
+	'"':  '"',
+	'\\': '\\',
+}
+
+// Lexes a string literal whose opening quote was just eaten, decoding its escapes.
+// A string ends on the line it starts on. The first invalid escape makes the literal an INVALID_ESCAPE
+// token spanning it, though the rest of the literal is still eaten, so it isn't lexed as code. Without
+// one, an unterminated string is an UNTERMINATED_STRING token.
+func (file *SourceFile) lexString(startLoc SourceLocation) Token {
+	value := strings.Builder{}
+	var invalidEscape *Token
+	for {
+		next, ok := file.PeekNextChar()
+		if !ok || isNewLine(next) {
+			if invalidEscape != nil {
+				return *invalidEscape
+			}
+			return Token{
+				TType:    TokenType_UNTERMINATED_STRING,
+				Location: startLoc,
+				HasValue: true,
+				Value:    "\"" + value.String(),
+			}
+		}
+		_, _ = file.EatNextChar()
+
+		if next == '"' {
+			if invalidEscape != nil {
+				return *invalidEscape
+			}
+			return Token{
+				TType:    TokenType_STRING,
+				Location: startLoc,
+				HasValue: true,
+				Value:    value.String(),
+			}
+		}
+		if next != '\\' {
+			value.WriteByte(next)
+			continue
+		}
+
+		escapeIndex := file.index - 1
+		escapeLoc := SourceLocation{
+			Path:   file.path,
+			Line:   file.line,
+			Column: file.column,
+			Offset: escapeIndex,
+		}
+		escape, ok := file.lexEscape()
+		if !ok {
+			if invalidEscape == nil {
+				escapeLoc.End = file.index
+				invalidEscape = &Token{
+					TType:    TokenType_INVALID_ESCAPE,
+					Location: escapeLoc,
+					HasValue: true,
+					Value:    string(file.content[escapeIndex:file.index]),
+				}
+			}
+			continue
+		}
+		value.WriteRune(escape)
+	}
+}
+
+// Decodes the escape following a backslash in a string literal, eating as much of it as is valid
+func (file *SourceFile) lexEscape() (rune, bool) {
+	next, ok := file.PeekNextChar()
+	if !ok || isNewLine(next) {
+		return 0, false
+	}
+	_, _ = file.EatNextChar()
+
+	escape, ok := stringEscapes[next]
+	if ok {
+		return rune(escape), true
+	}
+	if next != 'u' {
+		return 0, false
+	}
+
+	// \u{...} takes 1 to 6 hex digits naming a Unicode scalar value
+	next, ok = file.PeekNextChar()
+	if !ok || next != '{' {
+		return 0, false
+	}
+	_, _ = file.EatNextChar()
+	digits := ""
+	for {
+		next, ok = file.PeekNextChar()
+		if !ok || isNewLine(next) || next == '"' {
+			return 0, false
+		}
+		_, _ = file.EatNextChar()
+		if next == '}' {
+			break
+		}
+		digits += string(next)
+	}
+
+	if len(digits) == 0 || len(digits) > 6 {
+		return 0, false
+	}
+	codePoint, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil || !utf8.ValidRune(rune(codePoint)) {
+		return 0, false
+	}
+	return rune(codePoint), true
+}
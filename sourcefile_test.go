@@ -114,3 +114,67 @@ func TestGetNextToken(t *testing.T) {
 		i++
 	}
 }
+
+func TestGetNextTokenOperators(t *testing.T) {
+	expected := []baisl.TokenType{
+		baisl.TokenType_IDENTIFIER,
+		baisl.TokenType_PLUS,
+		baisl.TokenType_MINUS,
+		baisl.TokenType_STAR,
+		baisl.TokenType_SLASH,
+		baisl.TokenType_PERCENT,
+		baisl.TokenType_BANG,
+		baisl.TokenType_NEQ,
+		baisl.TokenType_EQ,
+		baisl.TokenType_LT,
+		baisl.TokenType_LTE,
+		baisl.TokenType_GT,
+		baisl.TokenType_GTE,
+		baisl.TokenType_ASSIGN,
+		baisl.TokenType_DOTDOT,
+		baisl.TokenType_DOT,
+		baisl.TokenType_LBRACKET,
+		baisl.TokenType_RBRACKET,
+		baisl.TokenType_FATARROW,
+		baisl.TokenType_UNDERSCORE,
+		baisl.TokenType_AND,
+		baisl.TokenType_OR,
+		baisl.TokenType_IDENTIFIER,
+		baisl.TokenType_EOF,
+	}
+
+	file, err := baisl.GetSourceFile("raw/operators.baisl")
+	if err != nil {
+		t.Fatalf("Error opening file")
+	}
+
+	for i, ttype := range expected {
+		token := file.GetNextToken()
+		if token.TType != ttype {
+			t.Errorf("Expected ttype %v, got ttype %v at i %d", ttype.String(), token.TType.String(), i)
+		}
+	}
+}
+
+func TestGetNextTokenStrings(t *testing.T) {
+	path := "raw/stringTokens.baisl"
+	expected := []baisl.Token{
+		{TType: baisl.TokenType_STRING, Location: baisl.SourceLocation{Path: path, Line: 1, Column: 1, Offset: 0, End: 6}, Value: "a\tb", HasValue: true},
+		{TType: baisl.TokenType_STRING, Location: baisl.SourceLocation{Path: path, Line: 1, Column: 8, Offset: 7, End: 9}, Value: "", HasValue: true},
+		{TType: baisl.TokenType_STRING, Location: baisl.SourceLocation{Path: path, Line: 1, Column: 11, Offset: 10, End: 21}, Value: "A\"x", HasValue: true},
+		{TType: baisl.TokenType_INVALID_ESCAPE, Location: baisl.SourceLocation{Path: path, Line: 2, Column: 2, Offset: 23, End: 25}, Value: "\\q", HasValue: true},
+		{TType: baisl.TokenType_UNTERMINATED_STRING, Location: baisl.SourceLocation{Path: path, Line: 3, Column: 1, Offset: 26, End: 31}, Value: "\"open", HasValue: true},
+	}
+
+	file, err := baisl.GetSourceFile(path)
+	if err != nil {
+		t.Fatalf("Error opening file")
+	}
+
+	for i, want := range expected {
+		token := file.GetNextToken()
+		if token != want {
+			t.Errorf("Expected token %+v, got %+v at i %d", want, token, i)
+		}
+	}
+}
@@ -1,7 +1,20 @@
 package baisl
 
+import (
+	"cmp"
+	"strings"
+)
+
 type SourceLocation struct {
 	Path   string
 	Line   int
 	Column int
+	// Byte offsets in the source of the start of the span and of the byte just past its end
+	Offset int
+	End    int
+}
+
+// Orders locations by path, then by position in the file
+func (l SourceLocation) Compare(other SourceLocation) int {
+	return cmp.Or(strings.Compare(l.Path, other.Path), cmp.Compare(l.Line, other.Line), cmp.Compare(l.Column, other.Column))
 }
@@ -7,30 +7,128 @@ const (
 	TokenType_EOF
 	TokenType_IDENTIFIER
 	TokenType_NUMBER
+	// A string literal, whose value has its escapes decoded
+	TokenType_STRING
+	// A string literal the lexer couldn't lex, which the parser reports as such. An invalid escape is at its
+	// backslash, and an unterminated string at its opening quote.
+	TokenType_INVALID_ESCAPE
+	TokenType_UNTERMINATED_STRING
 	TokenType_LPAREN
 	TokenType_RPAREN
 	TokenType_LBRACE
 	TokenType_RBRACE
 	TokenType_COLON
 	TokenType_COMMA
+	TokenType_PLUS
+	TokenType_MINUS
+	TokenType_STAR
+	TokenType_SLASH
+	TokenType_PERCENT
+	TokenType_BANG
+	TokenType_EQ
+	TokenType_NEQ
+	TokenType_LT
+	TokenType_LTE
+	TokenType_GT
+	TokenType_GTE
+	TokenType_ASSIGN
+	TokenType_DOTDOT
+	TokenType_DOT
+	TokenType_LBRACKET
+	TokenType_RBRACKET
+	// Separates the pattern of a match arm from its value, written =>
+	TokenType_FATARROW
+	// Matches anything in a match pattern, written _
+	TokenType_UNDERSCORE
+	TokenType_AND
+	TokenType_OR
 	TokenType_KEYW_FN
 	TokenType_KEYW_INT
 	TokenType_KEYW_VOID
 	TokenType_KEYW_RETURN
+	TokenType_KEYW_LET
+	TokenType_KEYW_IF
+	TokenType_KEYW_ELSE
+	TokenType_KEYW_WHILE
+	TokenType_KEYW_FOR
+	TokenType_KEYW_IN
+	TokenType_KEYW_BREAK
+	TokenType_KEYW_CONTINUE
+	TokenType_KEYW_BOOL
+	TokenType_KEYW_TRUE
+	TokenType_KEYW_FALSE
+	TokenType_KEYW_STRING
+	TokenType_KEYW_STRUCT
+	TokenType_KEYW_ENUM
+	TokenType_KEYW_MATCH
+	TokenType_KEYW_IMPORT
+	TokenType_KEYW_PUB
 )
 
 var TokenTypeToKeyword = map[TokenType]string{
-	TokenType_KEYW_FN:     "fn",
-	TokenType_KEYW_VOID:   "void",
-	TokenType_KEYW_INT:    "int",
-	TokenType_KEYW_RETURN: "return",
+	TokenType_KEYW_FN:       "fn",
+	TokenType_KEYW_VOID:     "void",
+	TokenType_KEYW_INT:      "int",
+	TokenType_KEYW_RETURN:   "return",
+	TokenType_KEYW_LET:      "let",
+	TokenType_KEYW_IF:       "if",
+	TokenType_KEYW_ELSE:     "else",
+	TokenType_KEYW_WHILE:    "while",
+	TokenType_KEYW_FOR:      "for",
+	TokenType_KEYW_IN:       "in",
+	TokenType_KEYW_BREAK:    "break",
+	TokenType_KEYW_CONTINUE: "continue",
+	TokenType_KEYW_BOOL:     "bool",
+	TokenType_KEYW_TRUE:     "true",
+	TokenType_KEYW_FALSE:    "false",
+	TokenType_KEYW_STRING:   "string",
+	TokenType_KEYW_STRUCT:   "struct",
+	TokenType_KEYW_ENUM:     "enum",
+	TokenType_KEYW_MATCH:    "match",
+	TokenType_KEYW_IMPORT:   "import",
+	TokenType_KEYW_PUB:      "pub",
 }
 
 var KeywordToTokenType = map[string]TokenType{
-	"fn":     TokenType_KEYW_FN,
-	"int":    TokenType_KEYW_INT,
-	"void":   TokenType_KEYW_VOID,
-	"return": TokenType_KEYW_RETURN,
+	"fn":       TokenType_KEYW_FN,
+	"int":      TokenType_KEYW_INT,
+	"void":     TokenType_KEYW_VOID,
+	"return":   TokenType_KEYW_RETURN,
+	"let":      TokenType_KEYW_LET,
+	"if":       TokenType_KEYW_IF,
+	"else":     TokenType_KEYW_ELSE,
+	"while":    TokenType_KEYW_WHILE,
+	"for":      TokenType_KEYW_FOR,
+	"in":       TokenType_KEYW_IN,
+	"break":    TokenType_KEYW_BREAK,
+	"continue": TokenType_KEYW_CONTINUE,
+	"bool":     TokenType_KEYW_BOOL,
+	"true":     TokenType_KEYW_TRUE,
+	"false":    TokenType_KEYW_FALSE,
+	"string":   TokenType_KEYW_STRING,
+	"struct":   TokenType_KEYW_STRUCT,
+	"enum":     TokenType_KEYW_ENUM,
+	"match":    TokenType_KEYW_MATCH,
+	"import":   TokenType_KEYW_IMPORT,
+	"pub":      TokenType_KEYW_PUB,
+}
+
+var TokenTypeToOperator = map[TokenType]string{
+	TokenType_PLUS:    "+",
+	TokenType_MINUS:   "-",
+	TokenType_STAR:    "*",
+	TokenType_SLASH:   "/",
+	TokenType_PERCENT: "%",
+	TokenType_BANG:    "!",
+	TokenType_EQ:      "==",
+	TokenType_NEQ:     "!=",
+	TokenType_LT:      "<",
+	TokenType_LTE:     "<=",
+	TokenType_GT:      ">",
+	TokenType_GTE:     ">=",
+	TokenType_DOTDOT:  "..",
+	TokenType_AND:     "&&",
+	TokenType_OR:      "||",
 }
 
 func IsKeywordTokenType(tokenType TokenType) bool {
@@ -43,10 +141,16 @@ func (ttype TokenType) String() string {
 		return "UNKNOWN"
 	case TokenType_EOF:
 		return "EOF"
+	case TokenType_INVALID_ESCAPE:
+		return "INVALID_ESCAPE"
+	case TokenType_UNTERMINATED_STRING:
+		return "UNTERMINATED_STRING"
 	case TokenType_IDENTIFIER:
 		return "IDENTIFIER"
 	case TokenType_NUMBER:
 		return "NUMBER"
+	case TokenType_STRING:
+		return "STRING"
 	case TokenType_LPAREN:
 		return "LPAREN"
 	case TokenType_RPAREN:
@@ -57,6 +161,50 @@ func (ttype TokenType) String() string {
 		return "RBRACE"
 	case TokenType_COLON:
 		return "COLON"
+	case TokenType_COMMA:
+		return "COMMA"
+	case TokenType_PLUS:
+		return "PLUS"
+	case TokenType_MINUS:
+		return "MINUS"
+	case TokenType_STAR:
+		return "STAR"
+	case TokenType_SLASH:
+		return "SLASH"
+	case TokenType_PERCENT:
+		return "PERCENT"
+	case TokenType_BANG:
+		return "BANG"
+	case TokenType_EQ:
+		return "EQ"
+	case TokenType_NEQ:
+		return "NEQ"
+	case TokenType_LT:
+		return "LT"
+	case TokenType_LTE:
+		return "LTE"
+	case TokenType_GT:
+		return "GT"
+	case TokenType_GTE:
+		return "GTE"
+	case TokenType_ASSIGN:
+		return "ASSIGN"
+	case TokenType_DOTDOT:
+		return "DOTDOT"
+	case TokenType_DOT:
+		return "DOT"
+	case TokenType_LBRACKET:
+		return "LBRACKET"
+	case TokenType_RBRACKET:
+		return "RBRACKET"
+	case TokenType_FATARROW:
+		return "FATARROW"
+	case TokenType_UNDERSCORE:
+		return "UNDERSCORE"
+	case TokenType_AND:
+		return "AND"
+	case TokenType_OR:
+		return "OR"
 	case TokenType_KEYW_FN:
 		return "KEYW_FN"
 	case TokenType_KEYW_VOID:
@@ -65,6 +213,40 @@ func (ttype TokenType) String() string {
 		return "KEYW_RETURN"
 	case TokenType_KEYW_INT:
 		return "KEYW_INT"
+	case TokenType_KEYW_LET:
+		return "KEYW_LET"
+	case TokenType_KEYW_IF:
+		return "KEYW_IF"
+	case TokenType_KEYW_ELSE:
+		return "KEYW_ELSE"
+	case TokenType_KEYW_WHILE:
+		return "KEYW_WHILE"
+	case TokenType_KEYW_FOR:
+		return "KEYW_FOR"
+	case TokenType_KEYW_IN:
+		return "KEYW_IN"
+	case TokenType_KEYW_BREAK:
+		return "KEYW_BREAK"
+	case TokenType_KEYW_CONTINUE:
+		return "KEYW_CONTINUE"
+	case TokenType_KEYW_BOOL:
+		return "KEYW_BOOL"
+	case TokenType_KEYW_TRUE:
+		return "KEYW_TRUE"
+	case TokenType_KEYW_FALSE:
+		return "KEYW_FALSE"
+	case TokenType_KEYW_STRING:
+		return "KEYW_STRING"
+	case TokenType_KEYW_STRUCT:
+		return "KEYW_STRUCT"
+	case TokenType_KEYW_ENUM:
+		return "KEYW_ENUM"
+	case TokenType_KEYW_MATCH:
+		return "KEYW_MATCH"
+	case TokenType_KEYW_IMPORT:
+		return "KEYW_IMPORT"
+	case TokenType_KEYW_PUB:
+		return "KEYW_PUB"
 	default:
 		return "UNKNOWN"
 	}
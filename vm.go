@@ -0,0 +1,317 @@
+package baisl
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Deep enough for real recursion, shallow enough to fail before exhausting memory. Limits the calls of
+// the interpreter as well as the VM, so both fail on the same programs.
+const maxCallDepth = 100000
+
+type vmFrame struct {
+	function *BytecodeFunction
+	pc       int
+	// Index in the value stack of the frame's first parameter, which is followed by its other locals
+	base int
+}
+
+// Index in the value stack of the first temporary above the frame's locals
+func (f *vmFrame) operandBase() int {
+	return f.base + f.function.NumParams + f.function.NumLocals
+}
+
+// Runs a BytecodeProgram on a value stack, with a stack of call frames
+type VM struct {
+	Program *BytecodeProgram
+	// Where PRINT writes, os.Stdout if nil
+	Output io.Writer
+
+	stack  []Value
+	frames []*vmFrame
+}
+
+// The source operator each arithmetic opcode evaluates
+var vmOperators = map[Opcode]TokenType{
+	Opcode_ADD: TokenType_PLUS,
+	Opcode_SUB: TokenType_MINUS,
+	Opcode_MUL: TokenType_STAR,
+	Opcode_DIV: TokenType_SLASH,
+	Opcode_MOD: TokenType_PERCENT,
+	Opcode_EQ:  TokenType_EQ,
+	Opcode_NE:  TokenType_NEQ,
+	Opcode_LT:  TokenType_LT,
+	Opcode_LE:  TokenType_LTE,
+	Opcode_GT:  TokenType_GT,
+	Opcode_GE:  TokenType_GTE,
+	Opcode_NEG: TokenType_MINUS,
+	Opcode_NOT: TokenType_BANG,
+}
+
+func (vm *VM) push(value Value) {
+	vm.stack = append(vm.stack, value)
+}
+
+func (vm *VM) pop() Value {
+	value := vm.stack[len(vm.stack)-1]
+	vm.stack = vm.stack[:len(vm.stack)-1]
+	return value
+}
+
+func (vm *VM) call(index int) error {
+	if index < 0 || index >= len(vm.Program.Functions) {
+		return fmt.Errorf("Function %d out of range", index)
+	}
+	if len(vm.frames) >= maxCallDepth {
+		return fmt.Errorf("Call stack overflow")
+	}
+
+	// The arguments are the caller's topmost temporaries, never its locals. Run calls main without a caller.
+	fn := vm.Program.Functions[index]
+	operandBase := 0
+	if len(vm.frames) > 0 {
+		operandBase = vm.frames[len(vm.frames)-1].operandBase()
+	}
+	if len(vm.stack)-operandBase < fn.NumParams {
+		return fmt.Errorf("Not enough arguments for %s", fn.Name)
+	}
+	vm.frames = append(vm.frames, &vmFrame{
+		function: fn,
+		base:     len(vm.stack) - fn.NumParams,
+	})
+	// Locals start out unset, the analyser guarantees they are stored before being loaded
+	for i := 0; i < fn.NumLocals; i++ {
+		vm.push(nil)
+	}
+	return nil
+}
+
+// Pops the current frame and its locals, pushing the result for the caller if there is one
+func (vm *VM) ret(result Value) {
+	frame := vm.frames[len(vm.frames)-1]
+	vm.frames = vm.frames[:len(vm.frames)-1]
+	vm.stack = vm.stack[:frame.base]
+	if result != nil {
+		vm.push(result)
+	}
+}
+
+func (vm *VM) step() error {
+	frame := vm.frames[len(vm.frames)-1]
+	op, operands, next, err := DecodeInstruction(frame.function.Code, frame.pc)
+	if err != nil {
+		return err
+	}
+	frame.pc = next
+
+	switch op {
+	case Opcode_PUSH_INT:
+		vm.push(&IntValue{Value: operands[0]})
+	case Opcode_PUSH_BOOL:
+		vm.push(&BoolValue{Value: operands[0] != 0})
+	case Opcode_PUSH_STRING:
+		if operands[0] < 0 || operands[0] >= len(vm.Program.Strings) {
+			return fmt.Errorf("String %d out of range", operands[0])
+		}
+		vm.push(&StringValue{Value: vm.Program.Strings[operands[0]]})
+	case Opcode_PRINT:
+		if len(vm.stack) <= frame.operandBase() {
+			return fmt.Errorf("Stack underflow")
+		}
+		output := vm.Output
+		if output == nil {
+			output = os.Stdout
+		}
+		return printValue(output, vm.pop(), operands[0] == 1)
+	case Opcode_MAKE_STRUCT:
+		if operands[0] < 0 || operands[0] >= len(vm.Program.Structs) {
+			return fmt.Errorf("Struct %d out of range", operands[0])
+		}
+		structDef := vm.Program.Structs[operands[0]]
+		if len(vm.stack) < frame.operandBase()+len(structDef.Fields) {
+			return fmt.Errorf("Stack underflow")
+		}
+		fields := make([]Value, len(structDef.Fields))
+		for i := len(fields) - 1; i >= 0; i-- {
+			fields[i] = vm.pop()
+		}
+		vm.push(&StructValue{
+			Name:       structDef.Name,
+			FieldNames: structDef.Fields,
+			Fields:     fields,
+		})
+	case Opcode_GET_FIELD:
+		if len(vm.stack) <= frame.operandBase() {
+			return fmt.Errorf("Stack underflow")
+		}
+		value, err := getField(vm.pop(), operands[0])
+		if err != nil {
+			return err
+		}
+		vm.push(value)
+	case Opcode_MAKE_VARIANT:
+		if !vm.Program.hasVariant(operands[0], operands[1]) {
+			return fmt.Errorf("Variant %d of enum %d out of range", operands[1], operands[0])
+		}
+		enumDef := vm.Program.Enums[operands[0]]
+		variant := enumDef.Variants[operands[1]]
+		if len(vm.stack) < frame.operandBase()+variant.NumPayload {
+			return fmt.Errorf("Stack underflow")
+		}
+		payload := make([]Value, variant.NumPayload)
+		for i := len(payload) - 1; i >= 0; i-- {
+			payload[i] = vm.pop()
+		}
+		vm.push(&EnumValue{
+			Enum:    enumDef.Name,
+			Variant: variant.Name,
+			Index:   operands[1],
+			Payload: payload,
+		})
+	case Opcode_TAG:
+		if len(vm.stack) <= frame.operandBase() {
+			return fmt.Errorf("Stack underflow")
+		}
+		tag, err := getTag(vm.pop())
+		if err != nil {
+			return err
+		}
+		vm.push(&IntValue{Value: tag})
+	case Opcode_GET_PAYLOAD:
+		if len(vm.stack) <= frame.operandBase() {
+			return fmt.Errorf("Stack underflow")
+		}
+		value, err := getPayload(vm.pop(), operands[0])
+		if err != nil {
+			return err
+		}
+		vm.push(value)
+	case Opcode_MAKE_ARRAY:
+		if len(vm.stack) < frame.operandBase()+operands[0] {
+			return fmt.Errorf("Stack underflow")
+		}
+		elems := make([]Value, operands[0])
+		for i := len(elems) - 1; i >= 0; i-- {
+			elems[i] = vm.pop()
+		}
+		vm.push(&ArrayValue{Elems: elems})
+	case Opcode_INDEX:
+		if len(vm.stack) < frame.operandBase()+2 {
+			return fmt.Errorf("Stack underflow")
+		}
+		index := vm.pop()
+		value, err := getElement(vm.pop(), index)
+		if err != nil {
+			return fmt.Errorf("%s at %d:%d", err, operands[0], operands[1])
+		}
+		vm.push(value)
+	case Opcode_LEN:
+		if len(vm.stack) <= frame.operandBase() {
+			return fmt.Errorf("Stack underflow")
+		}
+		value, err := getLength(vm.pop())
+		if err != nil {
+			return err
+		}
+		vm.push(value)
+	case Opcode_POP:
+		if len(vm.stack) <= frame.operandBase() {
+			return fmt.Errorf("Stack underflow")
+		}
+		vm.pop()
+	case Opcode_LOAD_LOCAL:
+		value := vm.stack[frame.base+operands[0]]
+		if value == nil {
+			return fmt.Errorf("Local %d loaded before being stored", operands[0])
+		}
+		vm.push(value)
+	case Opcode_STORE_LOCAL:
+		if len(vm.stack) <= frame.operandBase() {
+			return fmt.Errorf("Stack underflow")
+		}
+		vm.stack[frame.base+operands[0]] = vm.pop()
+	case Opcode_JUMP:
+		frame.pc += operands[0]
+	case Opcode_JUMP_IF_FALSE:
+		if len(vm.stack) <= frame.operandBase() {
+			return fmt.Errorf("Stack underflow")
+		}
+		cond, ok := vm.pop().(*BoolValue)
+		if !ok {
+			return fmt.Errorf("Condition must be bool")
+		}
+		if !cond.Value {
+			frame.pc += operands[0]
+		}
+	case Opcode_CALL:
+		return vm.call(operands[0])
+	case Opcode_RET:
+		if len(vm.stack) <= frame.operandBase() {
+			return fmt.Errorf("Stack underflow")
+		}
+		vm.ret(vm.pop())
+	case Opcode_RET_VOID:
+		vm.ret(nil)
+	case Opcode_DIV, Opcode_MOD:
+		if len(vm.stack) < frame.operandBase()+2 {
+			return fmt.Errorf("Stack underflow")
+		}
+		rhs := vm.pop()
+		lhs := vm.pop()
+		value, err := evaluateBinaryOperator(vmOperators[op], lhs, rhs)
+		if err != nil {
+			return fmt.Errorf("%s at %d:%d", err, operands[0], operands[1])
+		}
+		vm.push(value)
+	case Opcode_ADD, Opcode_SUB, Opcode_MUL,
+		Opcode_EQ, Opcode_NE, Opcode_LT, Opcode_LE, Opcode_GT, Opcode_GE:
+		if len(vm.stack) < frame.operandBase()+2 {
+			return fmt.Errorf("Stack underflow")
+		}
+		rhs := vm.pop()
+		lhs := vm.pop()
+		value, err := evaluateBinaryOperator(vmOperators[op], lhs, rhs)
+		if err != nil {
+			return err
+		}
+		vm.push(value)
+	case Opcode_NEG, Opcode_NOT:
+		if len(vm.stack) < frame.operandBase()+1 {
+			return fmt.Errorf("Stack underflow")
+		}
+		value, err := evaluateUnaryOperator(vmOperators[op], vm.pop())
+		if err != nil {
+			return err
+		}
+		vm.push(value)
+	default:
+		return fmt.Errorf("Unknown opcode %s", op)
+	}
+	return nil
+}
+
+// Runs main and returns its result, which is nil if main returns void
+func (vm *VM) Run() (Value, error) {
+	vm.stack = make([]Value, 0)
+	vm.frames = make([]*vmFrame, 0)
+
+	err := vm.call(vm.Program.Main)
+	if err != nil {
+		return nil, err
+	}
+
+	for len(vm.frames) > 0 {
+		frame := vm.frames[len(vm.frames)-1]
+		pc := frame.pc
+		err := vm.step()
+		if err != nil {
+			return nil, fmt.Errorf("Error in function %s at %04d: %s", frame.function.Name, pc, err)
+		}
+	}
+
+	if len(vm.stack) == 0 {
+		return nil, nil
+	}
+	return vm.pop(), nil
+}
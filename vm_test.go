@@ -0,0 +1,183 @@
+package baisl_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/frodi-karlsson/baisl"
+)
+
+type failVMTest struct {
+	program       *baisl.BytecodeProgram
+	errorContains string
+	name          string
+}
+
+var failVMTests = []failVMTest{
+	{
+		program: &baisl.BytecodeProgram{
+			Functions: []*baisl.BytecodeFunction{
+				{Name: "main", Code: []byte{byte(baisl.Opcode_CALL), 0}},
+			},
+		},
+		errorContains: "Call stack overflow",
+		name:          "Unbounded recursion",
+	},
+	{
+		program: &baisl.BytecodeProgram{
+			Functions: []*baisl.BytecodeFunction{
+				{Name: "main", Code: []byte{byte(baisl.Opcode_RET)}},
+			},
+		},
+		errorContains: "Stack underflow",
+		name:          "Return without value",
+	},
+	{
+		program: &baisl.BytecodeProgram{
+			Functions: []*baisl.BytecodeFunction{
+				{Name: "main", Code: []byte{
+					byte(baisl.Opcode_PUSH_INT), 2,
+					byte(baisl.Opcode_PUSH_INT), 0,
+					byte(baisl.Opcode_DIV), 4, 24,
+					byte(baisl.Opcode_RET),
+				}},
+			},
+		},
+		errorContains: "Error in function main at 0004: Division by zero at 2:12",
+		name:          "Division by zero",
+	},
+	{
+		program: &baisl.BytecodeProgram{
+			Functions: []*baisl.BytecodeFunction{
+				{Name: "main", NumLocals: 1, Code: []byte{
+					byte(baisl.Opcode_LOAD_LOCAL), 0,
+					byte(baisl.Opcode_RET),
+				}},
+			},
+		},
+		errorContains: "Local 0 loaded before being stored",
+		name:          "Unset local",
+	},
+	{
+		program: &baisl.BytecodeProgram{
+			Functions: []*baisl.BytecodeFunction{
+				{Name: "main", NumLocals: 1, Code: []byte{
+					byte(baisl.Opcode_PUSH_INT), 2,
+					byte(baisl.Opcode_STORE_LOCAL), 0,
+					byte(baisl.Opcode_CALL), 2,
+					byte(baisl.Opcode_RET),
+				}},
+				{Name: "first", NumParams: 1, Code: []byte{
+					byte(baisl.Opcode_LOAD_LOCAL), 0,
+					byte(baisl.Opcode_RET),
+				}},
+			},
+		},
+		errorContains: "Error in function main at 0004: Not enough arguments for first",
+		name:          "Call taking the caller's locals as arguments",
+	},
+	{
+		program: &baisl.BytecodeProgram{
+			Functions: []*baisl.BytecodeFunction{
+				{Name: "main", Code: []byte{
+					byte(baisl.Opcode_PUSH_INT), 2,
+					byte(baisl.Opcode_MAKE_ARRAY), 2,
+					byte(baisl.Opcode_PUSH_INT), 2,
+					byte(baisl.Opcode_INDEX), 8, 24,
+					byte(baisl.Opcode_RET),
+				}},
+			},
+		},
+		errorContains: "Error in function main at 0006: Index 1 out of range for length 1 at 4:12",
+		name:          "Index out of range",
+	},
+	{
+		program: &baisl.BytecodeProgram{
+			Functions: []*baisl.BytecodeFunction{
+				{Name: "main", Code: []byte{
+					byte(baisl.Opcode_PUSH_INT), 2,
+					byte(baisl.Opcode_TAG),
+					byte(baisl.Opcode_RET),
+				}},
+			},
+		},
+		errorContains: "Error in function main at 0002: Cannot match int",
+		name:          "Tag of an int",
+	},
+	{
+		program: &baisl.BytecodeProgram{
+			Enums: []*baisl.BytecodeEnum{
+				{Name: "Light", Variants: []*baisl.BytecodeVariant{{Name: "Red"}}},
+			},
+			Functions: []*baisl.BytecodeFunction{
+				{Name: "main", Code: []byte{
+					byte(baisl.Opcode_MAKE_VARIANT), 0, 0,
+					byte(baisl.Opcode_GET_PAYLOAD), 0,
+					byte(baisl.Opcode_RET),
+				}},
+			},
+		},
+		errorContains: "Error in function main at 0003: Payload value 0 out of range for Red",
+		name:          "Payload of a unit variant",
+	},
+}
+
+// The VM must agree with the interpreter on every program
+func TestVM(t *testing.T) {
+	for _, test := range interpreterTests {
+		vm := baisl.VM{
+			Program: compileBytecode(t, test.path),
+		}
+
+		result, err := vm.Run()
+		if err != nil {
+			t.Errorf("Error running %s: %s", test.path, err)
+			continue
+		}
+
+		got := ""
+		if result != nil {
+			got = result.String()
+		}
+		if got != test.expected {
+			t.Errorf("Running %s, expected <%s>, got <%s>", test.path, test.expected, got)
+		}
+	}
+
+	for _, test := range failVMTests {
+		vm := baisl.VM{
+			Program: test.program,
+		}
+
+		_, err := vm.Run()
+		if err == nil {
+			t.Errorf("Expected error in %s, got none", test.name)
+			continue
+		}
+
+		if !strings.Contains(err.Error(), test.errorContains) {
+			t.Errorf("Expected error containing <%s>, got <%s>", test.errorContains, err)
+		}
+	}
+}
+
+func TestVMOutput(t *testing.T) {
+	for _, test := range outputTests {
+		output := bytes.Buffer{}
+		vm := baisl.VM{
+			Program: compileBytecode(t, test.path),
+			Output:  &output,
+		}
+
+		_, err := vm.Run()
+		if err != nil {
+			t.Errorf("Error running %s: %s", test.path, err)
+			continue
+		}
+
+		if output.String() != test.expected {
+			t.Errorf("Running %s, expected output <%s>, got <%s>", test.path, test.expected, output.String())
+		}
+	}
+}
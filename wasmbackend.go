@@ -0,0 +1,589 @@
+package baisl
+
+import (
+	"fmt"
+)
+
+const (
+	wasmSection_TYPE     byte = 1
+	wasmSection_IMPORT   byte = 2
+	wasmSection_FUNCTION byte = 3
+	wasmSection_EXPORT   byte = 7
+	wasmSection_CODE     byte = 10
+)
+
+const (
+	wasmType_I32  byte = 0x7F
+	wasmType_I64  byte = 0x7E
+	wasmType_FUNC byte = 0x60
+	// Block type of structured instructions that leave nothing on the stack
+	wasmType_EMPTY byte = 0x40
+)
+
+const wasmExport_FUNC byte = 0x00
+
+const (
+	wasmOp_UNREACHABLE byte = 0x00
+	wasmOp_BLOCK       byte = 0x02
+	wasmOp_LOOP        byte = 0x03
+	wasmOp_IF          byte = 0x04
+	wasmOp_ELSE        byte = 0x05
+	wasmOp_END         byte = 0x0B
+	wasmOp_BR          byte = 0x0C
+	wasmOp_BR_IF       byte = 0x0D
+	wasmOp_RETURN      byte = 0x0F
+	wasmOp_CALL        byte = 0x10
+	wasmOp_DROP        byte = 0x1A
+	wasmOp_LOCAL_GET   byte = 0x20
+	wasmOp_LOCAL_SET   byte = 0x21
+	wasmOp_I32_CONST   byte = 0x41
+	wasmOp_I64_CONST   byte = 0x42
+	wasmOp_I32_EQZ     byte = 0x45
+	wasmOp_I64_EQZ     byte = 0x50
+	wasmOp_I64_EQ      byte = 0x51
+	wasmOp_I64_ADD     byte = 0x7C
+	wasmOp_I64_SUB     byte = 0x7D
+)
+
+// Bools are i32s, so comparing them takes the i32 instructions
+var wasmBoolBinaryOps = map[TokenType]byte{
+	TokenType_EQ:  0x46, // i32.eq
+	TokenType_NEQ: 0x47, // i32.ne
+}
+
+var wasmBinaryOps = map[TokenType]byte{
+	TokenType_EQ:      wasmOp_I64_EQ,
+	TokenType_NEQ:     0x52, // i64.ne
+	TokenType_LT:      0x53, // i64.lt_s
+	TokenType_GT:      0x55, // i64.gt_s
+	TokenType_LTE:     0x57, // i64.le_s
+	TokenType_GTE:     0x59, // i64.ge_s
+	TokenType_PLUS:    wasmOp_I64_ADD,
+	TokenType_MINUS:   wasmOp_I64_SUB,
+	TokenType_STAR:    0x7E, // i64.mul
+	TokenType_SLASH:   0x7F, // i64.div_s
+	TokenType_PERCENT: 0x81, // i64.rem_s
+}
+
+// Generates a WebAssembly binary module from resolved declarations.
+// Every function is emitted, with main exported under its own name.
+type WasmBackend struct {
+	Declarations []ResolvedDeclaration
+
+	functions []*ResolvedFunctionDeclaration
+	// Indices of the functions in the module's function index space
+	functionIndices map[string]int
+	// Indices of the locals of the current function
+	locals map[ResolvedDeclaration]int
+	// Number of structured instructions enclosing the current one, which branches count outwards from
+	depth int
+	// Depths of the blocks continue and break branch to, for each loop enclosing the current statement
+	loops []wasmLoop
+	// Whether a division is checked for a zero divisor, and whether the module imports the function
+	// reporting one, which precedes the module's own functions in the function index space
+	checksDivisions     bool
+	importsDivisionFail bool
+	// Index of the i64 local holding the dividend of a checked division in the current function, the
+	// divisor's following it
+	dividendLocal int
+}
+
+// The host function a module checking divisions imports, called with the line and column of a division
+// by zero before the module traps. Hosts report it like the other backends, and stop the program.
+const (
+	wasmDivisionFailModule = "baisl"
+	wasmDivisionFailName   = "division_fail"
+)
+
+type wasmLoop struct {
+	continueDepth int
+	breakDepth    int
+}
+
+func appendULEB128(out []byte, value uint64) []byte {
+	for {
+		b := byte(value & 0x7F)
+		value >>= 7
+		if value == 0 {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func appendSLEB128(out []byte, value int64) []byte {
+	for {
+		b := byte(value & 0x7F)
+		value >>= 7
+		if (value == 0 && b&0x40 == 0) || (value == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func appendWasmName(out []byte, name string) []byte {
+	out = appendULEB128(out, uint64(len(name)))
+	return append(out, name...)
+}
+
+// Appends a section with its id and size prefix
+func appendWasmSection(out []byte, id byte, content []byte) []byte {
+	out = append(out, id)
+	out = appendULEB128(out, uint64(len(content)))
+	return append(out, content...)
+}
+
+func wasmValueTypes(t Type) ([]byte, error) {
+	switch t.Kind {
+	case TypeType_INT:
+		return []byte{wasmType_I64}, nil
+	case TypeType_BOOL:
+		return []byte{wasmType_I32}, nil
+	case TypeType_VOID:
+		return []byte{}, nil
+	}
+	return nil, fmt.Errorf("Type %s is not supported by the WebAssembly backend", t)
+}
+
+func (wb *WasmBackend) GenerateFunctionType(fn *ResolvedFunctionDeclaration) ([]byte, error) {
+	out := []byte{wasmType_FUNC}
+
+	out = appendULEB128(out, uint64(len(fn.Params)))
+	for _, param := range fn.Params {
+		valueTypes, err := wasmValueTypes(param.(*ResolvedVariableDeclaration).Type)
+		if err != nil {
+			return nil, fmt.Errorf("Error generating parameter %s of %s: %s", param.GetId(), fn.GetId(), err)
+		}
+		out = append(out, valueTypes...)
+	}
+
+	results, err := wasmValueTypes(fn.ReturnType)
+	if err != nil {
+		return nil, fmt.Errorf("Error generating function %s: %s", fn.GetId(), err)
+	}
+	out = appendULEB128(out, uint64(len(results)))
+	return append(out, results...), nil
+}
+
+func (wb *WasmBackend) GenerateExpr(out []byte, expr ResolvedExpr) ([]byte, error) {
+	switch expr.(type) {
+	case *ResolvedValueExpr:
+		out = append(out, wasmOp_I64_CONST)
+		return appendSLEB128(out, int64(expr.(*ResolvedValueExpr).Value)), nil
+	case *ResolvedBoolExpr:
+		if expr.(*ResolvedBoolExpr).Value {
+			return append(out, wasmOp_I32_CONST, 1), nil
+		}
+		return append(out, wasmOp_I32_CONST, 0), nil
+	case *ResolvedStringExpr:
+		return nil, fmt.Errorf("Strings are not supported by the WebAssembly backend")
+	case *ResolvedStructExpr, *ResolvedFieldExpr:
+		return nil, fmt.Errorf("Structs are not supported by the WebAssembly backend")
+	case *ResolvedArrayExpr, *ResolvedIndexExpr:
+		return nil, fmt.Errorf("Arrays are not supported by the WebAssembly backend")
+	case *ResolvedVariantExpr, *ResolvedMatchExpr:
+		return nil, fmt.Errorf("Enums are not supported by the WebAssembly backend")
+	case *ResolvedBuiltinCallExpr:
+		return nil, fmt.Errorf("Builtin %s is not supported by the WebAssembly backend", expr.(*ResolvedBuiltinCallExpr).Builtin)
+	case *ResolvedRefExpr:
+		refExpr := expr.(*ResolvedRefExpr)
+		decl := *refExpr.Value
+		if !refExpr.IsCall {
+			index, ok := wb.locals[decl]
+			if !ok {
+				return nil, fmt.Errorf("Unknown variable %s", decl.GetId())
+			}
+			out = append(out, wasmOp_LOCAL_GET)
+			return appendULEB128(out, uint64(index)), nil
+		}
+
+		for _, arg := range refExpr.Args {
+			var err error
+			out, err = wb.GenerateExpr(out, arg)
+			if err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, wasmOp_CALL)
+		return appendULEB128(out, uint64(wb.functionIndices[decl.GetId()])), nil
+	case *ResolvedBinaryExpr:
+		binaryExpr := expr.(*ResolvedBinaryExpr)
+		out, err := wb.GenerateExpr(out, binaryExpr.Lhs)
+		if err != nil {
+			return nil, err
+		}
+
+		// The right operand of && and || is only evaluated in the branch of an if that needs it
+		switch binaryExpr.Operator {
+		case TokenType_AND, TokenType_OR:
+			out = append(out, wasmOp_IF, wasmType_I32)
+			wb.depth++
+			if binaryExpr.Operator == TokenType_OR {
+				out = append(out, wasmOp_I32_CONST, 1, wasmOp_ELSE)
+			}
+			out, err = wb.GenerateExpr(out, binaryExpr.Rhs)
+			if err != nil {
+				return nil, err
+			}
+			if binaryExpr.Operator == TokenType_AND {
+				out = append(out, wasmOp_ELSE, wasmOp_I32_CONST, 0)
+			}
+			wb.depth--
+			return append(out, wasmOp_END), nil
+		}
+
+		out, err = wb.GenerateExpr(out, binaryExpr.Rhs)
+		if err != nil {
+			return nil, err
+		}
+		if checksDivisor(binaryExpr) {
+			return wb.generateCheckedDivision(out, binaryExpr), nil
+		}
+
+		ops := wasmBinaryOps
+		if binaryExpr.Lhs.GetType() == Type_BOOL {
+			ops = wasmBoolBinaryOps
+		}
+		op, ok := ops[binaryExpr.Operator]
+		if !ok {
+			return nil, fmt.Errorf("Unknown binary operator %s", binaryExpr.Operator)
+		}
+		return append(out, op), nil
+	case *ResolvedUnaryExpr:
+		unaryExpr := expr.(*ResolvedUnaryExpr)
+		switch unaryExpr.Operator {
+		case TokenType_MINUS:
+			out = append(out, wasmOp_I64_CONST, 0)
+			out, err := wb.GenerateExpr(out, unaryExpr.Operand)
+			if err != nil {
+				return nil, err
+			}
+			return append(out, wasmOp_I64_SUB), nil
+		case TokenType_BANG:
+			out, err := wb.GenerateExpr(out, unaryExpr.Operand)
+			if err != nil {
+				return nil, err
+			}
+			return append(out, wasmOp_I32_EQZ), nil
+		}
+		return nil, fmt.Errorf("Unknown unary operator %s", unaryExpr.Operator)
+	}
+	return nil, fmt.Errorf("Unknown expression type %d", expr.GetExprType())
+}
+
+// Appends a br or br_if to the structured instruction at the given depth
+func (wb *WasmBackend) appendBranch(out []byte, op byte, depth int) []byte {
+	out = append(out, op)
+	return appendULEB128(out, uint64(wb.depth-depth))
+}
+
+func (wb *WasmBackend) generateLoopBody(out []byte, body *ResolvedBlock, loop wasmLoop) ([]byte, error) {
+	wb.loops = append(wb.loops, loop)
+	out, err := wb.GenerateBlock(out, body)
+	wb.loops = wb.loops[:len(wb.loops)-1]
+	return out, err
+}
+
+func (wb *WasmBackend) GenerateBlock(out []byte, block *ResolvedBlock) ([]byte, error) {
+	for _, stmt := range block.Stmts {
+		var err error
+		switch stmt.(type) {
+		case *ResolvedReturnStatement:
+			returnStmt := stmt.(*ResolvedReturnStatement)
+			if returnStmt.Expr != nil {
+				out, err = wb.GenerateExpr(out, returnStmt.Expr)
+				if err != nil {
+					return nil, err
+				}
+			}
+			out = append(out, wasmOp_RETURN)
+		case *ResolvedExprStatement:
+			expr := stmt.(*ResolvedExprStatement).Expr
+			out, err = wb.GenerateExpr(out, expr)
+			if err != nil {
+				return nil, err
+			}
+			if expr.GetType() != Type_VOID {
+				out = append(out, wasmOp_DROP)
+			}
+		case *ResolvedLetStatement:
+			variable := stmt.(*ResolvedLetStatement).Variable
+			out, err = wb.GenerateExpr(out, variable.Value)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, wasmOp_LOCAL_SET)
+			out = appendULEB128(out, uint64(wb.locals[variable]))
+		case *ResolvedAssignStatement:
+			assignStmt := stmt.(*ResolvedAssignStatement)
+			out, err = wb.GenerateExpr(out, assignStmt.Expr)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, wasmOp_LOCAL_SET)
+			out = appendULEB128(out, uint64(wb.locals[assignStmt.Variable]))
+		case *ResolvedIfStatement:
+			ifStmt := stmt.(*ResolvedIfStatement)
+			out, err = wb.GenerateExpr(out, ifStmt.Cond)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, wasmOp_IF, wasmType_EMPTY)
+			wb.depth++
+			out, err = wb.GenerateBlock(out, ifStmt.Then)
+			if err != nil {
+				return nil, err
+			}
+			if ifStmt.Else != nil {
+				out = append(out, wasmOp_ELSE)
+				out, err = wb.GenerateBlock(out, ifStmt.Else)
+				if err != nil {
+					return nil, err
+				}
+			}
+			wb.depth--
+			out = append(out, wasmOp_END)
+		case *ResolvedWhileStatement:
+			// The loop is wrapped in a block, which branching to the end of leaves the loop
+			whileStmt := stmt.(*ResolvedWhileStatement)
+			out = append(out, wasmOp_BLOCK, wasmType_EMPTY, wasmOp_LOOP, wasmType_EMPTY)
+			wb.depth += 2
+			loop := wasmLoop{continueDepth: wb.depth, breakDepth: wb.depth - 1}
+			out, err = wb.GenerateExpr(out, whileStmt.Cond)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, wasmOp_I32_EQZ)
+			out = wb.appendBranch(out, wasmOp_BR_IF, loop.breakDepth)
+			out, err = wb.generateLoopBody(out, whileStmt.Body, loop)
+			if err != nil {
+				return nil, err
+			}
+			out = wb.appendBranch(out, wasmOp_BR, loop.continueDepth)
+			wb.depth -= 2
+			out = append(out, wasmOp_END, wasmOp_END)
+		case *ResolvedForStatement:
+			// Like a while loop, with the body in a block of its own that continue leaves to step the variable
+			forStmt := stmt.(*ResolvedForStatement)
+			variable := uint64(wb.locals[forStmt.Variable])
+			limit := uint64(wb.locals[forStmt.Limit])
+			out, err = wb.GenerateExpr(out, forStmt.Variable.Value)
+			if err != nil {
+				return nil, err
+			}
+			out = appendULEB128(append(out, wasmOp_LOCAL_SET), variable)
+			out, err = wb.GenerateExpr(out, forStmt.Limit.Value)
+			if err != nil {
+				return nil, err
+			}
+			out = appendULEB128(append(out, wasmOp_LOCAL_SET), limit)
+
+			out = append(out, wasmOp_BLOCK, wasmType_EMPTY, wasmOp_LOOP, wasmType_EMPTY)
+			wb.depth += 2
+			breakDepth := wb.depth - 1
+			out = appendULEB128(append(out, wasmOp_LOCAL_GET), variable)
+			out = appendULEB128(append(out, wasmOp_LOCAL_GET), limit)
+			out = append(out, wasmBinaryOps[TokenType_GTE])
+			out = wb.appendBranch(out, wasmOp_BR_IF, breakDepth)
+
+			out = append(out, wasmOp_BLOCK, wasmType_EMPTY)
+			wb.depth++
+			out, err = wb.generateLoopBody(out, forStmt.Body, wasmLoop{continueDepth: wb.depth, breakDepth: breakDepth})
+			if err != nil {
+				return nil, err
+			}
+			wb.depth--
+			out = append(out, wasmOp_END)
+
+			out = appendULEB128(append(out, wasmOp_LOCAL_GET), variable)
+			out = append(out, wasmOp_I64_CONST, 1, wasmOp_I64_ADD)
+			out = appendULEB128(append(out, wasmOp_LOCAL_SET), variable)
+			out = wb.appendBranch(out, wasmOp_BR, wb.depth)
+			wb.depth -= 2
+			out = append(out, wasmOp_END, wasmOp_END)
+		case *ResolvedBreakStatement:
+			out = wb.appendBranch(out, wasmOp_BR, wb.loops[len(wb.loops)-1].breakDepth)
+		case *ResolvedContinueStatement:
+			out = wb.appendBranch(out, wasmOp_BR, wb.loops[len(wb.loops)-1].continueDepth)
+		default:
+			return nil, fmt.Errorf("Unknown statement type %s", stmt.GetStmtType())
+		}
+	}
+	return out, nil
+}
+
+// Divides the two i64s on the stack, calling the imported division_fail if the divisor is zero. i64.div_s
+// traps dividing the smallest i64 by -1 too, so -1 negates the dividend instead, wrapping around like in
+// Go. i64.rem_s gives 0 then already.
+func (wb *WasmBackend) generateCheckedDivision(out []byte, binaryExpr *ResolvedBinaryExpr) []byte {
+	wb.checksDivisions = true
+	dividend := uint64(wb.dividendLocal)
+	divisor := dividend + 1
+	location := binaryExpr.Location
+
+	out = append(out, wasmOp_LOCAL_SET)
+	out = appendULEB128(out, divisor)
+	out = append(out, wasmOp_LOCAL_SET)
+	out = appendULEB128(out, dividend)
+
+	out = append(out, wasmOp_LOCAL_GET)
+	out = appendULEB128(out, divisor)
+	out = append(out, wasmOp_I64_EQZ, wasmOp_IF, wasmType_EMPTY, wasmOp_I64_CONST)
+	out = appendSLEB128(out, int64(location.Line))
+	out = append(out, wasmOp_I64_CONST)
+	out = appendSLEB128(out, int64(location.Column))
+	out = append(out, wasmOp_CALL, 0, wasmOp_UNREACHABLE, wasmOp_END)
+
+	if binaryExpr.Operator == TokenType_SLASH {
+		out = append(out, wasmOp_LOCAL_GET)
+		out = appendULEB128(out, divisor)
+		out = append(out, wasmOp_I64_CONST, 0x7F, wasmOp_I64_EQ, wasmOp_IF, wasmType_I64, wasmOp_I64_CONST, 0, wasmOp_LOCAL_GET)
+		out = appendULEB128(out, dividend)
+		out = append(out, wasmOp_I64_SUB, wasmOp_ELSE)
+	}
+	out = append(out, wasmOp_LOCAL_GET)
+	out = appendULEB128(out, dividend)
+	out = append(out, wasmOp_LOCAL_GET)
+	out = appendULEB128(out, divisor)
+	out = append(out, wasmBinaryOps[binaryExpr.Operator])
+	if binaryExpr.Operator == TokenType_SLASH {
+		out = append(out, wasmOp_END)
+	}
+	return out
+}
+
+// Generates the body of a function, prefixed with its size as the code section expects
+func (wb *WasmBackend) GenerateFunctionBody(fn *ResolvedFunctionDeclaration) ([]byte, error) {
+	wb.locals = make(map[ResolvedDeclaration]int)
+	for i, param := range fn.Params {
+		wb.locals[param] = i
+	}
+
+	// Locals besides the parameters are declared one per group, following the parameters' indices, with
+	// the operands of checked divisions last
+	locals := fn.Body.Locals()
+	groups := len(locals)
+	if wb.importsDivisionFail {
+		groups++
+	}
+	body := appendULEB128([]byte{}, uint64(groups))
+	for i, local := range locals {
+		wb.locals[local] = len(fn.Params) + i
+		valueTypes, err := wasmValueTypes(local.Type)
+		if err != nil {
+			return nil, fmt.Errorf("Error generating local %s of %s: %s", local.GetId(), fn.GetId(), err)
+		}
+		body = appendULEB128(body, 1)
+		body = append(body, valueTypes...)
+	}
+	wb.dividendLocal = len(fn.Params) + len(locals)
+	if wb.importsDivisionFail {
+		body = append(body, 2, wasmType_I64)
+	}
+
+	body, err := wb.GenerateBlock(body, fn.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Error generating function %s: %s", fn.GetId(), err)
+	}
+
+	// Control reaching the end of a function with results is a bug in the analyser
+	if fn.ReturnType.Kind != TypeType_VOID {
+		body = append(body, wasmOp_UNREACHABLE)
+	}
+	body = append(body, wasmOp_END)
+
+	out := appendULEB128([]byte{}, uint64(len(body)))
+	return append(out, body...), nil
+}
+
+// Whether a division is checked is only known once the bodies are generated, so a module checking
+// divisions is generated again, importing division_fail
+func (wb *WasmBackend) Generate() ([]byte, error) {
+	wb.importsDivisionFail = false
+	wb.checksDivisions = false
+	out, err := wb.generateModule()
+	if err != nil || !wb.checksDivisions {
+		return out, err
+	}
+	wb.importsDivisionFail = true
+	return wb.generateModule()
+}
+
+func (wb *WasmBackend) generateModule() ([]byte, error) {
+	// Imported functions come first in the function index space
+	imported := 0
+	if wb.importsDivisionFail {
+		imported = 1
+	}
+	wb.functions = make([]*ResolvedFunctionDeclaration, 0)
+	wb.functionIndices = make(map[string]int)
+	for _, decl := range wb.Declarations {
+		fn, ok := decl.(*ResolvedFunctionDeclaration)
+		if ok {
+			wb.functionIndices[fn.GetId()] = imported + len(wb.functions)
+			wb.functions = append(wb.functions, fn)
+		}
+	}
+
+	mainIndex, ok := wb.functionIndices["main"]
+	if !ok {
+		return nil, fmt.Errorf("No main function found")
+	}
+
+	// Functions with the same signature share a type, division_fail's being the first
+	types := make([][]byte, 0)
+	typeIndices := make(map[string]int)
+	importSection := appendULEB128([]byte{}, uint64(imported))
+	if wb.importsDivisionFail {
+		divisionFailType := []byte{wasmType_FUNC, 2, wasmType_I64, wasmType_I64, 0}
+		typeIndices[string(divisionFailType)] = 0
+		types = append(types, divisionFailType)
+		importSection = appendWasmName(importSection, wasmDivisionFailModule)
+		importSection = appendWasmName(importSection, wasmDivisionFailName)
+		importSection = append(importSection, wasmExport_FUNC, 0)
+	}
+	functionSection := appendULEB128([]byte{}, uint64(len(wb.functions)))
+	codeSection := appendULEB128([]byte{}, uint64(len(wb.functions)))
+	for _, fn := range wb.functions {
+		functionType, err := wb.GenerateFunctionType(fn)
+		if err != nil {
+			return nil, err
+		}
+
+		key := string(functionType)
+		index, ok := typeIndices[key]
+		if !ok {
+			index = len(types)
+			typeIndices[key] = index
+			types = append(types, functionType)
+		}
+		functionSection = appendULEB128(functionSection, uint64(index))
+
+		body, err := wb.GenerateFunctionBody(fn)
+		if err != nil {
+			return nil, err
+		}
+		codeSection = append(codeSection, body...)
+	}
+
+	typeSection := appendULEB128([]byte{}, uint64(len(types)))
+	for _, functionType := range types {
+		typeSection = append(typeSection, functionType...)
+	}
+
+	exportSection := appendULEB128([]byte{}, 1)
+	exportSection = appendWasmName(exportSection, "main")
+	exportSection = append(exportSection, wasmExport_FUNC)
+	exportSection = appendULEB128(exportSection, uint64(mainIndex))
+
+	out := []byte{0x00, 'a', 's', 'm', 0x01, 0x00, 0x00, 0x00}
+	out = appendWasmSection(out, wasmSection_TYPE, typeSection)
+	if wb.importsDivisionFail {
+		out = appendWasmSection(out, wasmSection_IMPORT, importSection)
+	}
+	out = appendWasmSection(out, wasmSection_FUNCTION, functionSection)
+	out = appendWasmSection(out, wasmSection_EXPORT, exportSection)
+	out = appendWasmSection(out, wasmSection_CODE, codeSection)
+	return out, nil
+}
@@ -0,0 +1,1021 @@
+package baisl_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/frodi-karlsson/baisl"
+)
+
+type wasmBackendTest struct {
+	path     string
+	expected string
+}
+
+var wasmBackendTests = []wasmBackendTest{
+	{"raw/fnCall.baisl", `type 0: (i64) -> (i64)
+type 1: () -> (i64)
+func 0: type 0
+func 1: type 1
+export main: func 1
+code 0:
+  local.get 0
+  return
+  unreachable
+code 1:
+  i64.const 5
+  call 0
+  return
+  unreachable
+`},
+	{"raw/ret2.baisl", `type 0: () -> ()
+type 1: () -> (i64)
+func 0: type 0
+func 1: type 1
+export main: func 0
+code 0:
+  return
+code 1:
+  i64.const 2
+  return
+  unreachable
+`},
+	{"raw/params.baisl", `type 0: (i64, i64, i64) -> (i64)
+type 1: () -> (i64)
+func 0: type 0
+func 1: type 1
+export main: func 1
+code 0:
+  local.get 1
+  return
+  unreachable
+code 1:
+  i64.const 1
+  i64.const 2
+  i64.const 3
+  call 0
+  return
+  unreachable
+`},
+	{"raw/locals.baisl", `type 0: (i64) -> (i64)
+type 1: () -> (i64)
+func 0: type 0
+func 1: type 0
+func 2: type 1
+export main: func 2
+code 0:
+  local.get 0
+  i64.const 1
+  i64.add
+  local.set 0
+  local.get 0
+  return
+  unreachable
+code 1:
+  local 1 i64
+  local.get 0
+  local.get 0
+  i64.mul
+  local.set 1
+  local.get 1
+  return
+  unreachable
+code 2:
+  local 1 i64
+  local 1 i64
+  local 1 i64
+  i64.const 3
+  local.set 0
+  local.get 0
+  call 1
+  local.set 1
+  local.get 0
+  local.get 1
+  i64.add
+  call 0
+  local.set 0
+  i64.const 2
+  local.set 2
+  local.get 0
+  local.get 2
+  i64.mul
+  return
+  unreachable
+`},
+	{"raw/ifElse.baisl", `type 0: (i64) -> (i64)
+type 1: (i64, i64) -> (i64)
+type 2: (i64) -> ()
+type 3: () -> (i64)
+func 0: type 0
+func 1: type 1
+func 2: type 2
+func 3: type 3
+export main: func 3
+code 0:
+  local.get 0
+  i64.const 0
+  i64.lt_s
+  if
+  i64.const 0
+  i64.const 1
+  i64.sub
+  return
+  else
+  local.get 0
+  i64.const 0
+  i64.eq
+  if
+  i64.const 0
+  return
+  else
+  i64.const 1
+  return
+  end
+  end
+  unreachable
+code 1:
+  local.get 0
+  local.get 1
+  i64.gt_s
+  if
+  local.get 1
+  local.set 0
+  end
+  local.get 0
+  return
+  unreachable
+code 2:
+  local 1 i64
+  local.get 0
+  i64.const 0
+  i64.ne
+  if
+  return
+  end
+  local.get 0
+  local.set 1
+code 3:
+  local 1 i64
+  local 1 i64
+  local 1 i64
+  i64.const 10
+  local.set 0
+  local.get 0
+  i64.const 5
+  i64.gt_s
+  if
+  local.get 0
+  i64.const 2
+  i64.mul
+  local.set 1
+  local.get 1
+  i64.const 1
+  i64.add
+  local.set 1
+  end
+  i64.const 0
+  local.get 0
+  i64.sub
+  call 0
+  local.set 2
+  local.get 0
+  i64.const 40
+  i64.const 30
+  call 1
+  i64.add
+  local.get 2
+  i64.const 2
+  i64.mul
+  i64.add
+  local.get 0
+  call 0
+  i64.add
+  return
+  unreachable
+`},
+	{"raw/loopControl.baisl", `type 0: () -> (i64)
+func 0: type 0
+export main: func 0
+code 0:
+  local 1 i64
+  local 1 i64
+  local 1 i64
+  i64.const 0
+  local.set 0
+  block
+  loop
+  local.get 0
+  i64.const 10
+  i64.lt_s
+  i32.eqz
+  br_if 1
+  local.get 0
+  i64.const 3
+  i64.add
+  local.set 0
+  local.get 0
+  i64.const 6
+  i64.eq
+  if
+  br 1
+  end
+  br 0
+  end
+  end
+  i64.const 0
+  local.set 1
+  local.get 0
+  local.set 2
+  block
+  loop
+  local.get 1
+  local.get 2
+  i64.ge_s
+  br_if 1
+  block
+  local.get 1
+  i64.const 2
+  i64.eq
+  if
+  br 3
+  end
+  local.get 0
+  local.get 1
+  i64.add
+  local.set 0
+  end
+  local.get 1
+  i64.const 1
+  i64.add
+  local.set 1
+  br 0
+  end
+  end
+  local.get 0
+  return
+  unreachable
+`},
+	{"raw/bool.baisl", `type 0: (i64, i64) -> ()
+type 1: (i64, i64, i64) -> (i32)
+type 2: (i32, i32) -> (i32)
+type 3: () -> (i64)
+import 0: baisl.division_fail: type 0
+func 0: type 1
+func 1: type 2
+func 2: type 3
+export main: func 3
+code 0:
+  local 2 i64
+  local.get 0
+  local.get 1
+  i64.ge_s
+  if (result i32)
+  local.get 0
+  local.get 2
+  i64.lt_s
+  else
+  i32.const 0
+  end
+  return
+  unreachable
+code 1:
+  local 2 i64
+  local.get 0
+  local.get 1
+  i32.ne
+  return
+  unreachable
+code 2:
+  local 1 i64
+  local 1 i64
+  local 1 i32
+  local 1 i64
+  local 1 i64
+  local 2 i64
+  i64.const 0
+  local.set 0
+  i64.const 0
+  local.set 1
+  local.get 1
+  i64.const 0
+  i64.ne
+  if (result i32)
+  i64.const 10
+  local.get 1
+  local.set 6
+  local.set 5
+  local.get 6
+  i64.eqz
+  if
+  i64.const 13
+  i64.const 19
+  call 0
+  unreachable
+  end
+  local.get 6
+  i64.const -1
+  i64.eq
+  if (result i64)
+  i64.const 0
+  local.get 5
+  i64.sub
+  else
+  local.get 5
+  local.get 6
+  i64.div_s
+  end
+  i64.const 1
+  i64.gt_s
+  else
+  i32.const 0
+  end
+  if (result i32)
+  i32.const 1
+  else
+  local.get 1
+  i64.const 0
+  i64.const 5
+  call 1
+  i32.eqz
+  end
+  if
+  i64.const 100
+  local.set 0
+  end
+  i32.const 0
+  local.set 2
+  i64.const 0
+  local.set 3
+  i64.const 10
+  local.set 4
+  block
+  loop
+  local.get 3
+  local.get 4
+  i64.ge_s
+  br_if 1
+  block
+  local.get 3
+  i64.const 3
+  i64.const 6
+  call 1
+  if (result i32)
+  i32.const 1
+  else
+  local.get 3
+  i64.const 8
+  i64.eq
+  end
+  if
+  local.get 0
+  local.get 3
+  i64.add
+  local.set 0
+  end
+  local.get 2
+  if (result i32)
+  i32.const 1
+  else
+  local.get 3
+  i64.const 4
+  i64.eq
+  i32.const 1
+  i32.const 0
+  i32.eq
+  call 2
+  end
+  local.set 2
+  end
+  local.get 3
+  i64.const 1
+  i64.add
+  local.set 3
+  br 0
+  end
+  end
+  local.get 2
+  if (result i32)
+  i32.const 1
+  i32.const 1
+  call 2
+  i32.eqz
+  else
+  i32.const 0
+  end
+  if
+  local.get 0
+  i64.const 1
+  i64.add
+  local.set 0
+  end
+  local.get 0
+  return
+  unreachable
+`},
+	{"raw/divByZero.baisl", `type 0: (i64, i64) -> ()
+type 1: () -> (i64)
+import 0: baisl.division_fail: type 0
+func 0: type 1
+export main: func 1
+code 0:
+  local 2 i64
+  i64.const 1
+  i64.const 2
+  i64.const 2
+  i64.sub
+  local.set 1
+  local.set 0
+  local.get 1
+  i64.eqz
+  if
+  i64.const 2
+  i64.const 12
+  call 0
+  unreachable
+  end
+  local.get 1
+  i64.const -1
+  i64.eq
+  if (result i64)
+  i64.const 0
+  local.get 0
+  i64.sub
+  else
+  local.get 0
+  local.get 1
+  i64.div_s
+  end
+  return
+  unreachable
+`},
+	{"raw/divisionWraps.baisl", `type 0: (i64, i64) -> ()
+type 1: (i64, i64) -> (i64)
+type 2: () -> (i64)
+import 0: baisl.division_fail: type 0
+func 0: type 1
+func 1: type 1
+func 2: type 2
+export main: func 3
+code 0:
+  local 2 i64
+  local.get 0
+  local.get 1
+  local.set 3
+  local.set 2
+  local.get 3
+  i64.eqz
+  if
+  i64.const 3
+  i64.const 12
+  call 0
+  unreachable
+  end
+  local.get 3
+  i64.const -1
+  i64.eq
+  if (result i64)
+  i64.const 0
+  local.get 2
+  i64.sub
+  else
+  local.get 2
+  local.get 3
+  i64.div_s
+  end
+  return
+  unreachable
+code 1:
+  local 2 i64
+  local.get 0
+  local.get 1
+  local.set 3
+  local.set 2
+  local.get 3
+  i64.eqz
+  if
+  i64.const 7
+  i64.const 12
+  call 0
+  unreachable
+  end
+  local.get 2
+  local.get 3
+  i64.rem_s
+  return
+  unreachable
+code 2:
+  local 1 i64
+  local 2 i64
+  i64.const 0
+  i64.const 9223372036854775807
+  i64.sub
+  i64.const 1
+  i64.sub
+  local.set 0
+  local.get 0
+  i64.const 0
+  i64.const 1
+  i64.sub
+  call 1
+  local.get 0
+  i64.ne
+  if (result i32)
+  i32.const 1
+  else
+  local.get 0
+  i64.const 0
+  i64.const 1
+  i64.sub
+  local.set 2
+  local.set 1
+  local.get 2
+  i64.eqz
+  if
+  i64.const 12
+  i64.const 36
+  call 0
+  unreachable
+  end
+  local.get 2
+  i64.const -1
+  i64.eq
+  if (result i64)
+  i64.const 0
+  local.get 1
+  i64.sub
+  else
+  local.get 1
+  local.get 2
+  i64.div_s
+  end
+  local.get 0
+  i64.ne
+  end
+  if
+  i64.const 1
+  return
+  end
+  local.get 0
+  i64.const 0
+  i64.const 1
+  i64.sub
+  call 2
+  i64.const 0
+  i64.ne
+  if
+  i64.const 2
+  return
+  end
+  i64.const 0
+  i64.const 7
+  i64.sub
+  i64.const 2
+  call 1
+  i64.const 0
+  i64.const 3
+  i64.sub
+  i64.ne
+  if (result i32)
+  i32.const 1
+  else
+  i64.const 0
+  i64.const 7
+  i64.sub
+  i64.const 2
+  call 2
+  i64.const 0
+  i64.const 1
+  i64.sub
+  i64.ne
+  end
+  if
+  i64.const 3
+  return
+  end
+  i64.const 40
+  i64.const 8
+  i64.div_s
+  return
+  unreachable
+`},
+}
+
+// A minimal decoder for the subset of the binary format the backend emits
+type wasmReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *wasmReader) readByte() (byte, error) {
+	if r.pos >= len(r.data) {
+		return 0, fmt.Errorf("Unexpected end of module at %d", r.pos)
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *wasmReader) readULEB128() (uint64, error) {
+	result := uint64(0)
+	for shift := 0; ; shift += 7 {
+		b, err := r.readByte()
+		if err != nil {
+			return 0, err
+		}
+		result |= uint64(b&0x7F) << shift
+		if b&0x80 == 0 {
+			return result, nil
+		}
+	}
+}
+
+func (r *wasmReader) readSLEB128() (int64, error) {
+	result := int64(0)
+	shift := 0
+	for {
+		b, err := r.readByte()
+		if err != nil {
+			return 0, err
+		}
+		result |= int64(b&0x7F) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			if shift < 64 && b&0x40 != 0 {
+				result |= -1 << shift
+			}
+			return result, nil
+		}
+	}
+}
+
+func (r *wasmReader) readBytes(n int) ([]byte, error) {
+	if r.pos+n > len(r.data) {
+		return nil, fmt.Errorf("Unexpected end of module at %d", r.pos)
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+var wasmValueTypeNames = map[byte]string{
+	0x7F: "i32",
+	0x7E: "i64",
+}
+
+func (r *wasmReader) readValueTypes() (string, error) {
+	count, err := r.readULEB128()
+	if err != nil {
+		return "", err
+	}
+
+	names := make([]string, count)
+	for i := range names {
+		b, err := r.readByte()
+		if err != nil {
+			return "", err
+		}
+		name, ok := wasmValueTypeNames[b]
+		if !ok {
+			return "", fmt.Errorf("Unknown value type 0x%02x", b)
+		}
+		names[i] = name
+	}
+	return "(" + strings.Join(names, ", ") + ")", nil
+}
+
+type wasmInstruction struct {
+	name string
+	// Kind of the immediate following the opcode, if any
+	immediate string
+}
+
+var wasmInstructions = map[byte]wasmInstruction{
+	0x00: {"unreachable", ""},
+	0x02: {"block", "blocktype"},
+	0x03: {"loop", "blocktype"},
+	0x04: {"if", "blocktype"},
+	0x05: {"else", ""},
+	0x0B: {"end", ""},
+	0x0C: {"br", "uleb"},
+	0x0D: {"br_if", "uleb"},
+	0x0F: {"return", ""},
+	0x10: {"call", "uleb"},
+	0x1A: {"drop", ""},
+	0x20: {"local.get", "uleb"},
+	0x21: {"local.set", "uleb"},
+	0x41: {"i32.const", "sleb"},
+	0x42: {"i64.const", "sleb"},
+	0x45: {"i32.eqz", ""},
+	0x46: {"i32.eq", ""},
+	0x47: {"i32.ne", ""},
+	0x50: {"i64.eqz", ""},
+	0x51: {"i64.eq", ""},
+	0x52: {"i64.ne", ""},
+	0x53: {"i64.lt_s", ""},
+	0x55: {"i64.gt_s", ""},
+	0x57: {"i64.le_s", ""},
+	0x59: {"i64.ge_s", ""},
+	0x7C: {"i64.add", ""},
+	0x7D: {"i64.sub", ""},
+	0x7E: {"i64.mul", ""},
+	0x7F: {"i64.div_s", ""},
+	0x81: {"i64.rem_s", ""},
+}
+
+func (r *wasmReader) readCode(end int) (string, error) {
+	out := ""
+	for r.pos < end {
+		opcode, err := r.readByte()
+		if err != nil {
+			return "", err
+		}
+		if opcode == 0x0B && r.pos == end {
+			return out, nil
+		}
+
+		instruction, ok := wasmInstructions[opcode]
+		if !ok {
+			return "", fmt.Errorf("Unknown opcode 0x%02x at %d", opcode, r.pos-1)
+		}
+		out += "  " + instruction.name
+		switch instruction.immediate {
+		case "uleb":
+			value, err := r.readULEB128()
+			if err != nil {
+				return "", err
+			}
+			out += fmt.Sprintf(" %d", value)
+		case "sleb":
+			value, err := r.readSLEB128()
+			if err != nil {
+				return "", err
+			}
+			out += fmt.Sprintf(" %d", value)
+		case "blocktype":
+			// Blocks either leave nothing on the stack or a single value
+			blockType, err := r.readByte()
+			if err != nil {
+				return "", err
+			}
+			if blockType != 0x40 {
+				valueType, ok := wasmValueTypeNames[blockType]
+				if !ok {
+					return "", fmt.Errorf("Unexpected block type 0x%02x", blockType)
+				}
+				out += " (result " + valueType + ")"
+			}
+		}
+		out += "\n"
+	}
+	return "", fmt.Errorf("Function body is missing its end")
+}
+
+func (r *wasmReader) readName() (string, error) {
+	length, err := r.readULEB128()
+	if err != nil {
+		return "", err
+	}
+	name, err := r.readBytes(int(length))
+	if err != nil {
+		return "", err
+	}
+	return string(name), nil
+}
+
+func (r *wasmReader) readSection(id byte, end int) (string, error) {
+	count, err := r.readULEB128()
+	if err != nil {
+		return "", err
+	}
+
+	out := ""
+	for i := 0; i < int(count); i++ {
+		switch id {
+		case 1:
+			form, err := r.readByte()
+			if err != nil || form != 0x60 {
+				return "", fmt.Errorf("Expected function type, got 0x%02x", form)
+			}
+			params, err := r.readValueTypes()
+			if err != nil {
+				return "", err
+			}
+			results, err := r.readValueTypes()
+			if err != nil {
+				return "", err
+			}
+			out += fmt.Sprintf("type %d: %s -> %s\n", i, params, results)
+		case 2:
+			module, err := r.readName()
+			if err != nil {
+				return "", err
+			}
+			name, err := r.readName()
+			if err != nil {
+				return "", err
+			}
+			kind, err := r.readByte()
+			if err != nil || kind != 0x00 {
+				return "", fmt.Errorf("Expected function import, got 0x%02x", kind)
+			}
+			typeIndex, err := r.readULEB128()
+			if err != nil {
+				return "", err
+			}
+			out += fmt.Sprintf("import %d: %s.%s: type %d\n", i, module, name, typeIndex)
+		case 3:
+			typeIndex, err := r.readULEB128()
+			if err != nil {
+				return "", err
+			}
+			out += fmt.Sprintf("func %d: type %d\n", i, typeIndex)
+		case 7:
+			name, err := r.readName()
+			if err != nil {
+				return "", err
+			}
+			kind, err := r.readByte()
+			if err != nil || kind != 0x00 {
+				return "", fmt.Errorf("Expected function export, got 0x%02x", kind)
+			}
+			index, err := r.readULEB128()
+			if err != nil {
+				return "", err
+			}
+			out += fmt.Sprintf("export %s: func %d\n", name, index)
+		case 10:
+			size, err := r.readULEB128()
+			if err != nil {
+				return "", err
+			}
+			bodyEnd := r.pos + int(size)
+			localGroups, err := r.readULEB128()
+			if err != nil {
+				return "", err
+			}
+			out += fmt.Sprintf("code %d:\n", i)
+			for j := 0; j < int(localGroups); j++ {
+				localCount, err := r.readULEB128()
+				if err != nil {
+					return "", err
+				}
+				localType, err := r.readByte()
+				if err != nil {
+					return "", err
+				}
+				out += fmt.Sprintf("  local %d %s\n", localCount, wasmValueTypeNames[localType])
+			}
+			code, err := r.readCode(bodyEnd)
+			if err != nil {
+				return "", err
+			}
+			out += code
+		default:
+			return "", fmt.Errorf("Unexpected section %d", id)
+		}
+	}
+
+	if r.pos != end {
+		return "", fmt.Errorf("Section %d has %d trailing bytes", id, end-r.pos)
+	}
+	return out, nil
+}
+
+// Decodes a module into a readable listing of its sections
+func decodeWasm(data []byte) (string, error) {
+	r := wasmReader{data: data}
+	header, err := r.readBytes(8)
+	if err != nil {
+		return "", err
+	}
+	if string(header) != "\x00asm\x01\x00\x00\x00" {
+		return "", fmt.Errorf("Invalid module header %v", header)
+	}
+
+	out := ""
+	lastId := byte(0)
+	for r.pos < len(r.data) {
+		id, err := r.readByte()
+		if err != nil {
+			return "", err
+		}
+		if id <= lastId {
+			return "", fmt.Errorf("Section %d is out of order", id)
+		}
+		lastId = id
+
+		size, err := r.readULEB128()
+		if err != nil {
+			return "", err
+		}
+		section, err := r.readSection(id, r.pos+int(size))
+		if err != nil {
+			return "", err
+		}
+		out += section
+	}
+	return out, nil
+}
+
+func TestWasmBackend(t *testing.T) {
+	for _, test := range wasmBackendTests {
+		backend := baisl.WasmBackend{
+			Declarations: getResolvedDeclarations(t, test.path),
+		}
+
+		result, err := backend.Generate()
+		if err != nil {
+			t.Errorf("Error generating WebAssembly for %s: %s", test.path, err)
+			continue
+		}
+
+		decoded, err := decodeWasm(result)
+		if err != nil {
+			t.Errorf("Error decoding WebAssembly for %s: %s", test.path, err)
+			continue
+		}
+
+		if decoded != test.expected {
+			t.Errorf("\nExpected <%s>, got <%s>", test.expected, decoded)
+		}
+	}
+}
+
+// Runs a module's main with node, exiting with its result like the native backends. division_fail
+// reports a division by zero the way they do.
+const wasmRunner = `
+const fs = require("fs");
+const imports = {
+  baisl: {
+    division_fail(line, column) {
+      process.stderr.write("Division by zero at " + line + ":" + column + "\n");
+      process.exit(1);
+    },
+  },
+};
+WebAssembly.instantiate(fs.readFileSync(process.argv[2]), imports).then(({ instance }) => {
+  const result = instance.exports.main();
+  process.exit(result === undefined ? 0 : Number(BigInt.asUintN(8, result)));
+});
+`
+
+// Writes a program's module and the runner to run it with, returning the runner's arguments
+func writeWasmProgram(t *testing.T, dir string, path string) ([]string, bool) {
+	backend := baisl.WasmBackend{
+		Declarations: getResolvedDeclarations(t, path),
+	}
+
+	result, err := backend.Generate()
+	if err != nil {
+		t.Errorf("Error generating WebAssembly for %s: %s", path, err)
+		return nil, false
+	}
+
+	runner := filepath.Join(dir, "run.js")
+	module := filepath.Join(dir, filepath.Base(path)+".wasm")
+	err = os.WriteFile(runner, []byte(wasmRunner), 0644)
+	if err != nil {
+		t.Fatalf("Error writing %s: %s", runner, err)
+	}
+	err = os.WriteFile(module, result, 0644)
+	if err != nil {
+		t.Fatalf("Error writing %s: %s", module, err)
+	}
+	return []string{runner, module}, true
+}
+
+func TestWasmBackendRuns(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("No node found")
+	}
+
+	dir := t.TempDir()
+	for _, test := range compiledProgramTests {
+		args, ok := writeWasmProgram(t, dir, test.path)
+		if !ok {
+			continue
+		}
+
+		exitCode := runProgram(t, node, args...)
+		if exitCode != test.exitCode {
+			t.Errorf("Running %s, expected exit code %d, got %d", test.path, test.exitCode, exitCode)
+		}
+	}
+
+	for _, test := range divisionErrorTests {
+		args, ok := writeWasmProgram(t, dir, test.path)
+		if !ok {
+			continue
+		}
+
+		got := programError(t, node, args...)
+		if got != test.expected {
+			t.Errorf("Running %s, expected error <%s>, got <%s>", test.path, test.expected, got)
+		}
+	}
+}